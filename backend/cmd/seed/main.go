@@ -13,6 +13,7 @@ import (
 	"github.com/yourusername/algoholic/config"
 	"github.com/yourusername/algoholic/models"
 	"github.com/yourusername/algoholic/seed"
+	"github.com/yourusername/algoholic/services"
 )
 
 func main() {
@@ -96,6 +97,7 @@ func main() {
 	// Seed Questions
 	log.Println("❓ Seeding questions...")
 	questions := seed.GetSeedQuestions()
+	questionService := services.NewQuestionService(db, nil, nil)
 	successCount = 0
 
 	for i, question := range questions {
@@ -106,7 +108,7 @@ func main() {
 			}
 		}
 
-		if err := db.Create(&question).Error; err != nil {
+		if err := questionService.CreateQuestion(&question); err != nil {
 			log.Printf("   ❌ Failed to seed question %d: %v", i+1, err)
 		} else {
 			successCount++
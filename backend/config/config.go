@@ -14,15 +14,17 @@ import (
 
 // Config holds all application configuration
 type Config struct {
-	App      AppConfig      `koanf:"app"`
-	Server   ServerConfig   `koanf:"server"`
-	Database DatabaseConfig `koanf:"database"`
-	Redis    RedisConfig    `koanf:"redis"`
-	ChromaDB ChromaDBConfig `koanf:"chromadb"`
-	Ollama   OllamaConfig   `koanf:"ollama"`
-	RAG      RAGConfig      `koanf:"rag"`
-	Auth     AuthConfig     `koanf:"auth"`
-	Logging  LoggingConfig  `koanf:"logging"`
+	App        AppConfig        `koanf:"app"`
+	Server     ServerConfig     `koanf:"server"`
+	Database   DatabaseConfig   `koanf:"database"`
+	Redis      RedisConfig      `koanf:"redis"`
+	ChromaDB   ChromaDBConfig   `koanf:"chromadb"`
+	Ollama     OllamaConfig     `koanf:"ollama"`
+	RAG        RAGConfig        `koanf:"rag"`
+	Auth       AuthConfig       `koanf:"auth"`
+	Executor   ExecutorConfig   `koanf:"executor"`
+	Similarity SimilarityConfig `koanf:"similarity"`
+	Logging    LoggingConfig    `koanf:"logging"`
 }
 
 // AppConfig contains general application settings
@@ -74,7 +76,8 @@ type RedisConfig struct {
 	Port     int    `koanf:"port"`
 	Password string `koanf:"password"`
 	DB       int    `koanf:"db"`
-	TTL      int    `koanf:"ttl"` // seconds
+	TTL      int    `koanf:"ttl"`       // seconds
+	PoolSize int    `koanf:"pool_size"` // connections per API instance
 }
 
 // ChromaDBConfig contains vector database settings
@@ -126,6 +129,88 @@ type AuthConfig struct {
 	RefreshExpiry   int    `koanf:"refresh_expiry"`    // hours
 	BCryptCost      int    `koanf:"bcrypt_cost"`
 	SessionDuration int    `koanf:"session_duration"`  // hours
+	// AdminUserIDs are the IDs of the users allowed to use the admin endpoints
+	AdminUserIDs []int `koanf:"admin_user_ids"`
+}
+
+// ExecutorConfig contains code execution settings
+type ExecutorConfig struct {
+	Backend        string              `koanf:"backend"` // judge0, local
+	Judge0URL      string              `koanf:"judge0_url"`
+	BatchSize      int                 `koanf:"batch_size"`       // submissions per Judge0 batch request
+	PollWorkers    int                 `koanf:"poll_workers"`     // concurrent Judge0 batch pollers
+	PollIntervalMs int                 `koanf:"poll_interval_ms"` // milliseconds between polls
+	CPUTimeLimit   float64             `koanf:"cpu_time_limit"`   // seconds
+	WallTimeLimit  float64             `koanf:"wall_time_limit"`  // seconds
+	MemoryLimit    int                 `koanf:"memory_limit"`     // kilobytes
+	Local          LocalExecutorConfig `koanf:"local"`
+	Complexity     ComplexityConfig    `koanf:"complexity"`
+	Queue          QueueConfig         `koanf:"queue"`
+	Cache          ResultCacheConfig   `koanf:"cache"`
+	// Languages submissions may use, keyed by language ID (e.g. "python")
+	Languages map[string]LanguageConfig `koanf:"languages"`
+}
+
+// QueueConfig limits how much code is executed at once and per user. A
+// job is one grading, run or stress test request. Zero disables a limit.
+type QueueConfig struct {
+	MaxConcurrent    int     `koanf:"max_concurrent"`      // jobs executing at once
+	MaxQueued        int     `koanf:"max_queued"`          // jobs waiting across all users
+	MaxQueuedPerUser int     `koanf:"max_queued_per_user"` // jobs in flight per user
+	RatePerMinute    float64 `koanf:"rate_per_minute"`     // sustained jobs per user
+	Burst            int     `koanf:"burst"`               // jobs per user above the rate
+	DailyQuota       int     `koanf:"daily_quota"`         // jobs per user per UTC day
+}
+
+// ResultCacheConfig controls caching of grading results. Results are kept
+// in Redis when redis is enabled, otherwise in memory.
+type ResultCacheConfig struct {
+	Enabled    bool `koanf:"enabled"`
+	MaxEntries int  `koanf:"max_entries"` // in-memory cache size
+	TTL        int  `koanf:"ttl"`         // seconds
+}
+
+// LanguageConfig defines a programming language. Set enabled to false to
+// retire a language without removing its definition.
+type LanguageConfig struct {
+	Enabled        bool     `koanf:"enabled"`
+	Name           string   `koanf:"name"` // display name
+	Version        string   `koanf:"version"`
+	Judge0ID       int      `koanf:"judge0_id"`       // Judge0 language_id; 0 = not on Judge0
+	Extension      string   `koanf:"extension"`       // source file extension, e.g. "py"
+	TimeMultiplier float64  `koanf:"time_multiplier"` // scales CPU and wall time limits
+	StarterCode    string   `koanf:"starter_code"`    // template for stdin/stdout problems
+	Aliases        []string `koanf:"aliases"`
+}
+
+// LocalExecutorConfig contains settings for the local sandboxed runner
+type LocalExecutorConfig struct {
+	WorkDir        string `koanf:"work_dir"` // defaults to the OS temp dir
+	MaxProcesses   int    `koanf:"max_processes"`
+	MaxOutputBytes int    `koanf:"max_output_bytes"`
+	CompileTimeout int    `koanf:"compile_timeout"` // seconds
+	// RootfsPaths are the host paths (globs allowed) visible inside the
+	// sandbox; empty = the toolchain and loader directories
+	RootfsPaths []string `koanf:"rootfs_paths"`
+	// CgroupParent is a delegated cgroup v2 directory; each run gets a child
+	// cgroup whose memory.max is the memory limit. Empty = rlimits only.
+	CgroupParent string `koanf:"cgroup_parent"`
+}
+
+// ComplexityConfig contains settings for estimating the time and space
+// complexity of accepted submissions
+type ComplexityConfig struct {
+	Enabled bool `koanf:"enabled"`
+	MaxSize int  `koanf:"max_size"` // largest generated input size
+}
+
+// SimilarityConfig contains settings for detecting copied code among
+// submissions to the same problem
+type SimilarityConfig struct {
+	Enabled   bool    `koanf:"enabled"`
+	KGram     int     `koanf:"kgram"`     // tokens per fingerprinted k-gram
+	Window    int     `koanf:"window"`    // k-grams per winnowing window
+	Threshold float64 `koanf:"threshold"` // similarity at which a pair is suspicious
 }
 
 // LoggingConfig contains logging settings
@@ -162,9 +247,19 @@ func Load(configPath string) (*Config, error) {
 	// Load from environment variables (highest priority)
 	// Environment variables should be prefixed with ALGOHOLIC_
 	// e.g., ALGOHOLIC_DATABASE_HOST=localhost
+	// Keys may contain underscores themselves, e.g.
+	// ALGOHOLIC_EXECUTOR_JUDGE0_URL is executor.judge0_url, so names are
+	// looked up among the known keys before splitting at every underscore
+	knownKeys := make(map[string]string)
+	for _, key := range k.Keys() {
+		knownKeys[strings.Replace(key, ".", "_", -1)] = key
+	}
 	if err := k.Load(env.Provider("ALGOHOLIC_", ".", func(s string) string {
-		return strings.Replace(strings.ToLower(
-			strings.TrimPrefix(s, "ALGOHOLIC_")), "_", ".", -1)
+		name := strings.ToLower(strings.TrimPrefix(s, "ALGOHOLIC_"))
+		if key, ok := knownKeys[name]; ok {
+			return key
+		}
+		return strings.Replace(name, "_", ".", -1)
 	}), nil); err != nil {
 		return nil, fmt.Errorf("error loading environment variables: %w", err)
 	}
@@ -203,6 +298,38 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("auth.jwt_secret is required when auth is enabled")
 	}
 
+	// Executor validation
+	validBackends := map[string]bool{"judge0": true, "local": true}
+	if !validBackends[c.Executor.Backend] {
+		return fmt.Errorf("executor.backend must be one of: judge0, local")
+	}
+
+	for id, lang := range c.Executor.Languages {
+		if !lang.Enabled {
+			continue
+		}
+		if lang.Name == "" {
+			return fmt.Errorf("executor.languages.%s.name is required", id)
+		}
+		if lang.TimeMultiplier < 0 {
+			return fmt.Errorf("executor.languages.%s.time_multiplier must not be negative", id)
+		}
+	}
+
+	queue := c.Executor.Queue
+	if queue.MaxConcurrent < 0 || queue.MaxQueued < 0 || queue.MaxQueuedPerUser < 0 ||
+		queue.RatePerMinute < 0 || queue.Burst < 0 || queue.DailyQuota < 0 {
+		return fmt.Errorf("executor.queue limits must not be negative")
+	}
+	if c.Executor.Cache.MaxEntries < 0 || c.Executor.Cache.TTL < 0 {
+		return fmt.Errorf("executor.cache.max_entries and executor.cache.ttl must not be negative")
+	}
+
+	// Similarity validation
+	if c.Similarity.Enabled && (c.Similarity.KGram <= 0 || c.Similarity.Window <= 0) {
+		return fmt.Errorf("similarity.kgram and similarity.window must be positive")
+	}
+
 	// Environment validation
 	validEnvs := map[string]bool{"development": true, "staging": true, "production": true}
 	if !validEnvs[c.App.Environment] {
@@ -276,6 +403,7 @@ func defaultConfig() Config {
 			Password: "",
 			DB:       0,
 			TTL:      3600,
+			PoolSize: 10,
 		},
 		ChromaDB: ChromaDBConfig{
 			URL:       "http://localhost:8000",
@@ -317,6 +445,46 @@ func defaultConfig() Config {
 			BCryptCost:      10,
 			SessionDuration: 24,
 		},
+		Executor: ExecutorConfig{
+			Backend:        "judge0",
+			Judge0URL:      "http://localhost:2358",
+			BatchSize:      20,
+			PollWorkers:    4,
+			PollIntervalMs: 250,
+			CPUTimeLimit:   5.0,
+			WallTimeLimit:  10.0,
+			MemoryLimit:    128000,
+			Local: LocalExecutorConfig{
+				WorkDir:        "",
+				MaxProcesses:   64,
+				MaxOutputBytes: 1 << 20,
+				CompileTimeout: 30,
+			},
+			Complexity: ComplexityConfig{
+				Enabled: true,
+				MaxSize: 65536,
+			},
+			Queue: QueueConfig{
+				MaxConcurrent:    8,
+				MaxQueued:        200,
+				MaxQueuedPerUser: 3,
+				RatePerMinute:    20,
+				Burst:            5,
+				DailyQuota:       1000,
+			},
+			Cache: ResultCacheConfig{
+				Enabled:    true,
+				MaxEntries: 10000,
+				TTL:        86400,
+			},
+			Languages: DefaultLanguages(),
+		},
+		Similarity: SimilarityConfig{
+			Enabled:   true,
+			KGram:     5,
+			Window:    4,
+			Threshold: 0.6,
+		},
 		Logging: LoggingConfig{
 			Level:      "info",
 			Format:     "json",
@@ -329,3 +497,64 @@ func defaultConfig() Config {
 		},
 	}
 }
+
+// DefaultLanguages returns the built-in language definitions. Judge0 IDs
+// refer to Judge0 CE 1.13.
+func DefaultLanguages() map[string]LanguageConfig {
+	return map[string]LanguageConfig{
+		"python": {
+			Enabled: true, Name: "Python", Version: "3.11.2", Judge0ID: 92, Extension: "py", TimeMultiplier: 1,
+			Aliases:     []string{"python3", "py"},
+			StarterCode: "import sys\n\n\ndef main():\n    data = sys.stdin.read().split()\n\n\nif __name__ == \"__main__\":\n    main()\n",
+		},
+		"javascript": {
+			Enabled: true, Name: "JavaScript", Version: "Node.js 18.15.0", Judge0ID: 93, Extension: "js", TimeMultiplier: 1,
+			Aliases:     []string{"js", "node"},
+			StarterCode: "const data = require(\"fs\").readFileSync(0, \"utf8\").split(/\\s+/).filter(Boolean);\n\n",
+		},
+		"typescript": {
+			Enabled: true, Name: "TypeScript", Version: "5.0.3", Judge0ID: 94, Extension: "ts", TimeMultiplier: 1,
+			Aliases:     []string{"ts"},
+			StarterCode: "const data: string[] = require(\"fs\").readFileSync(0, \"utf8\").split(/\\s+/).filter(Boolean);\n\n",
+		},
+		"java": {
+			Enabled: true, Name: "Java", Version: "JDK 17.0.6", Judge0ID: 91, Extension: "java", TimeMultiplier: 1,
+			StarterCode: "import java.util.*;\n\npublic class Main {\n    public static void main(String[] args) {\n        Scanner in = new Scanner(System.in);\n        \n    }\n}\n",
+		},
+		"kotlin": {
+			Enabled: true, Name: "Kotlin", Version: "1.3.70", Judge0ID: 78, Extension: "kt", TimeMultiplier: 1,
+			StarterCode: "fun main() {\n    val tokens = generateSequence(::readLine).flatMap { it.split(\" \").asSequence() }.filter { it.isNotEmpty() }.toList()\n    \n}\n",
+		},
+		"cpp": {
+			Enabled: true, Name: "C++", Version: "GCC 9.2.0", Judge0ID: 54, Extension: "cpp", TimeMultiplier: 1,
+			Aliases:     []string{"c++"},
+			StarterCode: "#include <bits/stdc++.h>\nusing namespace std;\n\nint main() {\n    ios::sync_with_stdio(false);\n    cin.tie(nullptr);\n    \n    return 0;\n}\n",
+		},
+		"c": {
+			Enabled: true, Name: "C", Version: "GCC 9.2.0", Judge0ID: 50, Extension: "c", TimeMultiplier: 1,
+			StarterCode: "#include <stdio.h>\n\nint main(void) {\n    \n    return 0;\n}\n",
+		},
+		"go": {
+			Enabled: true, Name: "Go", Version: "1.18.5", Judge0ID: 95, Extension: "go", TimeMultiplier: 1,
+			Aliases:     []string{"golang"},
+			StarterCode: "package main\n\nimport (\n\t\"bufio\"\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc main() {\n\treader := bufio.NewReader(os.Stdin)\n\twriter := bufio.NewWriter(os.Stdout)\n\tdefer writer.Flush()\n\n\tvar n int\n\tfmt.Fscan(reader, &n)\n\tfmt.Fprintln(writer, n)\n}\n",
+		},
+		"rust": {
+			Enabled: true, Name: "Rust", Version: "1.40.0", Judge0ID: 73, Extension: "rs", TimeMultiplier: 1,
+			StarterCode: "use std::io::{self, Read};\n\nfn main() {\n    let mut input = String::new();\n    io::stdin().read_to_string(&mut input).unwrap();\n    let mut tokens = input.split_whitespace();\n    \n}\n",
+		},
+		"ruby": {
+			Enabled: true, Name: "Ruby", Version: "2.7.0", Judge0ID: 72, Extension: "rb", TimeMultiplier: 1,
+			Aliases:     []string{"rb"},
+			StarterCode: "tokens = STDIN.read.split\n\n",
+		},
+		"php": {
+			Enabled: true, Name: "PHP", Version: "7.4.1", Judge0ID: 68, Extension: "php", TimeMultiplier: 1,
+			StarterCode: "<?php\n$tokens = preg_split('/\\s+/', trim(stream_get_contents(STDIN)));\n\n",
+		},
+		"swift": {
+			Enabled: true, Name: "Swift", Version: "5.2.3", Judge0ID: 83, Extension: "swift", TimeMultiplier: 1,
+			StarterCode: "var tokens: [String] = []\nwhile let line = readLine() {\n    tokens += line.split(separator: \" \").map(String.init)\n}\n\n",
+		},
+	}
+}
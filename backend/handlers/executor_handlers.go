@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/algoholic/services"
+)
+
+type ExecutorHandler struct {
+	codeExecutor *services.CodeExecutor
+}
+
+func NewExecutorHandler(codeExecutor *services.CodeExecutor) *ExecutorHandler {
+	return &ExecutorHandler{codeExecutor: codeExecutor}
+}
+
+// GetStatus reports the execution backend, the depth of its queue and how
+// often results come from the cache
+func (h *ExecutorHandler) GetStatus(c *fiber.Ctx) error {
+	backend := h.codeExecutor.Backend()
+
+	return c.JSON(fiber.Map{
+		"backend":   backend.Name(),
+		"available": backend.IsAvailable(),
+		"queue":     h.codeExecutor.Queue().Stats(),
+		"cache":     h.codeExecutor.CacheStats(),
+	})
+}
+
+// queueRejected responds 429 with a Retry-After hint when err is the
+// execution queue turning a job away, and reports whether it did
+func queueRejected(c *fiber.Ctx, err error) (bool, error) {
+	var rejected *services.QueueRejectedError
+	if !errors.As(err, &rejected) {
+		return false, nil
+	}
+
+	retryAfter := int(math.Ceil(rejected.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return true, c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       rejected.Error(),
+		"reason":      rejected.Reason,
+		"retry_after": retryAfter,
+	})
+}
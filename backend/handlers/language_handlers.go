@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/algoholic/services"
+)
+
+type LanguageHandler struct {
+	codeExecutor *services.CodeExecutor
+}
+
+func NewLanguageHandler(codeExecutor *services.CodeExecutor) *LanguageHandler {
+	return &LanguageHandler{codeExecutor: codeExecutor}
+}
+
+// GetLanguages lists the languages code can be submitted in
+func (h *LanguageHandler) GetLanguages(c *fiber.Ctx) error {
+	languages := h.codeExecutor.Languages()
+
+	return c.JSON(fiber.Map{
+		"languages": languages,
+		"count":     len(languages),
+	})
+}
@@ -72,6 +72,36 @@ func (h *ProblemHandler) GetProblemBySlug(c *fiber.Ctx) error {
 	return c.JSON(problem)
 }
 
+// GetStarterCode returns the starter code for a problem in a language
+func (h *ProblemHandler) GetStarterCode(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid problem ID",
+		})
+	}
+
+	language := c.Query("language", "python")
+	code, err := h.problemService.GetStarterCode(id, language)
+	if err != nil {
+		switch err.Error() {
+		case "problem not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"language": language,
+		"code":     code,
+	})
+}
+
 // SearchProblems searches for problems
 func (h *ProblemHandler) SearchProblems(c *fiber.Ctx) error {
 	query := c.Query("q", "")
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,12 +13,14 @@ import (
 type QuestionHandler struct {
 	questionService *services.QuestionService
 	userService     *services.UserService
+	sessionService  *services.QuestionSessionService
 }
 
-func NewQuestionHandler(questionService *services.QuestionService, userService *services.UserService) *QuestionHandler {
+func NewQuestionHandler(questionService *services.QuestionService, userService *services.UserService, sessionService *services.QuestionSessionService) *QuestionHandler {
 	return &QuestionHandler{
 		questionService: questionService,
 		userService:     userService,
+		sessionService:  sessionService,
 	}
 }
 
@@ -78,7 +82,50 @@ func (h *QuestionHandler) GetRandomQuestion(c *fiber.Ctx) error {
 	return c.JSON(question)
 }
 
-// SubmitAnswer handles question answer submission
+// StartQuestion starts a question session, which the answer must be
+// submitted with. Template questions start with a fresh instance.
+func (h *QuestionHandler) StartQuestion(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid question ID",
+		})
+	}
+
+	started, err := h.sessionService.Start(userID, id)
+	if err != nil {
+		if err.Error() == "question not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Question not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start question",
+		})
+	}
+
+	if started.Seed != nil {
+		started.Instance, err = h.questionService.ShowInstance(id, *started.Seed)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(started)
+}
+
+// SubmitAnswer handles question answer submission. Time taken and hints
+// used are measured from the question session; submitting the same
+// session again returns the same result without recording another attempt.
 func (h *QuestionHandler) SubmitAnswer(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
@@ -103,13 +150,63 @@ func (h *QuestionHandler) SubmitAnswer(c *fiber.Ctx) error {
 
 	req.QuestionID = id
 
+	session, replay, err := h.sessionService.Claim(req.SessionToken, userID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSession):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, services.ErrSessionInProgress):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if replay != nil {
+		return c.JSON(replay)
+	}
+
+	req.SessionID = &session.SessionID
+	req.TimeTaken = h.sessionService.Elapsed(session)
+	req.HintsUsed = h.sessionService.HintsUsed(session)
+	req.Seed = session.Seed
+
 	response, err := h.questionService.SubmitAnswer(userID, req)
 	if err != nil {
+		h.sessionService.Release(session)
+		if handled, err := queueRejected(c, err); handled {
+			return err
+		}
+		if errors.Is(err, services.ErrInvalidAnswer) || errors.Is(err, services.ErrInvalidSession) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, services.ErrExecutionFailed) {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error": "Code execution service unavailable",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	if err := h.sessionService.Complete(session, response); err != nil {
+		log.Printf("Warning: failed to store response of session %s: %v", session.SessionID, err)
+	}
+
+	// Update proficiency in the question's topics
+	if topicIDs, err := h.questionService.GetQuestionTopicIDs(id); err == nil {
+		for _, topicID := range topicIDs {
+			h.userService.UpdateUserProgress(userID, topicID, response.Score, req.TimeTaken)
+		}
+	}
+
 	// Update user streak
 	h.userService.UpdateStreak(userID)
 
@@ -119,6 +216,47 @@ func (h *QuestionHandler) SubmitAnswer(c *fiber.Ctx) error {
 	return c.JSON(response)
 }
 
+// RunCode runs code against custom input or sample tests without recording an attempt
+func (h *QuestionHandler) RunCode(c *fiber.Ctx) error {
+	// Nothing is recorded, so anonymous callers share one user's limits
+	userID, _ := middleware.GetUserID(c)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid question ID",
+		})
+	}
+
+	var req services.RunRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	response, err := h.questionService.RunCode(userID, id, req)
+	if err != nil {
+		if handled, err := queueRejected(c, err); handled {
+			return err
+		}
+		if errors.Is(err, services.ErrExecutionFailed) {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error": "Code execution service unavailable",
+			})
+		}
+		status := fiber.StatusBadRequest
+		if err.Error() == "question not found" {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
 // GetQuestionsByProblem retrieves questions for a specific problem
 func (h *QuestionHandler) GetQuestionsByProblem(c *fiber.Ctx) error {
 	problemID, err := strconv.Atoi(c.Params("problemId"))
@@ -170,7 +308,8 @@ func (h *QuestionHandler) GetUserAttempts(c *fiber.Ctx) error {
 	})
 }
 
-// GetHint retrieves a hint for a question
+// GetHint retrieves a hint for a question. The hint counts toward the
+// user's open session for the question, if any.
 func (h *QuestionHandler) GetHint(c *fiber.Ctx) error {
 	questionID, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
@@ -195,7 +334,7 @@ func (h *QuestionHandler) GetHint(c *fiber.Ctx) error {
 
 	userID, _ := middleware.GetUserID(c)
 	if userID > 0 {
-		h.questionService.RecordHintUsage(userID, questionID, hintLevel)
+		h.questionService.RecordHintUsage(userID, questionID, hintLevel, h.sessionService.OpenSession(userID, questionID))
 	}
 
 	return c.JSON(fiber.Map{
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -272,6 +273,23 @@ type questionResult struct {
 	SimilarityScore float64 `json:"similarity_score,omitempty"`
 }
 
+// MarshalJSON adds the similarity score to the question's own encoding,
+// which would otherwise be used for the whole result
+func (r questionResult) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(r.Question)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if r.SimilarityScore != 0 {
+		fields["similarity_score"] = r.SimilarityScore
+	}
+	return json.Marshal(fields)
+}
+
 func (h *SearchHandler) enrichQuestions(docs []services.SimilarDoc) []questionResult {
 	results := make([]questionResult, 0, len(docs))
 	for _, doc := range docs {
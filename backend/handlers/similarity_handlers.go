@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/algoholic/services"
+)
+
+type SimilarityHandler struct {
+	similarityService *services.SimilarityService
+}
+
+// NewSimilarityHandler creates a similarity handler. similarityService is
+// nil when similarity detection is disabled.
+func NewSimilarityHandler(similarityService *services.SimilarityService) *SimilarityHandler {
+	return &SimilarityHandler{similarityService: similarityService}
+}
+
+// GetSuspiciousPairs lists pairs of submissions similar enough to suggest
+// copying, with their matching regions
+// GET /api/admin/similarity (admin only)
+func (h *SimilarityHandler) GetSuspiciousPairs(c *fiber.Ctx) error {
+	if h.similarityService == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Similarity detection is disabled",
+		})
+	}
+
+	problemID := c.QueryInt("problem_id", 0)
+	minSimilarity := c.QueryFloat("min_similarity", h.similarityService.Threshold())
+	limit := c.QueryInt("limit", 20)
+	offset := c.QueryInt("offset", 0)
+
+	pairs, total, err := h.similarityService.GetSuspiciousPairs(problemID, minSimilarity, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve similar submissions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"pairs":          pairs,
+		"total":          total,
+		"min_similarity": minSimilarity,
+		"limit":          limit,
+		"offset":         offset,
+	})
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/algoholic/middleware"
+	"github.com/yourusername/algoholic/services"
+)
+
+type SubmissionHandler struct {
+	submissionService *services.SubmissionService
+}
+
+func NewSubmissionHandler(submissionService *services.SubmissionService) *SubmissionHandler {
+	return &SubmissionHandler{submissionService: submissionService}
+}
+
+// CreateSubmission submits code for a problem; evaluation happens in the background
+func (h *SubmissionHandler) CreateSubmission(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	problemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid problem ID",
+		})
+	}
+
+	var req services.SubmissionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	submission, err := h.submissionService.CreateSubmission(userID, problemID, req)
+	if err != nil {
+		if handled, err := queueRejected(c, err); handled {
+			return err
+		}
+		status := fiber.StatusBadRequest
+		if err.Error() == "problem not found" {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(submission)
+}
+
+// GetSubmissions lists the user's submissions for a problem
+func (h *SubmissionHandler) GetSubmissions(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	problemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid problem ID",
+		})
+	}
+
+	limit := c.QueryInt("limit", 20)
+	offset := c.QueryInt("offset", 0)
+
+	submissions, total, err := h.submissionService.GetUserSubmissions(userID, problemID, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve submissions",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"submissions": submissions,
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+	})
+}
+
+// GetSubmission retrieves a single submission, for polling its status
+func (h *SubmissionHandler) GetSubmission(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	problemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid problem ID",
+		})
+	}
+
+	submissionID, err := strconv.Atoi(c.Params("submissionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid submission ID",
+		})
+	}
+
+	submission, err := h.submissionService.GetSubmission(userID, problemID, submissionID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Submission not found",
+		})
+	}
+
+	return c.JSON(submission)
+}
+
+// sseKeepAlive is how often an idle event stream sends a comment, keeping
+// proxies from closing it and noticing clients that went away
+const sseKeepAlive = 15 * time.Second
+
+// StreamSubmission streams a submission's evaluation as Server-Sent Events:
+// queued, running and judged events per test case, then a result event with
+// the full execution result (or an error event)
+func (h *SubmissionHandler) StreamSubmission(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	problemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid problem ID",
+		})
+	}
+
+	submissionID, err := strconv.Atoi(c.Params("submissionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid submission ID",
+		})
+	}
+
+	events, cancel, err := h.submissionService.SubscribeSubmission(userID, problemID, submissionID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Submission not found",
+		})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		ticker := time.NewTicker(sseKeepAlive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case event, open := <-events:
+				if !open {
+					// Dropped for falling behind; the client can fetch the
+					// submission for its result
+					writeSSE(w, services.SubmissionEventError, fiber.Map{"error": "progress stream interrupted"})
+					return
+				}
+				if err := writeSSE(w, event.Type, event.Data); err != nil {
+					return
+				}
+				if event.Type == services.SubmissionEventResult || event.Type == services.SubmissionEventError {
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeSSE writes one Server-Sent Event and flushes it to the client
+func writeSSE(w *bufio.Writer, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return w.Flush()
+}
+
+// StressTest compares code with the problem's reference solution on
+// generated inputs and returns the smallest disagreeing input found
+func (h *SubmissionHandler) StressTest(c *fiber.Ctx) error {
+	// Nothing is recorded, so anonymous callers share one user's limits
+	userID, _ := middleware.GetUserID(c)
+
+	problemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid problem ID",
+		})
+	}
+
+	var req services.StressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	result, err := h.submissionService.StressTest(userID, problemID, req)
+	if err != nil {
+		if handled, err := queueRejected(c, err); handled {
+			return err
+		}
+		if errors.Is(err, services.ErrExecutionFailed) {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error": "Code execution service unavailable",
+			})
+		}
+		status := fiber.StatusBadRequest
+		switch err.Error() {
+		case "problem not found", "problem has no reference solution", "problem has no input generator":
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
@@ -10,14 +10,16 @@ import (
 )
 
 type UserHandler struct {
-	userService     *services.UserService
-	questionService *services.QuestionService
+	userService        *services.UserService
+	questionService    *services.QuestionService
+	calibrationService *services.CalibrationService
 }
 
-func NewUserHandler(userService *services.UserService, questionService *services.QuestionService) *UserHandler {
+func NewUserHandler(userService *services.UserService, questionService *services.QuestionService, calibrationService *services.CalibrationService) *UserHandler {
 	return &UserHandler{
-		userService:     userService,
-		questionService: questionService,
+		userService:        userService,
+		questionService:    questionService,
+		calibrationService: calibrationService,
 	}
 }
 
@@ -105,8 +107,26 @@ func (h *UserHandler) GetRecommendations(c *fiber.Ctx) error {
 
 	recommendations := make([]fiber.Map, 0)
 
+	// Confidently wrong topics are weak topics the user does not know are weak
+	confidentlyWrong := make(map[int]bool)
+	if topicIDs, err := h.calibrationService.ConfidentlyWrongTopics(userID); err == nil {
+		for _, id := range topicIDs {
+			confidentlyWrong[id] = true
+		}
+	}
+
 	// Generate recommendations based on weak topics
 	for _, topic := range weakTopics {
+		if confidentlyWrong[topic.TopicID] {
+			recommendations = append(recommendations, fiber.Map{
+				"type":     "practice_topic",
+				"topic":    topic,
+				"reason":   "Confidently wrong answers - more confident than accurate",
+				"priority": "high",
+				"action":   "Practice questions for this topic and check your reasoning",
+			})
+			continue
+		}
 		recommendations = append(recommendations, fiber.Map{
 			"type":        "practice_topic",
 			"topic":       topic,
@@ -154,6 +174,26 @@ func (h *UserHandler) GetRecommendations(c *fiber.Ctx) error {
 	})
 }
 
+// GetCalibration retrieves how well the user's confidence predicts their
+// answers, overall and per topic
+func (h *UserHandler) GetCalibration(c *fiber.Ctx) error {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Unauthorized",
+		})
+	}
+
+	report, err := h.calibrationService.GetCalibration(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve calibration",
+		})
+	}
+
+	return c.JSON(report)
+}
+
 // GetUserProgress retrieves progress for a specific topic
 func (h *UserHandler) GetUserProgress(c *fiber.Ctx) error {
 	userID, ok := middleware.GetUserID(c)
@@ -64,6 +64,25 @@ func OptionalAuthMiddleware(authService *services.AuthService) fiber.Handler {
 	}
 }
 
+// AdminMiddleware allows only the listed user IDs through. Usernames are
+// first-come at registration, so they can't be trusted for this. It must run
+// after AuthMiddleware, which sets the user ID.
+func AdminMiddleware(adminIDs []int) fiber.Handler {
+	allowed := make(map[int]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		allowed[id] = true
+	}
+	return func(c *fiber.Ctx) error {
+		userID, ok := GetUserID(c)
+		if !ok || !allowed[userID] {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin access required",
+			})
+		}
+		return c.Next()
+	}
+}
+
 // GetUserID extracts user ID from context
 func GetUserID(c *fiber.Ctx) (int, bool) {
 	userID, ok := c.Locals("user_id").(int)
@@ -169,6 +169,9 @@ type Problem struct {
 	AcceptanceRate     *float64    `json:"acceptance_rate,omitempty" gorm:"column:acceptance_rate"`
 	Companies          JSONB       `json:"companies,omitempty" gorm:"column:companies;type:jsonb"`
 	Tags               JSONB       `json:"tags,omitempty" gorm:"column:tags;type:jsonb"`
+	FunctionSignature  JSONB       `json:"function_signature,omitempty" gorm:"column:function_signature;type:jsonb"`
+	ReferenceSolution  JSONB       `json:"-" gorm:"column:reference_solution;type:jsonb"`
+	InputGenerator     JSONB       `json:"-" gorm:"column:input_generator;type:jsonb"`
 	CreatedAt          time.Time   `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt          time.Time   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 }
@@ -226,6 +229,7 @@ type Question struct {
 	TotalAttempts           int         `json:"total_attempts" gorm:"column:total_attempts;default:0"`
 	CorrectAttempts         int         `json:"correct_attempts" gorm:"column:correct_attempts;default:0"`
 	AverageTimeSeconds      *float64    `json:"average_time_seconds,omitempty" gorm:"column:average_time_seconds"`
+	Template                JSONB       `json:"template,omitempty" gorm:"column:template;type:jsonb"`
 	CreatedAt               time.Time   `json:"created_at" gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt               time.Time   `json:"updated_at" gorm:"column:updated_at;autoUpdateTime"`
 }
@@ -234,6 +238,55 @@ func (Question) TableName() string {
 	return "questions"
 }
 
+// MarshalJSON encodes a question for clients, leaving its hidden test cases
+// out of the correct answer
+func (q Question) MarshalJSON() ([]byte, error) {
+	type question Question
+	public := question(q)
+	public.CorrectAnswer = PublicCorrectAnswer(q.CorrectAnswer)
+	return json.Marshal(public)
+}
+
+// PublicCorrectAnswer returns the part of a correct answer clients may see:
+// all of it but the hidden test cases
+func PublicCorrectAnswer(answer JSONB) JSONB {
+	testCases, ok := answer["test_cases"]
+	if !ok {
+		return answer
+	}
+
+	// Test cases built in Go are typed lists; decode them like stored ones
+	var cases []map[string]interface{}
+	if data, err := json.Marshal(testCases); err == nil {
+		json.Unmarshal(data, &cases)
+	}
+	visible := make([]map[string]interface{}, 0, len(cases))
+	for _, testCase := range cases {
+		if !IsHiddenTestCase(testCase) {
+			visible = append(visible, testCase)
+		}
+	}
+
+	public := make(JSONB, len(answer))
+	for key, value := range answer {
+		public[key] = value
+	}
+	public["test_cases"] = visible
+	return public
+}
+
+// IsHiddenTestCase reads a test case's visibility. Test cases are hidden
+// unless marked "sample": true or "hidden": false.
+func IsHiddenTestCase(testCase map[string]interface{}) bool {
+	if hidden, ok := testCase["hidden"].(bool); ok {
+		return hidden
+	}
+	if sample, ok := testCase["sample"].(bool); ok {
+		return !sample
+	}
+	return true
+}
+
 // UserAttempt tracks question/problem attempts
 type UserAttempt struct {
 	AttemptID         int         `json:"attempt_id" gorm:"primaryKey;column:attempt_id"`
@@ -242,6 +295,7 @@ type UserAttempt struct {
 	ProblemID         *int        `json:"problem_id,omitempty" gorm:"column:problem_id"`
 	UserAnswer        JSONB       `json:"user_answer" gorm:"column:user_answer;type:jsonb;not null"`
 	IsCorrect         bool        `json:"is_correct" gorm:"column:is_correct;not null"`
+	Score             *float64    `json:"score,omitempty" gorm:"column:score"`
 	TimeTakenSeconds  int         `json:"time_taken_seconds" gorm:"column:time_taken_seconds;not null"`
 	AttemptNumber     int         `json:"attempt_number" gorm:"column:attempt_number;default:1"`
 	HintsUsed         int         `json:"hints_used" gorm:"column:hints_used;default:0"`
@@ -426,33 +480,79 @@ func (ReviewQueue) TableName() string {
 
 // CodeSubmission tracks user code submissions for AI assessment
 type CodeSubmission struct {
-	SubmissionID    int        `json:"submission_id" gorm:"primaryKey;column:submission_id"`
-	UserID          int        `json:"user_id" gorm:"column:user_id;not null;index"`
-	ProblemID       int        `json:"problem_id" gorm:"column:problem_id;not null;index"`
-	Code            string     `json:"code" gorm:"column:code;not null;type:text"`
-	Language        string     `json:"language" gorm:"column:language;not null"`
-	Status          string     `json:"status" gorm:"column:status;default:'pending'"`
-	TestResults     JSONB      `json:"test_results,omitempty" gorm:"column:test_results;type:jsonb"`
-	AIFeedback      JSONB      `json:"ai_feedback,omitempty" gorm:"column:ai_feedback;type:jsonb"`
-	AIScore         *float64   `json:"ai_score,omitempty" gorm:"column:ai_score"`
-	TimeComplexity  *string    `json:"time_complexity,omitempty" gorm:"column:time_complexity"`
-	SpaceComplexity *string    `json:"space_complexity,omitempty" gorm:"column:space_complexity"`
-	ExecutionTimeMs *int       `json:"execution_time_ms,omitempty" gorm:"column:execution_time_ms"`
-	MemoryUsedKb    *int       `json:"memory_used_kb,omitempty" gorm:"column:memory_used_kb"`
-	SubmittedAt     time.Time  `json:"submitted_at" gorm:"column:submitted_at;autoCreateTime"`
-	EvaluatedAt     *time.Time `json:"evaluated_at,omitempty" gorm:"column:evaluated_at"`
+	SubmissionID       int        `json:"submission_id" gorm:"primaryKey;column:submission_id"`
+	UserID             int        `json:"user_id" gorm:"column:user_id;not null;index"`
+	ProblemID          int        `json:"problem_id" gorm:"column:problem_id;not null;index"`
+	Code               string     `json:"code" gorm:"column:code;not null;type:text"`
+	Language           string     `json:"language" gorm:"column:language;not null"`
+	Status             string     `json:"status" gorm:"column:status;default:'pending'"`
+	TestResults        JSONB      `json:"test_results,omitempty" gorm:"column:test_results;type:jsonb"`
+	AIFeedback         JSONB      `json:"ai_feedback,omitempty" gorm:"column:ai_feedback;type:jsonb"`
+	AIScore            *float64   `json:"ai_score,omitempty" gorm:"column:ai_score"`
+	TimeComplexity     *string    `json:"time_complexity,omitempty" gorm:"column:time_complexity"`
+	SpaceComplexity    *string    `json:"space_complexity,omitempty" gorm:"column:space_complexity"`
+	ComplexityAnalysis JSONB      `json:"complexity_analysis,omitempty" gorm:"column:complexity_analysis;type:jsonb"`
+	ExecutionTimeMs    *int       `json:"execution_time_ms,omitempty" gorm:"column:execution_time_ms"`
+	MemoryUsedKb       *int       `json:"memory_used_kb,omitempty" gorm:"column:memory_used_kb"`
+	SubmittedAt        time.Time  `json:"submitted_at" gorm:"column:submitted_at;autoCreateTime"`
+	EvaluatedAt        *time.Time `json:"evaluated_at,omitempty" gorm:"column:evaluated_at"`
 }
 
 func (CodeSubmission) TableName() string {
 	return "code_submissions"
 }
 
-// QuestionHintUsage tracks which hints a user has seen
+// SubmissionSimilarity is the fingerprint similarity between two users'
+// submissions to the same problem. SubmissionAID is the earlier submission.
+type SubmissionSimilarity struct {
+	SimilarityID  int        `json:"similarity_id" gorm:"primaryKey;column:similarity_id"`
+	ProblemID     int        `json:"problem_id" gorm:"column:problem_id;not null;index"`
+	Language      string     `json:"language" gorm:"column:language;not null"`
+	SubmissionAID int        `json:"submission_a_id" gorm:"column:submission_a_id;not null;uniqueIndex:idx_similarity_pair"`
+	SubmissionBID int        `json:"submission_b_id" gorm:"column:submission_b_id;not null;uniqueIndex:idx_similarity_pair"`
+	UserAID       int        `json:"user_a_id" gorm:"column:user_a_id;not null"`
+	UserBID       int        `json:"user_b_id" gorm:"column:user_b_id;not null"`
+	Similarity    float64    `json:"similarity" gorm:"column:similarity;not null;index"`
+	CoverageA     float64    `json:"coverage_a" gorm:"column:coverage_a"`
+	CoverageB     float64    `json:"coverage_b" gorm:"column:coverage_b"`
+	Matches       JSONBArray `json:"matches,omitempty" gorm:"column:matches;type:jsonb"`
+	ComputedAt    time.Time  `json:"computed_at" gorm:"column:computed_at;autoUpdateTime"`
+}
+
+func (SubmissionSimilarity) TableName() string {
+	return "submission_similarities"
+}
+
+// QuestionSession is an attempt at a question started on the server, so
+// that the time taken is measured there. SubmittedAt is set while the
+// answer is graded; Response is the graded answer, returned again when the
+// submission is replayed. Seed generates the instance of a template
+// question the session shows.
+type QuestionSession struct {
+	SessionID   string     `json:"session_id" gorm:"primaryKey;column:session_id"`
+	UserID      int        `json:"user_id" gorm:"column:user_id;not null;index"`
+	QuestionID  int        `json:"question_id" gorm:"column:question_id;not null"`
+	StartedAt   time.Time  `json:"started_at" gorm:"column:started_at;not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"column:expires_at;not null"`
+	SubmittedAt *time.Time `json:"submitted_at,omitempty" gorm:"column:submitted_at"`
+	AttemptID   *int       `json:"attempt_id,omitempty" gorm:"column:attempt_id"`
+	Response    JSONB      `json:"response,omitempty" gorm:"column:response;type:jsonb"`
+	Seed        *int64     `json:"-" gorm:"column:seed"`
+}
+
+func (QuestionSession) TableName() string {
+	return "question_sessions"
+}
+
+// QuestionHintUsage tracks which hints a user has seen. A hint is recorded
+// once per question session, or once for hints seen outside of sessions
+// (SessionID nil).
 type QuestionHintUsage struct {
 	UsageID    int       `json:"usage_id" gorm:"primaryKey;column:usage_id"`
-	UserID     int       `json:"user_id" gorm:"column:user_id;not null;uniqueIndex:idx_user_question_hint"`
-	QuestionID int       `json:"question_id" gorm:"column:question_id;not null;uniqueIndex:idx_user_question_hint"`
-	HintLevel  int       `json:"hint_level" gorm:"column:hint_level;not null;uniqueIndex:idx_user_question_hint"`
+	UserID     int       `json:"user_id" gorm:"column:user_id;not null;index:idx_hint_usage_user_question;uniqueIndex:idx_sessionless_hint,where:session_id IS NULL"`
+	QuestionID int       `json:"question_id" gorm:"column:question_id;not null;index:idx_hint_usage_user_question;uniqueIndex:idx_sessionless_hint"`
+	SessionID  *string   `json:"session_id,omitempty" gorm:"column:session_id;uniqueIndex:idx_session_hint"`
+	HintLevel  int       `json:"hint_level" gorm:"column:hint_level;not null;uniqueIndex:idx_session_hint;uniqueIndex:idx_sessionless_hint"`
 	UsedAt     time.Time `json:"used_at" gorm:"column:used_at;autoCreateTime"`
 }
 
@@ -491,6 +591,8 @@ func AutoMigrate(db *gorm.DB) error {
 		&SpacedRepetitionReview{},
 		&ReviewQueue{},
 		&CodeSubmission{},
+		&SubmissionSimilarity{},
+		&QuestionSession{},
 		&QuestionHintUsage{},
 	)
 }
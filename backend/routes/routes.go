@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"log"
+
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 
@@ -14,9 +16,28 @@ import (
 func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	// Initialize services
 	authService := services.NewAuthService(db, cfg)
-	problemService := services.NewProblemService(db)
-	questionService := services.NewQuestionService(db)
+	languages := services.NewLanguageRegistry(cfg.Executor.Languages)
+	problemService := services.NewProblemService(db, languages)
+
+	executor, err := services.NewExecutor(cfg.Executor)
+	if err != nil {
+		log.Printf("Warning: executor backend %q unavailable (%v), using judge0", cfg.Executor.Backend, err)
+		executor = services.NewJudge0Executor(cfg.Executor)
+	}
+	executionQueue := services.NewExecutionQueue(services.QueueOptionsFromConfig(cfg.Executor))
+	resultCache := services.NewResultCache(cfg.Executor.Cache, cfg.Redis)
+	codeExecutor := services.NewCodeExecutor(executor, services.LimitsFromConfig(cfg.Executor), languages, executionQueue, resultCache)
+	assessmentService := services.NewAssessmentService(cfg.Ollama)
+	questionService := services.NewQuestionService(db, codeExecutor, assessmentService)
+	questionSessionService := services.NewQuestionSessionService(db, cfg.Auth.JWTSecret)
+	var similarityService *services.SimilarityService
+	if cfg.Similarity.Enabled {
+		similarityService = services.NewSimilarityService(db, problemService, services.SimilarityOptionsFromConfig(cfg.Similarity))
+	}
+	submissionService := services.NewSubmissionService(db, codeExecutor, problemService, services.ComplexityOptionsFromConfig(cfg.Executor), similarityService)
+	go submissionService.ResumePending()
 	userService := services.NewUserService(db)
+	calibrationService := services.NewCalibrationService(db)
 	trainingPlanService := services.NewTrainingPlanService(db, questionService, userService)
 
 	// Phase 2: Intelligence services
@@ -27,13 +48,17 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	problemHandler := handlers.NewProblemHandler(problemService)
-	questionHandler := handlers.NewQuestionHandler(questionService, userService)
-	userHandler := handlers.NewUserHandler(userService, questionService)
+	submissionHandler := handlers.NewSubmissionHandler(submissionService)
+	questionHandler := handlers.NewQuestionHandler(questionService, userService, questionSessionService)
+	userHandler := handlers.NewUserHandler(userService, questionService, calibrationService)
 	trainingPlanHandler := handlers.NewTrainingPlanHandler(trainingPlanService)
 	listHandler := handlers.NewListHandler(db)
 	activityHandler := handlers.NewActivityHandler(db)
 	searchHandler := handlers.NewSearchHandler(db, vectorService, graphService)
 	topicHandler := handlers.NewTopicHandler(db)
+	languageHandler := handlers.NewLanguageHandler(codeExecutor)
+	executorHandler := handlers.NewExecutorHandler(codeExecutor)
+	similarityHandler := handlers.NewSimilarityHandler(similarityService)
 
 	// Public routes
 	api := app.Group("/api")
@@ -63,6 +88,12 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	protected.Get("/auth/me", authHandler.GetMe)
 	protected.Post("/auth/change-password", authHandler.ChangePassword)
 
+	// Language routes (public)
+	api.Get("/languages", languageHandler.GetLanguages)
+
+	// Executor routes (public)
+	api.Get("/executor/status", executorHandler.GetStatus)
+
 	// Problem routes
 	problems := api.Group("/problems")
 	problems.Get("/", problemHandler.GetProblems)
@@ -71,6 +102,12 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	problems.Get("/slug/:slug", problemHandler.GetProblemBySlug)
 	problems.Get("/:id/topics", problemHandler.GetProblemTopics)
 	problems.Get("/:id/similar", searchHandler.FindSimilarProblems)
+	problems.Get("/:id/starter-code", problemHandler.GetStarterCode)
+	protected.Post("/problems/:id/submissions", submissionHandler.CreateSubmission)
+	protected.Get("/problems/:id/submissions", submissionHandler.GetSubmissions)
+	protected.Get("/problems/:id/submissions/:submissionId", submissionHandler.GetSubmission)
+	protected.Get("/problems/:id/submissions/:submissionId/events", submissionHandler.StreamSubmission)
+	protected.Post("/problems/:id/stress", submissionHandler.StressTest)
 
 	// Question routes
 	questions := api.Group("/questions")
@@ -78,7 +115,9 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	questions.Get("/random", questionHandler.GetRandomQuestion)
 	questions.Get("/:id", questionHandler.GetQuestion)
 	questions.Get("/:id/hint", questionHandler.GetHint)
+	protected.Post("/questions/:id/start", questionHandler.StartQuestion)
 	protected.Post("/questions/:id/answer", questionHandler.SubmitAnswer)
+	protected.Post("/questions/:id/run", questionHandler.RunCode)
 	protected.Get("/questions/:id/attempts", questionHandler.GetUserAttempts)
 
 	// Topic routes (public)
@@ -97,6 +136,7 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	users.Get("/me/weaknesses", userHandler.GetWeaknesses)
 	users.Get("/me/recommendations", userHandler.GetRecommendations)
 	users.Get("/me/review-queue", userHandler.GetReviewQueue)
+	users.Get("/me/calibration", userHandler.GetCalibration)
 	users.Get("/me/skills", userHandler.GetUserSkills)
 	users.Get("/me/skills/:topicId", userHandler.GetUserProgress)
 	users.Get("/me/preferences", userHandler.GetPreferences)
@@ -146,6 +186,10 @@ func SetupRoutes(app *fiber.App, db *gorm.DB, cfg *config.Config) {
 	// Phase 2: Intelligence status (public health-check)
 	api.Get("/intelligence/status", searchHandler.IntelligenceStatus)
 
+	// Admin routes, authenticated whether or not auth is enabled
+	requireAdmin := []fiber.Handler{middleware.AuthMiddleware(authService), middleware.AdminMiddleware(cfg.Auth.AdminUserIDs)}
+	api.Get("/admin/similarity", append(requireAdmin, similarityHandler.GetSuspiciousPairs)...)
+
 	// Development-only routes
 	if cfg.IsDevelopment() {
 		api.Get("/config", func(c *fiber.Ctx) error {
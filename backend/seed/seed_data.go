@@ -144,6 +144,23 @@ func GetSeedProblems() []models.Problem {
 			SecondaryPatterns:  models.StringArray{"Array"},
 			TimeComplexity:     strPtr("O(n)"),
 			SpaceComplexity:    strPtr("O(n)"),
+			FunctionSignature: jsonbMap(map[string]interface{}{
+				"function": "twoSum",
+				"params": []map[string]interface{}{
+					{"name": "nums", "type": "int[]"},
+					{"name": "target", "type": "int"},
+				},
+				"return": "int[]",
+			}),
+			ReferenceSolution: jsonbMap(map[string]interface{}{
+				"language": "python",
+				"code":     "class Solution:\n    def twoSum(self, nums: List[int], target: int) -> List[int]:\n        seen = {}\n        for i, num in enumerate(nums):\n            if target - num in seen:\n                return [seen[target - num], i]\n            seen[num] = i\n        return []\n",
+			}),
+			// Plants exactly one pair summing to target
+			InputGenerator: jsonbMap(map[string]interface{}{
+				"language": "python",
+				"code":     "import random\n\nseed, size = map(int, input().split())\nrng = random.Random(seed)\nn = rng.randint(2, 2 + size // 10)\nwhile True:\n    nums = [rng.randint(-20, 20) for _ in range(n)]\n    i, j = sorted(rng.sample(range(n), 2))\n    target = nums[i] + nums[j]\n    pairs = [(a, b) for a in range(n) for b in range(a + 1, n) if nums[a] + nums[b] == target]\n    if len(pairs) == 1:\n        break\nprint(nums)\nprint(target)\n",
+			}),
 		},
 		{
 			LeetcodeNumber:     intPtr(121),
@@ -381,9 +398,9 @@ func GetSeedQuestions() []models.Question {
 				"language": "python",
 			}),
 			CorrectAnswer: jsonbMap(map[string]interface{}{
-				"test_cases": []map[string]string{
-					{"input": "[2,7,11,15]\n9", "expected": "[0, 1]"},
-					{"input": "[3,2,4]\n6", "expected": "[1, 2]"},
+				"test_cases": []map[string]interface{}{
+					{"input": "[2,7,11,15]\n9", "expected": "[0, 1]", "sample": true},
+					{"input": "[3,2,4]\n6", "expected": "[1, 2]", "sample": true},
 					{"input": "[3,3]\n6", "expected": "[0, 1]"},
 				},
 			}),
@@ -560,5 +577,50 @@ func GetSeedQuestions() []models.Question {
 			CreatedAt:            now,
 			UpdatedAt:            now,
 		},
+
+		// Template questions: each session shows a freshly generated instance
+		{
+			QuestionType:   "algorithm_trace",
+			QuestionFormat: "fill_blank",
+			QuestionText:   "Binary search looks for {{target}} in {{array}}, probing mid = lo + (hi - lo) / 2. What index does it return (-1 if the target is absent), and how many elements does it probe?",
+			CorrectAnswer: jsonbMap(map[string]interface{}{
+				"blanks": []map[string]interface{}{
+					{"answers": []string{"{{index}}"}, "exact": true},
+					{"answers": []string{"{{probes}}"}, "exact": true},
+				},
+			}),
+			Explanation:          "The search probes indices {{mids}} in turn, halving the range each time, and returns {{index}}.",
+			RelatedConcepts:      models.StringArray{"Binary Search", "Arrays"},
+			CommonMistakes:       models.StringArray{"Rounding mid up", "Moving hi to mid instead of mid - 1"},
+			DifficultyScore:      30.0,
+			EstimatedTimeSeconds: intPtr(120),
+			Template: jsonbMap(map[string]interface{}{
+				"generator": "binary_search",
+				"params":    map[string]interface{}{"length": []int{7, 12}, "values": []int{1, 60}},
+			}),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		{
+			QuestionType:   "algorithm_trace",
+			QuestionFormat: "fill_blank",
+			QuestionText:   "The values {{pushes}} are pushed one at a time onto an empty min-heap stored in an array. What is the array afterwards?",
+			CorrectAnswer: jsonbMap(map[string]interface{}{
+				"blanks": []map[string]interface{}{
+					{"answers": []string{"{{heap}}"}, "exact": true},
+				},
+			}),
+			Explanation:          "Each push appends the value and swaps it with its parent while it is smaller, leaving {{heap}}.",
+			RelatedConcepts:      models.StringArray{"Heap", "Priority Queue"},
+			CommonMistakes:       models.StringArray{"Sorting the values instead of sifting up", "Sifting down after a push"},
+			DifficultyScore:      35.0,
+			EstimatedTimeSeconds: intPtr(150),
+			Template: jsonbMap(map[string]interface{}{
+				"generator": "heap",
+				"params":    map[string]interface{}{"pushes": []int{5, 7}, "values": []int{1, 50}},
+			}),
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
 	}
 }
@@ -0,0 +1,843 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// BigO is a complexity expression such as O(n log n + m) in canonical form:
+// a sum of terms with constant factors dropped and every term that grows no
+// faster than another removed. Variables are single letters, compared
+// case-insensitively.
+type BigO struct {
+	terms []bigOTerm
+}
+
+// bigOTerm is a product of growth factors, one per variable, times a
+// constant. The constant only matters while parsing, for exponents such as
+// 2^(2n).
+type bigOTerm struct {
+	coef    float64
+	factors map[string]bigOFactor
+}
+
+// bigOFactor is the growth of a term in one variable n:
+// n!^factorial * base^n * n^power * (log n)^log * (log log n)^loglog
+type bigOFactor struct {
+	factorial float64
+	base      float64
+	power     float64
+	log       float64
+	loglog    float64
+}
+
+// ComplexityVerdict compares a complexity answer with the correct one
+type ComplexityVerdict string
+
+const (
+	// ComplexityTight is the correct bound
+	ComplexityTight ComplexityVerdict = "tight"
+	// ComplexityNotTight is a valid upper bound that grows faster than the
+	// correct one, such as O(n^2) for a linear algorithm
+	ComplexityNotTight ComplexityVerdict = "not_tight"
+	// ComplexityWrong is not an upper bound of the correct one
+	ComplexityWrong ComplexityVerdict = "wrong"
+)
+
+// complexityWords name growth classes in words
+var complexityWords = []struct {
+	word string
+	expr string
+}{
+	{"constant", "1"},
+	{"logarithmic", "log n"},
+	{"linearithmic", "n log n"},
+	{"linear", "n"},
+	{"quadratic", "n^2"},
+	{"cubic", "n^3"},
+	{"exponential", "2^n"},
+	{"factorial", "n!"},
+}
+
+// ParseBigO parses a complexity expression, with or without the O( )
+// around it. It understands sums, products (written or implied, as in
+// "nlogn"), powers, n!, sqrt, log (also lg, ln and any base) and
+// exponentials such as 2^n.
+func ParseBigO(text string) (*BigO, error) {
+	text = strings.TrimSpace(text)
+	if inner, ok := stripBigO(text); ok {
+		text = inner
+	}
+	p, err := newBigOParser(text)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q in complexity", p.peek().text)
+	}
+	return canonicalBigO(sum), nil
+}
+
+// ExtractBigO finds the complexity an answer gives: the first O(...) in it,
+// otherwise a growth class named in words such as "linear", otherwise the
+// whole answer read as an expression
+func ExtractBigO(text string) (*BigO, error) {
+	if inner, ok := findBigO(text); ok {
+		return ParseBigO(inner)
+	}
+	lower := strings.ToLower(text)
+	for _, w := range complexityWords {
+		if regexp.MustCompile(`\b` + w.word + `\b`).MatchString(lower) {
+			return ParseBigO(w.expr)
+		}
+	}
+	return ParseBigO(text)
+}
+
+// String formats the complexity, as in "O(n log n + m)"
+func (b *BigO) String() string {
+	parts := make([]string, len(b.terms))
+	for i, term := range b.terms {
+		parts[i] = term.String()
+	}
+	return "O(" + strings.Join(parts, " + ") + ")"
+}
+
+// Equal reports whether two complexities are the same
+func (b *BigO) Equal(other *BigO) bool {
+	return b.String() == other.String()
+}
+
+// Bounds reports whether b is an upper bound of other, that is whether
+// other is O(b)
+func (b *BigO) Bounds(other *BigO) bool {
+	for _, term := range other.terms {
+		bounded := false
+		for _, upper := range b.terms {
+			if term.dominatedBy(upper) {
+				bounded = true
+				break
+			}
+		}
+		if !bounded {
+			return false
+		}
+	}
+	return true
+}
+
+// CompareComplexity compares a complexity answer with the correct one
+func CompareComplexity(given, correct *BigO) ComplexityVerdict {
+	switch {
+	case given.Equal(correct):
+		return ComplexityTight
+	case given.Bounds(correct):
+		return ComplexityNotTight
+	default:
+		return ComplexityWrong
+	}
+}
+
+// stripBigO returns the expression inside text that is entirely O(...)
+func stripBigO(text string) (string, bool) {
+	inner, ok := findBigO(text)
+	if !ok {
+		return "", false
+	}
+	open := strings.Index(text, "(")
+	head := strings.TrimSpace(text[:open])
+	if !isBigOLetter(head) || strings.TrimSpace(text[open:]) != "("+inner+")" {
+		return "", false
+	}
+	return inner, true
+}
+
+// findBigO returns the expression inside the first O(...), Θ(...) or
+// Ω(...) in text
+func findBigO(text string) (string, bool) {
+	runes := []rune(text)
+	for i, r := range runes {
+		if !isBigOLetter(string(r)) || (i > 0 && unicode.IsLetter(runes[i-1])) {
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && runes[j] == ' ' {
+			j++
+		}
+		if j >= len(runes) || runes[j] != '(' {
+			continue
+		}
+		depth := 0
+		for k := j; k < len(runes); k++ {
+			switch runes[k] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 {
+					return string(runes[j+1 : k]), true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// isBigOLetter reports whether s is a letter of asymptotic notation
+func isBigOLetter(s string) bool {
+	switch s {
+	case "O", "o", "Θ", "θ", "Ω", "ω":
+		return true
+	}
+	return false
+}
+
+// canonicalBigO drops constant factors and dominated terms and orders what
+// is left
+func canonicalBigO(sum []bigOTerm) *BigO {
+	terms := make([]bigOTerm, 0, len(sum))
+	for _, term := range sum {
+		clean := bigOTerm{coef: 1, factors: map[string]bigOFactor{}}
+		for v, f := range term.factors {
+			if f = f.rounded(); !f.isOne() {
+				clean.factors[v] = f
+			}
+		}
+		terms = append(terms, clean)
+	}
+
+	kept := make([]bigOTerm, 0, len(terms))
+	for i, term := range terms {
+		dominated := false
+		for j, other := range terms {
+			if i == j || !term.dominatedBy(other) {
+				continue
+			}
+			// Of two equal terms keep the first
+			if !other.dominatedBy(term) || j < i {
+				dominated = true
+				break
+			}
+		}
+		if !dominated {
+			kept = append(kept, term)
+		}
+	}
+	if len(kept) == 0 {
+		kept = append(kept, bigOTerm{coef: 1, factors: map[string]bigOFactor{}})
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].String() < kept[j].String() })
+	return &BigO{terms: kept}
+}
+
+// dominatedBy reports whether a term grows no faster than another in every
+// variable
+func (t bigOTerm) dominatedBy(other bigOTerm) bool {
+	for v, f := range t.factors {
+		if f.compare(other.factor(v)) > 0 {
+			return false
+		}
+	}
+	for v, f := range other.factors {
+		if t.factor(v).compare(f) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// factor returns the term's factor in a variable, 1 if it has none
+func (t bigOTerm) factor(v string) bigOFactor {
+	if f, ok := t.factors[v]; ok {
+		return f
+	}
+	return bigOFactor{base: 1}
+}
+
+// String formats a term with variables in order, as in "n^2*m log n"
+func (t bigOTerm) String() string {
+	vars := make([]string, 0, len(t.factors))
+	for v := range t.factors {
+		vars = append(vars, v)
+	}
+	sort.Strings(vars)
+
+	var head, logs []string
+	for _, v := range vars {
+		f := t.factors[v]
+		switch {
+		case f.power == 1:
+			head = append(head, v)
+		case f.power == 0.5:
+			head = append(head, "sqrt("+v+")")
+		case f.power != 0:
+			head = append(head, v+"^"+formatBigONumber(f.power))
+		}
+		if f.base != 1 {
+			head = append(head, formatBigONumber(f.base)+"^"+v)
+		}
+		if f.factorial == 1 {
+			head = append(head, v+"!")
+		} else if f.factorial != 0 {
+			head = append(head, "("+v+"!)^"+formatBigONumber(f.factorial))
+		}
+		if f.log == 1 {
+			logs = append(logs, "log "+v)
+		} else if f.log != 0 {
+			logs = append(logs, "log^"+formatBigONumber(f.log)+" "+v)
+		}
+		if f.loglog == 1 {
+			logs = append(logs, "log log "+v)
+		} else if f.loglog != 0 {
+			logs = append(logs, "(log log "+v+")^"+formatBigONumber(f.loglog))
+		}
+	}
+
+	s := strings.Join(head, "*")
+	if len(logs) > 0 {
+		if s != "" {
+			s += " "
+		}
+		s += strings.Join(logs, " ")
+	}
+	if s == "" {
+		return "1"
+	}
+	return s
+}
+
+// formatBigONumber formats an exponent or base compactly
+func formatBigONumber(x float64) string {
+	return strconv.FormatFloat(x, 'g', 4, 64)
+}
+
+// rounded rounds a factor's powers and base so that arithmetic error does
+// not tell equal complexities apart
+func (f bigOFactor) rounded() bigOFactor {
+	round := func(x float64) float64 { return math.Round(x*1e6) / 1e6 }
+	return bigOFactor{
+		factorial: round(f.factorial),
+		base:      round(f.base),
+		power:     round(f.power),
+		log:       round(f.log),
+		loglog:    round(f.loglog),
+	}
+}
+
+// isOne reports whether a factor is constant
+func (f bigOFactor) isOne() bool {
+	return f == bigOFactor{base: 1}
+}
+
+// compare orders factors in one variable by growth: factorials beat
+// exponentials, which beat powers, which beat logs, which beat log logs
+func (f bigOFactor) compare(other bigOFactor) int {
+	for _, pair := range [][2]float64{
+		{f.factorial, other.factorial},
+		{f.base, other.base},
+		{f.power, other.power},
+		{f.log, other.log},
+		{f.loglog, other.loglog},
+	} {
+		if d := pair[0] - pair[1]; math.Abs(d) > 1e-9 {
+			if d < 0 {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// bigOToken is a lexical token of a complexity expression
+type bigOToken struct {
+	kind string // "num", "var", "func", "op" or "end"
+	text string
+	num  float64
+}
+
+// bigOFunctions are the functions complexity expressions may use. log
+// bases are ignored.
+var bigOFunctions = []string{"sqrt", "log", "exp", "lg", "ln"}
+
+// bigOWords are words read as operators, as in "n squared"
+var bigOWords = map[string]bigOToken{
+	"squared": {kind: "op", text: "^2"},
+	"cubed":   {kind: "op", text: "^3"},
+	"times":   {kind: "op", text: "*"},
+	"plus":    {kind: "op", text: "+"},
+}
+
+// bigOParser is a recursive-descent parser of complexity expressions
+type bigOParser struct {
+	tokens []bigOToken
+	pos    int
+}
+
+func newBigOParser(text string) (*bigOParser, error) {
+	tokens, err := lexBigO(text)
+	if err != nil {
+		return nil, err
+	}
+	return &bigOParser{tokens: tokens}, nil
+}
+
+// lexBigO splits a complexity expression into tokens. A run of letters is
+// split into function names and one-letter variables, so "nlogn" is n, log,
+// n; digits right after a variable are a power, as in "n2".
+func lexBigO(text string) ([]bigOToken, error) {
+	replacer := strings.NewReplacer("²", "^2", "³", "^3", "√", "sqrt", "·", "*", "×", "*", "**", "^", "|", " ", "−", "-")
+	runes := []rune(strings.ToLower(replacer.Replace(text)))
+
+	var tokens []bigOToken
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			num, err := strconv.ParseFloat(string(runes[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", string(runes[i:j]))
+			}
+			tokens = append(tokens, bigOToken{kind: "num", text: string(runes[i:j]), num: num})
+			i = j
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			word := string(runes[i:j])
+			i = j
+			if token, ok := bigOWords[word]; ok {
+				if token.text[0] == '^' {
+					tokens = append(tokens, bigOToken{kind: "op", text: "^"}, bigOToken{kind: "num", text: token.text[1:], num: float64(token.text[1] - '0')})
+				} else {
+					tokens = append(tokens, token)
+				}
+				continue
+			}
+			split, err := splitBigOWord(word)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, split...)
+
+			last := split[len(split)-1]
+			// log2 n and log_2 n: the base does not matter
+			if last.kind == "func" && last.text != "sqrt" && last.text != "exp" {
+				if i < len(runes) && runes[i] == '_' {
+					i++
+				}
+				for i < len(runes) && unicode.IsDigit(runes[i]) {
+					i++
+				}
+			}
+			// n2 is n^2
+			if last.kind == "var" && i < len(runes) && unicode.IsDigit(runes[i]) {
+				tokens = append(tokens, bigOToken{kind: "op", text: "^"})
+			}
+		case strings.ContainsRune("+-*/^!(),", r):
+			tokens = append(tokens, bigOToken{kind: "op", text: string(r)})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q in complexity", string(r))
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("complexity is empty")
+	}
+	return append(tokens, bigOToken{kind: "end"}), nil
+}
+
+// splitBigOWord splits a run of letters into function names and variables.
+// Longer runs without a function name are words, not expressions.
+func splitBigOWord(word string) ([]bigOToken, error) {
+	var tokens []bigOToken
+	hasFunction := false
+	for rest := word; rest != ""; {
+		matched := false
+		for _, name := range bigOFunctions {
+			if strings.HasPrefix(rest, name) {
+				tokens = append(tokens, bigOToken{kind: "func", text: name})
+				rest = rest[len(name):]
+				matched, hasFunction = true, true
+				break
+			}
+		}
+		if !matched {
+			_, size := firstRune(rest)
+			tokens = append(tokens, bigOToken{kind: "var", text: rest[:size]})
+			rest = rest[size:]
+		}
+	}
+	if !hasFunction && len(tokens) > 2 {
+		return nil, fmt.Errorf("unknown word %q in complexity", word)
+	}
+	return tokens, nil
+}
+
+// firstRune returns the first rune of s and its size in bytes
+func firstRune(s string) (rune, int) {
+	for i, r := range s {
+		if i > 0 {
+			return r, i
+		}
+	}
+	for _, r := range s {
+		return r, len(s)
+	}
+	return 0, 0
+}
+
+func (p *bigOParser) peek() bigOToken {
+	return p.tokens[p.pos]
+}
+
+func (p *bigOParser) next() bigOToken {
+	token := p.tokens[p.pos]
+	if token.kind != "end" {
+		p.pos++
+	}
+	return token
+}
+
+func (p *bigOParser) done() bool {
+	return p.peek().kind == "end"
+}
+
+func (p *bigOParser) isOp(text string) bool {
+	token := p.peek()
+	return token.kind == "op" && token.text == text
+}
+
+// parseExpr parses a sum. Subtraction is read as addition: O(n^2 - n) is
+// O(n^2).
+func (p *bigOParser) parseExpr() ([]bigOTerm, error) {
+	sum, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		p.next()
+		term, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		sum = append(sum, term...)
+	}
+	return sum, nil
+}
+
+// parseProduct parses factors multiplied explicitly or by juxtaposition.
+// Only constants may divide.
+func (p *bigOParser) parseProduct() ([]bigOTerm, error) {
+	product, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		switch {
+		case p.isOp("*"):
+			p.next()
+		case p.isOp("/"):
+			p.next()
+			divisor, err := p.parsePower()
+			if err != nil {
+				return nil, err
+			}
+			c, ok := bigOConstant(divisor)
+			if !ok || c == 0 {
+				return nil, errors.New("only constants may divide a complexity")
+			}
+			product = scaleBigO(product, 1/c)
+			continue
+		case token.kind == "num" || token.kind == "var" || token.kind == "func" || p.isOp("("):
+		default:
+			return product, nil
+		}
+		factor, err := p.parsePower()
+		if err != nil {
+			return nil, err
+		}
+		product = multiplyBigO(product, factor)
+	}
+}
+
+// parsePower parses a factor with an optional, right-associative power
+func (p *bigOParser) parsePower() ([]bigOTerm, error) {
+	base, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("^") {
+		return base, nil
+	}
+	p.next()
+	if p.isOp("-") {
+		return nil, errors.New("negative powers are not supported")
+	}
+	exponent, err := p.parsePower()
+	if err != nil {
+		return nil, err
+	}
+	return powBigO(base, exponent)
+}
+
+// parsePostfix parses an atom followed by factorials
+func (p *bigOParser) parsePostfix() ([]bigOTerm, error) {
+	atom, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("!") {
+		p.next()
+		if atom, err = factorialBigO(atom); err != nil {
+			return nil, err
+		}
+	}
+	return atom, nil
+}
+
+// parseAtom parses a number, variable, function call or parenthesized
+// expression
+func (p *bigOParser) parseAtom() ([]bigOTerm, error) {
+	token := p.next()
+	switch token.kind {
+	case "num":
+		return []bigOTerm{{coef: token.num, factors: map[string]bigOFactor{}}}, nil
+	case "var":
+		return []bigOTerm{{coef: 1, factors: map[string]bigOFactor{token.text: {base: 1, power: 1}}}}, nil
+	case "func":
+		return p.parseFunction(token.text)
+	case "op":
+		if token.text == "(" {
+			expr, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if !p.isOp(")") {
+				return nil, errors.New("missing ) in complexity")
+			}
+			p.next()
+			return expr, nil
+		}
+	case "end":
+		return nil, errors.New("complexity ends early")
+	}
+	return nil, fmt.Errorf("unexpected %q in complexity", token.text)
+}
+
+// parseFunction parses the argument of sqrt, exp or a log, which may be
+// raised to a power first, as in log^2 n
+func (p *bigOParser) parseFunction(name string) ([]bigOTerm, error) {
+	var power []bigOTerm
+	if p.isOp("^") {
+		p.next()
+		var err error
+		if power, err = p.parsePostfix(); err != nil {
+			return nil, err
+		}
+	}
+
+	// The argument binds tighter than products: log n * n is (log n) * n.
+	// A power after it raises the argument, as in log n^2, unless the
+	// argument is in parentheses, as in log(n)^2.
+	parenthesized := p.isOp("(")
+	arg, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("^") && !parenthesized {
+		p.next()
+		exponent, err := p.parsePower()
+		if err != nil {
+			return nil, err
+		}
+		if arg, err = powBigO(arg, exponent); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []bigOTerm
+	switch name {
+	case "sqrt":
+		result, err = powBigO(arg, bigONumber(0.5))
+	case "exp":
+		result, err = powBigO(bigONumber(math.E), arg)
+	default:
+		result, err = logBigO(arg)
+	}
+	if err != nil || power == nil {
+		return result, err
+	}
+	return powBigO(result, power)
+}
+
+// bigONumber is a constant expression
+func bigONumber(x float64) []bigOTerm {
+	return []bigOTerm{{coef: x, factors: map[string]bigOFactor{}}}
+}
+
+// bigOConstant returns the value of an expression without variables
+func bigOConstant(sum []bigOTerm) (float64, bool) {
+	total := 0.0
+	for _, term := range sum {
+		for _, f := range term.factors {
+			if !f.isOne() {
+				return 0, false
+			}
+		}
+		total += term.coef
+	}
+	return total, true
+}
+
+// scaleBigO multiplies an expression by a constant
+func scaleBigO(sum []bigOTerm, c float64) []bigOTerm {
+	scaled := make([]bigOTerm, len(sum))
+	for i, term := range sum {
+		scaled[i] = bigOTerm{coef: term.coef * c, factors: term.factors}
+	}
+	return scaled
+}
+
+// multiplyBigO multiplies two expressions out
+func multiplyBigO(a, b []bigOTerm) []bigOTerm {
+	product := make([]bigOTerm, 0, len(a)*len(b))
+	for _, x := range a {
+		for _, y := range b {
+			term := bigOTerm{coef: x.coef * y.coef, factors: make(map[string]bigOFactor)}
+			for v, f := range x.factors {
+				term.factors[v] = f
+			}
+			for v, f := range y.factors {
+				g := term.factor(v)
+				term.factors[v] = bigOFactor{
+					factorial: g.factorial + f.factorial,
+					base:      g.base * f.base,
+					power:     g.power + f.power,
+					log:       g.log + f.log,
+					loglog:    g.loglog + f.loglog,
+				}
+			}
+			product = append(product, term)
+		}
+	}
+	return product
+}
+
+// powBigO raises an expression to a power. A constant power applies to each
+// term, since (a + b)^k grows like a^k + b^k; a constant base may be raised
+// to a multiple of one variable, as in 2^(2n).
+func powBigO(base, exponent []bigOTerm) ([]bigOTerm, error) {
+	if k, ok := bigOConstant(exponent); ok {
+		if k < 0 {
+			return nil, errors.New("negative powers are not supported")
+		}
+		result := make([]bigOTerm, len(base))
+		for i, term := range base {
+			powered := bigOTerm{coef: math.Pow(term.coef, k), factors: make(map[string]bigOFactor)}
+			for v, f := range term.factors {
+				powered.factors[v] = bigOFactor{
+					factorial: f.factorial * k,
+					base:      math.Pow(f.base, k),
+					power:     f.power * k,
+					log:       f.log * k,
+					loglog:    f.loglog * k,
+				}
+			}
+			result[i] = powered
+		}
+		return result, nil
+	}
+
+	b, ok := bigOConstant(base)
+	if !ok {
+		return nil, errors.New("only constants may be raised to a variable power")
+	}
+
+	// The exponent must be linear in one variable: b^(kn + c) = b^c (b^k)^n
+	variable, k := "", 0.0
+	for _, term := range exponent {
+		if _, ok := bigOConstant([]bigOTerm{term}); ok {
+			continue
+		}
+		if len(term.factors) != 1 {
+			return nil, errors.New("exponents must be linear in one variable")
+		}
+		for v, f := range term.factors {
+			if f != (bigOFactor{base: 1, power: 1}) || (variable != "" && v != variable) {
+				return nil, errors.New("exponents must be linear in one variable")
+			}
+			variable = v
+		}
+		k += term.coef
+	}
+	growth := math.Pow(b, k)
+	if growth <= 1 {
+		return bigONumber(1), nil
+	}
+	return []bigOTerm{{coef: 1, factors: map[string]bigOFactor{variable: {base: growth}}}}, nil
+}
+
+// factorialBigO applies n! to a variable or a constant
+func factorialBigO(arg []bigOTerm) ([]bigOTerm, error) {
+	if _, ok := bigOConstant(arg); ok {
+		return bigONumber(1), nil
+	}
+	if len(arg) == 1 && len(arg[0].factors) == 1 {
+		for v, f := range arg[0].factors {
+			if f == (bigOFactor{base: 1, power: 1}) {
+				return []bigOTerm{{coef: 1, factors: map[string]bigOFactor{v: {base: 1, factorial: 1}}}}, nil
+			}
+		}
+	}
+	return nil, errors.New("factorials are only supported of a variable")
+}
+
+// logBigO takes the logarithm of an expression. The log of a sum grows like
+// the sum of the logs of its terms, and the log of a product is the sum of
+// the logs of its factors: log(n^2 2^m) grows like log n + m.
+func logBigO(arg []bigOTerm) ([]bigOTerm, error) {
+	var result []bigOTerm
+	for _, term := range arg {
+		for v, f := range term.factors {
+			var growth bigOFactor
+			switch {
+			case f.factorial > 0:
+				growth = bigOFactor{base: 1, power: 1, log: 1}
+			case f.base > 1:
+				growth = bigOFactor{base: 1, power: 1}
+			case f.power > 0:
+				growth = bigOFactor{base: 1, log: 1}
+			case f.log > 0:
+				growth = bigOFactor{base: 1, loglog: 1}
+			case f.loglog > 0:
+				return nil, errors.New("log log log is not supported")
+			default:
+				continue
+			}
+			result = append(result, bigOTerm{coef: 1, factors: map[string]bigOFactor{v: growth}})
+		}
+	}
+	if len(result) == 0 {
+		return bigONumber(1), nil
+	}
+	return result, nil
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"math"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Calibration compares how confident users say they are with how often they
+// are right. A confidence level from 1 to 5 states a probability of being
+// correct of level/5, and the outcome of an attempt is its score from 0 to
+// 1. A well calibrated user is right 60% of the time they answer at level
+// 3.
+
+const (
+	// maxConfidenceLevel is the highest confidence level an answer can give
+	maxConfidenceLevel = 5
+	// confidentLevel is the lowest confidence level that counts as sure
+	confidentLevel = 4
+	// minConfidentlyWrong is how many sure but wrong answers flag a topic
+	minConfidentlyWrong = 2
+	// confidentlyWrongShare is the share of sure answers in a topic that
+	// must be wrong to flag it
+	confidentlyWrongShare = 0.5
+)
+
+// CalibrationBin is a point of a reliability curve: the answers given at
+// one confidence level and how often they were right
+type CalibrationBin struct {
+	ConfidenceLevel int     `json:"confidence_level"`
+	Confidence      float64 `json:"confidence"`
+	Attempts        int     `json:"attempts"`
+	Accuracy        float64 `json:"accuracy"`
+	Gap             float64 `json:"gap"`
+}
+
+// CalibrationStats measures the calibration of a set of answers. The Brier
+// score is the mean squared difference between confidence and outcome, 0 at
+// best. Overconfidence and underconfidence are the mean amounts by which
+// confidence exceeded or fell short of the outcome.
+type CalibrationStats struct {
+	Attempts                int              `json:"attempts"`
+	BrierScore              float64          `json:"brier_score"`
+	Overconfidence          float64          `json:"overconfidence"`
+	Underconfidence         float64          `json:"underconfidence"`
+	ConfidentlyWrongAnswers int              `json:"confidently_wrong_answers"`
+	Reliability             []CalibrationBin `json:"reliability"`
+}
+
+// TopicCalibration is a user's calibration in one topic. ConfidentlyWrong
+// flags topics where the user is often sure of wrong answers.
+type TopicCalibration struct {
+	TopicID   int    `json:"topic_id"`
+	TopicName string `json:"topic_name"`
+	CalibrationStats
+	ConfidentlyWrong bool `json:"confidently_wrong"`
+}
+
+// CalibrationReport is a user's calibration overall and per topic, flagged
+// topics first
+type CalibrationReport struct {
+	Overall CalibrationStats   `json:"overall"`
+	Topics  []TopicCalibration `json:"topics"`
+}
+
+// CalibrationService measures how well users' confidence predicts their
+// answers
+type CalibrationService struct {
+	db *gorm.DB
+}
+
+// NewCalibrationService creates a new calibration service
+func NewCalibrationService(db *gorm.DB) *CalibrationService {
+	return &CalibrationService{db: db}
+}
+
+// calibrationAttempt is an answer given with a confidence level
+type calibrationAttempt struct {
+	AttemptID       int
+	ConfidenceLevel int
+	IsCorrect       bool
+	Score           *float64
+}
+
+// outcome is how right an answer was, from 0 to 1
+func (a calibrationAttempt) outcome() float64 {
+	if a.Score != nil {
+		return math.Max(0, math.Min(1, *a.Score))
+	}
+	return binaryScore(a.IsCorrect)
+}
+
+// GetCalibration computes a user's calibration from the answers they gave a
+// confidence level
+func (cs *CalibrationService) GetCalibration(userID int) (*CalibrationReport, error) {
+	var attempts []calibrationAttempt
+	err := cs.db.Table("user_attempts").
+		Select("attempt_id, confidence_level, is_correct, score").
+		Where("user_id = ? AND confidence_level BETWEEN 1 AND ?", userID, maxConfidenceLevel).
+		Scan(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var topicRows []struct {
+		AttemptID int
+		TopicID   int
+		TopicName string
+	}
+	err = cs.db.Table("user_attempts ua").
+		Select("ua.attempt_id, pt.topic_id, t.name as topic_name").
+		Joins("JOIN questions q ON q.question_id = ua.question_id").
+		Joins("JOIN problem_topics pt ON pt.problem_id = q.problem_id").
+		Joins("JOIN topics t ON t.topic_id = pt.topic_id").
+		Where("ua.user_id = ? AND ua.confidence_level BETWEEN 1 AND ?", userID, maxConfidenceLevel).
+		Scan(&topicRows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]calibrationAttempt, len(attempts))
+	for _, a := range attempts {
+		byID[a.AttemptID] = a
+	}
+	topicAttempts := make(map[int][]calibrationAttempt)
+	topicNames := make(map[int]string)
+	for _, row := range topicRows {
+		if a, ok := byID[row.AttemptID]; ok {
+			topicAttempts[row.TopicID] = append(topicAttempts[row.TopicID], a)
+			topicNames[row.TopicID] = row.TopicName
+		}
+	}
+
+	report := &CalibrationReport{
+		Overall: calibrationStats(attempts),
+		Topics:  make([]TopicCalibration, 0, len(topicAttempts)),
+	}
+	for topicID, answers := range topicAttempts {
+		topic := TopicCalibration{
+			TopicID:          topicID,
+			TopicName:        topicNames[topicID],
+			CalibrationStats: calibrationStats(answers),
+		}
+		topic.ConfidentlyWrong = confidentlyWrong(answers)
+		report.Topics = append(report.Topics, topic)
+	}
+	sort.Slice(report.Topics, func(i, j int) bool {
+		a, b := report.Topics[i], report.Topics[j]
+		if a.ConfidentlyWrong != b.ConfidentlyWrong {
+			return a.ConfidentlyWrong
+		}
+		if a.Overconfidence != b.Overconfidence {
+			return a.Overconfidence > b.Overconfidence
+		}
+		return a.TopicID < b.TopicID
+	})
+	return report, nil
+}
+
+// ConfidentlyWrongTopics returns the IDs of the topics where a user is often
+// sure of wrong answers, most overconfident first
+func (cs *CalibrationService) ConfidentlyWrongTopics(userID int) ([]int, error) {
+	report, err := cs.GetCalibration(userID)
+	if err != nil {
+		return nil, err
+	}
+	var topicIDs []int
+	for _, topic := range report.Topics {
+		if topic.ConfidentlyWrong {
+			topicIDs = append(topicIDs, topic.TopicID)
+		}
+	}
+	return topicIDs, nil
+}
+
+// calibrationStats measures the calibration of answers
+func calibrationStats(attempts []calibrationAttempt) CalibrationStats {
+	stats := CalibrationStats{Attempts: len(attempts), Reliability: []CalibrationBin{}}
+	if len(attempts) == 0 {
+		return stats
+	}
+
+	var counts [maxConfidenceLevel + 1]int
+	var outcomes [maxConfidenceLevel + 1]float64
+	var brier, over, under float64
+	for _, a := range attempts {
+		confidence := float64(a.ConfidenceLevel) / maxConfidenceLevel
+		outcome := a.outcome()
+		brier += (confidence - outcome) * (confidence - outcome)
+		over += math.Max(0, confidence-outcome)
+		under += math.Max(0, outcome-confidence)
+		counts[a.ConfidenceLevel]++
+		outcomes[a.ConfidenceLevel] += outcome
+		if a.ConfidenceLevel >= confidentLevel && !a.IsCorrect {
+			stats.ConfidentlyWrongAnswers++
+		}
+	}
+
+	n := float64(len(attempts))
+	stats.BrierScore = brier / n
+	stats.Overconfidence = over / n
+	stats.Underconfidence = under / n
+	for level := 1; level <= maxConfidenceLevel; level++ {
+		if counts[level] == 0 {
+			continue
+		}
+		confidence := float64(level) / maxConfidenceLevel
+		accuracy := outcomes[level] / float64(counts[level])
+		stats.Reliability = append(stats.Reliability, CalibrationBin{
+			ConfidenceLevel: level,
+			Confidence:      confidence,
+			Attempts:        counts[level],
+			Accuracy:        accuracy,
+			Gap:             confidence - accuracy,
+		})
+	}
+	return stats
+}
+
+// confidentlyWrong reports whether enough of the answers a user was sure of
+// were wrong to flag their topic
+func confidentlyWrong(attempts []calibrationAttempt) bool {
+	confident, wrong := 0, 0
+	for _, a := range attempts {
+		if a.ConfidenceLevel >= confidentLevel {
+			confident++
+			if !a.IsCorrect {
+				wrong++
+			}
+		}
+	}
+	return wrong >= minConfidentlyWrong && float64(wrong) >= confidentlyWrongShare*float64(confident)
+}
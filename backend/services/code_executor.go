@@ -1,25 +1,51 @@
 package services
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
-	"time"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/models"
 )
 
-// CodeExecutor handles code execution via Judge0 API
+// ErrExecutionFailed is returned when the execution backend itself fails, as
+// opposed to the submitted program failing
+var ErrExecutionFailed = errors.New("code execution failed")
+
+// CodeExecutor grades code against test cases using an Executor backend
 type CodeExecutor struct {
-	judge0URL string
-	timeout   time.Duration
+	executor  Executor
+	limits    ResourceLimits
+	languages *LanguageRegistry
+	queue     *ExecutionQueue
+	cache     ResultCache
+
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
 }
 
-// TestCase represents a single test case
+// TestCase represents a single test case. Hidden test cases only ever report
+// their verdict and index, so the suite cannot be read back from results.
 type TestCase struct {
 	Input    string `json:"input"`
 	Expected string `json:"expected"`
+	Hidden   bool   `json:"hidden"`
+
+	checker OutputChecker
+	stdin   string
+}
+
+// TestSuite is what code is graded against: raw test cases, the default
+// checker (see NewChecker) and, for LeetCode-style questions, the function
+// signature users implement. With a signature, code is wrapped in a
+// generated driver, test inputs are JSON-encoded arguments and outputs are
+// compared as JSON unless another checker is named.
+type TestSuite struct {
+	TestCases []interface{}
+	Checker   interface{}
+	Signature *FunctionSignature
 }
 
 // ExecutionResult contains the results of code execution
@@ -30,237 +56,550 @@ type ExecutionResult struct {
 	Failures    []FailureDetail `json:"failures,omitempty"`
 	TimeTaken   float64         `json:"time_taken_ms"`
 	MemoryUsed  int             `json:"memory_used_kb"`
+	TestResults []TestResult    `json:"test_results"`
 }
 
-// FailureDetail contains information about a failed test case
+// TestResult is the outcome of a single test case, in test order
+type TestResult struct {
+	TestNumber int     `json:"test_number"`
+	Passed     bool    `json:"passed"`
+	Verdict    Verdict `json:"verdict"`
+	TimeMs     float64 `json:"time_ms"`
+	MemoryKB   int     `json:"memory_kb"`
+	Hidden     bool    `json:"hidden,omitempty"`
+}
+
+// FailureDetail contains information about a failed test case. Input,
+// Expected, Got and Error are left empty for hidden test cases.
 type FailureDetail struct {
-	TestNumber int    `json:"test_number"`
-	Input      string `json:"input"`
-	Expected   string `json:"expected"`
-	Got        string `json:"got"`
-	Error      string `json:"error,omitempty"`
+	TestNumber int     `json:"test_number"`
+	Verdict    Verdict `json:"verdict"`
+	Hidden     bool    `json:"hidden,omitempty"`
+	Input      string  `json:"input,omitempty"`
+	Expected   string  `json:"expected,omitempty"`
+	Got        string  `json:"got,omitempty"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// NewCodeExecutor creates a new code executor on top of an execution
+// backend. A nil registry uses the default languages; a nil queue admits
+// every job; a nil cache runs every suite.
+func NewCodeExecutor(executor Executor, limits ResourceLimits, languages *LanguageRegistry, queue *ExecutionQueue, cache ResultCache) *CodeExecutor {
+	if executor == nil {
+		executor = NewJudge0Executor(config.ExecutorConfig{})
+	}
+	if limits.CPUTime <= 0 || limits.WallTime <= 0 || limits.MemoryKB <= 0 {
+		limits = DefaultResourceLimits
+	}
+	if languages == nil {
+		languages = NewLanguageRegistry(nil)
+	}
+	if queue == nil {
+		queue = NewExecutionQueue(QueueOptions{})
+	}
+
+	return &CodeExecutor{
+		executor:  executor,
+		limits:    limits,
+		languages: languages,
+		queue:     queue,
+		cache:     cache,
+	}
 }
 
-// Judge0Submission represents a submission to Judge0
-type Judge0Submission struct {
-	SourceCode       string  `json:"source_code"`
-	LanguageID       int     `json:"language_id"`
-	Stdin            string  `json:"stdin,omitempty"`
-	ExpectedOutput   string  `json:"expected_output,omitempty"`
-	CPUTimeLimit     float64 `json:"cpu_time_limit,omitempty"`
-	MemoryLimit      int     `json:"memory_limit,omitempty"`
-	WallTimeLimit    float64 `json:"wall_time_limit,omitempty"`
+// Backend returns the underlying execution backend
+func (ce *CodeExecutor) Backend() Executor {
+	return ce.executor
 }
 
-// Judge0Response represents Judge0 API response
-type Judge0Response struct {
-	Token  string `json:"token"`
-	Status struct {
-		ID          int    `json:"id"`
-		Description string `json:"description"`
-	} `json:"status"`
-	Stdout     string  `json:"stdout"`
-	Stderr     string  `json:"stderr"`
-	CompileOutput string `json:"compile_output"`
-	Time       string  `json:"time"`
-	Memory     int     `json:"memory"`
+// Queue returns the queue jobs wait in before executing. Callers Admit a
+// user's job (or Reserve one already admitted), Wait, execute and call Done.
+func (ce *CodeExecutor) Queue() *ExecutionQueue {
+	return ce.queue
 }
 
-// NewCodeExecutor creates a new code executor
-func NewCodeExecutor(judge0URL string) *CodeExecutor {
-	if judge0URL == "" {
-		judge0URL = "http://localhost:2358" // Default Judge0 CE URL
+// CacheStats is how often RunTests was answered from the result cache
+type CacheStats struct {
+	Enabled bool   `json:"enabled"`
+	Backend string `json:"backend,omitempty"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+}
+
+// CacheStats returns result cache usage since startup
+func (ce *CodeExecutor) CacheStats() CacheStats {
+	stats := CacheStats{Hits: ce.cacheHits.Load(), Misses: ce.cacheMisses.Load()}
+	if ce.cache != nil {
+		stats.Enabled = true
+		stats.Backend = ce.cache.Name()
 	}
+	return stats
+}
 
-	return &CodeExecutor{
-		judge0URL: judge0URL,
-		timeout:   30 * time.Second,
+// SupportsLanguage reports whether a language is enabled in the registry and
+// available on the backend
+func (ce *CodeExecutor) SupportsLanguage(language string) bool {
+	_, err := ce.resolveLanguage(language)
+	return err == nil
+}
+
+// Languages lists the enabled languages the backend can run
+func (ce *CodeExecutor) Languages() []Language {
+	languages := []Language{}
+	for _, lang := range ce.languages.List() {
+		if ce.executor.SupportsLanguage(lang.ID) {
+			languages = append(languages, lang)
+		}
+	}
+	return languages
+}
+
+// resolveLanguage maps a language name or alias to its registry ID
+func (ce *CodeExecutor) resolveLanguage(language string) (string, error) {
+	lang, ok := ce.languages.Lookup(language)
+	if !ok || !ce.executor.SupportsLanguage(lang.ID) {
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+	return lang.ID, nil
+}
+
+// limitsFor returns the resource limits scaled by a language's time
+// multiplier
+func (ce *CodeExecutor) limitsFor(language string) ResourceLimits {
+	lang, ok := ce.languages.Lookup(language)
+	if !ok {
+		return ce.limits
+	}
+	return lang.scaleLimits(ce.limits)
+}
+
+// RunTests executes code against a test suite, comparing outputs with the
+// suite's checker. A test case may override it with its own "checker" key.
+func (ce *CodeExecutor) RunTests(code, language string, suite TestSuite) (*ExecutionResult, error) {
+	return ce.RunTestsWithProgress(code, language, suite, nil)
+}
+
+// TestEventType is the stage a test case has reached while grading
+type TestEventType string
+
+const (
+	TestEventQueued  TestEventType = "queued"
+	TestEventRunning TestEventType = "running"
+	TestEventJudged  TestEventType = "judged"
+)
+
+// TestEvent reports the progress of one test case. Judged events carry the
+// test's result and, if it failed, the same (redacted) failure detail as
+// the final ExecutionResult.
+type TestEvent struct {
+	Type       TestEventType  `json:"type"`
+	TestNumber int            `json:"test_number"`
+	TotalCount int            `json:"total_count"`
+	Result     *TestResult    `json:"result,omitempty"`
+	Failure    *FailureDetail `json:"failure,omitempty"`
+}
+
+// RunTestsWithProgress is RunTests reporting each test case as it is queued,
+// starts running and is judged. Backends that cannot tell queued runs from
+// running ones report every test as running when it is submitted. progress
+// is never called concurrently.
+//
+// Results of code already graded against the same suite come from the
+// result cache, reporting only a judged event per test.
+func (ce *CodeExecutor) RunTestsWithProgress(code, language string, suite TestSuite, progress func(TestEvent)) (*ExecutionResult, error) {
+	language, err := ce.resolveLanguage(language)
+	if err != nil {
+		return nil, err
 	}
+
+	var cacheKey string
+	if ce.cache != nil {
+		cacheKey, err = ResultCacheKey(code, language, suite, ce.limitsFor(language))
+		if err != nil {
+			return nil, err
+		}
+		if cached, ok := ce.cache.Get(cacheKey); ok {
+			ce.cacheHits.Add(1)
+			replayResult(cached, progress)
+			return cached, nil
+		}
+		ce.cacheMisses.Add(1)
+	}
+
+	result, err := ce.runTests(code, language, suite, progress)
+	if err != nil {
+		return nil, err
+	}
+	if ce.cache != nil && cacheable(result) {
+		ce.cache.Set(cacheKey, result)
+	}
+	return result, nil
 }
 
-// RunTests executes code against test cases
-func (ce *CodeExecutor) RunTests(code, language string, testCases []interface{}) (*ExecutionResult, error) {
-	languageID := ce.getLanguageID(language)
-	if languageID == 0 {
-		return nil, fmt.Errorf("unsupported language: %s", language)
+// replayResult reports a judged event for each test of a cached result
+func replayResult(result *ExecutionResult, progress func(TestEvent)) {
+	if progress == nil {
+		return
+	}
+
+	failures := make(map[int]*FailureDetail, len(result.Failures))
+	for i := range result.Failures {
+		failures[result.Failures[i].TestNumber] = &result.Failures[i]
+	}
+	for i := range result.TestResults {
+		test := &result.TestResults[i]
+		progress(TestEvent{
+			Type:       TestEventJudged,
+			TestNumber: test.TestNumber,
+			TotalCount: result.TotalCount,
+			Result:     test,
+			Failure:    failures[test.TestNumber],
+		})
+	}
+}
+
+// runTests grades code in a resolved language on the backend
+func (ce *CodeExecutor) runTests(code, language string, suite TestSuite, progress func(TestEvent)) (*ExecutionResult, error) {
+	code, err := ce.wrapCode(code, language, suite.Signature)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := ce.parseTestCases(suite)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tests are judged as their runs finish so judged events are timely.
+	// The lock only keeps progress events from being reported concurrently;
+	// judging happens outside it, so a custom checker that runs code never
+	// holds up the callbacks of other tests. Each result slot is written by
+	// the single callback that finishes its test.
+	total := len(parsed)
+	results := make([]TestResult, total)
+	failures := make([]*FailureDetail, total)
+	var mu sync.Mutex
+	onProgress := func(i int, state RunState, output *ExecutionOutput) {
+		event := TestEvent{TestNumber: i + 1, TotalCount: total}
+		switch state {
+		case RunQueued:
+			event.Type = TestEventQueued
+		case RunRunning:
+			event.Type = TestEventRunning
+		case RunFinished:
+			if suite.Signature != nil {
+				suite.Signature.ExtractResult(output)
+			}
+			results[i], failures[i] = ce.judgeTest(i+1, parsed[i], output)
+			event.Type = TestEventJudged
+			event.Result = &results[i]
+			event.Failure = failures[i]
+		}
+		if progress != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			progress(event)
+		}
+	}
+
+	// A backend error means nothing was graded
+	outputs, err := ce.executeAllWithProgress(ce.testRequests(code, language, parsed), onProgress)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrExecutionFailed, ce.executor.Name(), err)
 	}
 
 	result := &ExecutionResult{
 		AllPassed:   true,
 		PassedCount: 0,
-		TotalCount:  len(testCases),
+		TotalCount:  total,
 		Failures:    []FailureDetail{},
 		TimeTaken:   0,
 		MemoryUsed:  0,
+		TestResults: results,
 	}
 
-	for i, tc := range testCases {
-		testCase, ok := tc.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("invalid test case format at index %d", i)
+	for i, output := range outputs {
+		result.TimeTaken += output.TimeMs
+		if output.MemoryKB > result.MemoryUsed {
+			result.MemoryUsed = output.MemoryKB
+		}
+		if results[i].Passed {
+			result.PassedCount++
 		}
+		if failures[i] != nil {
+			result.Failures = append(result.Failures, *failures[i])
+		}
+	}
+	result.AllPassed = result.PassedCount == result.TotalCount
 
-		input, _ := testCase["input"].(string)
-		expected, _ := testCase["expected"].(string)
+	return result, nil
+}
 
-		// Execute code for this test case
-		output, execTime, memory, err := ce.executeCode(code, languageID, input, expected)
+// judgeTest grades the output of one test case run, returning a failure
+// detail when it did not pass
+func (ce *CodeExecutor) judgeTest(testNumber int, testCase TestCase, output *ExecutionOutput) (TestResult, *FailureDetail) {
+	testResult := TestResult{
+		TestNumber: testNumber,
+		Verdict:    output.Verdict,
+		TimeMs:     output.TimeMs,
+		MemoryKB:   output.MemoryKB,
+		Hidden:     testCase.Hidden,
+	}
 
-		result.TimeTaken += execTime
-		if memory > result.MemoryUsed {
-			result.MemoryUsed = memory
-		}
+	if output.Verdict != VerdictAccepted {
+		failure := newFailureDetail(testNumber, testCase, output.Verdict, output.Stdout,
+			fmt.Sprintf("%s: %s", output.Verdict, output.ErrorMessage()))
+		return testResult, &failure
+	}
 
-		if err != nil {
-			result.AllPassed = false
-			result.Failures = append(result.Failures, FailureDetail{
-				TestNumber: i + 1,
-				Input:      input,
-				Expected:   expected,
-				Got:        output,
-				Error:      err.Error(),
-			})
-			continue
-		}
+	// Compare output with the test's checker
+	passed, message, err := testCase.checker.Check(testCase.Input, testCase.Expected, output.Stdout)
+	switch {
+	case err != nil:
+		testResult.Verdict = VerdictInternalError
+		failure := newFailureDetail(testNumber, testCase, VerdictInternalError, output.Stdout,
+			fmt.Sprintf("%s: %v", VerdictInternalError, err))
+		return testResult, &failure
+	case passed:
+		testResult.Passed = true
+		return testResult, nil
+	default:
+		testResult.Verdict = VerdictWrongAnswer
+		failure := newFailureDetail(testNumber, testCase, VerdictWrongAnswer, output.Stdout, message)
+		return testResult, &failure
+	}
+}
 
-		// Compare output (trim whitespace)
-		if ce.normalizeOutput(output) == ce.normalizeOutput(expected) {
-			result.PassedCount++
-		} else {
-			result.AllPassed = false
-			result.Failures = append(result.Failures, FailureDetail{
-				TestNumber: i + 1,
-				Input:      input,
-				Expected:   expected,
-				Got:        output,
-			})
+// SampleRun is the full output of running code against one sample test case
+type SampleRun struct {
+	TestNumber int    `json:"test_number"`
+	Input      string `json:"input"`
+	Expected   string `json:"expected"`
+	Passed     bool   `json:"passed"`
+	*ExecutionOutput
+}
+
+// Run executes code once with the given stdin, without grading it. With a
+// function signature, stdin holds the JSON-encoded arguments.
+func (ce *CodeExecutor) Run(code, language, stdin string, sig *FunctionSignature) (*ExecutionOutput, error) {
+	language, err := ce.resolveLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err = ce.wrapCode(code, language, sig)
+	if err != nil {
+		return nil, err
+	}
+	if sig != nil {
+		if stdin, err = sig.EncodeInput(stdin); err != nil {
+			return nil, fmt.Errorf("invalid input: %w", err)
 		}
 	}
 
-	return result, nil
+	output, err := ce.executor.Execute(ExecutionRequest{
+		SourceCode: code,
+		Language:   language,
+		Stdin:      stdin,
+		Limits:     ce.limitsFor(language),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrExecutionFailed, ce.executor.Name(), err)
+	}
+	if sig != nil {
+		sig.ExtractResult(output)
+	}
+	return output, nil
 }
 
-// executeCode submits code to Judge0 and waits for result
-func (ce *CodeExecutor) executeCode(code string, languageID int, stdin, expectedOutput string) (string, float64, int, error) {
-	// Create submission
-	submission := Judge0Submission{
-		SourceCode:     code,
-		LanguageID:     languageID,
-		Stdin:          stdin,
-		ExpectedOutput: expectedOutput,
-		CPUTimeLimit:   5.0,  // 5 seconds max
-		MemoryLimit:    128000, // 128 MB
-		WallTimeLimit:  10.0, // 10 seconds max wall time
+// RunSamples runs code against the sample test cases only and returns the
+// complete output of each run. Hidden test cases are skipped; test numbers
+// refer to positions in the full suite.
+func (ce *CodeExecutor) RunSamples(code, language string, suite TestSuite) ([]SampleRun, error) {
+	language, err := ce.resolveLanguage(language)
+	if err != nil {
+		return nil, err
 	}
 
-	jsonData, err := json.Marshal(submission)
+	code, err = ce.wrapCode(code, language, suite.Signature)
 	if err != nil {
-		return "", 0, 0, err
+		return nil, err
 	}
-
-	// Submit to Judge0
-	url := fmt.Sprintf("%s/submissions?wait=true", ce.judge0URL)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	parsed, err := ce.parseTestCases(suite)
 	if err != nil {
-		return "", 0, 0, fmt.Errorf("judge0 request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return "", 0, 0, fmt.Errorf("judge0 error (status %d): %s", resp.StatusCode, string(body))
+	var samples []TestCase
+	var testNumbers []int
+	for i, testCase := range parsed {
+		if !testCase.Hidden {
+			samples = append(samples, testCase)
+			testNumbers = append(testNumbers, i+1)
+		}
+	}
+	if len(samples) == 0 {
+		return []SampleRun{}, nil
 	}
 
-	// Parse response
-	var judge0Resp Judge0Response
-	if err := json.NewDecoder(resp.Body).Decode(&judge0Resp); err != nil {
-		return "", 0, 0, fmt.Errorf("failed to parse judge0 response: %w", err)
+	outputs, err := ce.executeAll(ce.testRequests(code, language, samples))
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrExecutionFailed, ce.executor.Name(), err)
 	}
 
-	// Check execution status
-	if judge0Resp.Status.ID != 3 { // 3 = Accepted
-		errMsg := judge0Resp.Status.Description
-		if judge0Resp.Stderr != "" {
-			errMsg = judge0Resp.Stderr
-		} else if judge0Resp.CompileOutput != "" {
-			errMsg = judge0Resp.CompileOutput
+	runs := make([]SampleRun, len(samples))
+	for i, output := range outputs {
+		if suite.Signature != nil {
+			suite.Signature.ExtractResult(output)
 		}
-		return "", 0, 0, fmt.Errorf("execution error: %s", errMsg)
+		runs[i] = SampleRun{
+			TestNumber:      testNumbers[i],
+			Input:           samples[i].Input,
+			Expected:        samples[i].Expected,
+			ExecutionOutput: output,
+		}
+		if output.Verdict != VerdictAccepted {
+			continue
+		}
+
+		passed, message, err := samples[i].checker.Check(samples[i].Input, samples[i].Expected, output.Stdout)
+		switch {
+		case err != nil:
+			output.Verdict = VerdictInternalError
+			output.Message = err.Error()
+		case passed:
+			runs[i].Passed = true
+		default:
+			output.Verdict = VerdictWrongAnswer
+			output.Message = message
+		}
+	}
+
+	return runs, nil
+}
+
+// wrapCode turns a function implementation into a complete program when the
+// suite has a signature
+func (ce *CodeExecutor) wrapCode(code, language string, sig *FunctionSignature) (string, error) {
+	if sig == nil {
+		return code, nil
+	}
+	return sig.WrapCode(language, code)
+}
+
+// parseTestCases reads raw test cases, resolves each one's checker and
+// encodes its stdin
+func (ce *CodeExecutor) parseTestCases(suite TestSuite) ([]TestCase, error) {
+	checkerSpec := suite.Checker
+	if checkerSpec == nil && suite.Signature != nil {
+		checkerSpec = CheckerJSON
+	}
+	defaultChecker, err := ce.NewChecker(checkerSpec)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse time (comes as string like "0.001")
-	var execTime float64
-	if judge0Resp.Time != "" {
-		fmt.Sscanf(judge0Resp.Time, "%f", &execTime)
-		execTime *= 1000 // Convert to milliseconds
+	parsed := make([]TestCase, len(suite.TestCases))
+	for i, tc := range suite.TestCases {
+		testCase, ok := tc.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid test case format at index %d", i)
+		}
+
+		parsed[i].Input, _ = testCase["input"].(string)
+		parsed[i].Expected, _ = testCase["expected"].(string)
+		parsed[i].Hidden = models.IsHiddenTestCase(testCase)
+		parsed[i].stdin = parsed[i].Input
+		if suite.Signature != nil {
+			if parsed[i].stdin, err = suite.Signature.EncodeInput(parsed[i].Input); err != nil {
+				return nil, fmt.Errorf("test case %d: %w", i+1, err)
+			}
+		}
+		parsed[i].checker = defaultChecker
+		if spec, ok := testCase["checker"]; ok {
+			if parsed[i].checker, err = ce.NewChecker(spec); err != nil {
+				return nil, fmt.Errorf("test case %d: %w", i+1, err)
+			}
+		}
 	}
 
-	return judge0Resp.Stdout, execTime, judge0Resp.Memory, nil
+	return parsed, nil
 }
 
-// getLanguageID maps language names to Judge0 language IDs
-func (ce *CodeExecutor) getLanguageID(language string) int {
-	languageMap := map[string]int{
-		"python":     71, // Python 3.8.1
-		"python3":    71,
-		"javascript": 63, // JavaScript (Node.js 12.14.0)
-		"js":         63,
-		"java":       62, // Java (OpenJDK 13.0.1)
-		"cpp":        54, // C++ (GCC 9.2.0)
-		"c++":        54,
-		"c":          50, // C (GCC 9.2.0)
-		"go":         60, // Go (1.13.5)
-		"rust":       73, // Rust (1.40.0)
-		"ruby":       72, // Ruby (2.7.0)
-		"php":        68, // PHP (7.4.1)
-		"swift":      83, // Swift (5.2.3)
-		"kotlin":     78, // Kotlin (1.3.70)
-		"typescript": 74, // TypeScript (3.7.4)
-		"ts":         74,
-	}
-
-	if id, ok := languageMap[language]; ok {
-		return id
-	}
-
-	return 0 // Unsupported language
+// testRequests builds one execution request per test case
+func (ce *CodeExecutor) testRequests(code, language string, testCases []TestCase) []ExecutionRequest {
+	requests := make([]ExecutionRequest, len(testCases))
+	for i, testCase := range testCases {
+		requests[i] = ExecutionRequest{
+			SourceCode: code,
+			Language:   language,
+			Stdin:      testCase.stdin,
+			Limits:     ce.limitsFor(language),
+		}
+	}
+	return requests
 }
 
-// normalizeOutput trims and normalizes output for comparison
-func (ce *CodeExecutor) normalizeOutput(output string) string {
-	// Trim leading/trailing whitespace
-	output = strings.TrimSpace(output)
+// newFailureDetail describes a failed test, redacting hidden test cases.
+// Compilation errors do not depend on the test data and are always reported.
+func newFailureDetail(testNumber int, testCase TestCase, verdict Verdict, got, message string) FailureDetail {
+	failure := FailureDetail{
+		TestNumber: testNumber,
+		Verdict:    verdict,
+		Hidden:     testCase.Hidden,
+	}
+	if testCase.Hidden {
+		if verdict == VerdictCompilationError {
+			failure.Error = message
+		}
+		return failure
+	}
 
-	// Normalize line endings
-	output = strings.ReplaceAll(output, "\r\n", "\n")
+	failure.Input = testCase.Input
+	failure.Expected = testCase.Expected
+	failure.Got = got
+	failure.Error = message
+	return failure
+}
 
-	return output
+
+// executeAll runs every request, batching when the backend supports it
+func (ce *CodeExecutor) executeAll(requests []ExecutionRequest) ([]*ExecutionOutput, error) {
+	return ce.executeAllWithProgress(requests, func(int, RunState, *ExecutionOutput) {})
 }
 
-// ValidateCode provides a simple validation without execution (for fallback)
-func (ce *CodeExecutor) ValidateCode(code, language string) bool {
-	// Basic validation: code is not empty and has reasonable length
-	if len(code) < 10 || len(code) > 50000 {
-		return false
-	}
-
-	// Language-specific basic checks
-	switch language {
-	case "python", "python3":
-		// Check for basic Python structure
-		return bytes.Contains([]byte(code), []byte("def ")) ||
-		       bytes.Contains([]byte(code), []byte("class "))
-	case "javascript", "js":
-		return bytes.Contains([]byte(code), []byte("function ")) ||
-		       bytes.Contains([]byte(code), []byte("const ")) ||
-		       bytes.Contains([]byte(code), []byte("=>"))
-	case "java":
-		return bytes.Contains([]byte(code), []byte("class ")) &&
-		       bytes.Contains([]byte(code), []byte("public "))
-	case "cpp", "c++":
-		return bytes.Contains([]byte(code), []byte("#include")) ||
-		       bytes.Contains([]byte(code), []byte("int main"))
-	default:
-		return true // Accept other languages without validation
+// executeAllWithProgress is executeAll reporting the state of each run.
+// Every returned output has been reported as finished.
+func (ce *CodeExecutor) executeAllWithProgress(requests []ExecutionRequest, onProgress func(index int, state RunState, output *ExecutionOutput)) ([]*ExecutionOutput, error) {
+	if reporter, ok := ce.executor.(ProgressExecutor); ok {
+		return reporter.ExecuteBatchWithProgress(requests, onProgress)
+	}
+
+	if batcher, ok := ce.executor.(BatchExecutor); ok {
+		for i := range requests {
+			onProgress(i, RunRunning, nil)
+		}
+		outputs, err := batcher.ExecuteBatch(requests)
+		if err != nil {
+			return nil, err
+		}
+		for i, output := range outputs {
+			onProgress(i, RunFinished, output)
+		}
+		return outputs, nil
+	}
+
+	for i := range requests {
+		onProgress(i, RunQueued, nil)
+	}
+	outputs := make([]*ExecutionOutput, len(requests))
+	for i, req := range requests {
+		onProgress(i, RunRunning, nil)
+		output, err := ce.executor.Execute(req)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = output
+		onProgress(i, RunFinished, output)
 	}
+	return outputs, nil
 }
@@ -0,0 +1,492 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// ComplexityClass is an asymptotic growth class in the notation problems use
+type ComplexityClass string
+
+const (
+	ComplexityConstant     ComplexityClass = "O(1)"
+	ComplexityLogarithmic  ComplexityClass = "O(log n)"
+	ComplexityLinear       ComplexityClass = "O(n)"
+	ComplexityLinearithmic ComplexityClass = "O(n log n)"
+	ComplexityQuadratic    ComplexityClass = "O(n^2)"
+	ComplexityExponential  ComplexityClass = "O(2^n)"
+)
+
+// complexityClasses lists the classes curves are fitted to, slowest growing
+// first. Growth functions take sizes normalized so the largest sample is 1,
+// which keeps 2^n finite.
+var complexityClasses = []struct {
+	class  ComplexityClass
+	growth func(n, max float64) float64
+}{
+	{ComplexityConstant, func(n, max float64) float64 { return 0 }},
+	{ComplexityLogarithmic, func(n, max float64) float64 { return math.Log2(n) / math.Log2(max) }},
+	{ComplexityLinear, func(n, max float64) float64 { return n / max }},
+	{ComplexityLinearithmic, func(n, max float64) float64 { return n * math.Log2(n) / (max * math.Log2(max)) }},
+	{ComplexityQuadratic, func(n, max float64) float64 { return (n / max) * (n / max) }},
+	{ComplexityExponential, func(n, max float64) float64 { return math.Exp2(n - max) }},
+}
+
+// Complexity estimation tuning
+const (
+	// complexityMinSamples is the fewest sizes a curve is fitted to
+	complexityMinSamples = 4
+	// complexityRuns is how often each size runs; the fastest run is kept
+	// to filter out scheduling noise
+	complexityRuns = 3
+	// complexityExponentialMaxSize is the largest size at which exponential
+	// growth is still plausible; beyond it 2^n could not have finished
+	complexityExponentialMaxSize = 64
+	// complexityTolerance lets a slower growing class win when its fit is
+	// nearly as good as the best one. Process-level timings rarely separate
+	// n from n log n, so estimates err towards the slower class.
+	complexityTolerance = 1.25
+	// complexityMinR2 is the goodness of fit below which growth is treated
+	// as noise around a constant
+	complexityMinR2 = 0.8
+)
+
+// complexitySmallSizes are sampled densely so exponential solutions yield
+// enough points before they exceed the time budget
+var complexitySmallSizes = []int{8, 12, 16, 20, 24, 28, 32}
+
+// ComplexityOptions controls empirical complexity estimation of accepted
+// submissions
+type ComplexityOptions struct {
+	Enabled bool
+	MaxSize int // largest generated input size
+}
+
+// DefaultComplexityOptions are used when no options are configured
+var DefaultComplexityOptions = ComplexityOptions{
+	Enabled: true,
+	MaxSize: 1 << 16,
+}
+
+// ComplexityOptionsFromConfig converts executor configuration into
+// complexity estimation options
+func ComplexityOptionsFromConfig(cfg config.ExecutorConfig) ComplexityOptions {
+	options := ComplexityOptions{
+		Enabled: cfg.Complexity.Enabled,
+		MaxSize: cfg.Complexity.MaxSize,
+	}
+	if options.MaxSize <= 0 {
+		options.MaxSize = DefaultComplexityOptions.MaxSize
+	}
+	return options
+}
+
+// ComplexitySample is one run of a submission on a generated input
+type ComplexitySample struct {
+	Size     int     `json:"size"`
+	TimeMs   float64 `json:"time_ms"`
+	MemoryKB int     `json:"memory_kb"`
+}
+
+// ComplexityAnalysis is the estimated growth of a submission's running time
+// and memory.
+//
+// Measurements cover the whole run, including reading the input, so when a
+// signature takes arrays, strings, lists or trees no class below InputBound
+// can be observed and only growth beyond it is compared with the reference.
+type ComplexityAnalysis struct {
+	Samples            []ComplexitySample `json:"samples"`
+	TimeComplexity     ComplexityClass    `json:"time_complexity,omitempty"`
+	SpaceComplexity    ComplexityClass    `json:"space_complexity,omitempty"`
+	InputBound         ComplexityClass    `json:"input_bound"`
+	ReferenceTime      ComplexityClass    `json:"reference_time_complexity,omitempty"`
+	ReferenceSpace     ComplexityClass    `json:"reference_space_complexity,omitempty"`
+	WorseThanReference bool               `json:"worse_than_reference"`
+	Message            string             `json:"message,omitempty"`
+}
+
+// AnalyzeComplexity runs a function implementation on generated inputs of
+// growing size and fits its running time and peak memory to the standard
+// complexity classes. Sizes grow until opts.MaxSize, a run fails or a run
+// uses a quarter of the CPU time limit. referenceTime and referenceSpace are
+// the problem's stated complexities; unrecognized ones are not compared.
+func (ce *CodeExecutor) AnalyzeComplexity(code, language string, sig *FunctionSignature, opts ComplexityOptions, referenceTime, referenceSpace string) (*ComplexityAnalysis, error) {
+	if sig == nil {
+		return nil, fmt.Errorf("complexity analysis needs a function signature")
+	}
+	language, err := ce.resolveLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+
+	code, err = sig.WrapCode(language, code)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &ComplexityAnalysis{
+		Samples:    []ComplexitySample{},
+		InputBound: sig.inputBound(),
+	}
+	budgetMs := ce.limitsFor(language).CPUTime * 1000 / 4
+	for _, size := range complexitySizes(opts.MaxSize) {
+		stdin, err := sig.generateInput(size)
+		if err != nil {
+			return nil, err
+		}
+
+		sample, ok, err := ce.complexitySample(code, language, stdin, size)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		analysis.Samples = append(analysis.Samples, sample)
+		if sample.TimeMs > budgetMs {
+			break
+		}
+	}
+
+	if len(analysis.Samples) < complexityMinSamples {
+		analysis.Message = fmt.Sprintf("only %d input sizes ran within limits, at least %d are needed",
+			len(analysis.Samples), complexityMinSamples)
+		return analysis, nil
+	}
+
+	sizes := make([]float64, len(analysis.Samples))
+	times := make([]float64, len(analysis.Samples))
+	memory := make([]float64, len(analysis.Samples))
+	for i, sample := range analysis.Samples {
+		sizes[i] = float64(sample.Size)
+		times[i] = sample.TimeMs
+		memory[i] = float64(sample.MemoryKB)
+	}
+	analysis.TimeComplexity = FitComplexity(sizes, times, 5)
+	analysis.SpaceComplexity = FitComplexity(sizes, memory, 512)
+
+	var worse []string
+	if ref, ok := ParseComplexityClass(referenceTime); ok {
+		analysis.ReferenceTime = ref
+		if exceedsComplexity(analysis.TimeComplexity, ref, analysis.InputBound) {
+			worse = append(worse, fmt.Sprintf("time %s is worse than the reference %s", analysis.TimeComplexity, ref))
+		}
+	}
+	if ref, ok := ParseComplexityClass(referenceSpace); ok {
+		analysis.ReferenceSpace = ref
+		if exceedsComplexity(analysis.SpaceComplexity, ref, analysis.InputBound) {
+			worse = append(worse, fmt.Sprintf("space %s is worse than the reference %s", analysis.SpaceComplexity, ref))
+		}
+	}
+	if len(worse) > 0 {
+		analysis.WorseThanReference = true
+		analysis.Message = strings.Join(worse, "; ")
+	}
+
+	return analysis, nil
+}
+
+// complexitySample runs code on one input complexityRuns times and keeps
+// the fastest time and smallest peak memory. ok is false when a run fails.
+func (ce *CodeExecutor) complexitySample(code, language, stdin string, size int) (sample ComplexitySample, ok bool, err error) {
+	requests := make([]ExecutionRequest, complexityRuns)
+	for i := range requests {
+		requests[i] = ExecutionRequest{
+			SourceCode: code,
+			Language:   language,
+			Stdin:      stdin,
+			Limits:     ce.limitsFor(language),
+		}
+	}
+	outputs, err := ce.executeAll(requests)
+	if err != nil {
+		return sample, false, fmt.Errorf("%w (%s): %v", ErrExecutionFailed, ce.executor.Name(), err)
+	}
+
+	sample.Size = size
+	for i, output := range outputs {
+		if output.Verdict != VerdictAccepted {
+			return sample, false, nil
+		}
+		if i == 0 || output.TimeMs < sample.TimeMs {
+			sample.TimeMs = output.TimeMs
+		}
+		if i == 0 || output.MemoryKB < sample.MemoryKB {
+			sample.MemoryKB = output.MemoryKB
+		}
+	}
+	return sample, true, nil
+}
+
+// FitComplexity picks the complexity class whose curve a + b*f(n), b >= 0,
+// best fits the measurements by least squares. Sizes must be ascending.
+//
+// Fixed overheads such as process startup hide growth at small sizes, so
+// only samples rising above the median of the smaller half by more than
+// noise (and 15% of that median) count as growth. With fewer than three
+// such samples, or two when every size is small enough for exponential
+// growth, the measurements are constant.
+func FitComplexity(sizes, values []float64, noise float64) ComplexityClass {
+	if len(sizes) < 2 || len(sizes) != len(values) {
+		return ComplexityConstant
+	}
+
+	small := append([]float64{}, values[:(len(values)+1)/2]...)
+	sort.Float64s(small)
+	baseline := small[len(small)/2]
+	band := math.Max(noise, baseline*0.15)
+
+	grown := 0
+	for _, v := range values {
+		if v > baseline+band {
+			grown++
+		}
+	}
+	maxSize := sizes[len(sizes)-1]
+	minGrown := 3
+	if maxSize <= complexityExponentialMaxSize {
+		minGrown = 2
+	}
+	if grown < minGrown || maxSize <= 1 {
+		return ComplexityConstant
+	}
+
+	type fit struct {
+		class ComplexityClass
+		sse   float64
+	}
+	var fits []fit
+	best := math.Inf(1)
+	for _, candidate := range complexityClasses[1:] {
+		if candidate.class == ComplexityExponential && maxSize > complexityExponentialMaxSize {
+			continue
+		}
+		x := make([]float64, len(sizes))
+		for i, n := range sizes {
+			x[i] = candidate.growth(n, maxSize)
+		}
+		sse := leastSquaresSSE(x, values)
+		fits = append(fits, fit{candidate.class, sse})
+		best = math.Min(best, sse)
+	}
+
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	total := 0.0
+	for _, v := range values {
+		total += (v - mean) * (v - mean)
+	}
+
+	for _, f := range fits {
+		if f.sse <= best*complexityTolerance {
+			if 1-f.sse/total < complexityMinR2 {
+				return ComplexityConstant
+			}
+			return f.class
+		}
+	}
+	return ComplexityConstant
+}
+
+// leastSquaresSSE fits y = a + b*x with b >= 0 and returns the sum of
+// squared residuals
+func leastSquaresSSE(x, y []float64) float64 {
+	n := float64(len(x))
+	var meanX, meanY float64
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+
+	var cov, varX float64
+	for i := range x {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+	}
+	b := 0.0
+	if varX > 0 && cov > 0 {
+		b = cov / varX
+	}
+	a := meanY - b*meanX
+
+	sse := 0.0
+	for i := range x {
+		r := y[i] - (a + b*x[i])
+		sse += r * r
+	}
+	return sse
+}
+
+// complexityRank orders classes by growth; unknown classes rank below all
+func complexityRank(class ComplexityClass) int {
+	for i, candidate := range complexityClasses {
+		if candidate.class == class {
+			return i
+		}
+	}
+	return -1
+}
+
+// exceedsComplexity reports whether an estimate grows faster than the
+// reference, ignoring growth the input size alone accounts for
+func exceedsComplexity(estimate, reference, inputBound ComplexityClass) bool {
+	limit := complexityRank(reference)
+	if bound := complexityRank(inputBound); bound > limit {
+		limit = bound
+	}
+	return complexityRank(estimate) > limit
+}
+
+// ParseComplexityClass reads a single-variable complexity such as "O(n)",
+// "O(n log n)" or "O(n²)". Expressions in other variables or combining
+// several, like "O(m*n)", are not recognized.
+func ParseComplexityClass(s string) (ComplexityClass, bool) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(s), ""))
+	normalized = strings.NewReplacer("²", "^2", "ⁿ", "^n", "·", "*", "lg", "log").Replace(normalized)
+	normalized = strings.TrimSuffix(strings.TrimPrefix(normalized, "o("), ")")
+
+	switch normalized {
+	case "1":
+		return ComplexityConstant, true
+	case "logn", "log(n)":
+		return ComplexityLogarithmic, true
+	case "n":
+		return ComplexityLinear, true
+	case "nlogn", "n*logn", "nlog(n)", "n*log(n)":
+		return ComplexityLinearithmic, true
+	case "n^2", "n*n":
+		return ComplexityQuadratic, true
+	case "2^n":
+		return ComplexityExponential, true
+	}
+	return "", false
+}
+
+// complexitySizes lists the input sizes sampled up to max: densely while
+// small, then growing by a factor of sqrt(2) so the few sizes a slow
+// solution completes within the time budget still trace its curve
+func complexitySizes(max int) []int {
+	var sizes []int
+	for _, size := range complexitySmallSizes {
+		if size <= max {
+			sizes = append(sizes, size)
+		}
+	}
+	last := float64(complexitySmallSizes[len(complexitySmallSizes)-1])
+	for step := 1; ; step++ {
+		size := int(math.Round(last * math.Pow(math.Sqrt2, float64(step))))
+		if size > max {
+			break
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes
+}
+
+// inputBound is the growth of merely reading a generated input: linear when
+// any argument is an array, string, list or tree, constant otherwise
+func (sig *FunctionSignature) inputBound() ComplexityClass {
+	if sig.scalarOnly() {
+		return ComplexityConstant
+	}
+	return ComplexityLinear
+}
+
+// scalarOnly reports whether every parameter is a number or boolean
+func (sig *FunctionSignature) scalarOnly() bool {
+	for _, param := range sig.Params {
+		if param.typ.dims > 0 || param.typ.base == harnessString ||
+			param.typ.base == harnessListNode || param.typ.base == harnessTreeNode {
+			return false
+		}
+	}
+	return true
+}
+
+// generateInput builds driver tokens for arguments of size n. Arrays,
+// strings and lists hold n elements, matrices and arrays of lists are about
+// sqrt(n) by sqrt(n) and trees are complete with n nodes. Integers scale
+// with n when every parameter is a scalar, as in fib(n), and are otherwise
+// random values below n; strings inside arrays are short words. The same
+// size always yields the same input.
+func (sig *FunctionSignature) generateInput(n int) (string, error) {
+	rng := rand.New(rand.NewSource(int64(n)))
+	scaleScalars := sig.scalarOnly()
+
+	var buf bytes.Buffer
+	for _, param := range sig.Params {
+		value := generateHarnessValue(rng, param.typ, n, scaleScalars)
+		if err := encodeHarnessValue(&buf, param.typ, value); err != nil {
+			return "", fmt.Errorf("argument %s: %w", param.Name, err)
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String(), nil
+}
+
+// generateHarnessValue generates one value of type t of size n in the form
+// encodeHarnessValue accepts
+func generateHarnessValue(rng *rand.Rand, t valueType, n int, scaleScalars bool) interface{} {
+	side := int(math.Ceil(math.Sqrt(float64(n))))
+
+	switch {
+	case t.dims == 2 || (t.dims == 1 && t.base == harnessListNode):
+		rows := make([]interface{}, side)
+		for i := range rows {
+			rows[i] = generateHarnessValue(rng, valueType{base: t.base, dims: t.dims - 1}, side, false)
+		}
+		return rows
+	case t.dims == 1, t.base == harnessListNode, t.base == harnessTreeNode:
+		elem := valueType{base: t.base}
+		if t.dims == 0 {
+			elem.base = harnessInt
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = generateHarnessScalar(rng, elem.base, n, false)
+		}
+		return items
+	case t.base == harnessString:
+		return generateHarnessString(rng, n)
+	}
+	return generateHarnessScalar(rng, t.base, n, scaleScalars)
+}
+
+// generateHarnessScalar generates a number, boolean or short string
+func generateHarnessScalar(rng *rand.Rand, base string, n int, scale bool) interface{} {
+	switch base {
+	case harnessInt, harnessLong:
+		if scale {
+			return json.Number(fmt.Sprint(n))
+		}
+		return json.Number(fmt.Sprint(rng.Intn(n)))
+	case harnessDouble:
+		return json.Number(fmt.Sprintf("%.3f", rng.Float64()*float64(n)))
+	case harnessBool:
+		return rng.Intn(2) == 1
+	default:
+		return generateHarnessString(rng, 1+rng.Intn(8))
+	}
+}
+
+// generateHarnessString generates a string of random lowercase letters
+func generateHarnessString(rng *rand.Rand, length int) string {
+	letters := make([]byte, length)
+	for i := range letters {
+		letters[i] = byte('a' + rng.Intn(26))
+	}
+	return string(letters)
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// QueueOptions limits code execution jobs. A job is one grading, run or
+// stress test request, however many test cases it executes, though a stress
+// test uses up the daily quota of one job per batch. Zero disables a limit.
+type QueueOptions struct {
+	MaxConcurrent    int
+	MaxQueued        int
+	MaxQueuedPerUser int
+	RatePerMinute    float64
+	Burst            int
+	DailyQuota       int
+}
+
+// QueueOptionsFromConfig builds queue options from executor configuration
+func QueueOptionsFromConfig(cfg config.ExecutorConfig) QueueOptions {
+	return QueueOptions{
+		MaxConcurrent:    cfg.Queue.MaxConcurrent,
+		MaxQueued:        cfg.Queue.MaxQueued,
+		MaxQueuedPerUser: cfg.Queue.MaxQueuedPerUser,
+		RatePerMinute:    cfg.Queue.RatePerMinute,
+		Burst:            cfg.Queue.Burst,
+		DailyQuota:       cfg.Queue.DailyQuota,
+	}
+}
+
+// RejectReason is why the queue turned a job away
+type RejectReason string
+
+const (
+	RejectRateLimited   RejectReason = "rate_limited"
+	RejectQuotaExceeded RejectReason = "quota_exceeded"
+	RejectQueueFull     RejectReason = "queue_full"
+)
+
+// QueueRejectedError is returned when a user's job is not admitted.
+// RetryAfter is when trying again may succeed.
+type QueueRejectedError struct {
+	Reason     RejectReason
+	RetryAfter time.Duration
+}
+
+func (e *QueueRejectedError) Error() string {
+	switch e.Reason {
+	case RejectQuotaExceeded:
+		return "daily code execution quota exceeded"
+	case RejectQueueFull:
+		return "too many code executions in progress"
+	default:
+		return fmt.Sprintf("code execution rate limit exceeded, retry in %s", e.RetryAfter.Round(time.Second))
+	}
+}
+
+// ExecutionQueue admits code execution jobs against per-user rate limits and
+// quotas and runs at most MaxConcurrent of them at once. Waiting jobs are
+// started round-robin across users, so one user's backlog cannot starve
+// everyone else.
+type ExecutionQueue struct {
+	mu      sync.Mutex
+	options QueueOptions
+
+	running  int
+	inFlight int
+	users    map[int]*queueUser
+	order    []int // users with waiting jobs, next to be served first
+	sweptAt  time.Time
+
+	admitted  int64
+	completed int64
+	rejected  map[RejectReason]int64
+	avgWait   time.Duration
+	avgRun    time.Duration
+}
+
+// queueUser is one user's limits state and waiting jobs
+type queueUser struct {
+	tokens     float64
+	refilledAt time.Time
+	day        string
+	used       int
+	inFlight   int
+	waiting    []*ExecutionJob
+}
+
+// ExecutionJob is a place in the queue. Call Wait before executing and Done
+// afterwards.
+type ExecutionJob struct {
+	queue      *ExecutionQueue
+	userID     int
+	enqueuedAt time.Time
+	startedAt  time.Time
+	ready      chan struct{}
+	done       bool
+}
+
+// QueueStats is a snapshot of the queue
+type QueueStats struct {
+	Running       int                    `json:"running"`
+	Queued        int                    `json:"queued"`
+	Users         int                    `json:"users"`
+	MaxConcurrent int                    `json:"max_concurrent"`
+	MaxQueued     int                    `json:"max_queued"`
+	Admitted      int64                  `json:"admitted"`
+	Completed     int64                  `json:"completed"`
+	Rejected      map[RejectReason]int64 `json:"rejected"`
+	AvgWaitMs     float64                `json:"avg_wait_ms"`
+	AvgRunMs      float64                `json:"avg_run_ms"`
+}
+
+// NewExecutionQueue creates an execution queue. Zero options admit every
+// job and run them all at once.
+func NewExecutionQueue(options QueueOptions) *ExecutionQueue {
+	if options.RatePerMinute > 0 && options.Burst <= 0 {
+		options.Burst = 1
+	}
+	return &ExecutionQueue{
+		options:  options,
+		users:    make(map[int]*queueUser),
+		rejected: make(map[RejectReason]int64),
+	}
+}
+
+// Admit checks a user's limits and, if they allow another job, takes a
+// place in the queue
+func (q *ExecutionQueue) Admit(userID int) (*ExecutionJob, error) {
+	return q.AdmitCost(userID, 1)
+}
+
+// AdmitCost is Admit for a job that uses up cost jobs of the daily quota,
+// such as a stress test running many batches
+func (q *ExecutionQueue) AdmitCost(userID, cost int) (*ExecutionJob, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	user := q.user(userID, now)
+
+	if err := q.checkLimits(user, cost, now); err != nil {
+		q.rejected[err.Reason]++
+		return nil, err
+	}
+
+	if q.options.RatePerMinute > 0 {
+		user.tokens--
+	}
+	user.used += cost
+	q.admitted++
+	return q.enqueue(userID, user, now), nil
+}
+
+// Reserve takes a place in the queue without checking limits, for work
+// admitted earlier such as submissions resumed after a restart
+func (q *ExecutionQueue) Reserve(userID int) *ExecutionJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	return q.enqueue(userID, q.user(userID, now), now)
+}
+
+// Stats returns a snapshot of the queue
+func (q *ExecutionQueue) Stats() QueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	rejected := make(map[RejectReason]int64, len(q.rejected))
+	for reason, count := range q.rejected {
+		rejected[reason] = count
+	}
+	return QueueStats{
+		Running:       q.running,
+		Queued:        q.inFlight - q.running,
+		Users:         len(q.users),
+		MaxConcurrent: q.options.MaxConcurrent,
+		MaxQueued:     q.options.MaxQueued,
+		Admitted:      q.admitted,
+		Completed:     q.completed,
+		Rejected:      rejected,
+		AvgWaitMs:     float64(q.avgWait) / float64(time.Millisecond),
+		AvgRunMs:      float64(q.avgRun) / float64(time.Millisecond),
+	}
+}
+
+// Wait blocks until the job may execute
+func (j *ExecutionJob) Wait() {
+	q := j.queue
+	q.mu.Lock()
+	if q.options.MaxConcurrent <= 0 || (q.running < q.options.MaxConcurrent && len(q.order) == 0) {
+		q.start(j)
+		q.mu.Unlock()
+		return
+	}
+
+	user := q.users[j.userID]
+	if len(user.waiting) == 0 {
+		q.order = append(q.order, j.userID)
+	}
+	user.waiting = append(user.waiting, j)
+	q.mu.Unlock()
+
+	<-j.ready
+}
+
+// Done releases the job's place and slot. It is safe to call more than once.
+func (j *ExecutionJob) Done() {
+	q := j.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if j.done {
+		return
+	}
+	j.done = true
+
+	q.inFlight--
+	user := q.users[j.userID]
+	user.inFlight--
+	if j.startedAt.IsZero() {
+		// Given up while waiting
+		for i, waiting := range user.waiting {
+			if waiting == j {
+				user.waiting = append(user.waiting[:i], user.waiting[i+1:]...)
+				break
+			}
+		}
+		if len(user.waiting) == 0 {
+			for i, id := range q.order {
+				if id == j.userID {
+					q.order = append(q.order[:i], q.order[i+1:]...)
+					break
+				}
+			}
+		}
+	} else {
+		q.running--
+		q.completed++
+		q.avgRun = movingAverage(q.avgRun, time.Since(j.startedAt))
+	}
+	if q.forgettable(user, time.Now()) {
+		delete(q.users, j.userID)
+	}
+	q.dispatch()
+}
+
+// queueSweepInterval is how often users are checked for state that can be
+// dropped
+const queueSweepInterval = time.Minute
+
+// user returns a user's state, refilling their rate limit tokens and
+// resetting their quota at the start of a new day
+func (q *ExecutionQueue) user(userID int, now time.Time) *queueUser {
+	if now.Sub(q.sweptAt) >= queueSweepInterval {
+		for id, user := range q.users {
+			if q.forgettable(user, now) {
+				delete(q.users, id)
+			}
+		}
+		q.sweptAt = now
+	}
+
+	user, ok := q.users[userID]
+	if !ok {
+		user = &queueUser{tokens: float64(q.options.Burst), refilledAt: now}
+		q.users[userID] = user
+	}
+
+	if q.options.RatePerMinute > 0 {
+		user.tokens += now.Sub(user.refilledAt).Minutes() * q.options.RatePerMinute
+		user.tokens = math.Min(user.tokens, float64(q.options.Burst))
+	}
+	user.refilledAt = now
+
+	if day := now.UTC().Format("2006-01-02"); day != user.day {
+		user.day = day
+		user.used = 0
+	}
+	return user
+}
+
+// forgettable reports whether a user's state is that of a user never seen:
+// no jobs in flight, all rate limit tokens back and no quota used today
+func (q *ExecutionQueue) forgettable(user *queueUser, now time.Time) bool {
+	if user.inFlight > 0 {
+		return false
+	}
+	if q.options.RatePerMinute > 0 &&
+		user.tokens+now.Sub(user.refilledAt).Minutes()*q.options.RatePerMinute < float64(q.options.Burst) {
+		return false
+	}
+	return q.options.DailyQuota <= 0 || user.used == 0 || user.day != now.UTC().Format("2006-01-02")
+}
+
+// checkLimits reports the first limit another job of the given cost would
+// break, with a hint for when to retry
+func (q *ExecutionQueue) checkLimits(user *queueUser, cost int, now time.Time) *QueueRejectedError {
+	if q.options.MaxQueuedPerUser > 0 && user.inFlight >= q.options.MaxQueuedPerUser {
+		return &QueueRejectedError{Reason: RejectQueueFull, RetryAfter: q.retryAfterRun(1)}
+	}
+	if q.options.MaxQueued > 0 && q.inFlight-q.running >= q.options.MaxQueued {
+		rounds := 1
+		if q.options.MaxConcurrent > 0 {
+			rounds = (q.inFlight-q.running)/q.options.MaxConcurrent + 1
+		}
+		return &QueueRejectedError{Reason: RejectQueueFull, RetryAfter: q.retryAfterRun(rounds)}
+	}
+	if q.options.DailyQuota > 0 && user.used+cost > q.options.DailyQuota {
+		tomorrow := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		return &QueueRejectedError{Reason: RejectQuotaExceeded, RetryAfter: tomorrow.Sub(now)}
+	}
+	if q.options.RatePerMinute > 0 && user.tokens < 1 {
+		wait := time.Duration((1 - user.tokens) / q.options.RatePerMinute * float64(time.Minute))
+		return &QueueRejectedError{Reason: RejectRateLimited, RetryAfter: wait}
+	}
+	return nil
+}
+
+// retryAfterRun estimates how long until the given number of rounds of
+// running jobs finish, at least a second
+func (q *ExecutionQueue) retryAfterRun(rounds int) time.Duration {
+	wait := time.Duration(rounds) * q.avgRun
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+func (q *ExecutionQueue) enqueue(userID int, user *queueUser, now time.Time) *ExecutionJob {
+	q.inFlight++
+	user.inFlight++
+	return &ExecutionJob{queue: q, userID: userID, enqueuedAt: now, ready: make(chan struct{})}
+}
+
+func (q *ExecutionQueue) start(j *ExecutionJob) {
+	j.startedAt = time.Now()
+	q.running++
+	q.avgWait = movingAverage(q.avgWait, j.startedAt.Sub(j.enqueuedAt))
+}
+
+// dispatch starts waiting jobs while slots are free, taking one job from
+// each user in turn
+func (q *ExecutionQueue) dispatch() {
+	for len(q.order) > 0 && (q.options.MaxConcurrent <= 0 || q.running < q.options.MaxConcurrent) {
+		userID := q.order[0]
+		q.order = q.order[1:]
+
+		user := q.users[userID]
+		job := user.waiting[0]
+		user.waiting = user.waiting[1:]
+		if len(user.waiting) > 0 {
+			q.order = append(q.order, userID)
+		}
+
+		q.start(job)
+		close(job.ready)
+	}
+}
+
+// movingAverage folds a sample into an exponentially weighted average
+func movingAverage(average, sample time.Duration) time.Duration {
+	if average == 0 {
+		return sample
+	}
+	return average + (sample-average)/10
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// Verdict is the normalized outcome of running a program once
+type Verdict string
+
+const (
+	VerdictAccepted            Verdict = "AC"
+	VerdictWrongAnswer         Verdict = "WA"
+	VerdictTimeLimitExceeded   Verdict = "TLE"
+	VerdictMemoryLimitExceeded Verdict = "MLE"
+	VerdictRuntimeError        Verdict = "RE"
+	VerdictCompilationError    Verdict = "CE"
+	VerdictInternalError       Verdict = "IE"
+)
+
+// ResourceLimits bounds a single program run
+type ResourceLimits struct {
+	CPUTime  float64 // seconds
+	WallTime float64 // seconds
+	MemoryKB int
+}
+
+// DefaultResourceLimits are used when no limits are configured
+var DefaultResourceLimits = ResourceLimits{
+	CPUTime:  5.0,
+	WallTime: 10.0,
+	MemoryKB: 128000,
+}
+
+// ExecutionRequest describes a single program run
+type ExecutionRequest struct {
+	SourceCode string
+	Language   string
+	Stdin      string
+	Limits     ResourceLimits
+}
+
+// ExecutionOutput is the raw result of running a program once
+type ExecutionOutput struct {
+	Verdict       Verdict `json:"verdict"`
+	Stdout        string  `json:"stdout"`
+	Stderr        string  `json:"stderr,omitempty"`
+	CompileOutput string  `json:"compile_output,omitempty"`
+	TimeMs        float64 `json:"time_ms"`
+	MemoryKB      int     `json:"memory_kb"`
+	Message       string  `json:"message,omitempty"`
+}
+
+// ErrorMessage returns the most useful diagnostic for a failed run
+func (o *ExecutionOutput) ErrorMessage() string {
+	switch {
+	case o.CompileOutput != "":
+		return o.CompileOutput
+	case o.Stderr != "":
+		return o.Stderr
+	case o.Message != "":
+		return o.Message
+	default:
+		return string(o.Verdict)
+	}
+}
+
+// Executor runs untrusted code in an isolated environment.
+//
+// Execute returns an error only when the backend itself fails (unreachable,
+// misconfigured, sandbox setup failure). Problems with the submitted program
+// are reported through ExecutionOutput.Verdict.
+type Executor interface {
+	Name() string
+	Execute(req ExecutionRequest) (*ExecutionOutput, error)
+	SupportsLanguage(language string) bool
+	IsAvailable() bool
+}
+
+// BatchExecutor is implemented by backends that can run one program against
+// many inputs more efficiently than one Execute call per input. Outputs are
+// returned in the same order as the requests.
+type BatchExecutor interface {
+	ExecuteBatch(reqs []ExecutionRequest) ([]*ExecutionOutput, error)
+}
+
+// RunState is the progress of a single run within a batch
+type RunState string
+
+const (
+	RunQueued   RunState = "queued"
+	RunRunning  RunState = "running"
+	RunFinished RunState = "finished"
+)
+
+// ProgressExecutor is implemented by batch backends that report each run's
+// state as it changes. onProgress may be called from several goroutines; it
+// receives the run's output with RunFinished and nil otherwise.
+type ProgressExecutor interface {
+	ExecuteBatchWithProgress(reqs []ExecutionRequest, onProgress func(index int, state RunState, output *ExecutionOutput)) ([]*ExecutionOutput, error)
+}
+
+// NewExecutor creates the executor backend selected in configuration
+func NewExecutor(cfg config.ExecutorConfig) (Executor, error) {
+	switch cfg.Backend {
+	case "", "judge0":
+		return NewJudge0Executor(cfg), nil
+	case "local":
+		return NewLocalExecutor(cfg.Local)
+	default:
+		return nil, fmt.Errorf("unknown executor backend: %s", cfg.Backend)
+	}
+}
+
+// LimitsFromConfig converts executor configuration into resource limits
+func LimitsFromConfig(cfg config.ExecutorConfig) ResourceLimits {
+	limits := DefaultResourceLimits
+	if cfg.CPUTimeLimit > 0 {
+		limits.CPUTime = cfg.CPUTimeLimit
+	}
+	if cfg.WallTimeLimit > 0 {
+		limits.WallTime = cfg.WallTimeLimit
+	}
+	if cfg.MemoryLimit > 0 {
+		limits.MemoryKB = cfg.MemoryLimit
+	}
+	return limits
+}
+
+// canonicalLanguage maps language aliases to a single canonical name
+func canonicalLanguage(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	aliases := map[string]string{
+		"python3": "python",
+		"py":      "python",
+		"js":      "javascript",
+		"node":    "javascript",
+		"c++":     "cpp",
+		"ts":      "typescript",
+		"golang":  "go",
+	}
+	if canonical, ok := aliases[language]; ok {
+		return canonical
+	}
+	return language
+}
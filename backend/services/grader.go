@@ -0,0 +1,141 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+var (
+	// ErrUnknownQuestionFormat is returned for a question format no grader
+	// is registered for
+	ErrUnknownQuestionFormat = errors.New("unknown question format")
+	// ErrInvalidQuestion is returned when a question's correct answer or
+	// data does not fit its format
+	ErrInvalidQuestion = errors.New("invalid question")
+	// ErrInvalidAnswer is returned when a user answer does not fit the
+	// question's format
+	ErrInvalidAnswer = errors.New("invalid answer")
+)
+
+// GradeResult is the outcome of grading one answer. Score runs from 0 to 1
+// and only full marks are correct. Mistakes lists what the grader found
+// wrong, in a form that can be shown to the user and stored with the
+// attempt; Details holds format-specific results such as per-blank marks.
+type GradeResult struct {
+	Score    float64     `json:"score"`
+	Correct  bool        `json:"correct"`
+	Feedback string      `json:"feedback,omitempty"`
+	Mistakes []string    `json:"mistakes,omitempty"`
+	Details  interface{} `json:"details,omitempty"`
+}
+
+// Grader grades the answers to one question format. Questions and answers
+// reach it as decoded JSON, so numbers are float64 and lists are
+// []interface{}.
+type Grader interface {
+	// Format is the question_format the grader handles
+	Format() string
+	// ValidateQuestion checks a question's correct_answer, question_data
+	// and answer_options when it is created
+	ValidateQuestion(question *models.Question) error
+	// ValidateAnswer checks the shape of a user answer before grading
+	ValidateAnswer(question *models.Question, answer map[string]interface{}) error
+	// Grade scores an answer that passed ValidateAnswer. Errors are
+	// reserved for answers that could not be graded, such as code the
+	// execution backend failed to run or a question stored before it was
+	// validated.
+	Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error)
+}
+
+// CodeGrader is implemented by graders that may run the answer's code.
+// Grading such answers waits its turn in the execution queue.
+type CodeGrader interface {
+	Grader
+	ExecutesCode(question *models.Question) bool
+}
+
+// GraderRegistry maps question formats to their graders
+type GraderRegistry struct {
+	mu      sync.RWMutex
+	graders map[string]Grader
+}
+
+// NewGraderRegistry creates an empty grader registry
+func NewGraderRegistry() *GraderRegistry {
+	return &GraderRegistry{graders: make(map[string]Grader)}
+}
+
+// Register adds a grader for its format. Each format has one grader.
+func (r *GraderRegistry) Register(grader Grader) error {
+	format := grader.Format()
+	if format == "" {
+		return errors.New("grader has no format")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.graders[format]; exists {
+		return fmt.Errorf("a grader is already registered for %q", format)
+	}
+	r.graders[format] = grader
+	return nil
+}
+
+// Lookup returns the grader for a question format
+func (r *GraderRegistry) Lookup(format string) (Grader, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	grader, ok := r.graders[format]
+	return grader, ok
+}
+
+// Formats lists the registered question formats in order
+func (r *GraderRegistry) Formats() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	formats := make([]string, 0, len(r.graders))
+	for format := range r.graders {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// decodedQuestion copies a question with its JSON fields round-tripped
+// through encoding/json, so graders see the same types whether the question
+// was loaded from the database or built in Go
+func decodedQuestion(question *models.Question) (*models.Question, error) {
+	decoded := *question
+	var err error
+	if decoded.CorrectAnswer, err = decodeJSON(question.CorrectAnswer); err != nil {
+		return nil, fmt.Errorf("correct_answer: %w", err)
+	}
+	if decoded.QuestionData, err = decodeJSON(question.QuestionData); err != nil {
+		return nil, fmt.Errorf("question_data: %w", err)
+	}
+	if decoded.AnswerOptions, err = decodeJSON(question.AnswerOptions); err != nil {
+		return nil, fmt.Errorf("answer_options: %w", err)
+	}
+	return &decoded, nil
+}
+
+// decodeJSON round-trips a JSON object through encoding/json
+func decodeJSON(value map[string]interface{}) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
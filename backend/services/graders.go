@@ -0,0 +1,822 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+// builtinGraders returns the graders for the question formats supported out
+// of the box
+func (s *QuestionService) builtinGraders() []Grader {
+	return []Grader{
+		multipleChoiceGrader{},
+		textGrader{assessor: s.assessor},
+		rankingGrader{},
+		codeGrader{s: s},
+		fillBlankGrader{},
+		debugGrader{s: s},
+		openEndedGrader{assessor: s.assessor},
+	}
+}
+
+// verdictDescriptions describe failed test verdicts in mistakes
+var verdictDescriptions = map[Verdict]string{
+	VerdictWrongAnswer:         "wrong answer",
+	VerdictTimeLimitExceeded:   "time limit exceeded",
+	VerdictMemoryLimitExceeded: "memory limit exceeded",
+	VerdictRuntimeError:        "runtime error",
+	VerdictCompilationError:    "compilation error",
+	VerdictInternalError:       "internal error",
+}
+
+// testMistakes describes each failed test of an execution result
+func testMistakes(result *ExecutionResult) []string {
+	mistakes := make([]string, 0, len(result.Failures))
+	for _, failure := range result.Failures {
+		description, ok := verdictDescriptions[failure.Verdict]
+		if !ok {
+			description = string(failure.Verdict)
+		}
+		if failure.Hidden {
+			mistakes = append(mistakes, fmt.Sprintf("hidden test %d: %s", failure.TestNumber, description))
+		} else {
+			mistakes = append(mistakes, fmt.Sprintf("test %d: %s", failure.TestNumber, description))
+		}
+	}
+	return mistakes
+}
+
+// binaryScore is the score of an answer that is either right or wrong
+func binaryScore(correct bool) float64 {
+	if correct {
+		return 1
+	}
+	return 0
+}
+
+// multipleChoiceGrader grades multiple choice questions. With a list of
+// "answers" in correct_answer the question is multi-select and earns
+// per-option credit (see isMultiSelect).
+type multipleChoiceGrader struct{}
+
+func (multipleChoiceGrader) Format() string { return "multiple_choice" }
+
+func (multipleChoiceGrader) ValidateQuestion(question *models.Question) error {
+	var correct []interface{}
+	if isMultiSelect(question) {
+		correct, _ = question.CorrectAnswer["answers"].([]interface{})
+		if len(correct) == 0 {
+			return errors.New(`correct_answer "answers" is empty`)
+		}
+	} else {
+		answer, _ := question.CorrectAnswer["answer"].(string)
+		if answer == "" {
+			return errors.New(`correct_answer needs an "answer" or a list of "answers"`)
+		}
+		correct = []interface{}{answer}
+	}
+
+	options, _ := question.AnswerOptions["options"].([]interface{})
+	if len(options) == 0 {
+		return nil
+	}
+	ids := make(map[string]bool, len(options))
+	for _, option := range options {
+		if option, ok := option.(map[string]interface{}); ok {
+			ids[fmt.Sprint(option["id"])] = true
+		}
+	}
+	for _, answer := range correct {
+		if !ids[fmt.Sprint(answer)] {
+			return fmt.Errorf("correct option %v is not one of the answer options", answer)
+		}
+	}
+	return nil
+}
+
+func (multipleChoiceGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if isMultiSelect(question) {
+		switch answer["answers"].(type) {
+		case []interface{}, string:
+			return nil
+		}
+		return errors.New(`"answers" must list the chosen options`)
+	}
+	if _, ok := answer["answer"].(string); !ok {
+		return errors.New(`"answer" must be the chosen option`)
+	}
+	return nil
+}
+
+func (multipleChoiceGrader) Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error) {
+	if !isMultiSelect(question) {
+		chosen := answer["answer"].(string)
+		if chosen == question.CorrectAnswer["answer"] {
+			return &GradeResult{Score: 1}, nil
+		}
+		return &GradeResult{Mistakes: []string{fmt.Sprintf("option %s is not correct", chosen)}}, nil
+	}
+
+	// Multi-select: each correct option chosen earns an equal share and each
+	// wrong option chosen takes one away, with a floor of 0, so choosing
+	// every option scores no better than choosing none
+	correctList := question.CorrectAnswer["answers"].([]interface{})
+	correct := make(map[string]bool, len(correctList))
+	for _, option := range correctList {
+		correct[fmt.Sprint(option)] = true
+	}
+	if len(correct) == 0 {
+		return nil, fmt.Errorf("%w: no correct options", ErrInvalidQuestion)
+	}
+
+	var chosen []interface{}
+	switch v := answer["answers"].(type) {
+	case []interface{}:
+		chosen = v
+	case string:
+		chosen = []interface{}{v}
+	}
+
+	result := &GradeResult{}
+	seen := make(map[string]bool, len(chosen))
+	hits, misses := 0, 0
+	for _, option := range chosen {
+		id := fmt.Sprint(option)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if correct[id] {
+			hits++
+		} else {
+			misses++
+			result.Mistakes = append(result.Mistakes, fmt.Sprintf("option %s is not correct", id))
+		}
+	}
+	for _, option := range correctList {
+		if id := fmt.Sprint(option); !seen[id] {
+			seen[id] = true
+			result.Mistakes = append(result.Mistakes, fmt.Sprintf("missed option %s", id))
+		}
+	}
+
+	if score := float64(hits-misses) / float64(len(correct)); score > 0 {
+		result.Score = score
+	}
+	result.Feedback = fmt.Sprintf("Chose %d of %d correct options", hits, len(correct))
+	return result, nil
+}
+
+// textGrader grades short text answers with fuzzy matching against one
+// answer or a list of acceptable answers. Complexity questions compare the
+// parsed complexity instead when both sides have one, so O(nlogn) matches
+// O(n * log(n)). Questions with "matching": "semantic" are also matched by
+// meaning when an assessment service is configured (see MatchText).
+type textGrader struct {
+	assessor *AssessmentService
+}
+
+func (textGrader) Format() string { return "text" }
+
+func (textGrader) ValidateQuestion(question *models.Question) error {
+	switch matching := question.CorrectAnswer["matching"]; matching {
+	case nil, TextMatchingFuzzy, TextMatchingSemantic:
+	default:
+		return fmt.Errorf("unknown matching %v", matching)
+	}
+	if threshold, ok := question.CorrectAnswer["semantic_threshold"]; ok {
+		if t, isNumber := threshold.(float64); !isNumber || t <= 0 || t > 1 {
+			return errors.New(`"semantic_threshold" must be a number in (0, 1]`)
+		}
+	}
+
+	switch v := question.CorrectAnswer["answer"].(type) {
+	case string:
+		if strings.TrimSpace(v) != "" {
+			return nil
+		}
+	case []interface{}:
+		for _, answer := range v {
+			if s, ok := answer.(string); !ok || strings.TrimSpace(s) == "" {
+				return errors.New(`correct_answer "answer" must list non-empty strings`)
+			}
+		}
+		if len(v) > 0 {
+			return nil
+		}
+	}
+	return errors.New(`correct_answer needs an "answer" or a list of acceptable answers`)
+}
+
+func (textGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if _, ok := answer["answer"].(string); !ok {
+		return errors.New(`"answer" must be a string`)
+	}
+	return nil
+}
+
+func (g textGrader) Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error) {
+	userText := answer["answer"].(string)
+	validator := NewTextValidator()
+
+	var acceptableAnswers []string
+	switch v := question.CorrectAnswer["answer"].(type) {
+	case string:
+		acceptableAnswers = []string{v}
+	case []interface{}:
+		acceptableAnswers = make([]string, 0, len(v))
+		for _, ans := range v {
+			if ansStr, ok := ans.(string); ok {
+				acceptableAnswers = append(acceptableAnswers, ansStr)
+			}
+		}
+	}
+
+	if isComplexityQuestion(question) {
+		if result, ok := gradeComplexity(userText, acceptableAnswers); ok {
+			return result, nil
+		}
+	}
+	if question.CorrectAnswer["matching"] == TextMatchingSemantic && g.assessor != nil {
+		threshold, _ := question.CorrectAnswer["semantic_threshold"].(float64)
+		match := g.assessor.MatchText(userText, acceptableAnswers, threshold)
+		return &GradeResult{Score: binaryScore(match.Matched), Feedback: match.Reason, Details: match}, nil
+	}
+	correct := validator.MatchMultiple(userText, acceptableAnswers)
+	return &GradeResult{Score: binaryScore(correct)}, nil
+}
+
+// ComplexityMatch compares the complexity an answer gives with the correct
+// one
+type ComplexityMatch struct {
+	Given    string            `json:"given"`
+	Expected string            `json:"expected"`
+	Verdict  ComplexityVerdict `json:"verdict"`
+}
+
+// isComplexityQuestion reports whether a question asks for a time or space
+// complexity
+func isComplexityQuestion(question *models.Question) bool {
+	if question.QuestionType == "complexity_analysis" {
+		return true
+	}
+	return question.QuestionSubtype != nil && strings.Contains(*question.QuestionSubtype, "complexity")
+}
+
+// gradeComplexity compares the complexity in an answer with those in the
+// acceptable answers, telling a loose upper bound apart from a wrong one.
+// It reports false when either side has no complexity it can parse, so the
+// answer is matched as text instead.
+func gradeComplexity(userText string, acceptableAnswers []string) (*GradeResult, bool) {
+	given, err := ExtractBigO(userText)
+	if err != nil {
+		return nil, false
+	}
+
+	var match *ComplexityMatch
+	for _, acceptable := range acceptableAnswers {
+		expected, err := ExtractBigO(acceptable)
+		if err != nil {
+			continue
+		}
+		verdict := CompareComplexity(given, expected)
+		if match == nil || verdictRank(verdict) > verdictRank(match.Verdict) {
+			match = &ComplexityMatch{Given: given.String(), Expected: expected.String(), Verdict: verdict}
+		}
+	}
+	if match == nil {
+		return nil, false
+	}
+
+	result := &GradeResult{Score: binaryScore(match.Verdict == ComplexityTight), Details: match}
+	switch match.Verdict {
+	case ComplexityNotTight:
+		result.Feedback = "Correct but not tight"
+		result.Mistakes = []string{fmt.Sprintf("%s is an upper bound but not tight", match.Given)}
+	case ComplexityWrong:
+		result.Mistakes = []string{fmt.Sprintf("%s is not an upper bound", match.Given)}
+	}
+	return result, true
+}
+
+// verdictRank orders complexity verdicts from worst to best
+func verdictRank(verdict ComplexityVerdict) int {
+	switch verdict {
+	case ComplexityTight:
+		return 2
+	case ComplexityNotTight:
+		return 1
+	}
+	return 0
+}
+
+// rankingGrader gives credit for a ranking that is partly in order. By
+// default the score is the share of item pairs in the right relative order
+// (the normalized Kendall tau distance subtracted from 1); with "scoring":
+// "positional" it is the share of items in the right place. Items missing
+// from the answer count against it, and extra or repeated items scale the
+// score down, so only the exact ranking scores 1.
+type rankingGrader struct{}
+
+func (rankingGrader) Format() string { return "ranking" }
+
+func (rankingGrader) ValidateQuestion(question *models.Question) error {
+	ranking, _ := question.CorrectAnswer["ranking"].([]interface{})
+	if len(ranking) == 0 {
+		return errors.New(`correct_answer "ranking" is empty`)
+	}
+	seen := make(map[string]bool, len(ranking))
+	for _, item := range ranking {
+		id := fmt.Sprint(item)
+		if seen[id] {
+			return fmt.Errorf("%s is ranked twice", id)
+		}
+		seen[id] = true
+	}
+	if method, ok := question.CorrectAnswer["scoring"]; ok &&
+		method != RankingScoringKendall && method != RankingScoringPositional {
+		return fmt.Errorf("unknown ranking scoring %v", method)
+	}
+	return nil
+}
+
+func (rankingGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if _, ok := answer["ranking"].([]interface{}); !ok {
+		return errors.New(`"ranking" must list the ranked items`)
+	}
+	return nil
+}
+
+func (rankingGrader) Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error) {
+	userRanking := answer["ranking"].([]interface{})
+	correctRanking, _ := question.CorrectAnswer["ranking"].([]interface{})
+	if len(correctRanking) == 0 {
+		return nil, fmt.Errorf("%w: no correct ranking", ErrInvalidQuestion)
+	}
+
+	given := make([]string, len(userRanking))
+	for i, item := range userRanking {
+		given[i] = fmt.Sprint(item)
+	}
+	correct := make([]string, len(correctRanking))
+	for i, item := range correctRanking {
+		correct[i] = fmt.Sprint(item)
+	}
+
+	result := &GradeResult{}
+	position := make(map[string]int, len(given))
+	for i, item := range given {
+		if _, seen := position[item]; !seen {
+			position[item] = i
+		}
+	}
+	inRanking := make(map[string]bool, len(correct))
+	for _, item := range correct {
+		inRanking[item] = true
+		if _, ok := position[item]; !ok {
+			result.Mistakes = append(result.Mistakes, fmt.Sprintf("%s is missing", item))
+		}
+	}
+	for _, item := range given {
+		if !inRanking[item] {
+			inRanking[item] = true
+			result.Mistakes = append(result.Mistakes, fmt.Sprintf("%s does not belong in the ranking", item))
+		}
+	}
+
+	if method, _ := question.CorrectAnswer["scoring"].(string); method == RankingScoringPositional {
+		result.Score = positionalScore(given, correct)
+		for i, item := range correct {
+			if _, ok := position[item]; ok && (i >= len(given) || given[i] != item) {
+				result.Mistakes = append(result.Mistakes, fmt.Sprintf("%s should be at position %d", item, i+1))
+			}
+		}
+	} else {
+		result.Score = kendallScore(given, correct)
+		for i := 0; i < len(correct); i++ {
+			for j := i + 1; j < len(correct); j++ {
+				a, okA := position[correct[i]]
+				b, okB := position[correct[j]]
+				if okA && okB && a > b {
+					result.Mistakes = append(result.Mistakes, fmt.Sprintf("%s should come before %s", correct[i], correct[j]))
+				}
+			}
+		}
+	}
+
+	if len(given) > len(correct) {
+		result.Score *= float64(len(correct)) / float64(len(given))
+	}
+	return result, nil
+}
+
+// codeGrader grades code answers by running them against the question's
+// test cases. Questions without test cases only get a structural check.
+type codeGrader struct {
+	s *QuestionService
+}
+
+func (codeGrader) Format() string { return "code" }
+
+func (codeGrader) ExecutesCode(question *models.Question) bool { return true }
+
+// ValidateQuestion requires test cases: code is only known to be correct
+// once it has been run
+func (g codeGrader) ValidateQuestion(question *models.Question) error {
+	testCases, ok := question.CorrectAnswer["test_cases"]
+	if !ok {
+		return errors.New(`correct_answer needs "test_cases"`)
+	}
+	testCaseList, ok := testCases.([]interface{})
+	if !ok {
+		return errors.New(`correct_answer "test_cases" must be a list`)
+	}
+	if len(testCaseList) == 0 {
+		return errors.New(`correct_answer "test_cases" is empty`)
+	}
+	return g.s.validateTestSuite(question, testCaseList)
+}
+
+func (g codeGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if _, ok := answer["code"].(string); !ok {
+		return errors.New(`"code" must be a string`)
+	}
+	return g.s.validateLanguage(answer)
+}
+
+func (g codeGrader) Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error) {
+	code := answer["code"].(string)
+	if strings.TrimSpace(code) == "" {
+		return &GradeResult{Mistakes: []string{"no code was submitted"}}, nil
+	}
+	language, _ := answer["language"].(string)
+	if language == "" {
+		language = "python" // default to Python
+	}
+
+	// Without test cases nothing shows the code is correct
+	testCases, ok := question.CorrectAnswer["test_cases"].([]interface{})
+	if !ok || len(testCases) == 0 {
+		return nil, fmt.Errorf("%w: no test cases", ErrInvalidQuestion)
+	}
+
+	suite, err := g.s.codeTestSuite(question, testCases)
+	if err != nil {
+		return nil, err
+	}
+	// Never fall back to heuristics if the code cannot be run: code that
+	// could not be run is not known to be correct
+	result, err := g.s.executor.RunTests(code, language, suite)
+	if err != nil {
+		return nil, err
+	}
+	return &GradeResult{
+		Score:    binaryScore(result.AllPassed),
+		Feedback: fmt.Sprintf("Passed %d of %d tests", result.PassedCount, result.TotalCount),
+		Mistakes: testMistakes(result),
+		Details:  result,
+	}, nil
+}
+
+// validateTestSuite checks that a question's test cases and checkers parse
+func (s *QuestionService) validateTestSuite(question *models.Question, testCases []interface{}) error {
+	suite, err := s.codeTestSuite(question, testCases)
+	if err != nil {
+		return err
+	}
+	_, err = s.executor.parseTestCases(suite)
+	return err
+}
+
+// validateLanguage checks that an answer's "language", if given, is known
+func (s *QuestionService) validateLanguage(answer map[string]interface{}) error {
+	language, ok := answer["language"]
+	if !ok {
+		return nil
+	}
+	name, ok := language.(string)
+	if !ok {
+		return errors.New(`"language" must be a string`)
+	}
+	if _, ok := s.executor.languages.Lookup(name); name != "" && !ok {
+		return fmt.Errorf("unsupported language: %s", name)
+	}
+	return nil
+}
+
+// fillBlankGrader grades each blank of a fill-in-the-blank answer on its
+// own, in order. correct_answer lists the blanks, each an answer, a list of
+// acceptable answers or {"answers": [...], "exact": true}:
+//
+//	{"blanks": ["mid + 1", ["n", "len(nums)"], {"answers": ["<="], "exact": true}]}
+//
+// The user answer lists what was written in each blank. Every blank must be
+// right; Details holds whether each one is.
+type fillBlankGrader struct{}
+
+func (fillBlankGrader) Format() string { return "fill_blank" }
+
+func (fillBlankGrader) ValidateQuestion(question *models.Question) error {
+	blanks, _ := question.CorrectAnswer["blanks"].([]interface{})
+	if len(blanks) == 0 {
+		return errors.New(`correct_answer "blanks" is empty`)
+	}
+	for i, blank := range blanks {
+		if answers, _ := blankAnswers(blank); len(answers) == 0 {
+			return fmt.Errorf("blank %d has no answers", i+1)
+		}
+	}
+	return nil
+}
+
+func (fillBlankGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if _, ok := answer["blanks"].([]interface{}); !ok {
+		return errors.New(`"blanks" must list the answer to each blank`)
+	}
+	return nil
+}
+
+func (fillBlankGrader) Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error) {
+	blanks, _ := question.CorrectAnswer["blanks"].([]interface{})
+	if len(blanks) == 0 {
+		return nil, fmt.Errorf("%w: no blanks", ErrInvalidQuestion)
+	}
+	given := answer["blanks"].([]interface{})
+
+	validator := NewTextValidator()
+	results := make([]bool, len(blanks))
+	result := &GradeResult{Details: results}
+	right := 0
+	for i, blank := range blanks {
+		text := ""
+		if i < len(given) && given[i] != nil {
+			if s, ok := given[i].(string); ok {
+				text = s
+			} else {
+				text = fmt.Sprint(given[i])
+			}
+		}
+		if strings.TrimSpace(text) == "" {
+			result.Mistakes = append(result.Mistakes, fmt.Sprintf("blank %d is empty", i+1))
+			continue
+		}
+
+		answers, exact := blankAnswers(blank)
+		for _, answer := range answers {
+			if validator.MatchBlank(text, answer, exact) {
+				results[i] = true
+				break
+			}
+		}
+		if results[i] {
+			right++
+		} else {
+			result.Mistakes = append(result.Mistakes, fmt.Sprintf("blank %d is wrong", i+1))
+		}
+	}
+
+	result.Score = binaryScore(right == len(blanks))
+	result.Feedback = fmt.Sprintf("%d of %d blanks correct", right, len(blanks))
+	return result, nil
+}
+
+// blankAnswers returns the acceptable answers for one blank and whether
+// they must match exactly
+func blankAnswers(blank interface{}) ([]string, bool) {
+	exact := false
+	if spec, ok := blank.(map[string]interface{}); ok {
+		exact, _ = spec["exact"].(bool)
+		blank = spec["answers"]
+	}
+
+	switch v := blank.(type) {
+	case string:
+		return []string{v}, exact
+	case []interface{}:
+		answers := make([]string, 0, len(v))
+		for _, answer := range v {
+			if answer != nil {
+				answers = append(answers, fmt.Sprint(answer))
+			}
+		}
+		return answers, exact
+	case nil:
+		return nil, exact
+	default:
+		return []string{fmt.Sprint(v)}, exact
+	}
+}
+
+// debugGrader grades find-the-bug questions. The user names the buggy line
+// and gives a fix, either the corrected line or the whole corrected
+// program:
+//
+//	{"bug_line": 4, "fix": "lo = mid + 1"}
+//	{"bug_line": 4, "code": "...", "language": "python"}
+//
+// The line must match correct_answer's bug_line. If the question has test
+// cases, the buggy code from question_data with the fix applied must pass
+// them, so any working fix is accepted; otherwise the fix must match one of
+// correct_answer's fixes.
+type debugGrader struct {
+	s *QuestionService
+}
+
+func (debugGrader) Format() string { return "debug" }
+
+func (debugGrader) ExecutesCode(question *models.Question) bool {
+	testCases, _ := question.CorrectAnswer["test_cases"].([]interface{})
+	return len(testCases) > 0
+}
+
+func (g debugGrader) ValidateQuestion(question *models.Question) error {
+	buggyCode, _ := question.QuestionData["code"].(string)
+	if strings.TrimSpace(buggyCode) == "" {
+		return errors.New(`question_data "code" is empty`)
+	}
+	bugLine, ok := lineNumber(question.CorrectAnswer["bug_line"])
+	if !ok {
+		return errors.New(`correct_answer "bug_line" must be a line number`)
+	}
+	if lineAt(buggyCode, bugLine) == "" {
+		return fmt.Errorf("line %d of the code is empty", bugLine)
+	}
+
+	if testCases, ok := question.CorrectAnswer["test_cases"].([]interface{}); ok && len(testCases) > 0 {
+		return g.s.validateTestSuite(question, testCases)
+	}
+	if fixes, _ := blankAnswers(question.CorrectAnswer["fix"]); len(fixes) == 0 {
+		return errors.New(`correct_answer needs test cases or a "fix"`)
+	}
+	return nil
+}
+
+func (g debugGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if _, ok := lineNumber(answer["bug_line"]); !ok {
+		return errors.New(`"bug_line" must be a line number`)
+	}
+	fix, fixOK := answer["fix"].(string)
+	code, codeOK := answer["code"].(string)
+	if !fixOK && !codeOK {
+		return errors.New(`give the corrected line as "fix" or the corrected program as "code"`)
+	}
+	if strings.TrimSpace(fix) == "" && strings.TrimSpace(code) == "" {
+		return errors.New("the fix is empty")
+	}
+	return g.s.validateLanguage(answer)
+}
+
+func (g debugGrader) Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error) {
+	bugLine, ok := lineNumber(question.CorrectAnswer["bug_line"])
+	if !ok {
+		return nil, fmt.Errorf("%w: no bug line", ErrInvalidQuestion)
+	}
+	line, _ := lineNumber(answer["bug_line"])
+	if line != bugLine {
+		return &GradeResult{Mistakes: []string{fmt.Sprintf("the bug is not on line %d", line)}}, nil
+	}
+
+	fix, _ := answer["fix"].(string)
+	fixedCode, _ := answer["code"].(string)
+	buggyCode, _ := question.QuestionData["code"].(string)
+	if strings.TrimSpace(fixedCode) == "" {
+		fixedCode = applyLineFix(buggyCode, line, fix)
+	}
+	if strings.TrimSpace(fix) == "" {
+		fix = lineAt(fixedCode, line)
+	}
+
+	testCases, ok := question.CorrectAnswer["test_cases"].([]interface{})
+	if !ok || len(testCases) == 0 {
+		answers, _ := blankAnswers(question.CorrectAnswer["fix"])
+		validator := NewTextValidator()
+		for _, answer := range answers {
+			if validator.MatchBlank(fix, answer, true) {
+				return &GradeResult{Score: 1}, nil
+			}
+		}
+		return &GradeResult{Mistakes: []string{"the fix does not correct the bug"}}, nil
+	}
+
+	language, _ := answer["language"].(string)
+	if language == "" {
+		language, _ = question.QuestionData["language"].(string)
+	}
+	if language == "" {
+		language = "python" // default to Python
+	}
+
+	suite, err := g.s.codeTestSuite(question, testCases)
+	if err != nil {
+		return nil, err
+	}
+	result, err := g.s.executor.RunTests(fixedCode, language, suite)
+	if err != nil {
+		return nil, err
+	}
+	return &GradeResult{
+		Score:    binaryScore(result.AllPassed),
+		Feedback: fmt.Sprintf("The fixed code passed %d of %d tests", result.PassedCount, result.TotalCount),
+		Mistakes: testMistakes(result),
+		Details:  result,
+	}, nil
+}
+
+// lineNumber reads a 1-based line number given as a JSON number or string
+func lineNumber(v interface{}) (int, bool) {
+	var n int
+	switch v := v.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return 0, false
+		}
+		n = int(v)
+	case int:
+		n = v
+	case string:
+		if _, err := fmt.Sscan(strings.TrimSpace(v), &n); err != nil {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+	return n, n > 0
+}
+
+// applyLineFix replaces a 1-based line of code with a fix, keeping the
+// line's indentation when the fix has none
+func applyLineFix(code string, line int, fix string) string {
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	if line > len(lines) {
+		return code
+	}
+	old := lines[line-1]
+	indent := old[:len(old)-len(strings.TrimLeft(old, " \t"))]
+	if strings.TrimLeft(fix, " \t") == fix {
+		fix = indent + fix
+	}
+	lines[line-1] = strings.TrimRight(fix, "\r\n")
+	return strings.Join(lines, "\n")
+}
+
+// lineAt returns a 1-based line of code, or "" if there is none
+func lineAt(code string, line int) string {
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	if line > len(lines) {
+		return ""
+	}
+	return lines[line-1]
+}
+
+// openEndedGrader grades open-ended answers against the rubric in
+// correct_answer (see ParseRubric), with the assessor's model when there is
+// one. An answer that passes the rubric scores 1; Details holds the rubric
+// result.
+type openEndedGrader struct {
+	assessor *AssessmentService
+}
+
+func (openEndedGrader) Format() string { return "open_ended" }
+
+func (openEndedGrader) ValidateQuestion(question *models.Question) error {
+	_, err := ParseRubric(question.CorrectAnswer)
+	return err
+}
+
+func (openEndedGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if _, ok := answer["answer"].(string); !ok {
+		return errors.New(`"answer" must be a string`)
+	}
+	return nil
+}
+
+func (g openEndedGrader) Grade(question *models.Question, answer map[string]interface{}) (*GradeResult, error) {
+	rubric, err := ParseRubric(question.CorrectAnswer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuestion, err)
+	}
+	var rubricResult *RubricResult
+	if g.assessor != nil {
+		rubricResult = g.assessor.Grade(question.QuestionText, answer["answer"].(string), rubric)
+	} else {
+		rubricResult = NewTextValidator().GradeRubric(answer["answer"].(string), rubric)
+	}
+
+	result := &GradeResult{Score: binaryScore(rubricResult.Passed), Details: rubricResult}
+	met := 0
+	for _, criterion := range rubricResult.Criteria {
+		if criterion.Met {
+			met++
+		} else {
+			result.Mistakes = append(result.Mistakes, "missing: "+criterion.Criterion)
+		}
+	}
+	result.Feedback = rubricResult.Feedback
+	if result.Feedback == "" {
+		result.Feedback = fmt.Sprintf("Covered %d of %d rubric criteria", met, len(rubricResult.Criteria))
+	}
+	return result, nil
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+// Base types supported in function signatures
+const (
+	harnessInt      = "int"
+	harnessLong     = "long"
+	harnessDouble   = "double"
+	harnessBool     = "bool"
+	harnessString   = "string"
+	harnessListNode = "ListNode"
+	harnessTreeNode = "TreeNode"
+)
+
+// harnessTypeAliases maps accepted spellings to base type names
+var harnessTypeAliases = map[string]string{
+	"int":      harnessInt,
+	"integer":  harnessInt,
+	"long":     harnessLong,
+	"double":   harnessDouble,
+	"float":    harnessDouble,
+	"bool":     harnessBool,
+	"boolean":  harnessBool,
+	"string":   harnessString,
+	"str":      harnessString,
+	"listnode": harnessListNode,
+	"treenode": harnessTreeNode,
+}
+
+// FunctionSignature describes a LeetCode-style function that users implement
+// instead of a full stdin/stdout program, e.g.
+//
+//	{"function": "twoSum",
+//	 "params": [{"name": "nums", "type": "int[]"}, {"name": "target", "type": "int"}],
+//	 "return": "int[]"}
+//
+// Types are int, long, double, bool and string with up to two [] suffixes,
+// ListNode, ListNode[] and TreeNode.
+type FunctionSignature struct {
+	Function string          `json:"function"`
+	Params   []FunctionParam `json:"params"`
+	Return   string          `json:"return"`
+
+	returnType valueType
+}
+
+// FunctionParam is one named, typed parameter of a FunctionSignature
+type FunctionParam struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	typ valueType
+}
+
+// valueType is a parsed signature type: a base type with array dimensions
+type valueType struct {
+	base string
+	dims int
+}
+
+var harnessIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseFunctionSignature reads and validates a signature stored as JSON
+func ParseFunctionSignature(raw interface{}) (*FunctionSignature, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid function signature: %w", err)
+	}
+
+	var sig FunctionSignature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("invalid function signature: %w", err)
+	}
+
+	if !harnessIdentifier.MatchString(sig.Function) {
+		return nil, fmt.Errorf("invalid function name: %q", sig.Function)
+	}
+	if sig.returnType, err = parseValueType(sig.Return); err != nil {
+		return nil, fmt.Errorf("return type: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range sig.Params {
+		param := &sig.Params[i]
+		if !harnessIdentifier.MatchString(param.Name) {
+			return nil, fmt.Errorf("invalid parameter name: %q", param.Name)
+		}
+		if seen[param.Name] {
+			return nil, fmt.Errorf("duplicate parameter name: %s", param.Name)
+		}
+		seen[param.Name] = true
+
+		if param.typ, err = parseValueType(param.Type); err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", param.Name, err)
+		}
+	}
+
+	return &sig, nil
+}
+
+// problemSignature parses a problem's function signature. Problems without
+// one are graded as full stdin/stdout programs and yield nil.
+func problemSignature(problem *models.Problem) (*FunctionSignature, error) {
+	if len(problem.FunctionSignature) == 0 {
+		return nil, nil
+	}
+	sig, err := ParseFunctionSignature(map[string]interface{}(problem.FunctionSignature))
+	if err != nil {
+		return nil, fmt.Errorf("problem %d: %w", problem.ProblemID, err)
+	}
+	return sig, nil
+}
+
+// parseValueType parses a type such as "int[][]" or "ListNode"
+func parseValueType(s string) (valueType, error) {
+	name := strings.TrimSpace(s)
+	dims := 0
+	for strings.HasSuffix(name, "[]") {
+		name = strings.TrimSpace(strings.TrimSuffix(name, "[]"))
+		dims++
+	}
+
+	base, ok := harnessTypeAliases[strings.ToLower(name)]
+	if !ok {
+		return valueType{}, fmt.Errorf("unsupported type: %q", s)
+	}
+
+	switch {
+	case dims > 2:
+		return valueType{}, fmt.Errorf("unsupported type: %q (at most two dimensions)", s)
+	case base == harnessListNode && dims > 1, base == harnessTreeNode && dims > 0:
+		return valueType{}, fmt.Errorf("unsupported type: %q", s)
+	}
+
+	return valueType{base: base, dims: dims}, nil
+}
+
+// harnessLanguage generates starter code and drivers for one language
+type harnessLanguage interface {
+	// starter returns the function stub shown to the user
+	starter(sig *FunctionSignature) string
+	// driver wraps the user's code into a complete program that reads the
+	// encoded arguments from stdin and prints harnessSentinelLine followed
+	// by the result as JSON
+	driver(sig *FunctionSignature, code string) string
+}
+
+// HarnessSentinel marks where a driver's result starts in stdout. The user's
+// code shares stdout with the driver, so anything it prints comes before
+// the sentinel and is never taken for the result.
+const HarnessSentinel = "--algoholic-result-5f0c2e--"
+
+// harnessSentinelLine is printed before the result. The leading newline
+// keeps the sentinel on a line of its own when the user's output does not
+// end with one.
+const harnessSentinelLine = "\n" + HarnessSentinel + "\n"
+
+// ExtractResult reduces the stdout of a wrapped program to the result line
+// its driver printed, dropping whatever the user's code printed. Without a
+// sentinel the function never returned, so there is no result.
+func (sig *FunctionSignature) ExtractResult(output *ExecutionOutput) {
+	i := strings.LastIndex(output.Stdout, HarnessSentinel+"\n")
+	if i < 0 {
+		output.Stdout = ""
+		return
+	}
+	result, _, _ := strings.Cut(output.Stdout[i+len(HarnessSentinel)+1:], "\n")
+	output.Stdout = result + "\n"
+}
+
+var harnessLanguages = map[string]harnessLanguage{
+	"python":     pythonHarness{},
+	"javascript": javascriptHarness{},
+	"typescript": typescriptHarness{},
+	"java":       javaHarness{},
+	"kotlin":     kotlinHarness{},
+	"cpp":        cppHarness{},
+	"c":          cHarness{},
+	"go":         goHarness{},
+	"rust":       rustHarness{},
+	"ruby":       rubyHarness{},
+	"php":        phpHarness{},
+	"swift":      swiftHarness{},
+}
+
+// StarterCode returns the function stub users fill in for a language
+func (sig *FunctionSignature) StarterCode(language string) (string, error) {
+	lang, ok := harnessLanguages[canonicalLanguage(language)]
+	if !ok {
+		return "", fmt.Errorf("function harness not available for %s", language)
+	}
+	return lang.starter(sig), nil
+}
+
+// WrapCode turns the user's function into a complete program for a language
+func (sig *FunctionSignature) WrapCode(language, code string) (string, error) {
+	lang, ok := harnessLanguages[canonicalLanguage(language)]
+	if !ok {
+		return "", fmt.Errorf("function harness not available for %s", language)
+	}
+	return lang.driver(sig, code), nil
+}
+
+// EncodeInput converts a test input of JSON-encoded arguments, one per
+// parameter in order (e.g. "[2,7,11,15]\n9"), into the token stream the
+// generated drivers read.
+//
+// Numbers and booleans are single tokens, strings are their byte length
+// followed by one space and the raw bytes, arrays are their length followed
+// by their elements, and trees are their level-order length followed by
+// values or "null".
+func (sig *FunctionSignature) EncodeInput(input string) (string, error) {
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+
+	var buf bytes.Buffer
+	for _, param := range sig.Params {
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return "", fmt.Errorf("argument %s: %w", param.Name, err)
+		}
+		if err := encodeHarnessValue(&buf, param.typ, value); err != nil {
+			return "", fmt.Errorf("argument %s: %w", param.Name, err)
+		}
+		buf.WriteByte('\n')
+	}
+
+	return buf.String(), nil
+}
+
+// encodeHarnessValue writes one value of type t as driver tokens
+func encodeHarnessValue(buf *bytes.Buffer, t valueType, value interface{}) error {
+	if t.dims > 0 || t.base == harnessListNode || t.base == harnessTreeNode {
+		items, ok := value.([]interface{})
+		if !ok && value != nil {
+			return fmt.Errorf("expected an array, got %v", value)
+		}
+		fmt.Fprintf(buf, "%d ", len(items))
+
+		elem := valueType{base: t.base, dims: t.dims - 1}
+		if t.dims == 0 {
+			// A list or tree: its node values
+			elem = valueType{base: harnessInt}
+		}
+		for _, item := range items {
+			if item == nil && t.base == harnessTreeNode && t.dims == 0 {
+				buf.WriteString("null ")
+				continue
+			}
+			if err := encodeHarnessValue(buf, elem, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch t.base {
+	case harnessInt, harnessLong:
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected an integer, got %v", value)
+		}
+		n, err := strconv.ParseInt(number.String(), 10, 64)
+		if err != nil || (t.base == harnessInt && (n < math.MinInt32 || n > math.MaxInt32)) {
+			return fmt.Errorf("expected a %s, got %s", t.base, number)
+		}
+		fmt.Fprintf(buf, "%d ", n)
+	case harnessDouble:
+		number, ok := value.(json.Number)
+		if !ok {
+			return fmt.Errorf("expected a number, got %v", value)
+		}
+		buf.WriteString(number.String() + " ")
+	case harnessBool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected a boolean, got %v", value)
+		}
+		if b {
+			buf.WriteString("1 ")
+		} else {
+			buf.WriteString("0 ")
+		}
+	case harnessString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a string, got %v", value)
+		}
+		fmt.Fprintf(buf, "%d %s ", len(s), s)
+	}
+	return nil
+}
+
+// snakeCase converts camelCase identifiers for languages that use snake_case
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// hoistImports splits import lines out of user code for languages where
+// imports must precede every declaration. Lines matching drop are removed.
+func hoistImports(code string, drop *regexp.Regexp) (imports []string, rest string) {
+	var body []string
+	lines := strings.Split(code, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case drop != nil && drop.MatchString(trimmed):
+			continue
+		case trimmed == "import (":
+			// Go import block
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != ")"; i++ {
+				if spec := strings.TrimSpace(lines[i]); spec != "" {
+					imports = append(imports, "import "+spec)
+				}
+			}
+		case strings.HasPrefix(trimmed, "import "):
+			imports = append(imports, strings.TrimSuffix(trimmed, ";")+";")
+		default:
+			body = append(body, line)
+		}
+	}
+	return imports, strings.Join(body, "\n")
+}
+
+// mergeImports appends user imports to the harness imports without duplicates
+func mergeImports(harness, user []string, format func(string) string) string {
+	seen := make(map[string]bool)
+	var lines []string
+	for _, spec := range append(append([]string{}, harness...), user...) {
+		spec = format(spec)
+		if !seen[spec] {
+			seen[spec] = true
+			lines = append(lines, spec)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// paramNames lists the parameter names of a signature, optionally converted
+func (sig *FunctionSignature) paramNames(convert func(string) string) []string {
+	names := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		names[i] = param.Name
+		if convert != nil {
+			names[i] = convert(param.Name)
+		}
+	}
+	return names
+}
+
+// argNames lists the driver's argument variables: arg0, arg1, ...
+func (sig *FunctionSignature) argNames() []string {
+	names := make([]string, len(sig.Params))
+	for i := range sig.Params {
+		names[i] = fmt.Sprintf("arg%d", i)
+	}
+	return names
+}
+
+// typeName renders a type in a language given its base type names and a
+// function wrapping one array dimension
+func (t valueType) typeName(bases map[string]string, array func(string) string) string {
+	name := bases[t.base]
+	for i := 0; i < t.dims; i++ {
+		name = array(name)
+	}
+	return name
+}
+
+// harnessSuffix names the per-type reader/writer helpers in static languages
+func (t valueType) harnessSuffix() string {
+	suffix := map[string]string{
+		harnessInt:      "Int",
+		harnessLong:     "Long",
+		harnessDouble:   "Double",
+		harnessBool:     "Bool",
+		harnessString:   "String",
+		harnessListNode: "List",
+		harnessTreeNode: "Tree",
+	}[t.base]
+	switch t.dims {
+	case 1:
+		suffix += "Array"
+	case 2:
+		suffix += "Matrix"
+	}
+	return suffix
+}
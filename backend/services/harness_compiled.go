@@ -0,0 +1,1728 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Function harnesses for statically typed languages. Readers and writers are
+// generated per type and named after valueType.harnessSuffix, e.g.
+// readIntArray or harness_write_string_matrix.
+
+// harnessArrayBases are the base types that get array and matrix helpers
+var harnessArrayBases = []string{harnessInt, harnessLong, harnessDouble, harnessBool, harnessString, harnessListNode}
+
+// expandHarnessHelpers instantiates tmpl once per array base type, replacing
+// {S} with the base helper suffix and every other placeholder with its entry
+// for that base
+func expandHarnessHelpers(tmpl string, fields map[string]map[string]string) string {
+	var b strings.Builder
+	for _, base := range harnessArrayBases {
+		pairs := []string{"{S}", valueType{base: base}.harnessSuffix()}
+		for placeholder, values := range fields {
+			pairs = append(pairs, placeholder, values[base])
+		}
+		strings.NewReplacer(pairs...).WriteString(&b, tmpl)
+	}
+	return b.String()
+}
+
+// packageDeclaration matches package clauses, which the drivers supply
+var packageDeclaration = regexp.MustCompile(`^package\s`)
+
+// javaHarness targets LeetCode's "class Solution" style inside a Main class
+type javaHarness struct{}
+
+var javaTypes = map[string]string{
+	harnessInt:      "int",
+	harnessLong:     "long",
+	harnessDouble:   "double",
+	harnessBool:     "boolean",
+	harnessString:   "String",
+	harnessListNode: "ListNode",
+	harnessTreeNode: "TreeNode",
+}
+
+var javaImports = []string{
+	"import java.util.*;",
+	"import java.io.*;",
+	"import java.nio.charset.StandardCharsets;",
+}
+
+// javaPublicSolution matches a public Solution class, which cannot share a
+// file with the public Main class
+var javaPublicSolution = regexp.MustCompile(`(?m)^(\s*)public\s+class\s+Solution\b`)
+
+const javaNodes = `
+
+class ListNode {
+    int val;
+    ListNode next;
+    ListNode() {}
+    ListNode(int val) { this.val = val; }
+    ListNode(int val, ListNode next) { this.val = val; this.next = next; }
+}
+
+class TreeNode {
+    int val;
+    TreeNode left;
+    TreeNode right;
+    TreeNode() {}
+    TreeNode(int val) { this.val = val; }
+    TreeNode(int val, TreeNode left, TreeNode right) {
+        this.val = val;
+        this.left = left;
+        this.right = right;
+    }
+}
+
+`
+
+const javaRuntime = `
+
+public class Main {
+    static byte[] data;
+    static int pos;
+
+    static String token() {
+        while (pos < data.length && (data[pos] & 0xff) <= 32) pos++;
+        int start = pos;
+        while (pos < data.length && (data[pos] & 0xff) > 32) pos++;
+        return new String(data, start, pos - start, StandardCharsets.UTF_8);
+    }
+
+    static int readInt() { return Integer.parseInt(token()); }
+    static long readLong() { return Long.parseLong(token()); }
+    static double readDouble() { return Double.parseDouble(token()); }
+    static boolean readBool() { return token().equals("1"); }
+
+    static String readString() {
+        int n = readInt();
+        int start = pos + 1;
+        pos = start + n;
+        return new String(data, start, n, StandardCharsets.UTF_8);
+    }
+
+    static ListNode readList() {
+        int[] values = readIntArray();
+        ListNode head = null;
+        for (int i = values.length - 1; i >= 0; i--) head = new ListNode(values[i], head);
+        return head;
+    }
+
+    static TreeNode readTree() {
+        String[] tokens = new String[readInt()];
+        for (int i = 0; i < tokens.length; i++) tokens[i] = token();
+        if (tokens.length == 0 || tokens[0].equals("null")) return null;
+        TreeNode root = new TreeNode(Integer.parseInt(tokens[0]));
+        ArrayDeque<TreeNode> queue = new ArrayDeque<>();
+        queue.add(root);
+        int i = 1;
+        while (!queue.isEmpty() && i < tokens.length) {
+            TreeNode node = queue.poll();
+            if (i < tokens.length && !tokens[i].equals("null")) {
+                node.left = new TreeNode(Integer.parseInt(tokens[i]));
+                queue.add(node.left);
+            }
+            i++;
+            if (i < tokens.length && !tokens[i].equals("null")) {
+                node.right = new TreeNode(Integer.parseInt(tokens[i]));
+                queue.add(node.right);
+            }
+            i++;
+        }
+        return root;
+    }
+
+    static void writeInt(StringBuilder sb, int v) { sb.append(v); }
+    static void writeLong(StringBuilder sb, long v) { sb.append(v); }
+    static void writeDouble(StringBuilder sb, double v) { sb.append(v); }
+    static void writeBool(StringBuilder sb, boolean v) { sb.append(v); }
+
+    static void writeString(StringBuilder sb, String v) {
+        sb.append('"');
+        for (char c : v.toCharArray()) {
+            if (c == '"' || c == '\\') sb.append('\\').append(c);
+            else if (c < 0x20) sb.append(String.format("\\u%04x", (int) c));
+            else sb.append(c);
+        }
+        sb.append('"');
+    }
+
+    static void writeList(StringBuilder sb, ListNode v) {
+        sb.append('[');
+        for (ListNode node = v; node != null; node = node.next) {
+            if (node != v) sb.append(',');
+            sb.append(node.val);
+        }
+        sb.append(']');
+    }
+
+    static void writeTree(StringBuilder sb, TreeNode v) {
+        List<String> values = new ArrayList<>();
+        LinkedList<TreeNode> queue = new LinkedList<>();
+        queue.add(v);
+        while (!queue.isEmpty()) {
+            TreeNode node = queue.poll();
+            if (node == null) {
+                values.add("null");
+                continue;
+            }
+            values.add(String.valueOf(node.val));
+            queue.add(node.left);
+            queue.add(node.right);
+        }
+        while (!values.isEmpty() && values.get(values.size() - 1).equals("null")) values.remove(values.size() - 1);
+        sb.append('[').append(String.join(",", values)).append(']');
+    }
+`
+
+const javaArrayHelpers = `
+    static {T}[] read{S}Array() {
+        {T}[] a = new {T}[readInt()];
+        for (int i = 0; i < a.length; i++) a[i] = read{S}();
+        return a;
+    }
+
+    static {T}[][] read{S}Matrix() {
+        {T}[][] a = new {T}[readInt()][];
+        for (int i = 0; i < a.length; i++) a[i] = read{S}Array();
+        return a;
+    }
+
+    static void write{S}Array(StringBuilder sb, {T}[] a) {
+        sb.append('[');
+        for (int i = 0; i < a.length; i++) {
+            if (i > 0) sb.append(',');
+            write{S}(sb, a[i]);
+        }
+        sb.append(']');
+    }
+
+    static void write{S}Matrix(StringBuilder sb, {T}[][] a) {
+        sb.append('[');
+        for (int i = 0; i < a.length; i++) {
+            if (i > 0) sb.append(',');
+            write{S}Array(sb, a[i]);
+        }
+        sb.append(']');
+    }
+`
+
+func (javaHarness) typeName(t valueType) string {
+	return t.typeName(javaTypes, func(s string) string { return s + "[]" })
+}
+
+func (h javaHarness) starter(sig *FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = h.typeName(param.typ) + " " + param.Name
+	}
+	return fmt.Sprintf("class Solution {\n    public %s %s(%s) {\n        \n    }\n}\n",
+		h.typeName(sig.returnType), sig.Function, strings.Join(params, ", "))
+}
+
+func (h javaHarness) driver(sig *FunctionSignature, code string) string {
+	imports, rest := hoistImports(code, packageDeclaration)
+
+	var b strings.Builder
+	b.WriteString(mergeImports(javaImports, imports, strings.TrimSpace))
+	b.WriteString(javaNodes)
+	b.WriteString(javaPublicSolution.ReplaceAllString(rest, "${1}class Solution"))
+	b.WriteString(javaRuntime)
+	b.WriteString(expandHarnessHelpers(javaArrayHelpers, map[string]map[string]string{"{T}": javaTypes}))
+
+	b.WriteString("\n    public static void main(String[] args) throws Exception {\n")
+	b.WriteString("        data = System.in.readAllBytes();\n")
+	for i, param := range sig.Params {
+		fmt.Fprintf(&b, "        %s arg%d = read%s();\n", h.typeName(param.typ), i, param.typ.harnessSuffix())
+	}
+	fmt.Fprintf(&b, "        %s result = new Solution().%s(%s);\n",
+		h.typeName(sig.returnType), sig.Function, strings.Join(sig.argNames(), ", "))
+	b.WriteString("        StringBuilder sb = new StringBuilder();\n")
+	fmt.Fprintf(&b, "        write%s(sb, result);\n", sig.returnType.harnessSuffix())
+	fmt.Fprintf(&b, "        System.out.println(%q + sb);\n    }\n}\n", harnessSentinelLine)
+	return b.String()
+}
+
+// kotlinHarness targets LeetCode's "class Solution" style with Kotlin's
+// primitive array types
+type kotlinHarness struct{}
+
+var kotlinTypes = map[string]string{
+	harnessInt:      "Int",
+	harnessLong:     "Long",
+	harnessDouble:   "Double",
+	harnessBool:     "Boolean",
+	harnessString:   "String",
+	harnessListNode: "ListNode?",
+	harnessTreeNode: "TreeNode?",
+}
+
+var kotlinArrayTypes = map[string]string{
+	harnessInt:      "IntArray",
+	harnessLong:     "LongArray",
+	harnessDouble:   "DoubleArray",
+	harnessBool:     "BooleanArray",
+	harnessString:   "Array<String>",
+	harnessListNode: "Array<ListNode?>",
+}
+
+var kotlinArrayConstructors = map[string]string{
+	harnessInt:      "IntArray",
+	harnessLong:     "LongArray",
+	harnessDouble:   "DoubleArray",
+	harnessBool:     "BooleanArray",
+	harnessString:   "Array",
+	harnessListNode: "Array",
+}
+
+// Kotlin escapes the "val" property and System.in with backticks, so these
+// parts are spelled out with interpreted strings
+const kotlinNodes = "\n\nclass ListNode(var `val`: Int) {\n    var next: ListNode? = null\n}\n\n" +
+	"class TreeNode(var `val`: Int) {\n    var left: TreeNode? = null\n    var right: TreeNode? = null\n}\n\n"
+
+const kotlinRuntime = "\n\nprivate val harnessData: ByteArray = System.`in`.readBytes()\n" + `private var harnessPos = 0
+
+fun harnessToken(): String {
+    while (harnessPos < harnessData.size && (harnessData[harnessPos].toInt() and 0xff) <= 32) harnessPos++
+    val start = harnessPos
+    while (harnessPos < harnessData.size && (harnessData[harnessPos].toInt() and 0xff) > 32) harnessPos++
+    return String(harnessData, start, harnessPos - start, Charsets.UTF_8)
+}
+
+fun harnessReadInt(): Int = harnessToken().toInt()
+fun harnessReadLong(): Long = harnessToken().toLong()
+fun harnessReadDouble(): Double = harnessToken().toDouble()
+fun harnessReadBool(): Boolean = harnessToken() == "1"
+
+fun harnessReadString(): String {
+    val n = harnessReadInt()
+    val start = harnessPos + 1
+    harnessPos = start + n
+    return String(harnessData, start, n, Charsets.UTF_8)
+}
+
+fun harnessReadList(): ListNode? {
+    val values = harnessReadIntArray()
+    var head: ListNode? = null
+    for (i in values.indices.reversed()) {
+        val node = ListNode(values[i])
+        node.next = head
+        head = node
+    }
+    return head
+}
+
+fun harnessReadTree(): TreeNode? {
+    val tokens = Array(harnessReadInt()) { harnessToken() }
+    if (tokens.isEmpty() || tokens[0] == "null") return null
+    val root = TreeNode(tokens[0].toInt())
+    val queue = java.util.ArrayDeque<TreeNode>()
+    queue.add(root)
+    var i = 1
+    while (queue.isNotEmpty() && i < tokens.size) {
+        val node = queue.poll()
+        if (i < tokens.size && tokens[i] != "null") {
+            val left = TreeNode(tokens[i].toInt())
+            node.left = left
+            queue.add(left)
+        }
+        i++
+        if (i < tokens.size && tokens[i] != "null") {
+            val right = TreeNode(tokens[i].toInt())
+            node.right = right
+            queue.add(right)
+        }
+        i++
+    }
+    return root
+}
+
+fun harnessWriteInt(sb: StringBuilder, v: Int) { sb.append(v) }
+fun harnessWriteLong(sb: StringBuilder, v: Long) { sb.append(v) }
+fun harnessWriteDouble(sb: StringBuilder, v: Double) { sb.append(v) }
+fun harnessWriteBool(sb: StringBuilder, v: Boolean) { sb.append(v) }
+
+fun harnessWriteString(sb: StringBuilder, v: String) {
+    sb.append('"')
+    for (c in v) {
+        when {
+            c == '"' || c == '\\' -> sb.append('\\').append(c)
+            c < ' ' -> sb.append(String.format("\\u%04x", c.toInt()))
+            else -> sb.append(c)
+        }
+    }
+    sb.append('"')
+}
+
+fun harnessWriteList(sb: StringBuilder, v: ListNode?) {
+    val values = ArrayList<Int>()
+    var node = v
+    while (node != null) {
+        values.add(node.` + "`val`" + `)
+        node = node.next
+    }
+    sb.append('[').append(values.joinToString(",")).append(']')
+}
+
+fun harnessWriteTree(sb: StringBuilder, v: TreeNode?) {
+    val values = ArrayList<String>()
+    val queue = java.util.LinkedList<TreeNode?>()
+    queue.add(v)
+    while (queue.isNotEmpty()) {
+        val node = queue.poll()
+        if (node == null) {
+            values.add("null")
+            continue
+        }
+        values.add(node.` + "`val`" + `.toString())
+        queue.add(node.left)
+        queue.add(node.right)
+    }
+    while (values.isNotEmpty() && values[values.size - 1] == "null") values.removeAt(values.size - 1)
+    sb.append('[').append(values.joinToString(",")).append(']')
+}
+`
+
+const kotlinArrayHelpers = `
+fun harnessRead{S}Array(): {A} = {C}(harnessReadInt()) { harnessRead{S}() }
+
+fun harnessRead{S}Matrix(): Array<{A}> = Array(harnessReadInt()) { harnessRead{S}Array() }
+
+fun harnessWrite{S}Array(sb: StringBuilder, a: {A}) {
+    sb.append('[')
+    for (i in a.indices) {
+        if (i > 0) sb.append(',')
+        harnessWrite{S}(sb, a[i])
+    }
+    sb.append(']')
+}
+
+fun harnessWrite{S}Matrix(sb: StringBuilder, a: Array<{A}>) {
+    sb.append('[')
+    for (i in a.indices) {
+        if (i > 0) sb.append(',')
+        harnessWrite{S}Array(sb, a[i])
+    }
+    sb.append(']')
+}
+`
+
+func (kotlinHarness) typeName(t valueType) string {
+	switch t.dims {
+	case 0:
+		return kotlinTypes[t.base]
+	case 1:
+		return kotlinArrayTypes[t.base]
+	default:
+		return "Array<" + kotlinArrayTypes[t.base] + ">"
+	}
+}
+
+func (h kotlinHarness) starter(sig *FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = param.Name + ": " + h.typeName(param.typ)
+	}
+	return fmt.Sprintf("class Solution {\n    fun %s(%s): %s {\n        \n    }\n}\n",
+		sig.Function, strings.Join(params, ", "), h.typeName(sig.returnType))
+}
+
+func (kotlinHarness) driver(sig *FunctionSignature, code string) string {
+	imports, rest := hoistImports(code, packageDeclaration)
+
+	var b strings.Builder
+	b.WriteString(mergeImports(nil, imports, strings.TrimSpace))
+	b.WriteString(kotlinNodes)
+	b.WriteString(rest)
+	b.WriteString(kotlinRuntime)
+	b.WriteString(expandHarnessHelpers(kotlinArrayHelpers, map[string]map[string]string{
+		"{A}": kotlinArrayTypes,
+		"{C}": kotlinArrayConstructors,
+	}))
+
+	b.WriteString("\nfun main() {\n")
+	for i, param := range sig.Params {
+		fmt.Fprintf(&b, "    val arg%d = harnessRead%s()\n", i, param.typ.harnessSuffix())
+	}
+	fmt.Fprintf(&b, "    val result = Solution().%s(%s)\n", sig.Function, strings.Join(sig.argNames(), ", "))
+	b.WriteString("    val sb = StringBuilder()\n")
+	fmt.Fprintf(&b, "    harnessWrite%s(sb, result)\n", sig.returnType.harnessSuffix())
+	fmt.Fprintf(&b, "    println(%q + sb)\n}\n", harnessSentinelLine)
+	return b.String()
+}
+
+// swiftHarness targets LeetCode's "class Solution" style with unlabeled
+// parameters
+type swiftHarness struct{}
+
+var swiftTypes = map[string]string{
+	harnessInt:      "Int",
+	harnessLong:     "Int",
+	harnessDouble:   "Double",
+	harnessBool:     "Bool",
+	harnessString:   "String",
+	harnessListNode: "ListNode?",
+	harnessTreeNode: "TreeNode?",
+}
+
+const swiftPrelude = `import Foundation
+
+public class ListNode {
+    public var val: Int
+    public var next: ListNode?
+    public init() { self.val = 0; self.next = nil; }
+    public init(_ val: Int) { self.val = val; self.next = nil; }
+    public init(_ val: Int, _ next: ListNode?) { self.val = val; self.next = next; }
+}
+
+public class TreeNode {
+    public var val: Int
+    public var left: TreeNode?
+    public var right: TreeNode?
+    public init() { self.val = 0; self.left = nil; self.right = nil; }
+    public init(_ val: Int) { self.val = val; self.left = nil; self.right = nil; }
+    public init(_ val: Int, _ left: TreeNode?, _ right: TreeNode?) {
+        self.val = val
+        self.left = left
+        self.right = right
+    }
+}
+
+`
+
+const swiftRuntime = `
+
+let harnessData = [UInt8](FileHandle.standardInput.readDataToEndOfFile())
+var harnessPos = 0
+
+func harnessToken() -> String {
+    while harnessPos < harnessData.count && harnessData[harnessPos] <= 32 { harnessPos += 1 }
+    let start = harnessPos
+    while harnessPos < harnessData.count && harnessData[harnessPos] > 32 { harnessPos += 1 }
+    return String(decoding: harnessData[start..<harnessPos], as: UTF8.self)
+}
+
+func harnessReadInt() -> Int { return Int(harnessToken())! }
+func harnessReadLong() -> Int { return harnessReadInt() }
+func harnessReadDouble() -> Double { return Double(harnessToken())! }
+func harnessReadBool() -> Bool { return harnessToken() == "1" }
+
+func harnessReadString() -> String {
+    let n = harnessReadInt()
+    let start = harnessPos + 1
+    harnessPos = start + n
+    return String(decoding: harnessData[start..<harnessPos], as: UTF8.self)
+}
+
+func harnessReadArray<T>(_ read: () -> T) -> [T] {
+    let n = harnessReadInt()
+    var out: [T] = []
+    for _ in 0..<n { out.append(read()) }
+    return out
+}
+
+func harnessReadList() -> ListNode? {
+    var head: ListNode? = nil
+    for value in harnessReadArray(harnessReadInt).reversed() { head = ListNode(value, head) }
+    return head
+}
+
+func harnessReadTree() -> TreeNode? {
+    let tokens = harnessReadArray(harnessToken)
+    if tokens.isEmpty || tokens[0] == "null" { return nil }
+    let root = TreeNode(Int(tokens[0])!)
+    var queue = [root]
+    var head = 0
+    var i = 1
+    while head < queue.count && i < tokens.count {
+        let node = queue[head]
+        head += 1
+        if i < tokens.count && tokens[i] != "null" {
+            let left = TreeNode(Int(tokens[i])!)
+            node.left = left
+            queue.append(left)
+        }
+        i += 1
+        if i < tokens.count && tokens[i] != "null" {
+            let right = TreeNode(Int(tokens[i])!)
+            node.right = right
+            queue.append(right)
+        }
+        i += 1
+    }
+    return root
+}
+
+func harnessWriteInt(_ v: Int) -> String { return String(v) }
+func harnessWriteLong(_ v: Int) -> String { return String(v) }
+func harnessWriteDouble(_ v: Double) -> String { return String(v) }
+func harnessWriteBool(_ v: Bool) -> String { return v ? "true" : "false" }
+
+func harnessWriteString(_ v: String) -> String {
+    var out = "\""
+    for scalar in v.unicodeScalars {
+        switch scalar {
+        case "\"": out += "\\\""
+        case "\\": out += "\\\\"
+        default:
+            if scalar.value < 0x20 {
+                out += String(format: "\\u%04x", scalar.value)
+            } else {
+                out.unicodeScalars.append(scalar)
+            }
+        }
+    }
+    return out + "\""
+}
+
+func harnessWriteList(_ v: ListNode?) -> String {
+    var values: [String] = []
+    var node = v
+    while let current = node {
+        values.append(String(current.val))
+        node = current.next
+    }
+    return "[" + values.joined(separator: ",") + "]"
+}
+
+func harnessWriteTree(_ v: TreeNode?) -> String {
+    var values: [String] = []
+    var queue: [TreeNode?] = [v]
+    var head = 0
+    while head < queue.count {
+        let node = queue[head]
+        head += 1
+        guard let current = node else {
+            values.append("null")
+            continue
+        }
+        values.append(String(current.val))
+        queue.append(current.left)
+        queue.append(current.right)
+    }
+    while values.last == "null" { values.removeLast() }
+    return "[" + values.joined(separator: ",") + "]"
+}
+
+func harnessWriteArray<T>(_ a: [T], _ write: (T) -> String) -> String {
+    return "[" + a.map(write).joined(separator: ",") + "]"
+}
+`
+
+func (swiftHarness) typeName(t valueType) string {
+	return t.typeName(swiftTypes, func(s string) string { return "[" + s + "]" })
+}
+
+func (h swiftHarness) starter(sig *FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = fmt.Sprintf("_ %s: %s", param.Name, h.typeName(param.typ))
+	}
+	return fmt.Sprintf("class Solution {\n    func %s(%s) -> %s {\n        \n    }\n}\n",
+		sig.Function, strings.Join(params, ", "), h.typeName(sig.returnType))
+}
+
+func (h swiftHarness) driver(sig *FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString(swiftPrelude)
+	b.WriteString(code)
+	b.WriteString(swiftRuntime)
+	b.WriteString("\n")
+
+	for i, param := range sig.Params {
+		read := "harnessRead" + valueType{base: param.typ.base}.harnessSuffix()
+		switch param.typ.dims {
+		case 0:
+			read += "()"
+		case 1:
+			read = "harnessReadArray(" + read + ")"
+		case 2:
+			read = "harnessReadArray { harnessReadArray(" + read + ") }"
+		}
+		fmt.Fprintf(&b, "let arg%d: %s = %s\n", i, h.typeName(param.typ), read)
+	}
+	fmt.Fprintf(&b, "let result = Solution().%s(%s)\n", sig.Function, strings.Join(sig.argNames(), ", "))
+
+	write := "harnessWrite" + valueType{base: sig.returnType.base}.harnessSuffix()
+	switch sig.returnType.dims {
+	case 0:
+		write += "(result)"
+	case 1:
+		write = "harnessWriteArray(result, " + write + ")"
+	case 2:
+		write = "harnessWriteArray(result) { harnessWriteArray($0, " + write + ") }"
+	}
+	fmt.Fprintf(&b, "print(%q + %s)\n", harnessSentinelLine, write)
+	return b.String()
+}
+
+// cppHarness targets LeetCode's "class Solution" style with vectors
+type cppHarness struct{}
+
+var cppTypes = map[string]string{
+	harnessInt:      "int",
+	harnessLong:     "long long",
+	harnessDouble:   "double",
+	harnessBool:     "bool",
+	harnessString:   "string",
+	harnessListNode: "ListNode*",
+	harnessTreeNode: "TreeNode*",
+}
+
+const cppPrelude = `#include <bits/stdc++.h>
+using namespace std;
+
+struct ListNode {
+    int val;
+    ListNode *next;
+    ListNode() : val(0), next(nullptr) {}
+    ListNode(int x) : val(x), next(nullptr) {}
+    ListNode(int x, ListNode *next) : val(x), next(next) {}
+};
+
+struct TreeNode {
+    int val;
+    TreeNode *left;
+    TreeNode *right;
+    TreeNode() : val(0), left(nullptr), right(nullptr) {}
+    TreeNode(int x) : val(x), left(nullptr), right(nullptr) {}
+    TreeNode(int x, TreeNode *left, TreeNode *right) : val(x), left(left), right(right) {}
+};
+
+`
+
+const cppRuntime = `
+
+namespace harness {
+
+string input;
+size_t pos = 0;
+
+string token() {
+    while (pos < input.size() && (unsigned char)input[pos] <= 32) pos++;
+    size_t start = pos;
+    while (pos < input.size() && (unsigned char)input[pos] > 32) pos++;
+    return input.substr(start, pos - start);
+}
+
+void read(int &v) { v = stoi(token()); }
+void read(long long &v) { v = stoll(token()); }
+void read(double &v) { v = stod(token()); }
+void read(bool &v) { v = token() == "1"; }
+
+void read(string &v) {
+    size_t n = stoul(token());
+    v = input.substr(pos + 1, n);
+    pos += 1 + n;
+}
+
+void read(ListNode *&v);
+void read(TreeNode *&v);
+
+template <typename T>
+void read(vector<T> &v) {
+    size_t n = stoul(token());
+    v.clear();
+    for (size_t i = 0; i < n; i++) {
+        T x;
+        read(x);
+        v.push_back(x);
+    }
+}
+
+void read(ListNode *&v) {
+    vector<int> values;
+    read(values);
+    v = nullptr;
+    for (size_t i = values.size(); i-- > 0;) v = new ListNode(values[i], v);
+}
+
+void read(TreeNode *&v) {
+    size_t n = stoul(token());
+    vector<string> tokens(n);
+    for (size_t i = 0; i < n; i++) tokens[i] = token();
+    v = nullptr;
+    if (n == 0 || tokens[0] == "null") return;
+    v = new TreeNode(stoi(tokens[0]));
+    queue<TreeNode *> nodes;
+    nodes.push(v);
+    for (size_t i = 1; i < n && !nodes.empty(); i += 2) {
+        TreeNode *node = nodes.front();
+        nodes.pop();
+        if (tokens[i] != "null") {
+            node->left = new TreeNode(stoi(tokens[i]));
+            nodes.push(node->left);
+        }
+        if (i + 1 < n && tokens[i + 1] != "null") {
+            node->right = new TreeNode(stoi(tokens[i + 1]));
+            nodes.push(node->right);
+        }
+    }
+}
+
+void write(string &out, int v) { out += to_string(v); }
+void write(string &out, long long v) { out += to_string(v); }
+void write(string &out, bool v) { out += v ? "true" : "false"; }
+
+void write(string &out, double v) {
+    char buf[32];
+    snprintf(buf, sizeof buf, "%.17g", v);
+    out += buf;
+}
+
+void write(string &out, const string &v) {
+    out += '"';
+    for (unsigned char c : v) {
+        if (c == '"' || c == '\\') {
+            out += '\\';
+            out += c;
+        } else if (c < 0x20) {
+            char buf[8];
+            snprintf(buf, sizeof buf, "\\u%04x", c);
+            out += buf;
+        } else {
+            out += c;
+        }
+    }
+    out += '"';
+}
+
+void write(string &out, ListNode *v);
+void write(string &out, TreeNode *v);
+
+template <typename T>
+void write(string &out, const vector<T> &v) {
+    out += '[';
+    for (size_t i = 0; i < v.size(); i++) {
+        if (i > 0) out += ',';
+        T x = v[i];
+        write(out, x);
+    }
+    out += ']';
+}
+
+void write(string &out, ListNode *v) {
+    vector<int> values;
+    for (ListNode *node = v; node != nullptr; node = node->next) values.push_back(node->val);
+    write(out, values);
+}
+
+void write(string &out, TreeNode *v) {
+    vector<string> values;
+    queue<TreeNode *> nodes;
+    nodes.push(v);
+    while (!nodes.empty()) {
+        TreeNode *node = nodes.front();
+        nodes.pop();
+        if (node == nullptr) {
+            values.push_back("null");
+            continue;
+        }
+        values.push_back(to_string(node->val));
+        nodes.push(node->left);
+        nodes.push(node->right);
+    }
+    while (!values.empty() && values.back() == "null") values.pop_back();
+    out += '[';
+    for (size_t i = 0; i < values.size(); i++) {
+        if (i > 0) out += ',';
+        out += values[i];
+    }
+    out += ']';
+}
+
+} // namespace harness
+`
+
+func (cppHarness) typeName(t valueType) string {
+	return t.typeName(cppTypes, func(s string) string { return "vector<" + s + ">" })
+}
+
+func (h cppHarness) starter(sig *FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		name := h.typeName(param.typ)
+		if param.typ.dims > 0 {
+			name += "&"
+		}
+		params[i] = name + " " + param.Name
+	}
+	return fmt.Sprintf("class Solution {\npublic:\n    %s %s(%s) {\n        \n    }\n};\n",
+		h.typeName(sig.returnType), sig.Function, strings.Join(params, ", "))
+}
+
+func (h cppHarness) driver(sig *FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString(cppPrelude)
+	b.WriteString(code)
+	b.WriteString(cppRuntime)
+
+	b.WriteString("\nint main() {\n")
+	b.WriteString("    harness::input.assign(istreambuf_iterator<char>(cin), istreambuf_iterator<char>());\n")
+	for i, param := range sig.Params {
+		fmt.Fprintf(&b, "    %s arg%d;\n    harness::read(arg%d);\n", h.typeName(param.typ), i, i)
+	}
+	b.WriteString("    Solution solution;\n")
+	fmt.Fprintf(&b, "    %s result = solution.%s(%s);\n",
+		h.typeName(sig.returnType), sig.Function, strings.Join(sig.argNames(), ", "))
+	b.WriteString("    string out;\n    harness::write(out, result);\n")
+	fmt.Fprintf(&b, "    cout << %q << out << endl;\n    return 0;\n}\n", harnessSentinelLine)
+	return b.String()
+}
+
+// cHarness targets LeetCode's C conventions: arrays are passed with a size
+// parameter, matrices also with per-row sizes, and returned arrays report
+// their sizes through returnSize and returnColumnSizes
+type cHarness struct{}
+
+var cTypes = map[string]string{
+	harnessInt:      "int",
+	harnessLong:     "long long",
+	harnessDouble:   "double",
+	harnessBool:     "bool",
+	harnessString:   "char*",
+	harnessListNode: "struct ListNode*",
+	harnessTreeNode: "struct TreeNode*",
+}
+
+// cHelperNames name each base type in the HARNESS_ARRAY helpers
+var cHelperNames = map[string]string{
+	harnessInt:      "int",
+	harnessLong:     "long",
+	harnessDouble:   "double",
+	harnessBool:     "bool",
+	harnessString:   "string",
+	harnessListNode: "list",
+}
+
+const cPrelude = `#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <stdbool.h>
+#include <stdint.h>
+#include <limits.h>
+#include <ctype.h>
+#include <math.h>
+
+struct ListNode {
+    int val;
+    struct ListNode *next;
+};
+
+struct TreeNode {
+    int val;
+    struct TreeNode *left;
+    struct TreeNode *right;
+};
+
+`
+
+const cRuntime = `
+
+static char *harness_input;
+static size_t harness_length, harness_pos;
+
+static void harness_load(void) {
+    size_t capacity = 1 << 16;
+    harness_input = malloc(capacity);
+    for (;;) {
+        size_t n = fread(harness_input + harness_length, 1, capacity - harness_length - 1, stdin);
+        if (n == 0) break;
+        harness_length += n;
+        if (harness_length + 1 == capacity) {
+            capacity *= 2;
+            harness_input = realloc(harness_input, capacity);
+        }
+    }
+    harness_input[harness_length] = '\0';
+}
+
+/* harness_token returns the next token, terminated in place */
+static char *harness_token(void) {
+    while (harness_pos < harness_length && (unsigned char)harness_input[harness_pos] <= 32) harness_pos++;
+    char *start = harness_input + harness_pos;
+    while (harness_pos < harness_length && (unsigned char)harness_input[harness_pos] > 32) harness_pos++;
+    harness_input[harness_pos] = '\0';
+    return start;
+}
+
+static int harness_read_int(void) { return (int)strtol(harness_token(), NULL, 10); }
+static long long harness_read_long(void) { return strtoll(harness_token(), NULL, 10); }
+static double harness_read_double(void) { return strtod(harness_token(), NULL); }
+static bool harness_read_bool(void) { return strcmp(harness_token(), "1") == 0; }
+
+static char *harness_read_string(void) {
+    size_t n = (size_t)strtoul(harness_token(), NULL, 10);
+    char *s = malloc(n + 1);
+    memcpy(s, harness_input + harness_pos + 1, n);
+    s[n] = '\0';
+    harness_pos += 1 + n;
+    return s;
+}
+
+static struct ListNode *harness_read_list(void) {
+    int n = harness_read_int();
+    struct ListNode head = {0, NULL}, *tail = &head;
+    for (int i = 0; i < n; i++) {
+        tail->next = malloc(sizeof(struct ListNode));
+        tail = tail->next;
+        tail->val = harness_read_int();
+        tail->next = NULL;
+    }
+    return head.next;
+}
+
+static struct TreeNode *harness_new_tree(const char *token) {
+    if (strcmp(token, "null") == 0) return NULL;
+    struct TreeNode *node = malloc(sizeof(struct TreeNode));
+    node->val = (int)strtol(token, NULL, 10);
+    node->left = node->right = NULL;
+    return node;
+}
+
+static struct TreeNode *harness_read_tree(void) {
+    int n = harness_read_int();
+    if (n == 0) return NULL;
+    struct TreeNode **queue = malloc(sizeof(struct TreeNode *) * n);
+    int head = 0, tail = 0;
+    struct TreeNode *root = harness_new_tree(harness_token());
+    if (root != NULL) queue[tail++] = root;
+    for (int i = 1; i < n; i++) {
+        struct TreeNode *child = harness_new_tree(harness_token());
+        if (head < tail) {
+            if (i % 2 == 1) {
+                queue[head]->left = child;
+            } else {
+                queue[head++]->right = child;
+            }
+        }
+        if (child != NULL) queue[tail++] = child;
+    }
+    free(queue);
+    return root;
+}
+
+static void harness_write_int(int v) { printf("%d", v); }
+static void harness_write_long(long long v) { printf("%lld", v); }
+static void harness_write_double(double v) { printf("%.17g", v); }
+static void harness_write_bool(bool v) { fputs(v ? "true" : "false", stdout); }
+
+static void harness_write_string(char *v) {
+    putchar('"');
+    for (unsigned char *p = (unsigned char *)v; *p; p++) {
+        if (*p == '"' || *p == '\\') printf("\\%c", *p);
+        else if (*p < 0x20) printf("\\u%04x", *p);
+        else putchar(*p);
+    }
+    putchar('"');
+}
+
+static void harness_write_list(struct ListNode *v) {
+    putchar('[');
+    for (struct ListNode *node = v; node != NULL; node = node->next) {
+        if (node != v) putchar(',');
+        printf("%d", node->val);
+    }
+    putchar(']');
+}
+
+static void harness_write_tree(struct TreeNode *v) {
+    int capacity = 16, head = 0, tail = 0, last = 0;
+    struct TreeNode **queue = malloc(sizeof(struct TreeNode *) * capacity);
+    queue[tail++] = v;
+    while (head < tail) {
+        struct TreeNode *node = queue[head++];
+        if (node == NULL) continue;
+        last = head;
+        if (tail + 2 > capacity) {
+            capacity *= 2;
+            queue = realloc(queue, sizeof(struct TreeNode *) * capacity);
+        }
+        queue[tail++] = node->left;
+        queue[tail++] = node->right;
+    }
+    putchar('[');
+    for (int i = 0; i < last; i++) {
+        if (i > 0) putchar(',');
+        if (queue[i] == NULL) fputs("null", stdout);
+        else printf("%d", queue[i]->val);
+    }
+    putchar(']');
+    free(queue);
+}
+
+#define HARNESS_ARRAY(name, type) \
+    static type *harness_read_##name##_array(int *size) { \
+        int n = harness_read_int(); \
+        type *a = malloc(sizeof(type) * (n > 0 ? n : 1)); \
+        for (int i = 0; i < n; i++) a[i] = harness_read_##name(); \
+        *size = n; \
+        return a; \
+    } \
+    static type **harness_read_##name##_matrix(int *size, int **colSizes) { \
+        int n = harness_read_int(); \
+        type **a = malloc(sizeof(type *) * (n > 0 ? n : 1)); \
+        *colSizes = malloc(sizeof(int) * (n > 0 ? n : 1)); \
+        for (int i = 0; i < n; i++) a[i] = harness_read_##name##_array(&(*colSizes)[i]); \
+        *size = n; \
+        return a; \
+    } \
+    static void harness_write_##name##_array(type *a, int size) { \
+        putchar('['); \
+        for (int i = 0; i < size; i++) { \
+            if (i > 0) putchar(','); \
+            harness_write_##name(a[i]); \
+        } \
+        putchar(']'); \
+    } \
+    static void harness_write_##name##_matrix(type **a, int size, int *colSizes) { \
+        putchar('['); \
+        for (int i = 0; i < size; i++) { \
+            if (i > 0) putchar(','); \
+            harness_write_##name##_array(a[i], colSizes[i]); \
+        } \
+        putchar(']'); \
+    }
+
+`
+
+func (cHarness) typeName(t valueType) string {
+	return t.typeName(cTypes, func(s string) string { return s + "*" })
+}
+
+// helper names the reader/writer for a type, e.g. harness_read_int_array
+func (cHarness) helper(prefix string, t valueType) string {
+	return prefix + snakeCase(t.harnessSuffix())
+}
+
+func (h cHarness) starter(sig *FunctionSignature) string {
+	var params []string
+	for _, param := range sig.Params {
+		params = append(params, h.typeName(param.typ)+" "+param.Name)
+		switch param.typ.dims {
+		case 1:
+			params = append(params, "int "+param.Name+"Size")
+		case 2:
+			params = append(params, "int "+param.Name+"Size", "int* "+param.Name+"ColSize")
+		}
+	}
+
+	var b strings.Builder
+	switch sig.returnType.dims {
+	case 1:
+		params = append(params, "int* returnSize")
+		b.WriteString("/**\n * Note: The returned array must be malloced, assume caller calls free().\n */\n")
+	case 2:
+		params = append(params, "int* returnSize", "int** returnColumnSizes")
+		b.WriteString("/**\n * Return an array of arrays of size *returnSize.\n" +
+			" * The sizes of the arrays are returned as *returnColumnSizes array.\n" +
+			" * Note: Both returned array and *columnSizes array must be malloced, assume caller calls free().\n */\n")
+	}
+	fmt.Fprintf(&b, "%s %s(%s) {\n    \n}\n", h.typeName(sig.returnType), sig.Function, strings.Join(params, ", "))
+	return b.String()
+}
+
+func (h cHarness) driver(sig *FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString(cPrelude)
+	b.WriteString(code)
+	b.WriteString(cRuntime)
+	for _, base := range harnessArrayBases {
+		fmt.Fprintf(&b, "HARNESS_ARRAY(%s, %s)\n", cHelperNames[base], cTypes[base])
+	}
+
+	b.WriteString("\nint main(void) {\n    harness_load();\n")
+	var args []string
+	for i, param := range sig.Params {
+		arg := fmt.Sprintf("arg%d", i)
+		read := h.helper("harness_read_", param.typ)
+		switch param.typ.dims {
+		case 0:
+			fmt.Fprintf(&b, "    %s %s = %s();\n", h.typeName(param.typ), arg, read)
+			args = append(args, arg)
+		case 1:
+			fmt.Fprintf(&b, "    int %sSize;\n    %s %s = %s(&%sSize);\n", arg, h.typeName(param.typ), arg, read, arg)
+			args = append(args, arg, arg+"Size")
+		case 2:
+			fmt.Fprintf(&b, "    int %sSize;\n    int* %sColSize;\n    %s %s = %s(&%sSize, &%sColSize);\n",
+				arg, arg, h.typeName(param.typ), arg, read, arg, arg)
+			args = append(args, arg, arg+"Size", arg+"ColSize")
+		}
+	}
+
+	write := h.helper("harness_write_", sig.returnType)
+	sentinel := fmt.Sprintf("fputs(%q, stdout);", harnessSentinelLine)
+	switch sig.returnType.dims {
+	case 0:
+		fmt.Fprintf(&b, "    %s result = %s(%s);\n    %s\n    %s(result);\n",
+			h.typeName(sig.returnType), sig.Function, strings.Join(args, ", "), sentinel, write)
+	case 1:
+		args = append(args, "&resultSize")
+		fmt.Fprintf(&b, "    int resultSize = 0;\n    %s result = %s(%s);\n    %s\n    %s(result, resultSize);\n",
+			h.typeName(sig.returnType), sig.Function, strings.Join(args, ", "), sentinel, write)
+	case 2:
+		args = append(args, "&resultSize", "&resultColSizes")
+		fmt.Fprintf(&b, "    int resultSize = 0;\n    int* resultColSizes = NULL;\n    %s result = %s(%s);\n    %s\n    %s(result, resultSize, resultColSizes);\n",
+			h.typeName(sig.returnType), sig.Function, strings.Join(args, ", "), sentinel, write)
+	}
+	b.WriteString("    putchar('\\n');\n    return 0;\n}\n")
+	return b.String()
+}
+
+// goHarness targets LeetCode's plain function style
+type goHarness struct{}
+
+var goTypes = map[string]string{
+	harnessInt:      "int",
+	harnessLong:     "int",
+	harnessDouble:   "float64",
+	harnessBool:     "bool",
+	harnessString:   "string",
+	harnessListNode: "*ListNode",
+	harnessTreeNode: "*TreeNode",
+}
+
+var goImports = []string{`"encoding/json"`, `"io/ioutil"`, `"os"`, `"strconv"`, `"strings"`}
+
+const goNodes = `
+
+type ListNode struct {
+	Val  int
+	Next *ListNode
+}
+
+type TreeNode struct {
+	Val   int
+	Left  *TreeNode
+	Right *TreeNode
+}
+
+`
+
+const goRuntime = `
+
+var harnessInput, _ = ioutil.ReadAll(os.Stdin)
+var harnessPos int
+
+func harnessToken() string {
+	for harnessPos < len(harnessInput) && harnessInput[harnessPos] <= ' ' {
+		harnessPos++
+	}
+	start := harnessPos
+	for harnessPos < len(harnessInput) && harnessInput[harnessPos] > ' ' {
+		harnessPos++
+	}
+	return string(harnessInput[start:harnessPos])
+}
+
+func harnessReadInt() int {
+	n, _ := strconv.Atoi(harnessToken())
+	return n
+}
+
+func harnessReadLong() int {
+	return harnessReadInt()
+}
+
+func harnessReadDouble() float64 {
+	f, _ := strconv.ParseFloat(harnessToken(), 64)
+	return f
+}
+
+func harnessReadBool() bool {
+	return harnessToken() == "1"
+}
+
+func harnessReadString() string {
+	n := harnessReadInt()
+	start := harnessPos + 1
+	harnessPos = start + n
+	return string(harnessInput[start:harnessPos])
+}
+
+func harnessReadList() *ListNode {
+	var head *ListNode
+	values := harnessReadIntArray()
+	for i := len(values) - 1; i >= 0; i-- {
+		head = &ListNode{Val: values[i], Next: head}
+	}
+	return head
+}
+
+func harnessReadTree() *TreeNode {
+	tokens := make([]string, harnessReadInt())
+	for i := range tokens {
+		tokens[i] = harnessToken()
+	}
+	newNode := func(token string) *TreeNode {
+		if token == "null" {
+			return nil
+		}
+		val, _ := strconv.Atoi(token)
+		return &TreeNode{Val: val}
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	root := newNode(tokens[0])
+	queue := []*TreeNode{root}
+	for i := 1; i < len(tokens) && len(queue) > 0 && queue[0] != nil; i += 2 {
+		node := queue[0]
+		queue = queue[1:]
+		if node.Left = newNode(tokens[i]); node.Left != nil {
+			queue = append(queue, node.Left)
+		}
+		if i+1 < len(tokens) {
+			if node.Right = newNode(tokens[i+1]); node.Right != nil {
+				queue = append(queue, node.Right)
+			}
+		}
+	}
+	return root
+}
+
+func harnessWriteInt(b *strings.Builder, v int) {
+	b.WriteString(strconv.Itoa(v))
+}
+
+func harnessWriteLong(b *strings.Builder, v int) {
+	b.WriteString(strconv.Itoa(v))
+}
+
+func harnessWriteDouble(b *strings.Builder, v float64) {
+	b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+func harnessWriteBool(b *strings.Builder, v bool) {
+	b.WriteString(strconv.FormatBool(v))
+}
+
+func harnessWriteString(b *strings.Builder, v string) {
+	data, _ := json.Marshal(v)
+	b.Write(data)
+}
+
+func harnessWriteList(b *strings.Builder, v *ListNode) {
+	var values []int
+	for node := v; node != nil; node = node.Next {
+		values = append(values, node.Val)
+	}
+	harnessWriteIntArray(b, values)
+}
+
+func harnessWriteTree(b *strings.Builder, v *TreeNode) {
+	var values []string
+	queue := []*TreeNode{v}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if node == nil {
+			values = append(values, "null")
+			continue
+		}
+		values = append(values, strconv.Itoa(node.Val))
+		queue = append(queue, node.Left, node.Right)
+	}
+	for len(values) > 0 && values[len(values)-1] == "null" {
+		values = values[:len(values)-1]
+	}
+	b.WriteString("[" + strings.Join(values, ",") + "]")
+}
+`
+
+const goArrayHelpers = `
+func harnessRead{S}Array() []{T} {
+	a := make([]{T}, harnessReadInt())
+	for i := range a {
+		a[i] = harnessRead{S}()
+	}
+	return a
+}
+
+func harnessRead{S}Matrix() [][]{T} {
+	a := make([][]{T}, harnessReadInt())
+	for i := range a {
+		a[i] = harnessRead{S}Array()
+	}
+	return a
+}
+
+func harnessWrite{S}Array(b *strings.Builder, a []{T}) {
+	b.WriteByte('[')
+	for i, v := range a {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		harnessWrite{S}(b, v)
+	}
+	b.WriteByte(']')
+}
+
+func harnessWrite{S}Matrix(b *strings.Builder, a [][]{T}) {
+	b.WriteByte('[')
+	for i, v := range a {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		harnessWrite{S}Array(b, v)
+	}
+	b.WriteByte(']')
+}
+`
+
+func (goHarness) typeName(t valueType) string {
+	return t.typeName(goTypes, func(s string) string { return "[]" + s })
+}
+
+func (h goHarness) starter(sig *FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = param.Name + " " + h.typeName(param.typ)
+	}
+	return fmt.Sprintf("func %s(%s) %s {\n    \n}\n", sig.Function, strings.Join(params, ", "), h.typeName(sig.returnType))
+}
+
+func (goHarness) driver(sig *FunctionSignature, code string) string {
+	imports, rest := hoistImports(code, packageDeclaration)
+
+	var b strings.Builder
+	b.WriteString("package main\n\nimport (\n")
+	b.WriteString(mergeImports(goImports, imports, func(spec string) string {
+		spec = strings.TrimSuffix(strings.TrimPrefix(spec, "import "), ";")
+		return "\t" + strings.TrimSpace(spec)
+	}))
+	b.WriteString("\n)")
+	b.WriteString(goNodes)
+	b.WriteString(rest)
+	b.WriteString(goRuntime)
+	b.WriteString(expandHarnessHelpers(goArrayHelpers, map[string]map[string]string{"{T}": goTypes}))
+
+	b.WriteString("\nfunc main() {\n")
+	for i, param := range sig.Params {
+		fmt.Fprintf(&b, "\targ%d := harnessRead%s()\n", i, param.typ.harnessSuffix())
+	}
+	fmt.Fprintf(&b, "\tresult := %s(%s)\n", sig.Function, strings.Join(sig.argNames(), ", "))
+	b.WriteString("\tvar b strings.Builder\n")
+	fmt.Fprintf(&b, "\tharnessWrite%s(&b, result)\n", sig.returnType.harnessSuffix())
+	fmt.Fprintf(&b, "\tos.Stdout.WriteString(%q + b.String() + \"\\n\")\n}\n", harnessSentinelLine)
+	return b.String()
+}
+
+// rustHarness targets LeetCode's "impl Solution" style with snake_case names
+type rustHarness struct{}
+
+var rustTypes = map[string]string{
+	harnessInt:      "i32",
+	harnessLong:     "i64",
+	harnessDouble:   "f64",
+	harnessBool:     "bool",
+	harnessString:   "String",
+	harnessListNode: "Option<Box<ListNode>>",
+	harnessTreeNode: "Option<Rc<RefCell<TreeNode>>>",
+}
+
+// rustPrelude spells out std paths so user code can import Rc and RefCell
+// itself, as LeetCode's tree stubs do
+const rustPrelude = `#![allow(dead_code, unused_imports, unused_variables, unused_mut, non_snake_case)]
+
+#[derive(PartialEq, Eq, Clone, Debug)]
+pub struct ListNode {
+    pub val: i32,
+    pub next: Option<Box<ListNode>>,
+}
+
+impl ListNode {
+    #[inline]
+    fn new(val: i32) -> Self {
+        ListNode { next: None, val: val }
+    }
+}
+
+#[derive(Debug, PartialEq, Eq)]
+pub struct TreeNode {
+    pub val: i32,
+    pub left: Option<::std::rc::Rc<::std::cell::RefCell<TreeNode>>>,
+    pub right: Option<::std::rc::Rc<::std::cell::RefCell<TreeNode>>>,
+}
+
+impl TreeNode {
+    #[inline]
+    pub fn new(val: i32) -> Self {
+        TreeNode { val: val, left: None, right: None }
+    }
+}
+
+pub struct Solution;
+
+`
+
+const rustRuntime = `
+
+mod harness {
+    use super::{ListNode, TreeNode};
+    use ::std::cell::RefCell;
+    use ::std::collections::VecDeque;
+    use ::std::io::Read;
+    use ::std::rc::Rc;
+
+    pub struct Reader {
+        data: Vec<u8>,
+        pos: usize,
+    }
+
+    impl Reader {
+        pub fn new() -> Reader {
+            let mut data = Vec::new();
+            ::std::io::stdin().read_to_end(&mut data).unwrap();
+            Reader { data: data, pos: 0 }
+        }
+
+        fn token(&mut self) -> String {
+            while self.pos < self.data.len() && self.data[self.pos] <= 32 {
+                self.pos += 1;
+            }
+            let start = self.pos;
+            while self.pos < self.data.len() && self.data[self.pos] > 32 {
+                self.pos += 1;
+            }
+            String::from_utf8_lossy(&self.data[start..self.pos]).into_owned()
+        }
+    }
+
+    pub trait HarnessRead: Sized {
+        fn read(reader: &mut Reader) -> Self;
+    }
+
+    impl HarnessRead for i32 {
+        fn read(reader: &mut Reader) -> i32 {
+            reader.token().parse().unwrap()
+        }
+    }
+
+    impl HarnessRead for i64 {
+        fn read(reader: &mut Reader) -> i64 {
+            reader.token().parse().unwrap()
+        }
+    }
+
+    impl HarnessRead for f64 {
+        fn read(reader: &mut Reader) -> f64 {
+            reader.token().parse().unwrap()
+        }
+    }
+
+    impl HarnessRead for bool {
+        fn read(reader: &mut Reader) -> bool {
+            reader.token() == "1"
+        }
+    }
+
+    impl HarnessRead for String {
+        fn read(reader: &mut Reader) -> String {
+            let n: usize = reader.token().parse().unwrap();
+            let start = reader.pos + 1;
+            reader.pos = start + n;
+            String::from_utf8_lossy(&reader.data[start..reader.pos]).into_owned()
+        }
+    }
+
+    impl<T: HarnessRead> HarnessRead for Vec<T> {
+        fn read(reader: &mut Reader) -> Vec<T> {
+            let n: usize = reader.token().parse().unwrap();
+            (0..n).map(|_| T::read(reader)).collect()
+        }
+    }
+
+    impl HarnessRead for Option<Box<ListNode>> {
+        fn read(reader: &mut Reader) -> Self {
+            let values: Vec<i32> = HarnessRead::read(reader);
+            let mut head = None;
+            for val in values.into_iter().rev() {
+                head = Some(Box::new(ListNode { val: val, next: head }));
+            }
+            head
+        }
+    }
+
+    impl HarnessRead for Option<Rc<RefCell<TreeNode>>> {
+        fn read(reader: &mut Reader) -> Self {
+            let n: usize = reader.token().parse().unwrap();
+            let tokens: Vec<String> = (0..n).map(|_| reader.token()).collect();
+            let node = |token: &String| -> Option<Rc<RefCell<TreeNode>>> {
+                if token == "null" {
+                    None
+                } else {
+                    Some(Rc::new(RefCell::new(TreeNode::new(token.parse().unwrap()))))
+                }
+            };
+            if tokens.is_empty() {
+                return None;
+            }
+            let root = node(&tokens[0]);
+            let mut queue = VecDeque::new();
+            if let Some(ref root) = root {
+                queue.push_back(root.clone());
+            }
+            let mut i = 1;
+            while i < tokens.len() {
+                let parent = match queue.pop_front() {
+                    Some(parent) => parent,
+                    None => break,
+                };
+                let left = node(&tokens[i]);
+                if let Some(ref left) = left {
+                    queue.push_back(left.clone());
+                }
+                parent.borrow_mut().left = left;
+                if i + 1 < tokens.len() {
+                    let right = node(&tokens[i + 1]);
+                    if let Some(ref right) = right {
+                        queue.push_back(right.clone());
+                    }
+                    parent.borrow_mut().right = right;
+                }
+                i += 2;
+            }
+            root
+        }
+    }
+
+    pub trait HarnessWrite {
+        fn write(&self, out: &mut String);
+    }
+
+    impl HarnessWrite for i32 {
+        fn write(&self, out: &mut String) {
+            out.push_str(&self.to_string());
+        }
+    }
+
+    impl HarnessWrite for i64 {
+        fn write(&self, out: &mut String) {
+            out.push_str(&self.to_string());
+        }
+    }
+
+    impl HarnessWrite for f64 {
+        fn write(&self, out: &mut String) {
+            out.push_str(&self.to_string());
+        }
+    }
+
+    impl HarnessWrite for bool {
+        fn write(&self, out: &mut String) {
+            out.push_str(&self.to_string());
+        }
+    }
+
+    impl HarnessWrite for String {
+        fn write(&self, out: &mut String) {
+            out.push('"');
+            for c in self.chars() {
+                match c {
+                    '"' => out.push_str("\\\""),
+                    '\\' => out.push_str("\\\\"),
+                    c if (c as u32) < 0x20 => out.push_str(&format!("\\u{:04x}", c as u32)),
+                    c => out.push(c),
+                }
+            }
+            out.push('"');
+        }
+    }
+
+    impl<T: HarnessWrite> HarnessWrite for Vec<T> {
+        fn write(&self, out: &mut String) {
+            out.push('[');
+            for (i, item) in self.iter().enumerate() {
+                if i > 0 {
+                    out.push(',');
+                }
+                item.write(out);
+            }
+            out.push(']');
+        }
+    }
+
+    impl HarnessWrite for Option<Box<ListNode>> {
+        fn write(&self, out: &mut String) {
+            let mut values: Vec<i32> = Vec::new();
+            let mut node = self;
+            while let Some(ref current) = *node {
+                values.push(current.val);
+                node = &current.next;
+            }
+            values.write(out);
+        }
+    }
+
+    impl HarnessWrite for Option<Rc<RefCell<TreeNode>>> {
+        fn write(&self, out: &mut String) {
+            let mut values: Vec<String> = Vec::new();
+            let mut queue = VecDeque::new();
+            queue.push_back(self.clone());
+            while let Some(node) = queue.pop_front() {
+                match node {
+                    None => values.push("null".to_string()),
+                    Some(node) => {
+                        let node = node.borrow();
+                        values.push(node.val.to_string());
+                        queue.push_back(node.left.clone());
+                        queue.push_back(node.right.clone());
+                    }
+                }
+            }
+            while values.last().map_or(false, |v| v == "null") {
+                values.pop();
+            }
+            out.push('[');
+            out.push_str(&values.join(","));
+            out.push(']');
+        }
+    }
+}
+`
+
+// typeName renders a type; qualified spells out std paths for the driver,
+// which cannot rely on the user's imports
+func (rustHarness) typeName(t valueType, qualified bool) string {
+	name := t.typeName(rustTypes, func(s string) string { return "Vec<" + s + ">" })
+	if qualified {
+		name = strings.Replace(name, "Rc<RefCell<", "::std::rc::Rc<::std::cell::RefCell<", -1)
+	}
+	return name
+}
+
+func (h rustHarness) starter(sig *FunctionSignature) string {
+	var b strings.Builder
+	usesTree := sig.returnType.base == harnessTreeNode
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = snakeCase(param.Name) + ": " + h.typeName(param.typ, false)
+		usesTree = usesTree || param.typ.base == harnessTreeNode
+	}
+	if usesTree {
+		b.WriteString("use std::rc::Rc;\nuse std::cell::RefCell;\n")
+	}
+	fmt.Fprintf(&b, "impl Solution {\n    pub fn %s(%s) -> %s {\n        \n    }\n}\n",
+		snakeCase(sig.Function), strings.Join(params, ", "), h.typeName(sig.returnType, false))
+	return b.String()
+}
+
+func (h rustHarness) driver(sig *FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString(rustPrelude)
+	b.WriteString(code)
+	b.WriteString(rustRuntime)
+
+	b.WriteString("\nfn main() {\n    let mut reader = harness::Reader::new();\n")
+	for i, param := range sig.Params {
+		fmt.Fprintf(&b, "    let arg%d: %s = harness::HarnessRead::read(&mut reader);\n", i, h.typeName(param.typ, true))
+	}
+	fmt.Fprintf(&b, "    let result = Solution::%s(%s);\n", snakeCase(sig.Function), strings.Join(sig.argNames(), ", "))
+	b.WriteString("    let mut out = String::new();\n    harness::HarnessWrite::write(&result, &mut out);\n")
+	fmt.Fprintf(&b, "    println!(\"{}{}\", %q, out);\n}\n", harnessSentinelLine)
+	return b.String()
+}
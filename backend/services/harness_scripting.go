@@ -0,0 +1,790 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Function harnesses for dynamically typed languages. Each driver reads the
+// token stream produced by FunctionSignature.EncodeInput, calls the user's
+// function and prints the result as compact JSON.
+
+// pythonHarness targets LeetCode's "class Solution" style; a plain top-level
+// function is accepted too
+type pythonHarness struct{}
+
+var pythonTypes = map[string]string{
+	harnessInt:      "int",
+	harnessLong:     "int",
+	harnessDouble:   "float",
+	harnessBool:     "bool",
+	harnessString:   "str",
+	harnessListNode: "Optional[ListNode]",
+	harnessTreeNode: "Optional[TreeNode]",
+}
+
+var pythonReaders = map[string]string{
+	harnessInt:      "read_int",
+	harnessLong:     "read_int",
+	harnessDouble:   "read_double",
+	harnessBool:     "read_bool",
+	harnessString:   "read_string",
+	harnessListNode: "read_list",
+	harnessTreeNode: "read_tree",
+}
+
+const pythonPrelude = `import sys
+import json
+import math
+import heapq
+import bisect
+import itertools
+import functools
+import collections
+from typing import *
+from collections import *
+
+
+class ListNode:
+    def __init__(self, val=0, next=None):
+        self.val = val
+        self.next = next
+
+
+class TreeNode:
+    def __init__(self, val=0, left=None, right=None):
+        self.val = val
+        self.left = left
+        self.right = right
+
+`
+
+const pythonRuntime = `
+
+class _HarnessReader:
+    def __init__(self, data):
+        self.data = data
+        self.pos = 0
+
+    def token(self):
+        data, pos = self.data, self.pos
+        while pos < len(data) and data[pos] <= 32:
+            pos += 1
+        start = pos
+        while pos < len(data) and data[pos] > 32:
+            pos += 1
+        self.pos = pos
+        return data[start:pos].decode()
+
+    def read_int(self):
+        return int(self.token())
+
+    def read_double(self):
+        return float(self.token())
+
+    def read_bool(self):
+        return self.token() == "1"
+
+    def read_string(self):
+        n = int(self.token())
+        start = self.pos + 1
+        self.pos = start + n
+        return self.data[start:self.pos].decode()
+
+    def read_array(self, read):
+        return [read() for _ in range(int(self.token()))]
+
+    def read_list(self):
+        head = tail = ListNode()
+        for value in self.read_array(self.read_int):
+            tail.next = ListNode(value)
+            tail = tail.next
+        return head.next
+
+    def read_tree(self):
+        tokens = [self.token() for _ in range(int(self.token()))]
+        values = [None if t == "null" else int(t) for t in tokens]
+        if not values or values[0] is None:
+            return None
+        root = TreeNode(values[0])
+        queue = collections.deque([root])
+        i = 1
+        while queue and i < len(values):
+            node = queue.popleft()
+            if i < len(values) and values[i] is not None:
+                node.left = TreeNode(values[i])
+                queue.append(node.left)
+            i += 1
+            if i < len(values) and values[i] is not None:
+                node.right = TreeNode(values[i])
+                queue.append(node.right)
+            i += 1
+        return root
+
+
+def _harness_json(value, base, dims):
+    if dims > 0:
+        return [_harness_json(v, base, dims - 1) for v in value]
+    if base == "ListNode":
+        out = []
+        while value is not None:
+            out.append(value.val)
+            value = value.next
+        return out
+    if base == "TreeNode":
+        out, queue = [], collections.deque([value])
+        while queue:
+            node = queue.popleft()
+            if node is None:
+                out.append(None)
+                continue
+            out.append(node.val)
+            queue.append(node.left)
+            queue.append(node.right)
+        while out and out[-1] is None:
+            out.pop()
+        return out
+    if base == "double":
+        return float(value)
+    if base in ("int", "long"):
+        return int(value)
+    if base == "bool":
+        return bool(value)
+    return value
+
+
+_reader = _HarnessReader(sys.stdin.buffer.read())
+`
+
+func (pythonHarness) typeName(t valueType) string {
+	return t.typeName(pythonTypes, func(s string) string { return "List[" + s + "]" })
+}
+
+func (h pythonHarness) starter(sig *FunctionSignature) string {
+	params := []string{"self"}
+	for _, param := range sig.Params {
+		params = append(params, fmt.Sprintf("%s: %s", param.Name, h.typeName(param.typ)))
+	}
+	return fmt.Sprintf("class Solution:\n    def %s(%s) -> %s:\n        pass\n",
+		sig.Function, strings.Join(params, ", "), h.typeName(sig.returnType))
+}
+
+func (pythonHarness) driver(sig *FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString(pythonPrelude)
+	b.WriteString(code)
+	b.WriteString(pythonRuntime)
+
+	for i, param := range sig.Params {
+		read := "_reader." + pythonReaders[param.typ.base]
+		for d := 0; d < param.typ.dims; d++ {
+			read = fmt.Sprintf("(lambda: _reader.read_array(%s))", read)
+		}
+		fmt.Fprintf(&b, "arg%d = %s()\n", i, read)
+	}
+
+	fmt.Fprintf(&b, `_solution = Solution() if "Solution" in globals() else None
+_function = getattr(_solution, %q) if _solution is not None else globals()[%q]
+_result = _function(%s)
+sys.stdout.write(%q + json.dumps(_harness_json(_result, %q, %d), separators=(",", ":")) + "\n")
+`, sig.Function, sig.Function, strings.Join(sig.argNames(), ", "), harnessSentinelLine, sig.returnType.base, sig.returnType.dims)
+
+	return b.String()
+}
+
+// javascriptHarness targets LeetCode's "var fn = function(...)" style
+type javascriptHarness struct{}
+
+var javascriptTypes = map[string]string{
+	harnessInt:      "number",
+	harnessLong:     "number",
+	harnessDouble:   "number",
+	harnessBool:     "boolean",
+	harnessString:   "string",
+	harnessListNode: "ListNode",
+	harnessTreeNode: "TreeNode",
+}
+
+var javascriptReaders = map[string]string{
+	harnessInt:      "__readNumber",
+	harnessLong:     "__readNumber",
+	harnessDouble:   "__readNumber",
+	harnessBool:     "__readBool",
+	harnessString:   "__readString",
+	harnessListNode: "__readList",
+	harnessTreeNode: "__readTree",
+}
+
+const javascriptPrelude = `function ListNode(val, next) {
+    this.val = (val === undefined ? 0 : val);
+    this.next = (next === undefined ? null : next);
+}
+
+function TreeNode(val, left, right) {
+    this.val = (val === undefined ? 0 : val);
+    this.left = (left === undefined ? null : left);
+    this.right = (right === undefined ? null : right);
+}
+
+`
+
+// javascriptRuntime is shared with TypeScript, so it avoids ES2015 library
+// features that TypeScript's default target does not declare
+const javascriptRuntime = `
+
+var __data = require("fs").readFileSync(0);
+var __pos = 0;
+
+function __token() {
+    while (__pos < __data.length && __data[__pos] <= 32) __pos++;
+    var start = __pos;
+    while (__pos < __data.length && __data[__pos] > 32) __pos++;
+    return __data.toString("utf8", start, __pos);
+}
+
+function __readNumber() {
+    return Number(__token());
+}
+
+function __readBool() {
+    return __token() === "1";
+}
+
+function __readString() {
+    var n = Number(__token());
+    var start = __pos + 1;
+    __pos = start + n;
+    return __data.toString("utf8", start, __pos);
+}
+
+function __readArray(read) {
+    var n = Number(__token());
+    var out = [];
+    for (var i = 0; i < n; i++) out.push(read());
+    return out;
+}
+
+function __readList() {
+    var values = __readArray(__readNumber);
+    var head = null;
+    for (var i = values.length - 1; i >= 0; i--) head = new ListNode(values[i], head);
+    return head;
+}
+
+function __readTree() {
+    var tokens = __readArray(__token);
+    if (tokens.length === 0 || tokens[0] === "null") return null;
+    var root = new TreeNode(Number(tokens[0]), null, null);
+    var queue = [root];
+    var head = 0;
+    var i = 1;
+    while (head < queue.length && i < tokens.length) {
+        var node = queue[head++];
+        if (i < tokens.length && tokens[i] !== "null") {
+            node.left = new TreeNode(Number(tokens[i]), null, null);
+            queue.push(node.left);
+        }
+        i++;
+        if (i < tokens.length && tokens[i] !== "null") {
+            node.right = new TreeNode(Number(tokens[i]), null, null);
+            queue.push(node.right);
+        }
+        i++;
+    }
+    return root;
+}
+
+function __toJSON(value, base, dims) {
+    var out = [];
+    if (dims > 0) {
+        for (var i = 0; i < value.length; i++) out.push(__toJSON(value[i], base, dims - 1));
+        return out;
+    }
+    if (base === "ListNode") {
+        for (var node = value; node; node = node.next) out.push(node.val);
+        return out;
+    }
+    if (base === "TreeNode") {
+        var queue = [value];
+        for (var head = 0; head < queue.length; head++) {
+            var current = queue[head];
+            if (!current) {
+                out.push(null);
+                continue;
+            }
+            out.push(current.val);
+            queue.push(current.left, current.right);
+        }
+        while (out.length > 0 && out[out.length - 1] === null) out.pop();
+        return out;
+    }
+    if (base === "bool") return !!value;
+    return value;
+}
+`
+
+func (javascriptHarness) typeName(t valueType) string {
+	return t.typeName(javascriptTypes, func(s string) string { return s + "[]" })
+}
+
+func (h javascriptHarness) starter(sig *FunctionSignature) string {
+	var b strings.Builder
+	b.WriteString("/**\n")
+	for _, param := range sig.Params {
+		fmt.Fprintf(&b, " * @param {%s} %s\n", h.typeName(param.typ), param.Name)
+	}
+	fmt.Fprintf(&b, " * @return {%s}\n */\n", h.typeName(sig.returnType))
+	fmt.Fprintf(&b, "var %s = function(%s) {\n    \n};\n", sig.Function, strings.Join(sig.paramNames(nil), ", "))
+	return b.String()
+}
+
+func (javascriptHarness) driver(sig *FunctionSignature, code string) string {
+	return javascriptPrelude + code + javascriptRuntime + javascriptMain(sig)
+}
+
+// javascriptMain reads the arguments, calls the function and prints the result
+func javascriptMain(sig *FunctionSignature) string {
+	var b strings.Builder
+	b.WriteString("\n")
+	for i, param := range sig.Params {
+		read := javascriptReaders[param.typ.base]
+		for d := 0; d < param.typ.dims; d++ {
+			read = fmt.Sprintf("function () { return __readArray(%s); }", read)
+		}
+		fmt.Fprintf(&b, "var arg%d = (%s)();\n", i, read)
+	}
+	fmt.Fprintf(&b, "var __result = %s(%s);\n", sig.Function, strings.Join(sig.argNames(), ", "))
+	fmt.Fprintf(&b, "console.log(%q + JSON.stringify(__toJSON(__result, %q, %d)));\n", harnessSentinelLine, sig.returnType.base, sig.returnType.dims)
+	return b.String()
+}
+
+// typescriptHarness targets LeetCode's typed function style and reuses the
+// JavaScript runtime
+type typescriptHarness struct{}
+
+var typescriptTypes = map[string]string{
+	harnessInt:      "number",
+	harnessLong:     "number",
+	harnessDouble:   "number",
+	harnessBool:     "boolean",
+	harnessString:   "string",
+	harnessListNode: "ListNode | null",
+	harnessTreeNode: "TreeNode | null",
+}
+
+const typescriptPrelude = `declare var require: any;
+
+class ListNode {
+    val: number
+    next: ListNode | null
+    constructor(val?: number, next?: ListNode | null) {
+        this.val = (val === undefined ? 0 : val)
+        this.next = (next === undefined ? null : next)
+    }
+}
+
+class TreeNode {
+    val: number
+    left: TreeNode | null
+    right: TreeNode | null
+    constructor(val?: number, left?: TreeNode | null, right?: TreeNode | null) {
+        this.val = (val === undefined ? 0 : val)
+        this.left = (left === undefined ? null : left)
+        this.right = (right === undefined ? null : right)
+    }
+}
+
+`
+
+func (typescriptHarness) typeName(t valueType) string {
+	return t.typeName(typescriptTypes, func(s string) string {
+		if strings.Contains(s, "|") {
+			return "Array<" + s + ">"
+		}
+		return s + "[]"
+	})
+}
+
+func (h typescriptHarness) starter(sig *FunctionSignature) string {
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		params[i] = fmt.Sprintf("%s: %s", param.Name, h.typeName(param.typ))
+	}
+	return fmt.Sprintf("function %s(%s): %s {\n    \n};\n",
+		sig.Function, strings.Join(params, ", "), h.typeName(sig.returnType))
+}
+
+func (typescriptHarness) driver(sig *FunctionSignature, code string) string {
+	return typescriptPrelude + code + javascriptRuntime + javascriptMain(sig)
+}
+
+// rubyHarness targets LeetCode's top-level snake_case method style
+type rubyHarness struct{}
+
+var rubyTypes = map[string]string{
+	harnessInt:      "Integer",
+	harnessLong:     "Integer",
+	harnessDouble:   "Float",
+	harnessBool:     "Boolean",
+	harnessString:   "String",
+	harnessListNode: "ListNode",
+	harnessTreeNode: "TreeNode",
+}
+
+var rubyReaders = map[string]string{
+	harnessInt:      "read_int",
+	harnessLong:     "read_int",
+	harnessDouble:   "read_double",
+	harnessBool:     "read_bool",
+	harnessString:   "read_string",
+	harnessListNode: "read_list",
+	harnessTreeNode: "read_tree",
+}
+
+const rubyPrelude = `require 'json'
+require 'set'
+
+class ListNode
+  attr_accessor :val, :next
+  def initialize(val = 0, _next = nil)
+    @val = val
+    @next = _next
+  end
+end
+
+class TreeNode
+  attr_accessor :val, :left, :right
+  def initialize(val = 0, left = nil, right = nil)
+    @val = val
+    @left = left
+    @right = right
+  end
+end
+
+`
+
+const rubyRuntime = `
+
+class HarnessReader
+  def initialize(data)
+    @data = data
+    @pos = 0
+  end
+
+  def token
+    @pos += 1 while @pos < @data.bytesize && @data.getbyte(@pos) <= 32
+    start = @pos
+    @pos += 1 while @pos < @data.bytesize && @data.getbyte(@pos) > 32
+    @data.byteslice(start, @pos - start)
+  end
+
+  def read_int
+    token.to_i
+  end
+
+  def read_double
+    token.to_f
+  end
+
+  def read_bool
+    token == "1"
+  end
+
+  def read_string
+    n = token.to_i
+    start = @pos + 1
+    @pos = start + n
+    @data.byteslice(start, n).force_encoding("UTF-8")
+  end
+
+  def read_array
+    Array.new(token.to_i) { yield }
+  end
+
+  def read_list
+    values = read_array { read_int }
+    head = nil
+    values.reverse_each { |v| head = ListNode.new(v, head) }
+    head
+  end
+
+  def read_tree
+    tokens = read_array { token }
+    return nil if tokens.empty? || tokens[0] == "null"
+    root = TreeNode.new(tokens[0].to_i)
+    queue = [root]
+    i = 1
+    until queue.empty? || i >= tokens.size
+      node = queue.shift
+      if i < tokens.size && tokens[i] != "null"
+        node.left = TreeNode.new(tokens[i].to_i)
+        queue << node.left
+      end
+      i += 1
+      if i < tokens.size && tokens[i] != "null"
+        node.right = TreeNode.new(tokens[i].to_i)
+        queue << node.right
+      end
+      i += 1
+    end
+    root
+  end
+end
+
+def harness_json(value, base, dims)
+  return value.map { |v| harness_json(v, base, dims - 1) } if dims > 0
+  case base
+  when "ListNode"
+    out = []
+    until value.nil?
+      out << value.val
+      value = value.next
+    end
+    out
+  when "TreeNode"
+    out = []
+    queue = [value]
+    until queue.empty?
+      node = queue.shift
+      if node.nil?
+        out << nil
+        next
+      end
+      out << node.val
+      queue << node.left << node.right
+    end
+    out.pop while !out.empty? && out[-1].nil?
+    out
+  when "double" then value.to_f
+  when "int", "long" then value.to_i
+  when "bool" then value ? true : false
+  else value
+  end
+end
+
+$harness_reader = HarnessReader.new(STDIN.binmode.read)
+`
+
+func (rubyHarness) typeName(t valueType) string {
+	return t.typeName(rubyTypes, func(s string) string { return s + "[]" })
+}
+
+func (h rubyHarness) starter(sig *FunctionSignature) string {
+	var b strings.Builder
+	for _, param := range sig.Params {
+		fmt.Fprintf(&b, "# @param {%s} %s\n", h.typeName(param.typ), snakeCase(param.Name))
+	}
+	fmt.Fprintf(&b, "# @return {%s}\n", h.typeName(sig.returnType))
+	fmt.Fprintf(&b, "def %s(%s)\n    \nend\n", snakeCase(sig.Function), strings.Join(sig.paramNames(snakeCase), ", "))
+	return b.String()
+}
+
+func (rubyHarness) driver(sig *FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString(rubyPrelude)
+	b.WriteString(code)
+	b.WriteString(rubyRuntime)
+
+	for i, param := range sig.Params {
+		read := "$harness_reader." + rubyReaders[param.typ.base]
+		for d := 0; d < param.typ.dims; d++ {
+			read = fmt.Sprintf("$harness_reader.read_array { %s }", read)
+		}
+		fmt.Fprintf(&b, "arg%d = %s\n", i, read)
+	}
+	fmt.Fprintf(&b, "result = %s(%s)\n", snakeCase(sig.Function), strings.Join(sig.argNames(), ", "))
+	fmt.Fprintf(&b, "puts %q + JSON.generate(harness_json(result, %q, %d))\n", harnessSentinelLine, sig.returnType.base, sig.returnType.dims)
+	return b.String()
+}
+
+// phpHarness targets LeetCode's "class Solution" style
+type phpHarness struct{}
+
+var phpTypes = map[string]string{
+	harnessInt:      "Integer",
+	harnessLong:     "Integer",
+	harnessDouble:   "Float",
+	harnessBool:     "Boolean",
+	harnessString:   "String",
+	harnessListNode: "ListNode",
+	harnessTreeNode: "TreeNode",
+}
+
+var phpReaders = map[string]string{
+	harnessInt:      "readInt",
+	harnessLong:     "readInt",
+	harnessDouble:   "readDouble",
+	harnessBool:     "readBool",
+	harnessString:   "readString",
+	harnessListNode: "readList",
+	harnessTreeNode: "readTree",
+}
+
+// phpOpenTag matches an opening tag in user code, which the driver supplies
+var phpOpenTag = regexp.MustCompile(`^\s*<\?php`)
+
+const phpPrelude = `<?php
+
+class ListNode {
+    public $val = 0;
+    public $next = null;
+    function __construct($val = 0, $next = null) {
+        $this->val = $val;
+        $this->next = $next;
+    }
+}
+
+class TreeNode {
+    public $val = null;
+    public $left = null;
+    public $right = null;
+    function __construct($val = 0, $left = null, $right = null) {
+        $this->val = $val;
+        $this->left = $left;
+        $this->right = $right;
+    }
+}
+
+`
+
+const phpRuntime = `
+
+class HarnessReader {
+    private $data;
+    private $pos = 0;
+
+    function __construct($data) {
+        $this->data = $data;
+    }
+
+    function token() {
+        $len = strlen($this->data);
+        while ($this->pos < $len && ord($this->data[$this->pos]) <= 32) $this->pos++;
+        $start = $this->pos;
+        while ($this->pos < $len && ord($this->data[$this->pos]) > 32) $this->pos++;
+        return substr($this->data, $start, $this->pos - $start);
+    }
+
+    function readInt() { return intval($this->token()); }
+    function readDouble() { return floatval($this->token()); }
+    function readBool() { return $this->token() === "1"; }
+
+    function readString() {
+        $n = intval($this->token());
+        $start = $this->pos + 1;
+        $this->pos = $start + $n;
+        return (string)substr($this->data, $start, $n);
+    }
+
+    function readArray($read) {
+        $n = intval($this->token());
+        $out = [];
+        for ($i = 0; $i < $n; $i++) $out[] = $read();
+        return $out;
+    }
+
+    function readList() {
+        $values = $this->readArray(function () { return $this->readInt(); });
+        $head = null;
+        for ($i = count($values) - 1; $i >= 0; $i--) $head = new ListNode($values[$i], $head);
+        return $head;
+    }
+
+    function readTree() {
+        $tokens = $this->readArray(function () { return $this->token(); });
+        if (count($tokens) === 0 || $tokens[0] === "null") return null;
+        $root = new TreeNode(intval($tokens[0]));
+        $queue = [$root];
+        $head = 0;
+        $i = 1;
+        while ($head < count($queue) && $i < count($tokens)) {
+            $node = $queue[$head++];
+            if ($i < count($tokens) && $tokens[$i] !== "null") {
+                $node->left = new TreeNode(intval($tokens[$i]));
+                $queue[] = $node->left;
+            }
+            $i++;
+            if ($i < count($tokens) && $tokens[$i] !== "null") {
+                $node->right = new TreeNode(intval($tokens[$i]));
+                $queue[] = $node->right;
+            }
+            $i++;
+        }
+        return $root;
+    }
+}
+
+function harness_json($value, $base, $dims) {
+    if ($dims > 0) {
+        $out = [];
+        foreach ($value as $v) $out[] = harness_json($v, $base, $dims - 1);
+        return $out;
+    }
+    switch ($base) {
+        case "ListNode":
+            $out = [];
+            for ($node = $value; $node !== null; $node = $node->next) $out[] = $node->val;
+            return $out;
+        case "TreeNode":
+            $out = [];
+            $queue = [$value];
+            for ($head = 0; $head < count($queue); $head++) {
+                $node = $queue[$head];
+                if ($node === null) {
+                    $out[] = null;
+                    continue;
+                }
+                $out[] = $node->val;
+                $queue[] = $node->left;
+                $queue[] = $node->right;
+            }
+            while (count($out) > 0 && end($out) === null) array_pop($out);
+            return $out;
+        case "double": return (float)$value;
+        case "int":
+        case "long": return (int)$value;
+        case "bool": return (bool)$value;
+        default: return $value;
+    }
+}
+
+$harnessReader = new HarnessReader(stream_get_contents(STDIN));
+`
+
+func (phpHarness) typeName(t valueType) string {
+	return t.typeName(phpTypes, func(s string) string { return s + "[]" })
+}
+
+func (h phpHarness) starter(sig *FunctionSignature) string {
+	var b strings.Builder
+	b.WriteString("class Solution {\n\n    /**\n")
+	params := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		fmt.Fprintf(&b, "     * @param %s $%s\n", h.typeName(param.typ), param.Name)
+		params[i] = "$" + param.Name
+	}
+	fmt.Fprintf(&b, "     * @return %s\n     */\n", h.typeName(sig.returnType))
+	fmt.Fprintf(&b, "    function %s(%s) {\n        \n    }\n}\n", sig.Function, strings.Join(params, ", "))
+	return b.String()
+}
+
+func (phpHarness) driver(sig *FunctionSignature, code string) string {
+	var b strings.Builder
+	b.WriteString(phpPrelude)
+	b.WriteString(phpOpenTag.ReplaceAllString(code, ""))
+	b.WriteString(phpRuntime)
+
+	args := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		read := fmt.Sprintf("$harnessReader->%s()", phpReaders[param.typ.base])
+		for d := 0; d < param.typ.dims; d++ {
+			read = fmt.Sprintf("$harnessReader->readArray(function () use ($harnessReader) { return %s; })", read)
+		}
+		fmt.Fprintf(&b, "$arg%d = %s;\n", i, read)
+		args[i] = fmt.Sprintf("$arg%d", i)
+	}
+	fmt.Fprintf(&b, "$result = (new Solution())->%s(%s);\n", sig.Function, strings.Join(args, ", "))
+	fmt.Fprintf(&b, "echo %q, json_encode(harness_json($result, %q, %d)), \"\\n\";\n", harnessSentinelLine, sig.returnType.base, sig.returnType.dims)
+	return b.String()
+}
@@ -0,0 +1,451 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// Judge0Executor runs code on a Judge0 CE instance over HTTP
+type Judge0Executor struct {
+	judge0URL    string
+	httpClient   *http.Client
+	batchSize    int
+	pollWorkers  int
+	pollInterval time.Duration
+	languageIDs  map[string]int // language ID -> Judge0 language_id
+}
+
+// Judge0Submission represents a submission to Judge0
+type Judge0Submission struct {
+	SourceCode     string  `json:"source_code"`
+	LanguageID     int     `json:"language_id"`
+	Stdin          string  `json:"stdin,omitempty"`
+	ExpectedOutput string  `json:"expected_output,omitempty"`
+	CPUTimeLimit   float64 `json:"cpu_time_limit,omitempty"`
+	MemoryLimit    int     `json:"memory_limit,omitempty"`
+	WallTimeLimit  float64 `json:"wall_time_limit,omitempty"`
+}
+
+// Judge0Response represents Judge0 API response
+type Judge0Response struct {
+	Token  string `json:"token"`
+	Status struct {
+		ID          int    `json:"id"`
+		Description string `json:"description"`
+	} `json:"status"`
+	Stdout        string `json:"stdout"`
+	Stderr        string `json:"stderr"`
+	CompileOutput string `json:"compile_output"`
+	Message       string `json:"message"`
+	Time          string `json:"time"`
+	Memory        int    `json:"memory"`
+}
+
+// judge0BatchRequest is the body of POST /submissions/batch
+type judge0BatchRequest struct {
+	Submissions []Judge0Submission `json:"submissions"`
+}
+
+// judge0BatchResponse is the body of GET /submissions/batch
+type judge0BatchResponse struct {
+	Submissions []Judge0Response `json:"submissions"`
+}
+
+// judge0ResultFields limits batch polling responses to what we read
+const judge0ResultFields = "token,stdout,stderr,compile_output,message,status,time,memory"
+
+// NewJudge0Executor creates a Judge0-backed executor
+func NewJudge0Executor(cfg config.ExecutorConfig) *Judge0Executor {
+	judge0URL := cfg.Judge0URL
+	if judge0URL == "" {
+		judge0URL = "http://localhost:2358" // Default Judge0 CE URL
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 || batchSize > 20 {
+		batchSize = 20 // Judge0 CE default max_submission_batch_size
+	}
+	pollWorkers := cfg.PollWorkers
+	if pollWorkers <= 0 {
+		pollWorkers = 4
+	}
+	pollInterval := time.Duration(cfg.PollIntervalMs) * time.Millisecond
+	if pollInterval <= 0 {
+		pollInterval = 250 * time.Millisecond
+	}
+
+	languages := cfg.Languages
+	if len(languages) == 0 {
+		languages = config.DefaultLanguages()
+	}
+	languageIDs := make(map[string]int)
+	for id, lang := range languages {
+		if lang.Enabled && lang.Judge0ID > 0 {
+			languageIDs[strings.ToLower(id)] = lang.Judge0ID
+		}
+	}
+
+	return &Judge0Executor{
+		judge0URL:    judge0URL,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		batchSize:    batchSize,
+		pollWorkers:  pollWorkers,
+		pollInterval: pollInterval,
+		languageIDs:  languageIDs,
+	}
+}
+
+// Name returns the backend name
+func (je *Judge0Executor) Name() string {
+	return "judge0"
+}
+
+// SupportsLanguage reports whether Judge0 has a language ID for the language
+func (je *Judge0Executor) SupportsLanguage(language string) bool {
+	return je.getLanguageID(language) != 0
+}
+
+// IsAvailable checks if the Judge0 instance is reachable
+func (je *Judge0Executor) IsAvailable() bool {
+	resp, err := je.httpClient.Get(fmt.Sprintf("%s/about", je.judge0URL))
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// Execute submits code to Judge0 and waits for the result
+func (je *Judge0Executor) Execute(req ExecutionRequest) (*ExecutionOutput, error) {
+	languageID := je.getLanguageID(req.Language)
+	if languageID == 0 {
+		return nil, fmt.Errorf("unsupported language: %s", req.Language)
+	}
+
+	submission := Judge0Submission{
+		SourceCode:    req.SourceCode,
+		LanguageID:    languageID,
+		Stdin:         req.Stdin,
+		CPUTimeLimit:  req.Limits.CPUTime,
+		MemoryLimit:   req.Limits.MemoryKB,
+		WallTimeLimit: req.Limits.WallTime,
+	}
+
+	jsonData, err := json.Marshal(submission)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/submissions?wait=true", je.judge0URL)
+	resp, err := je.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("judge0 request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("judge0 error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var judge0Resp Judge0Response
+	if err := json.NewDecoder(resp.Body).Decode(&judge0Resp); err != nil {
+		return nil, fmt.Errorf("failed to parse judge0 response: %w", err)
+	}
+
+	return je.toOutput(&judge0Resp), nil
+}
+
+// ExecuteBatch submits all requests through Judge0's batch API and polls the
+// returned tokens concurrently. Requests are split into chunks of batchSize;
+// each chunk is submitted and then polled by one worker of a bounded pool.
+func (je *Judge0Executor) ExecuteBatch(reqs []ExecutionRequest) ([]*ExecutionOutput, error) {
+	return je.ExecuteBatchWithProgress(reqs, nil)
+}
+
+// ExecuteBatchWithProgress is ExecuteBatch reporting each submission as it
+// is queued on Judge0, starts processing and finishes. The first chunk that
+// fails fails the batch: chunks not yet submitted are skipped and the
+// others stop polling.
+func (je *Judge0Executor) ExecuteBatchWithProgress(reqs []ExecutionRequest, onProgress func(index int, state RunState, output *ExecutionOutput)) ([]*ExecutionOutput, error) {
+	if onProgress == nil {
+		onProgress = func(int, RunState, *ExecutionOutput) {}
+	}
+	outputs := make([]*ExecutionOutput, len(reqs))
+	if len(reqs) == 0 {
+		return outputs, nil
+	}
+
+	type chunk struct{ start, end int }
+	chunks := make(chan chunk)
+	errs := make(chan error, je.pollWorkers)
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+
+	var wg sync.WaitGroup
+	for w := 0; w < je.pollWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				start := c.start
+				results, err := je.runChunk(reqs[c.start:c.end], stop, func(i int, state RunState, output *ExecutionOutput) {
+					onProgress(start+i, state, output)
+				})
+				if errors.Is(err, errJudge0Cancelled) {
+					continue
+				}
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					stopOnce.Do(func() { close(stop) })
+					continue
+				}
+				copy(outputs[c.start:c.end], results)
+			}
+		}()
+	}
+
+dispatch:
+	for start := 0; start < len(reqs); start += je.batchSize {
+		end := start + je.batchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		select {
+		case chunks <- chunk{start: start, end: end}:
+		case <-stop:
+			break dispatch
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+	}
+	return outputs, nil
+}
+
+// errJudge0Cancelled is returned by chunks skipped or abandoned because
+// another chunk of the batch failed
+var errJudge0Cancelled = errors.New("judge0 batch cancelled")
+
+// judge0PollRetries is how many failed polls in a row a chunk retries
+// before failing
+const judge0PollRetries = 3
+
+// runChunk submits one batch and polls its tokens until all are finished or
+// stop is closed. Failed polls are retried with exponential backoff, since
+// Judge0 keeps the results of submissions that are already queued.
+func (je *Judge0Executor) runChunk(reqs []ExecutionRequest, stop <-chan struct{}, onProgress func(index int, state RunState, output *ExecutionOutput)) ([]*ExecutionOutput, error) {
+	select {
+	case <-stop:
+		return nil, errJudge0Cancelled
+	default:
+	}
+
+	tokens, err := je.submitBatch(reqs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tokens {
+		onProgress(i, RunQueued, nil)
+	}
+
+	// Allow every submission in the chunk its full wall time, plus queueing
+	var wallBudget float64
+	for _, req := range reqs {
+		wallBudget += req.Limits.WallTime
+	}
+	deadline := time.Now().Add(time.Duration(wallBudget*float64(time.Second)) + 60*time.Second)
+
+	outputs := make([]*ExecutionOutput, len(tokens))
+	running := make([]bool, len(tokens))
+	pending := make(map[string]int, len(tokens))
+	for i, token := range tokens {
+		pending[token] = i
+	}
+
+	failures := 0
+	for len(pending) > 0 {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("judge0 batch timed out with %d submissions pending", len(pending))
+		}
+		select {
+		case <-stop:
+			return nil, errJudge0Cancelled
+		case <-time.After(je.pollInterval << failures):
+		}
+
+		remaining := make([]string, 0, len(pending))
+		for token := range pending {
+			remaining = append(remaining, token)
+		}
+		responses, err := je.fetchBatch(remaining)
+		if err != nil {
+			if failures++; failures > judge0PollRetries {
+				return nil, err
+			}
+			continue
+		}
+		failures = 0
+
+		for i := range responses {
+			resp := &responses[i]
+			idx, ok := pending[resp.Token]
+			if !ok || resp.Status.ID == 1 { // In Queue
+				continue
+			}
+			if resp.Status.ID == 2 { // Processing
+				if !running[idx] {
+					running[idx] = true
+					onProgress(idx, RunRunning, nil)
+				}
+				continue
+			}
+			outputs[idx] = je.toOutput(resp)
+			delete(pending, resp.Token)
+			onProgress(idx, RunFinished, outputs[idx])
+		}
+	}
+
+	return outputs, nil
+}
+
+// submitBatch creates submissions via POST /submissions/batch
+func (je *Judge0Executor) submitBatch(reqs []ExecutionRequest) ([]string, error) {
+	batch := judge0BatchRequest{Submissions: make([]Judge0Submission, len(reqs))}
+	for i, req := range reqs {
+		languageID := je.getLanguageID(req.Language)
+		if languageID == 0 {
+			return nil, fmt.Errorf("unsupported language: %s", req.Language)
+		}
+		batch.Submissions[i] = Judge0Submission{
+			SourceCode:    req.SourceCode,
+			LanguageID:    languageID,
+			Stdin:         req.Stdin,
+			CPUTimeLimit:  req.Limits.CPUTime,
+			MemoryLimit:   req.Limits.MemoryKB,
+			WallTimeLimit: req.Limits.WallTime,
+		}
+	}
+
+	jsonData, err := json.Marshal(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/submissions/batch?base64_encoded=false", je.judge0URL)
+	resp, err := je.httpClient.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("judge0 batch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("judge0 error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	// Each entry is either {"token": "..."} or a validation error object
+	var created []map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to parse judge0 batch response: %w", err)
+	}
+	if len(created) != len(reqs) {
+		return nil, fmt.Errorf("judge0 returned %d tokens for %d submissions", len(created), len(reqs))
+	}
+
+	tokens := make([]string, len(created))
+	for i, entry := range created {
+		token, ok := entry["token"].(string)
+		if !ok || token == "" {
+			return nil, fmt.Errorf("judge0 rejected submission %d: %v", i+1, entry)
+		}
+		tokens[i] = token
+	}
+	return tokens, nil
+}
+
+// fetchBatch polls submission state via GET /submissions/batch
+func (je *Judge0Executor) fetchBatch(tokens []string) ([]Judge0Response, error) {
+	url := fmt.Sprintf("%s/submissions/batch?tokens=%s&base64_encoded=false&fields=%s",
+		je.judge0URL, strings.Join(tokens, ","), judge0ResultFields)
+	resp, err := je.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("judge0 poll failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("judge0 error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	var batch judge0BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("failed to parse judge0 poll response: %w", err)
+	}
+	return batch.Submissions, nil
+}
+
+// toOutput converts a Judge0 response into a normalized execution output
+func (je *Judge0Executor) toOutput(resp *Judge0Response) *ExecutionOutput {
+	output := &ExecutionOutput{
+		Verdict:       je.mapStatus(resp.Status.ID),
+		Stdout:        resp.Stdout,
+		Stderr:        resp.Stderr,
+		CompileOutput: resp.CompileOutput,
+		MemoryKB:      resp.Memory,
+		Message:       resp.Message,
+	}
+	if output.Message == "" && output.Verdict != VerdictAccepted {
+		output.Message = resp.Status.Description
+	}
+
+	// Time comes as a string like "0.001" (seconds)
+	if resp.Time != "" {
+		var execTime float64
+		fmt.Sscanf(resp.Time, "%f", &execTime)
+		output.TimeMs = execTime * 1000
+	}
+
+	return output
+}
+
+// mapStatus maps Judge0 status IDs to verdicts
+func (je *Judge0Executor) mapStatus(statusID int) Verdict {
+	switch statusID {
+	case 3: // Accepted
+		return VerdictAccepted
+	case 4: // Wrong Answer
+		return VerdictWrongAnswer
+	case 5: // Time Limit Exceeded
+		return VerdictTimeLimitExceeded
+	case 6: // Compilation Error
+		return VerdictCompilationError
+	case 7, 8, 9, 10, 11, 12: // Runtime Error (SIGSEGV, SIGXFSZ, SIGFPE, SIGABRT, NZEC, Other)
+		return VerdictRuntimeError
+	default: // 13 Internal Error, 14 Exec Format Error
+		return VerdictInternalError
+	}
+}
+
+// getLanguageID maps a language to its configured Judge0 language ID, or 0
+// when the language is not available on Judge0
+func (je *Judge0Executor) getLanguageID(language string) int {
+	return je.languageIDs[canonicalLanguage(language)]
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// Language is a programming language code can be submitted in
+type Language struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	Version        string   `json:"version"`
+	Extension      string   `json:"extension"`
+	TimeMultiplier float64  `json:"time_multiplier"`
+	StarterCode    string   `json:"starter_code"`
+	Aliases        []string `json:"aliases,omitempty"`
+	// FunctionHarness reports whether LeetCode-style problems with a
+	// function signature can be solved in the language
+	FunctionHarness bool `json:"function_harness"`
+}
+
+// LanguageRegistry holds the enabled language definitions from
+// configuration and resolves names and aliases to them
+type LanguageRegistry struct {
+	languages map[string]Language
+	names     map[string]string // lowercased ID or alias -> ID
+}
+
+// NewLanguageRegistry builds a registry from language configuration,
+// falling back to config.DefaultLanguages when none is configured
+func NewLanguageRegistry(cfg map[string]config.LanguageConfig) *LanguageRegistry {
+	if len(cfg) == 0 {
+		cfg = config.DefaultLanguages()
+	}
+
+	registry := &LanguageRegistry{
+		languages: make(map[string]Language),
+		names:     make(map[string]string),
+	}
+	for id, lang := range cfg {
+		if !lang.Enabled {
+			continue
+		}
+		id = strings.ToLower(id)
+		multiplier := lang.TimeMultiplier
+		if multiplier <= 0 {
+			multiplier = 1
+		}
+		_, harness := harnessLanguages[id]
+
+		registry.languages[id] = Language{
+			ID:              id,
+			Name:            lang.Name,
+			Version:         lang.Version,
+			Extension:       lang.Extension,
+			TimeMultiplier:  multiplier,
+			StarterCode:     lang.StarterCode,
+			Aliases:         lang.Aliases,
+			FunctionHarness: harness,
+		}
+		registry.names[id] = id
+		for _, alias := range lang.Aliases {
+			registry.names[strings.ToLower(alias)] = id
+		}
+	}
+	return registry
+}
+
+// Lookup resolves a language ID or alias, case-insensitively
+func (r *LanguageRegistry) Lookup(name string) (Language, bool) {
+	id, ok := r.names[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Language{}, false
+	}
+	return r.languages[id], true
+}
+
+// List returns every enabled language ordered by display name
+func (r *LanguageRegistry) List() []Language {
+	languages := make([]Language, 0, len(r.languages))
+	for _, lang := range r.languages {
+		languages = append(languages, lang)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		return strings.ToLower(languages[i].Name) < strings.ToLower(languages[j].Name)
+	})
+	return languages
+}
+
+// scaleLimits applies a language's time multiplier to resource limits
+func (lang Language) scaleLimits(limits ResourceLimits) ResourceLimits {
+	limits.CPUTime *= lang.TimeMultiplier
+	limits.WallTime *= lang.TimeMultiplier
+	return limits
+}
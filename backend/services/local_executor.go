@@ -0,0 +1,406 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// errSandboxUnsupported is returned on platforms without sandbox support
+var errSandboxUnsupported = errors.New("local sandbox is only supported on linux")
+
+// LocalExecutor runs code on the API host inside a Linux sandbox
+// (namespaces, rlimits and a seccomp filter) instead of calling Judge0
+type LocalExecutor struct {
+	workDir        string
+	maxProcesses   int
+	maxOutputBytes int
+	compileLimits  ResourceLimits
+	toolchains     map[string]localToolchain
+	// rootfsPaths are the host paths visible inside the sandbox, and
+	// searchPath the directories of PATH among them
+	rootfsPaths []string
+	searchPath  []string
+	// cgroupParent holds a cgroup per run when memory is capped by cgroup
+	cgroupParent string
+}
+
+// localToolchain describes how to build and run one language locally
+type localToolchain struct {
+	sourceFile string
+	compile    []string // empty for interpreted languages
+	run        []string
+	env        []string
+	// unboundedAddressSpace disables RLIMIT_AS for runtimes that reserve large
+	// virtual mappings up front (JVM, V8, Go). Their heaps are sized from the
+	// limit through run and env, where {memory_mb} is replaced by it; the
+	// cgroup, when configured, enforces it and max RSS is checked otherwise.
+	unboundedAddressSpace bool
+}
+
+// sandboxSpec is a single sandboxed process invocation
+type sandboxSpec struct {
+	Root           string   `json:"root"`  // empty mount point for the sandbox root
+	Binds          []string `json:"binds"` // host paths bound read-only into the root
+	Dir            string   `json:"dir"`
+	Argv           []string `json:"argv"`
+	Env            []string `json:"env"`
+	CPUSeconds     uint64   `json:"cpu_seconds"`
+	AddressSpaceKB uint64   `json:"address_space_kb"` // 0 = unlimited
+	StackKB        uint64   `json:"stack_kb"`
+	FileSizeBytes  uint64   `json:"file_size_bytes"`
+	MaxProcesses   uint64   `json:"max_processes"`
+	MaxOpenFiles   uint64   `json:"max_open_files"`
+
+	// Applied by the parent when the process is started in a cgroup
+	CgroupParent string `json:"-"`
+	MemoryKB     uint64 `json:"-"`
+}
+
+// sandboxResult is what the parent observes about a sandboxed process
+type sandboxResult struct {
+	ExitCode        int
+	Signaled        bool
+	Signal          string
+	WallTimeout     bool
+	OutputTruncated bool
+	OOMKilled       bool // killed by the cgroup's memory.max
+	Stdout          string
+	Stderr          string
+	CPUTimeMs       float64
+	MaxRSSKB        int
+}
+
+// defaultRootfsPaths are the host paths a sandbox sees when none are
+// configured: the toolchains and the dynamic loader configuration. Missing
+// paths are skipped.
+var defaultRootfsPaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+	"/etc/java-*-openjdk",
+}
+
+// defaultToolchains are the per-language build and run commands
+func defaultToolchains() map[string]localToolchain {
+	return map[string]localToolchain{
+		"python": {
+			sourceFile: "main.py",
+			run:        []string{"python3", "main.py"},
+		},
+		"javascript": {
+			sourceFile:            "main.js",
+			run:                   []string{"node", "--max-old-space-size={memory_mb}", "main.js"},
+			unboundedAddressSpace: true,
+		},
+		"java": {
+			sourceFile:            "Main.java",
+			compile:               []string{"javac", "Main.java"},
+			run:                   []string{"java", "-Xmx{memory_mb}m", "-Xss64m", "-XX:+UseSerialGC", "Main"},
+			unboundedAddressSpace: true,
+		},
+		"cpp": {
+			sourceFile: "main.cpp",
+			compile:    []string{"g++", "-O2", "-std=c++17", "-o", "main", "main.cpp"},
+			run:        []string{"./main"},
+		},
+		"c": {
+			sourceFile: "main.c",
+			compile:    []string{"gcc", "-O2", "-std=c11", "-o", "main", "main.c", "-lm"},
+			run:        []string{"./main"},
+		},
+		"go": {
+			sourceFile:            "main.go",
+			compile:               []string{"go", "build", "-o", "main", "main.go"},
+			run:                   []string{"./main"},
+			env:                   []string{"GOMEMLIMIT={memory_mb}MiB"},
+			unboundedAddressSpace: true,
+		},
+		"rust": {
+			sourceFile: "main.rs",
+			compile:    []string{"rustc", "-O", "-o", "main", "main.rs"},
+			run:        []string{"./main"},
+		},
+		"ruby": {
+			sourceFile: "main.rb",
+			run:        []string{"ruby", "main.rb"},
+		},
+		"php": {
+			sourceFile: "main.php",
+			run:        []string{"php", "main.php"},
+		},
+	}
+}
+
+// NewLocalExecutor creates a sandboxed local executor
+func NewLocalExecutor(cfg config.LocalExecutorConfig) (*LocalExecutor, error) {
+	if !sandboxSupported() {
+		return nil, errSandboxUnsupported
+	}
+
+	workDir := cfg.WorkDir
+	if workDir == "" {
+		workDir = os.TempDir()
+	}
+	if err := os.MkdirAll(workDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create executor work dir: %w", err)
+	}
+
+	maxProcesses := cfg.MaxProcesses
+	if maxProcesses <= 0 {
+		maxProcesses = 64
+	}
+	maxOutputBytes := cfg.MaxOutputBytes
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = 1 << 20
+	}
+	compileTimeout := float64(cfg.CompileTimeout)
+	if compileTimeout <= 0 {
+		compileTimeout = 30
+	}
+	rootfsPatterns := cfg.RootfsPaths
+	if len(rootfsPatterns) == 0 {
+		rootfsPatterns = defaultRootfsPaths
+	}
+	var rootfsPaths []string
+	for _, pattern := range rootfsPatterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rootfs path %q: %w", pattern, err)
+		}
+		rootfsPaths = append(rootfsPaths, matches...)
+	}
+	if cfg.CgroupParent != "" {
+		if err := prepareCgroupParent(cfg.CgroupParent); err != nil {
+			return nil, fmt.Errorf("invalid cgroup parent: %w", err)
+		}
+	} else {
+		log.Printf("Warning: local executor has no cgroup_parent; JavaScript, Java and Go memory is capped by runtime flags only")
+	}
+
+	return &LocalExecutor{
+		workDir:        workDir,
+		maxProcesses:   maxProcesses,
+		maxOutputBytes: maxOutputBytes,
+		compileLimits: ResourceLimits{
+			CPUTime:  compileTimeout,
+			WallTime: compileTimeout,
+			MemoryKB: 2 * 1024 * 1024,
+		},
+		toolchains:   defaultToolchains(),
+		rootfsPaths:  rootfsPaths,
+		searchPath:   sandboxSearchPath(os.Getenv("PATH"), rootfsPaths),
+		cgroupParent: cfg.CgroupParent,
+	}, nil
+}
+
+// Name returns the backend name
+func (le *LocalExecutor) Name() string {
+	return "local"
+}
+
+// SupportsLanguage reports whether a toolchain is configured for the language
+func (le *LocalExecutor) SupportsLanguage(language string) bool {
+	_, ok := le.toolchains[canonicalLanguage(language)]
+	return ok
+}
+
+// IsAvailable checks that the sandbox can be used on this host
+func (le *LocalExecutor) IsAvailable() bool {
+	return sandboxSupported()
+}
+
+// Execute compiles (if needed) and runs the program inside the sandbox
+func (le *LocalExecutor) Execute(req ExecutionRequest) (*ExecutionOutput, error) {
+	toolchain, ok := le.toolchains[canonicalLanguage(req.Language)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language: %s", req.Language)
+	}
+
+	runDir, err := os.MkdirTemp(le.workDir, "algoholic-run-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox dir: %w", err)
+	}
+	defer os.RemoveAll(runDir)
+
+	// The program works in dir; root is only a mount point for the
+	// sandbox's root filesystem and stays empty on the host
+	dir, root := filepath.Join(runDir, "work"), filepath.Join(runDir, "root")
+	for _, d := range []string{dir, root} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create sandbox dir: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, toolchain.sourceFile), []byte(req.SourceCode), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write source: %w", err)
+	}
+
+	// Compile step
+	if len(toolchain.compile) > 0 {
+		spec, err := le.buildSpec(root, dir, toolchain.compile, nil, le.compileLimits, true)
+		if err != nil {
+			return nil, err
+		}
+		res, err := runSandboxed(spec, "", le.compileLimits.WallTime, le.maxOutputBytes)
+		if err != nil {
+			return nil, err
+		}
+		if res.WallTimeout || res.Signaled || res.ExitCode != 0 {
+			compileOutput := strings.TrimSpace(res.Stderr + "\n" + res.Stdout)
+			if res.WallTimeout {
+				compileOutput = "compilation timed out"
+			}
+			return &ExecutionOutput{
+				Verdict:       VerdictCompilationError,
+				CompileOutput: compileOutput,
+			}, nil
+		}
+	}
+
+	// Run step
+	spec, err := le.buildSpec(root, dir, toolchain.run, toolchain.env, req.Limits, toolchain.unboundedAddressSpace)
+	if err != nil {
+		return nil, err
+	}
+	res, err := runSandboxed(spec, req.Stdin, req.Limits.WallTime, le.maxOutputBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return le.toOutput(res, req.Limits), nil
+}
+
+// buildSpec resolves the command and applies resource limits
+func (le *LocalExecutor) buildSpec(root, dir string, argv, env []string, limits ResourceLimits, unboundedAddressSpace bool) (sandboxSpec, error) {
+	memory := strings.NewReplacer("{memory_mb}", strconv.Itoa(limits.MemoryKB/1024))
+	resolved := make([]string, len(argv))
+	for i, arg := range argv {
+		resolved[i] = memory.Replace(arg)
+	}
+	if !strings.HasPrefix(argv[0], "./") {
+		path, err := le.lookPath(argv[0])
+		if err != nil {
+			return sandboxSpec{}, fmt.Errorf("toolchain not installed: %s", argv[0])
+		}
+		resolved[0] = path
+	}
+
+	// CPU limit is rounded up; the exact value is enforced from rusage
+	cpuSeconds := uint64(limits.CPUTime)
+	if float64(cpuSeconds) < limits.CPUTime {
+		cpuSeconds++
+	}
+
+	spec := sandboxSpec{
+		Root:  root,
+		Binds: le.rootfsPaths,
+		Dir:   dir,
+		Argv:  resolved,
+		Env: []string{
+			"PATH=" + strings.Join(le.searchPath, string(os.PathListSeparator)),
+			"HOME=" + dir,
+			"TMPDIR=" + dir,
+			"GOCACHE=" + filepath.Join(dir, ".gocache"),
+			"LANG=C.UTF-8",
+		},
+		CPUSeconds:    cpuSeconds,
+		StackKB:       uint64(limits.MemoryKB),
+		FileSizeBytes: uint64(le.maxOutputBytes) * 16,
+		MaxProcesses:  uint64(le.maxProcesses),
+		MaxOpenFiles:  64,
+		CgroupParent:  le.cgroupParent,
+		MemoryKB:      uint64(limits.MemoryKB),
+	}
+	for _, variable := range env {
+		spec.Env = append(spec.Env, memory.Replace(variable))
+	}
+	if !unboundedAddressSpace {
+		spec.AddressSpaceKB = uint64(limits.MemoryKB)
+	}
+
+	return spec, nil
+}
+
+// lookPath finds a toolchain binary in the directories of PATH that are
+// visible inside the sandbox
+func (le *LocalExecutor) lookPath(name string) (string, error) {
+	for _, dir := range le.searchPath {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && info.Mode()&0o111 != 0 {
+			return path, nil
+		}
+	}
+	return "", exec.ErrNotFound
+}
+
+// sandboxSearchPath keeps the PATH entries that lie below one of the
+// sandbox's rootfs paths; the others do not exist inside it
+func sandboxSearchPath(hostPath string, rootfsPaths []string) []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(hostPath) {
+		for _, path := range rootfsPaths {
+			if dir == path || strings.HasPrefix(dir, path+"/") {
+				dirs = append(dirs, dir)
+				break
+			}
+		}
+	}
+	return dirs
+}
+
+// toOutput derives a verdict from what the sandbox observed
+func (le *LocalExecutor) toOutput(res *sandboxResult, limits ResourceLimits) *ExecutionOutput {
+	output := &ExecutionOutput{
+		Verdict:  VerdictAccepted,
+		Stdout:   res.Stdout,
+		Stderr:   res.Stderr,
+		TimeMs:   res.CPUTimeMs,
+		MemoryKB: res.MaxRSSKB,
+	}
+
+	switch {
+	case res.WallTimeout:
+		output.Verdict = VerdictTimeLimitExceeded
+		output.Message = "wall time limit exceeded"
+	case res.CPUTimeMs > limits.CPUTime*1000 || res.Signal == "SIGXCPU":
+		output.Verdict = VerdictTimeLimitExceeded
+		output.Message = "cpu time limit exceeded"
+	case res.OOMKilled || res.MaxRSSKB > limits.MemoryKB:
+		output.Verdict = VerdictMemoryLimitExceeded
+		output.Message = "memory limit exceeded"
+	case (res.ExitCode != 0 || res.Signaled) && isOutOfMemory(res.Stderr):
+		// A program that prints these words or recovers from a failed
+		// allocation has not run out of memory
+		output.Verdict = VerdictMemoryLimitExceeded
+		output.Message = "memory limit exceeded"
+	case res.OutputTruncated:
+		output.Verdict = VerdictRuntimeError
+		output.Message = "output limit exceeded"
+	case res.Signaled:
+		output.Verdict = VerdictRuntimeError
+		output.Message = "killed by " + res.Signal
+	case res.ExitCode != 0:
+		output.Verdict = VerdictRuntimeError
+		output.Message = fmt.Sprintf("exit code %d", res.ExitCode)
+	}
+
+	return output
+}
+
+// isOutOfMemory detects runtimes reporting a failed allocation under RLIMIT_AS
+func isOutOfMemory(stderr string) bool {
+	markers := []string{"MemoryError", "std::bad_alloc", "out of memory", "OutOfMemoryError"}
+	for _, marker := range markers {
+		if strings.Contains(stderr, marker) {
+			return true
+		}
+	}
+	return false
+}
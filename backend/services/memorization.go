@@ -0,0 +1,215 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+// Memorization is flagged on correct answers from three signals, weighted
+// so that neither a fast repeat (it may just be mastery) nor a canonical
+// copy (a well-learned answer can match word for word) is enough alone:
+//   - a fast repeat: the question was answered before, and this time in a
+//     fraction of the expected time
+//   - a variant gap: the user fails most variants of the question, other
+//     questions of the same type on the same problem or concept
+//   - a canonical copy: the answer is the explanation, accepted answer or
+//     reference solution word for word
+
+const (
+	// fastRepeatRatio is the share of the expected time under which a
+	// repeat answer is fast
+	fastRepeatRatio = 0.25
+	// minVariantAttempts is how many variant answers a variant gap needs
+	minVariantAttempts = 2
+	// variantGapAccuracy is the variant accuracy below which there is a gap
+	variantGapAccuracy = 0.5
+	// minCopyWords is how long a text answer must be to count as a copy
+	minCopyWords = 8
+	// variantAttemptWindow is how many of the user's latest variant answers
+	// a variant gap looks at
+	variantAttemptWindow = 50
+	// memorizationThreshold is the signal weight that flags an answer
+	memorizationThreshold = 0.5
+)
+
+// memorizationWeights weigh each memorization signal
+var memorizationWeights = map[string]float64{
+	MemorizationFastRepeat:    0.3,
+	MemorizationVariantGap:    0.5,
+	MemorizationCanonicalCopy: 0.4,
+}
+
+// Memorization signals
+const (
+	MemorizationFastRepeat    = "fast_repeat"
+	MemorizationVariantGap    = "variant_gap"
+	MemorizationCanonicalCopy = "canonical_copy"
+)
+
+// MemorizationCheck is the memorization signals found in an answer
+type MemorizationCheck struct {
+	Signals           []string `json:"signals"`
+	Score             float64  `json:"score"`
+	ShowsMemorization bool     `json:"shows_memorization"`
+}
+
+// MemorizationDetector looks for signs that a correct answer was memorized
+// rather than understood
+type MemorizationDetector struct {
+	db *gorm.DB
+}
+
+// NewMemorizationDetector creates a new memorization detector
+func NewMemorizationDetector(db *gorm.DB) *MemorizationDetector {
+	return &MemorizationDetector{db: db}
+}
+
+// Check checks an attempt at a question before it is recorded. Wrong
+// answers show no memorization.
+func (d *MemorizationDetector) Check(question *models.Question, attempt *models.UserAttempt) *MemorizationCheck {
+	check := &MemorizationCheck{Signals: []string{}}
+	if !attempt.IsCorrect {
+		return check
+	}
+
+	if attempt.AttemptNumber > 1 && isFastAnswer(question, attempt.TimeTakenSeconds) {
+		check.Signals = append(check.Signals, MemorizationFastRepeat)
+	}
+	if d.hasVariantGap(question, attempt.UserID) {
+		check.Signals = append(check.Signals, MemorizationVariantGap)
+	}
+	if d.copiesCanonical(question, attempt.UserAnswer) {
+		check.Signals = append(check.Signals, MemorizationCanonicalCopy)
+	}
+
+	for _, signal := range check.Signals {
+		check.Score += memorizationWeights[signal]
+	}
+	if check.Score > 1 {
+		check.Score = 1
+	}
+	check.ShowsMemorization = check.Score >= memorizationThreshold
+	return check
+}
+
+// isFastAnswer reports whether an answer took a small fraction of the
+// question's estimated or, failing that, average time
+func isFastAnswer(question *models.Question, seconds int) bool {
+	var expected float64
+	switch {
+	case question.EstimatedTimeSeconds != nil && *question.EstimatedTimeSeconds > 0:
+		expected = float64(*question.EstimatedTimeSeconds)
+	case question.AverageTimeSeconds != nil && *question.AverageTimeSeconds > 0:
+		expected = *question.AverageTimeSeconds
+	default:
+		return false
+	}
+	return float64(seconds) < fastRepeatRatio*expected
+}
+
+// hasVariantGap reports whether the user mostly fails the variants of a
+// question: other questions of the same type that share its problem or one
+// of its related concepts. Only the user's latest variant answers count.
+func (d *MemorizationDetector) hasVariantGap(question *models.Question, userID int) bool {
+	// Concepts are matched loosely in SQL, where text[] columns can only be
+	// compared portably as text, and exactly below
+	var scope []string
+	var args []interface{}
+	if question.ProblemID != nil {
+		scope = append(scope, "q.problem_id = ?")
+		args = append(args, *question.ProblemID)
+	}
+	concepts := make(map[string]bool, len(question.RelatedConcepts))
+	for _, concept := range question.RelatedConcepts {
+		concepts[strings.ToLower(concept)] = true
+		scope = append(scope, "LOWER(CAST(q.related_concepts AS TEXT)) LIKE ?")
+		args = append(args, "%"+strings.ToLower(concept)+"%")
+	}
+	if len(scope) == 0 {
+		return false
+	}
+
+	var attempts []struct {
+		ProblemID       *int
+		RelatedConcepts models.StringArray `gorm:"type:text[]"`
+		IsCorrect       bool
+		Score           *float64
+	}
+	err := d.db.Table("user_attempts ua").
+		Select("q.problem_id, q.related_concepts, ua.is_correct, ua.score").
+		Joins("JOIN questions q ON q.question_id = ua.question_id").
+		Where("ua.user_id = ? AND q.question_type = ? AND q.question_id <> ?", userID, question.QuestionType, question.QuestionID).
+		Where("("+strings.Join(scope, " OR ")+")", args...).
+		Order("ua.attempted_at DESC").
+		Limit(variantAttemptWindow).
+		Scan(&attempts).Error
+	if err != nil {
+		log.Printf("Warning: failed to load variant attempts of question %d: %v", question.QuestionID, err)
+		return false
+	}
+
+	count, total := 0, 0.0
+	for _, a := range attempts {
+		variant := question.ProblemID != nil && a.ProblemID != nil && *a.ProblemID == *question.ProblemID
+		for _, concept := range a.RelatedConcepts {
+			variant = variant || concepts[strings.ToLower(concept)]
+		}
+		if !variant {
+			continue
+		}
+		count++
+		if a.Score != nil {
+			total += *a.Score
+		} else {
+			total += binaryScore(a.IsCorrect)
+		}
+	}
+	return count >= minVariantAttempts && total/float64(count) < variantGapAccuracy
+}
+
+// copiesCanonical reports whether an answer repeats the question's
+// explanation, an accepted answer or the problem's reference solution word
+// for word. Short text answers are not copies: there is only one way to
+// write "O(n)".
+func (d *MemorizationDetector) copiesCanonical(question *models.Question, answer models.JSONB) bool {
+	if code, ok := answer["code"].(string); ok && question.ProblemID != nil {
+		var problem models.Problem
+		if err := d.db.Select("reference_solution").First(&problem, *question.ProblemID).Error; err != nil {
+			return false
+		}
+		var reference ReferenceSolution
+		if len(problem.ReferenceSolution) == 0 || decodeJSONB(problem.ReferenceSolution, &reference) != nil {
+			return false
+		}
+		return reference.Code != "" && strings.Join(strings.Fields(code), "") == strings.Join(strings.Fields(reference.Code), "")
+	}
+
+	text, ok := answer["answer"].(string)
+	if !ok || len(strings.Fields(text)) < minCopyWords {
+		return false
+	}
+	canonical := []string{question.Explanation}
+	switch v := question.CorrectAnswer["answer"].(type) {
+	case string:
+		canonical = append(canonical, v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				canonical = append(canonical, s)
+			}
+		}
+	}
+
+	validator := NewTextValidator()
+	normalized := validator.NormalizeText(text)
+	for _, c := range canonical {
+		if c != "" && validator.NormalizeText(c) == normalized {
+			return true
+		}
+	}
+	return false
+}
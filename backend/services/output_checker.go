@@ -0,0 +1,409 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Checker names accepted in a question's correct_answer.checker
+const (
+	CheckerExact          = "exact"
+	CheckerTokens         = "tokens"
+	CheckerFloat          = "float"
+	CheckerUnorderedLines = "unordered_lines"
+	CheckerSet            = "set"
+	CheckerCustom         = "custom"
+	CheckerJSON           = "json"
+)
+
+// Default tolerances for the float checker
+const (
+	defaultAbsEpsilon = 1e-6
+	defaultRelEpsilon = 1e-6
+)
+
+// OutputChecker decides whether a program's output is an acceptable answer.
+//
+// Check returns an error only when the checker itself could not run (for
+// example a custom checker program that fails to compile); a wrong answer is
+// reported as ok == false with an optional message.
+type OutputChecker interface {
+	Name() string
+	Check(input, expected, got string) (ok bool, message string, err error)
+}
+
+// CheckerSpec is the parsed form of correct_answer.checker. It may be given
+// either as a plain name ("tokens") or as an object:
+//
+//	{"type": "float", "abs_epsilon": 1e-6, "rel_epsilon": 1e-9}
+//	{"type": "custom", "language": "python", "source": "..."}
+type CheckerSpec struct {
+	Type       string   `json:"type"`
+	AbsEpsilon *float64 `json:"abs_epsilon,omitempty"`
+	RelEpsilon *float64 `json:"rel_epsilon,omitempty"`
+	Language   string   `json:"language,omitempty"`
+	Source     string   `json:"source,omitempty"`
+}
+
+// ParseCheckerSpec converts a raw JSON value into a CheckerSpec. A nil value
+// selects the exact checker.
+func ParseCheckerSpec(raw interface{}) (CheckerSpec, error) {
+	switch v := raw.(type) {
+	case nil:
+		return CheckerSpec{Type: CheckerExact}, nil
+	case string:
+		return CheckerSpec{Type: v}, nil
+	case map[string]interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return CheckerSpec{}, fmt.Errorf("invalid checker: %w", err)
+		}
+		var spec CheckerSpec
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return CheckerSpec{}, fmt.Errorf("invalid checker: %w", err)
+		}
+		if spec.Type == "" {
+			spec.Type = CheckerExact
+		}
+		return spec, nil
+	default:
+		return CheckerSpec{}, fmt.Errorf("invalid checker format: %T", raw)
+	}
+}
+
+// NewChecker builds the checker described by a raw correct_answer.checker
+// value. Custom checkers run on this executor's backend.
+func (ce *CodeExecutor) NewChecker(raw interface{}) (OutputChecker, error) {
+	spec, err := ParseCheckerSpec(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(spec.Type) {
+	case CheckerExact:
+		return exactChecker{}, nil
+	case CheckerTokens:
+		return tokenChecker{}, nil
+	case CheckerFloat:
+		checker := floatChecker{absEpsilon: defaultAbsEpsilon, relEpsilon: defaultRelEpsilon}
+		if spec.AbsEpsilon != nil {
+			checker.absEpsilon = *spec.AbsEpsilon
+		}
+		if spec.RelEpsilon != nil {
+			checker.relEpsilon = *spec.RelEpsilon
+		}
+		if checker.absEpsilon < 0 || checker.relEpsilon < 0 {
+			return nil, fmt.Errorf("float checker epsilons must be non-negative")
+		}
+		return checker, nil
+	case CheckerUnorderedLines:
+		return unorderedLinesChecker{}, nil
+	case CheckerSet:
+		return setChecker{}, nil
+	case CheckerJSON:
+		checker := jsonChecker{absEpsilon: defaultAbsEpsilon, relEpsilon: defaultRelEpsilon}
+		if spec.AbsEpsilon != nil {
+			checker.absEpsilon = *spec.AbsEpsilon
+		}
+		if spec.RelEpsilon != nil {
+			checker.relEpsilon = *spec.RelEpsilon
+		}
+		if checker.absEpsilon < 0 || checker.relEpsilon < 0 {
+			return nil, fmt.Errorf("json checker epsilons must be non-negative")
+		}
+		return checker, nil
+	case CheckerCustom:
+		if strings.TrimSpace(spec.Source) == "" {
+			return nil, fmt.Errorf("custom checker requires source")
+		}
+		language := spec.Language
+		if language == "" {
+			language = "python"
+		}
+		id, err := ce.resolveLanguage(language)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported checker language: %s", language)
+		}
+		return &customChecker{
+			executor: ce.executor,
+			limits:   ce.limitsFor(id),
+			language: id,
+			source:   spec.Source,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown checker: %s", spec.Type)
+	}
+}
+
+// exactChecker compares whole outputs after trimming and normalizing line endings
+type exactChecker struct{}
+
+func (exactChecker) Name() string { return CheckerExact }
+
+func (exactChecker) Check(_, expected, got string) (bool, string, error) {
+	return normalizeOutput(got) == normalizeOutput(expected), "", nil
+}
+
+// tokenChecker compares whitespace-separated tokens, ignoring layout
+type tokenChecker struct{}
+
+func (tokenChecker) Name() string { return CheckerTokens }
+
+func (tokenChecker) Check(_, expected, got string) (bool, string, error) {
+	want, have := strings.Fields(expected), strings.Fields(got)
+	if len(want) != len(have) {
+		return false, fmt.Sprintf("expected %d tokens, got %d", len(want), len(have)), nil
+	}
+	for i := range want {
+		if want[i] != have[i] {
+			return false, fmt.Sprintf("token %d differs: expected %q, got %q", i+1, want[i], have[i]), nil
+		}
+	}
+	return true, "", nil
+}
+
+// floatChecker compares tokens, treating numeric tokens as equal when they are
+// within an absolute or relative epsilon
+type floatChecker struct {
+	absEpsilon float64
+	relEpsilon float64
+}
+
+func (floatChecker) Name() string { return CheckerFloat }
+
+func (fc floatChecker) Check(_, expected, got string) (bool, string, error) {
+	want, have := strings.Fields(expected), strings.Fields(got)
+	if len(want) != len(have) {
+		return false, fmt.Sprintf("expected %d tokens, got %d", len(want), len(have)), nil
+	}
+	for i := range want {
+		if want[i] == have[i] {
+			continue
+		}
+		wantNum, errWant := strconv.ParseFloat(want[i], 64)
+		haveNum, errHave := strconv.ParseFloat(have[i], 64)
+		if errWant != nil || errHave != nil || math.IsNaN(haveNum) {
+			return false, fmt.Sprintf("token %d differs: expected %q, got %q", i+1, want[i], have[i]), nil
+		}
+		diff := math.Abs(wantNum - haveNum)
+		if diff > fc.absEpsilon && diff > fc.relEpsilon*math.Abs(wantNum) {
+			return false, fmt.Sprintf("value %d differs: expected %s, got %s", i+1, want[i], have[i]), nil
+		}
+	}
+	return true, "", nil
+}
+
+// unorderedLinesChecker accepts the expected lines in any order
+type unorderedLinesChecker struct{}
+
+func (unorderedLinesChecker) Name() string { return CheckerUnorderedLines }
+
+func (unorderedLinesChecker) Check(_, expected, got string) (bool, string, error) {
+	want, have := sortedLines(expected), sortedLines(got)
+	if len(want) != len(have) {
+		return false, fmt.Sprintf("expected %d lines, got %d", len(want), len(have)), nil
+	}
+	for i := range want {
+		if want[i] != have[i] {
+			return false, "lines differ", nil
+		}
+	}
+	return true, "", nil
+}
+
+// setChecker accepts any output containing exactly the expected set of
+// tokens, ignoring order and duplicates
+type setChecker struct{}
+
+func (setChecker) Name() string { return CheckerSet }
+
+func (setChecker) Check(_, expected, got string) (bool, string, error) {
+	want, have := tokenSet(expected), tokenSet(got)
+	for token := range want {
+		if !have[token] {
+			return false, fmt.Sprintf("missing %q", token), nil
+		}
+	}
+	for token := range have {
+		if !want[token] {
+			return false, fmt.Sprintf("unexpected %q", token), nil
+		}
+	}
+	return true, "", nil
+}
+
+// jsonChecker parses both outputs as JSON and compares the values. Integers
+// must match exactly; other numbers may differ by an absolute or relative
+// epsilon. It is the default for function-signature questions, whose drivers
+// print the return value as JSON.
+type jsonChecker struct {
+	absEpsilon float64
+	relEpsilon float64
+}
+
+func (jsonChecker) Name() string { return CheckerJSON }
+
+func (jc jsonChecker) Check(_, expected, got string) (bool, string, error) {
+	want, err := decodeJSONOutput(expected)
+	if err != nil {
+		return false, "", fmt.Errorf("expected output is not valid JSON: %w", err)
+	}
+	have, err := decodeJSONOutput(got)
+	if err != nil {
+		return false, "output is not valid JSON", nil
+	}
+	if !jc.equal(want, have) {
+		return false, "values differ", nil
+	}
+	return true, "", nil
+}
+
+// equal compares decoded JSON values
+func (jc jsonChecker) equal(want, have interface{}) bool {
+	switch w := want.(type) {
+	case json.Number:
+		h, ok := have.(json.Number)
+		if !ok {
+			return false
+		}
+		wantInt, errWant := strconv.ParseInt(w.String(), 10, 64)
+		haveInt, errHave := strconv.ParseInt(h.String(), 10, 64)
+		if errWant == nil && errHave == nil {
+			return wantInt == haveInt
+		}
+		wantNum, errWant := w.Float64()
+		haveNum, errHave := h.Float64()
+		if errWant != nil || errHave != nil {
+			return false
+		}
+		diff := math.Abs(wantNum - haveNum)
+		return diff <= jc.absEpsilon || diff <= jc.relEpsilon*math.Abs(wantNum)
+	case []interface{}:
+		h, ok := have.([]interface{})
+		if !ok || len(w) != len(h) {
+			return false
+		}
+		for i := range w {
+			if !jc.equal(w[i], h[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		h, ok := have.(map[string]interface{})
+		if !ok || len(w) != len(h) {
+			return false
+		}
+		for key, value := range w {
+			if other, ok := h[key]; !ok || !jc.equal(value, other) {
+				return false
+			}
+		}
+		return true
+	default:
+		return want == have
+	}
+}
+
+// decodeJSONOutput parses output holding exactly one JSON value
+func decodeJSONOutput(output string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+// customChecker runs a checker program in the executor.
+//
+// The program receives a JSON object on stdin with the keys "input",
+// "expected" and "output", and must exit normally after printing "AC" or "WA"
+// as its first token; the rest of stdout is returned as feedback. Any other
+// outcome is treated as a broken checker, so a crashing checker never
+// silently rejects answers.
+type customChecker struct {
+	executor Executor
+	limits   ResourceLimits
+	language string
+	source   string
+}
+
+func (cc *customChecker) Name() string { return CheckerCustom }
+
+func (cc *customChecker) Check(input, expected, got string) (bool, string, error) {
+	stdin, err := json.Marshal(map[string]string{
+		"input":    input,
+		"expected": expected,
+		"output":   got,
+	})
+	if err != nil {
+		return false, "", err
+	}
+
+	output, err := cc.executor.Execute(ExecutionRequest{
+		SourceCode: cc.source,
+		Language:   cc.language,
+		Stdin:      string(stdin),
+		Limits:     cc.limits,
+	})
+	if err != nil {
+		return false, "", fmt.Errorf("checker execution failed: %w", err)
+	}
+
+	if output.Verdict != VerdictAccepted {
+		return false, "", fmt.Errorf("checker failed (%s): %s", output.Verdict, output.ErrorMessage())
+	}
+
+	verdict, message, _ := strings.Cut(strings.TrimSpace(output.Stdout), "\n")
+	verdict, feedback, _ := strings.Cut(strings.TrimSpace(verdict), " ")
+	message = strings.TrimSpace(feedback + "\n" + message)
+	switch strings.ToUpper(verdict) {
+	case "AC":
+		return true, message, nil
+	case "WA":
+		return false, message, nil
+	default:
+		return false, "", fmt.Errorf("checker printed no verdict: %q", output.Stdout)
+	}
+}
+
+// normalizeOutput trims and normalizes output for comparison
+func normalizeOutput(output string) string {
+	// Normalize line endings
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+
+	// Trim leading/trailing whitespace
+	return strings.TrimSpace(output)
+}
+
+// sortedLines splits output into trimmed, non-empty lines in sorted order
+func sortedLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(normalizeOutput(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// tokenSet returns the distinct whitespace-separated tokens of the output
+func tokenSet(output string) map[string]bool {
+	set := make(map[string]bool)
+	for _, token := range strings.Fields(output) {
+		set[token] = true
+	}
+	return set
+}
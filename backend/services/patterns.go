@@ -0,0 +1,1138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+// Submitted code is parsed (Go with go/parser, Python with the parser in
+// python_parser.go) into a small language-neutral tree of statements and
+// expressions, and the pattern rules below look for the shape each technique
+// leaves in code: a loop narrowing lo..hi around a midpoint, a queue popped
+// from the front while it is refilled, a recursive call between a push and a
+// pop, and so on. Rules are heuristics over structure, not names, so renamed
+// variables are still recognized.
+
+// Algorithm patterns the analyzer recognizes. Names match the pattern names
+// used by Problem.PrimaryPattern where one exists.
+const (
+	PatternBinarySearch       = "Binary Search"
+	PatternTwoPointers        = "Two Pointers"
+	PatternSlidingWindow      = "Sliding Window"
+	PatternBFS                = "BFS"
+	PatternDFS                = "DFS"
+	PatternBacktracking       = "Backtracking"
+	PatternMemoization        = "Memoization"
+	PatternDynamicProgramming = "Dynamic Programming"
+	PatternHeap               = "Heap"
+	PatternStack              = "Stack"
+	PatternLinkedList         = "Linked List"
+	PatternHashTable          = "Hash Table"
+	PatternSorting            = "Sorting"
+	PatternRecursion          = "Recursion"
+)
+
+// patternOrder lists patterns from most to least specific; results are
+// reported in this order
+var patternOrder = []string{
+	PatternBinarySearch, PatternTwoPointers, PatternSlidingWindow, PatternBFS,
+	PatternDFS, PatternBacktracking, PatternMemoization, PatternDynamicProgramming,
+	PatternHeap, PatternStack, PatternLinkedList, PatternHashTable, PatternSorting,
+	PatternRecursion,
+}
+
+// patternAliases are other names a problem's pattern may be given that a
+// detected pattern satisfies
+var patternAliases = map[string][]string{
+	PatternBFS:                {"Breadth-First Search", "Graph Traversal", "Tree Traversal"},
+	PatternDFS:                {"Depth-First Search", "Graph Traversal", "Tree Traversal"},
+	PatternBacktracking:       {"DFS", "Depth-First Search"},
+	PatternMemoization:        {"Dynamic Programming", "DP"},
+	PatternDynamicProgramming: {"DP"},
+	PatternHeap:               {"Priority Queue"},
+	PatternHashTable:          {"Hash Map", "Hashing", "Hash Set"},
+	PatternStack:              {"Monotonic Stack"},
+	PatternTwoPointers:        {"Fast and Slow Pointers"},
+}
+
+// ErrPatternLanguageUnsupported is returned for languages without a parser
+var ErrPatternLanguageUnsupported = errors.New("pattern detection is not supported for this language")
+
+// DetectedPattern is one technique found in code, with the line it was first
+// seen on and what gave it away
+type DetectedPattern struct {
+	Name     string `json:"name"`
+	Line     int    `json:"line"`
+	Evidence string `json:"evidence"`
+}
+
+// PatternAnalysis is the result of DetectPatterns
+type PatternAnalysis struct {
+	Language string            `json:"language"`
+	Patterns []DetectedPattern `json:"patterns"`
+}
+
+// Names returns the detected pattern names, most specific first
+func (a *PatternAnalysis) Names() []string {
+	names := make([]string, len(a.Patterns))
+	for i, pattern := range a.Patterns {
+		names[i] = pattern.Name
+	}
+	return names
+}
+
+// DetectPatterns statically analyzes code for algorithm patterns. Go and
+// Python are supported; code that does not parse returns an error.
+func DetectPatterns(code, language string) (*PatternAnalysis, error) {
+	var stmts []*patStmt
+	var err error
+	switch strings.ToLower(language) {
+	case "go", "golang":
+		language = "go"
+		stmts, err = parseGoPatterns(code)
+	case "python", "python3", "py":
+		language = "python"
+		stmts, err = parsePythonPatterns(code)
+	default:
+		return nil, ErrPatternLanguageUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := newPatternDetector()
+	d.detect(stmts)
+	return &PatternAnalysis{Language: language, Patterns: d.results()}, nil
+}
+
+// PatternFeedback compares the patterns used in code with the problem's
+// intended one
+type PatternFeedback struct {
+	Detected     []string `json:"detected"`
+	Intended     string   `json:"intended"`
+	UsedIntended bool     `json:"used_intended"`
+	Message      string   `json:"message"`
+}
+
+// ComparePatterns builds feedback on detected patterns against a problem's
+// primary pattern, mentioning its secondary patterns when one of those was
+// used instead. It returns nil when the problem has no primary pattern.
+func ComparePatterns(detected []string, problem *models.Problem) *PatternFeedback {
+	if problem == nil || problem.PrimaryPattern == nil || strings.TrimSpace(*problem.PrimaryPattern) == "" {
+		return nil
+	}
+	intended := *problem.PrimaryPattern
+
+	feedback := &PatternFeedback{Detected: detected, Intended: intended}
+	if feedback.Detected == nil {
+		feedback.Detected = []string{}
+	}
+	used := joinPatterns(detected)
+
+	for _, name := range detected {
+		if !patternSatisfies(name, intended) {
+			continue
+		}
+		feedback.UsedIntended = true
+		if normalizePatternName(name) == normalizePatternName(intended) {
+			feedback.Message = fmt.Sprintf("You used %s, the intended pattern.", name)
+		} else {
+			feedback.Message = fmt.Sprintf("You used %s, which fits the intended pattern, %s.", name, intended)
+		}
+		return feedback
+	}
+
+	switch {
+	case len(detected) == 0:
+		feedback.Message = fmt.Sprintf("No known pattern was detected; the intended pattern is %s.", intended)
+	case usesAny(detected, problem.SecondaryPatterns):
+		feedback.Message = fmt.Sprintf("You used %s, which also works here, but the intended pattern is %s.", used, intended)
+	default:
+		feedback.Message = fmt.Sprintf("You used %s, the intended pattern is %s.", used, intended)
+	}
+	return feedback
+}
+
+func usesAny(detected []string, patterns []string) bool {
+	for _, name := range detected {
+		for _, pattern := range patterns {
+			if patternSatisfies(name, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// patternSatisfies reports whether a detected pattern is the given pattern
+// name or one of its aliases
+func patternSatisfies(detected, pattern string) bool {
+	want := normalizePatternName(pattern)
+	if normalizePatternName(detected) == want {
+		return true
+	}
+	for _, alias := range patternAliases[detected] {
+		if normalizePatternName(alias) == want {
+			return true
+		}
+	}
+	return false
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+func normalizePatternName(name string) string {
+	return nonAlphanumeric.ReplaceAllString(strings.ToLower(name), "")
+}
+
+// joinPatterns lists names as "A", "A and B" or "A, B and C"
+func joinPatterns(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// The language-neutral tree rules work on
+
+type patStmtKind int
+
+const (
+	patExprStmt patStmtKind = iota
+	patAssign
+	patReturn
+	patIf
+	patLoop
+	patFunc
+	patBlock // any other statement with a body: class, try, with, switch
+)
+
+type patStmt struct {
+	kind    patStmtKind
+	line    int
+	op      string     // patAssign: "=" or an augmented operator such as "+="
+	targets []*patExpr // patAssign
+	value   *patExpr   // assigned value, expression, return value or condition
+	body    []*patStmt
+	orelse  []*patStmt // patIf
+
+	// patLoop: for-in targets or variables declared in a for clause, and
+	// the for-in iterable. While loops have neither.
+	loopVars []string
+	iter     *patExpr
+
+	// patFunc
+	name       string
+	params     []string
+	receiver   string // method receiver, such as Python's self
+	decorators []*patExpr
+}
+
+type patExprKind int
+
+const (
+	patName patExprKind = iota
+	patNum
+	patStr
+	patConst // booleans and None/nil
+	patCall  // x(args)
+	patAttr  // x.name
+	patIndex // x[y]
+	patSlice // x[y:z], either bound may be nil
+	patBinary
+	patUnary
+	patList // list and slice literals; sized for make([]T, n)
+	patTuple
+	patDict // dict and map literals, make(map...)
+	patSet
+	patComp   // comprehension of kind name (list, set, dict or gen) over args
+	patLambda // function literal; body holds its statements
+	patCond   // x if y else z
+	patOther
+)
+
+type patExpr struct {
+	kind    patExprKind
+	line    int
+	name    string // identifier, attribute, literal text or comprehension kind
+	op      string // patBinary and patUnary operator; comparisons and and/or included
+	x, y, z *patExpr
+	args    []*patExpr
+	body    []*patStmt
+	sized   bool
+}
+
+// walkPat visits statements depth first, including those in nested
+// functions and lambdas, and every expression in them. Returning false from
+// stmt skips a statement and everything in it.
+func walkPat(stmts []*patStmt, stmt func(*patStmt) bool, expr func(*patExpr)) {
+	for _, s := range stmts {
+		if s == nil || (stmt != nil && !stmt(s)) {
+			continue
+		}
+		for _, e := range s.targets {
+			walkPatExpr(e, stmt, expr)
+		}
+		walkPatExpr(s.value, stmt, expr)
+		walkPatExpr(s.iter, stmt, expr)
+		for _, e := range s.decorators {
+			walkPatExpr(e, stmt, expr)
+		}
+		walkPat(s.body, stmt, expr)
+		walkPat(s.orelse, stmt, expr)
+	}
+}
+
+func walkPatExpr(e *patExpr, stmt func(*patStmt) bool, expr func(*patExpr)) {
+	if e == nil {
+		return
+	}
+	if expr != nil {
+		expr(e)
+	}
+	walkPatExpr(e.x, stmt, expr)
+	walkPatExpr(e.y, stmt, expr)
+	walkPatExpr(e.z, stmt, expr)
+	for _, arg := range e.args {
+		walkPatExpr(arg, stmt, expr)
+	}
+	walkPat(e.body, stmt, expr)
+}
+
+// exprsIn returns every expression in stmts
+func exprsIn(stmts []*patStmt) []*patExpr {
+	var exprs []*patExpr
+	walkPat(stmts, nil, func(e *patExpr) { exprs = append(exprs, e) })
+	return exprs
+}
+
+// stmtsIn returns every statement in stmts, nested ones included
+func stmtsIn(stmts []*patStmt) []*patStmt {
+	var all []*patStmt
+	walkPat(stmts, func(s *patStmt) bool {
+		all = append(all, s)
+		return true
+	}, nil)
+	return all
+}
+
+// refersTo reports whether e mentions the name
+func refersTo(e *patExpr, name string) bool {
+	found := false
+	walkPatExpr(e, nil, func(e *patExpr) {
+		if e.kind == patName && e.name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// rootName returns the variable at the root of x, x.a, x[i] or x[i:j]
+func rootName(e *patExpr) string {
+	for e != nil {
+		switch e.kind {
+		case patName:
+			return e.name
+		case patAttr, patIndex, patSlice:
+			e = e.x
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+func isName(e *patExpr, name string) bool {
+	return e != nil && e.kind == patName && e.name == name
+}
+
+func isNum(e *patExpr, text string) bool {
+	return e != nil && e.kind == patNum && e.name == text
+}
+
+// callee splits a call into the root name of its receiver, if any, and the
+// called name: f(x) is ("", "f"), heapq.heappush(h, x) is ("heapq",
+// "heappush") and q.append(x) is ("q", "append")
+func callee(e *patExpr) (recv, name string) {
+	if e == nil || e.kind != patCall || e.x == nil {
+		return "", ""
+	}
+	switch e.x.kind {
+	case patName:
+		return "", e.x.name
+	case patAttr:
+		return rootName(e.x.x), e.x.name
+	}
+	return "", ""
+}
+
+// assignPairs pairs the targets of an assignment with their values,
+// element-wise for a, b = x, y
+func assignPairs(s *patStmt) [][2]*patExpr {
+	if s.kind != patAssign {
+		return nil
+	}
+	if len(s.targets) > 1 && s.value != nil && s.value.kind == patTuple && len(s.value.args) == len(s.targets) {
+		pairs := make([][2]*patExpr, len(s.targets))
+		for i, target := range s.targets {
+			pairs[i] = [2]*patExpr{target, s.value.args[i]}
+		}
+		return pairs
+	}
+	if len(s.targets) == 1 && s.targets[0].kind == patTuple && s.value != nil && s.value.kind == patTuple &&
+		len(s.targets[0].args) == len(s.value.args) {
+		pairs := make([][2]*patExpr, len(s.value.args))
+		for i, target := range s.targets[0].args {
+			pairs[i] = [2]*patExpr{target, s.value.args[i]}
+		}
+		return pairs
+	}
+	pairs := make([][2]*patExpr, len(s.targets))
+	for i, target := range s.targets {
+		pairs[i] = [2]*patExpr{target, s.value}
+	}
+	return pairs
+}
+
+// stepOf returns +1 or -1 when s moves the named variable up or down by a
+// constant, as in i += 1, i = i - 1 or i++, and 0 otherwise
+func stepOf(s *patStmt, name string) int {
+	for _, pair := range assignPairs(s) {
+		target, value := pair[0], pair[1]
+		if !isName(target, name) || value == nil {
+			continue
+		}
+		switch s.op {
+		case "+=":
+			if value.kind == patNum {
+				return 1
+			}
+		case "-=":
+			if value.kind == patNum {
+				return -1
+			}
+		case "=":
+			if value.kind != patBinary || (value.op != "+" && value.op != "-") {
+				continue
+			}
+			if isName(value.x, name) && value.y.kind == patNum {
+				if value.op == "+" {
+					return 1
+				}
+				return -1
+			}
+			if value.op == "+" && isName(value.y, name) && value.x.kind == patNum {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// pushedTo returns the variable s adds an element to, if any: q.append(x),
+// s.add(x), q = append(q, x) and the like
+func pushedTo(s *patStmt) string {
+	switch s.kind {
+	case patExprStmt:
+		switch recv, name := callee(s.value); name {
+		case "append", "appendleft", "add", "put", "push", "extend", "PushBack", "PushFront", "insert":
+			return recv
+		}
+	case patAssign:
+		for _, pair := range assignPairs(s) {
+			target, value := pair[0], pair[1]
+			if target.kind != patName || value == nil {
+				continue
+			}
+			if recv, name := callee(value); recv == "" && name == "append" && len(value.args) > 0 && isName(value.args[0], target.name) {
+				return target.name
+			}
+		}
+	}
+	return ""
+}
+
+// popEnd is which end of a sequence an element is removed from
+type popEnd int
+
+const (
+	popNone popEnd = iota
+	popFront
+	popBack
+)
+
+// poppedFrom returns the variable s removes an element from and which end:
+// q.popleft(), q.pop(0), s.pop(), q = q[1:], s = s[:len(s)-1], ...
+func poppedFrom(s *patStmt) (string, popEnd) {
+	var exprs []*patExpr
+	for _, e := range s.targets {
+		exprs = append(exprs, e)
+	}
+	if s.kind == patExprStmt || s.kind == patAssign || s.kind == patReturn || s.kind == patIf || (s.kind == patLoop && s.iter == nil) {
+		exprs = append(exprs, s.value)
+	}
+	for _, root := range exprs {
+		name, end := "", popNone
+		walkPatExpr(root, nil, func(e *patExpr) {
+			if end != popNone {
+				return
+			}
+			recv, method := callee(e)
+			switch {
+			case method == "popleft", method == "get" && len(e.args) == 0 && recv != "":
+				name, end = recv, popFront
+			case method == "pop" && len(e.args) == 0 && recv != "":
+				name, end = recv, popBack
+			case method == "pop" && len(e.args) == 1 && isNum(e.args[0], "0"):
+				name, end = recv, popFront
+			case method == "Remove" && len(e.args) == 1:
+				if _, which := callee(e.args[0]); which == "Front" {
+					name, end = recv, popFront
+				} else if which == "Back" {
+					name, end = recv, popBack
+				}
+			}
+		})
+		if end != popNone {
+			return name, end
+		}
+	}
+
+	// Reslicing in Go: q = q[1:] and s = s[:len(s)-1]
+	for _, pair := range assignPairs(s) {
+		target, value := pair[0], pair[1]
+		if target.kind != patName || value == nil || value.kind != patSlice || !isName(value.x, target.name) {
+			continue
+		}
+		if isNum(value.y, "1") && value.z == nil {
+			return target.name, popFront
+		}
+		if value.y == nil && value.z != nil && value.z.kind == patBinary && value.z.op == "-" && isNum(value.z.y, "1") {
+			if recv, name := callee(value.z.x); recv == "" && name == "len" {
+				return target.name, popBack
+			}
+		}
+	}
+	return "", popNone
+}
+
+// isAllocation reports whether e creates a sized table: [0] * n,
+// [[0] * m for _ in range(n)], make([]int, n), [False for _ in s], ...
+func isAllocation(e *patExpr) bool {
+	if e == nil {
+		return false
+	}
+	switch e.kind {
+	case patList:
+		return e.sized
+	case patComp:
+		return e.name == "list"
+	case patBinary:
+		return e.op == "*" && (e.x.kind == patList || e.y.kind == patList)
+	case patCall:
+		recv, name := callee(e)
+		return recv == "" && name == "list" && len(e.args) == 1 && isAllocation(e.args[0])
+	}
+	return false
+}
+
+// isHashAllocation reports whether e creates a dict, set or counter
+func isHashAllocation(e *patExpr) bool {
+	if e == nil {
+		return false
+	}
+	switch e.kind {
+	case patDict, patSet:
+		return true
+	case patComp:
+		return e.name == "dict" || e.name == "set"
+	case patCall:
+		switch recv, name := callee(e); name {
+		case "dict", "set", "frozenset", "defaultdict", "Counter", "OrderedDict":
+			return recv == "" || recv == "collections"
+		}
+	}
+	return false
+}
+
+// patternDetector applies the pattern rules to a parsed program
+type patternDetector struct {
+	found map[string]DetectedPattern
+
+	memoTables  map[string]bool
+	queueNames  map[string]bool // BFS queues and DFS stacks
+	undoneNames map[string]bool // backtracking state
+}
+
+func newPatternDetector() *patternDetector {
+	return &patternDetector{
+		found:       make(map[string]DetectedPattern),
+		memoTables:  make(map[string]bool),
+		queueNames:  make(map[string]bool),
+		undoneNames: make(map[string]bool),
+	}
+}
+
+// add records a pattern, keeping the earliest evidence for it
+func (d *patternDetector) add(name string, line int, evidence string) {
+	if existing, ok := d.found[name]; ok && existing.Line <= line {
+		return
+	}
+	d.found[name] = DetectedPattern{Name: name, Line: line, Evidence: evidence}
+}
+
+func (d *patternDetector) results() []DetectedPattern {
+	patterns := []DetectedPattern{}
+	for _, name := range patternOrder {
+		if pattern, ok := d.found[name]; ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+func (d *patternDetector) detect(stmts []*patStmt) {
+	all := stmtsIn(stmts)
+
+	for _, s := range all {
+		switch s.kind {
+		case patFunc:
+			d.detectFunction(s)
+		case patLoop:
+			d.detectLoop(s)
+		}
+	}
+	d.detectLibraryCalls(stmts)
+	d.detectTables(all)
+	d.detectStacks(all)
+}
+
+// detectLibraryCalls finds patterns implemented by library calls
+func (d *patternDetector) detectLibraryCalls(stmts []*patStmt) {
+	walkPat(stmts, nil, func(e *patExpr) {
+		recv, name := callee(e)
+		switch {
+		case recv == "heapq" || (recv == "" && strings.HasPrefix(name, "heap")),
+			recv == "heap" && (name == "Push" || name == "Pop" || name == "Init" || name == "Fix"),
+			name == "PriorityQueue":
+			d.add(PatternHeap, e.line, fmt.Sprintf("uses a heap (%s)", name))
+		case recv == "bisect" || (recv == "" && (strings.HasPrefix(name, "bisect") || strings.HasPrefix(name, "insort"))),
+			recv == "sort" && strings.HasPrefix(name, "Search"),
+			recv == "slices" && strings.HasPrefix(name, "BinarySearch"):
+			d.add(PatternBinarySearch, e.line, fmt.Sprintf("calls %s", name))
+		case recv == "" && name == "sorted",
+			recv != "" && recv != "sort" && recv != "slices" && name == "sort",
+			recv == "sort" && (name == "Ints" || name == "Strings" || name == "Float64s" || name == "Slice" || name == "SliceStable" || name == "Sort" || name == "Stable"),
+			recv == "slices" && strings.HasPrefix(name, "Sort"):
+			d.add(PatternSorting, e.line, fmt.Sprintf("sorts with %s", name))
+		}
+	})
+}
+
+// detectFunction finds recursion and the patterns built on it
+func (d *patternDetector) detectFunction(fn *patStmt) {
+	for _, decorator := range fn.decorators {
+		target := decorator
+		if target.kind == patCall {
+			target = target.x
+		}
+		if (isName(target, "cache") || isName(target, "lru_cache")) ||
+			(target.kind == patAttr && (target.name == "cache" || target.name == "lru_cache")) {
+			d.add(PatternMemoization, fn.line, fmt.Sprintf("%s is cached with @%s", fn.name, target.name))
+		}
+	}
+	if fn.name == "" {
+		return
+	}
+
+	isSelfCall := func(e *patExpr) bool {
+		if e.kind != patCall || e.x == nil {
+			return false
+		}
+		if isName(e.x, fn.name) {
+			return true
+		}
+		return e.x.kind == patAttr && e.x.name == fn.name &&
+			(isName(e.x.x, "self") || (fn.receiver != "" && isName(e.x.x, fn.receiver)))
+	}
+
+	// Recursive calls, and whether each is inside a loop
+	var calls []*patExpr
+	inLoop := map[*patExpr]bool{}
+	var visit func(stmts []*patStmt, loops int)
+	visit = func(stmts []*patStmt, loops int) {
+		for _, s := range stmts {
+			if s.kind == patFunc && s.name == fn.name {
+				continue // shadowed
+			}
+			depth := loops
+			if s.kind == patLoop {
+				depth++
+			}
+			for _, e := range append(append([]*patExpr{s.value, s.iter}, s.targets...), s.decorators...) {
+				walkPatExpr(e, nil, func(e *patExpr) {
+					if isSelfCall(e) {
+						calls = append(calls, e)
+						inLoop[e] = depth > 0
+					}
+				})
+			}
+			visit(s.body, depth)
+			visit(s.orelse, depth)
+		}
+	}
+	visit(fn.body, 0)
+	if len(calls) == 0 {
+		return
+	}
+	d.add(PatternRecursion, calls[0].line, fmt.Sprintf("%s calls itself", fn.name))
+
+	for _, call := range calls {
+		hasAttrArg := false
+		for _, arg := range call.args {
+			if arg.kind == patAttr {
+				hasAttrArg = true
+			}
+		}
+		switch {
+		case inLoop[call]:
+			d.add(PatternDFS, call.line, fmt.Sprintf("%s recurses into each neighbor in a loop", fn.name))
+		case hasAttrArg:
+			d.add(PatternDFS, call.line, fmt.Sprintf("%s recurses into child nodes", fn.name))
+		case len(calls) >= 4:
+			d.add(PatternDFS, call.line, fmt.Sprintf("%s recurses in %d directions", fn.name, len(calls)))
+		}
+	}
+
+	d.detectMemoTable(fn)
+	d.detectBacktracking(fn, isSelfCall)
+}
+
+// detectMemoTable finds a recursive function that looks up and stores its
+// results in a table
+func (d *patternDetector) detectMemoTable(fn *patStmt) {
+	looked := map[string]int{}
+	stored := map[string]bool{}
+	for _, s := range stmtsIn(fn.body) {
+		for _, pair := range assignPairs(s) {
+			if target := pair[0]; target.kind == patIndex {
+				stored[rootName(target)] = true
+			}
+		}
+		// Go: if v, ok := memo[key]; ok
+		if s.kind == patAssign && len(s.targets) == 2 && s.value != nil && s.value.kind == patIndex {
+			looked[rootName(s.value)] = s.line
+		}
+	}
+	walkPat(fn.body, nil, func(e *patExpr) {
+		if e.kind == patBinary && (e.op == "in" || e.op == "not in") && e.y != nil && e.y.kind == patName {
+			looked[e.y.name] = e.line
+		}
+		if recv, name := callee(e); name == "get" && recv != "" {
+			looked[recv] = e.line
+		}
+	})
+	for name, line := range looked {
+		if name != "" && stored[name] {
+			d.memoTables[name] = true
+			d.add(PatternMemoization, line, fmt.Sprintf("%s caches results in %s", fn.name, name))
+		}
+	}
+}
+
+// detectBacktracking finds state changed before a recursive call and undone
+// after it, in the same block
+func (d *patternDetector) detectBacktracking(fn *patStmt, isSelfCall func(*patExpr) bool) {
+	containsCall := func(s *patStmt) bool {
+		found := false
+		walkPat([]*patStmt{s}, func(s *patStmt) bool { return s.kind != patFunc }, func(e *patExpr) {
+			if isSelfCall(e) {
+				found = true
+			}
+		})
+		return found
+	}
+	marked := func(s *patStmt) string {
+		if name := pushedTo(s); name != "" {
+			return name
+		}
+		if s.kind == patAssign && len(s.targets) == 1 && s.targets[0].kind == patIndex {
+			return rootName(s.targets[0])
+		}
+		return ""
+	}
+	undone := func(s *patStmt, name string) bool {
+		if popped, end := poppedFrom(s); popped == name && end == popBack {
+			return true
+		}
+		if recv, method := callee(s.value); s.kind == patExprStmt && recv == name && (method == "remove" || method == "discard") {
+			return true
+		}
+		return s.kind == patAssign && len(s.targets) == 1 && s.targets[0].kind == patIndex && rootName(s.targets[0]) == name
+	}
+
+	var visit func(stmts []*patStmt)
+	visit = func(stmts []*patStmt) {
+		for i, s := range stmts {
+			if name := marked(s); name != "" {
+				for j := i + 1; j < len(stmts); j++ {
+					if !containsCall(stmts[j]) {
+						continue
+					}
+					for k := j + 1; k < len(stmts); k++ {
+						if undone(stmts[k], name) {
+							d.undoneNames[name] = true
+							d.add(PatternBacktracking, s.line, fmt.Sprintf("%s changes %s, recurses, then undoes the change", fn.name, name))
+						}
+					}
+					break
+				}
+			}
+			if s.kind != patFunc {
+				visit(s.body)
+				visit(s.orelse)
+			}
+		}
+	}
+	visit(fn.body)
+}
+
+// detectLoop finds patterns shaped by a single loop
+func (d *patternDetector) detectLoop(loop *patStmt) {
+	body := stmtsIn(loop.body)
+	isLoopVar := func(name string) bool {
+		for _, v := range loop.loopVars {
+			if v == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Pointer pairs compared in the loop condition
+	var pairs [][2]string
+	walkPatExpr(loop.value, nil, func(e *patExpr) {
+		if e.kind == patBinary && e.x != nil && e.y != nil && e.x.kind == patName && e.y.kind == patName && e.x.name != e.y.name {
+			switch e.op {
+			case "<", "<=", ">", ">=", "!=":
+				pairs = append(pairs, [2]string{e.x.name, e.y.name})
+			}
+		}
+	})
+
+	for _, pair := range pairs {
+		lo, hi := pair[0], pair[1]
+		if mid, ok := midpointOf(body, lo, hi); ok {
+			d.add(PatternBinarySearch, loop.line, fmt.Sprintf("loop narrows %s..%s around %s", lo, hi, mid))
+			continue
+		}
+		loSteps, hiSteps := 0, 0
+		for _, s := range body {
+			loSteps |= stepBits(stepOf(s, lo))
+			hiSteps |= stepBits(stepOf(s, hi))
+		}
+		if (loSteps&1 != 0 && hiSteps&2 != 0) || (loSteps&2 != 0 && hiSteps&1 != 0) {
+			d.add(PatternTwoPointers, loop.line, fmt.Sprintf("%s and %s move toward each other", lo, hi))
+		}
+	}
+
+	// Slow pointer writing behind the loop variable: nums[k] = nums[i]; k += 1
+	if len(loop.loopVars) > 0 {
+		stepped := map[string]bool{}
+		for _, s := range body {
+			for _, pair := range assignPairs(s) {
+				if target := pair[0]; target.kind == patName && !isLoopVar(target.name) && stepOf(s, target.name) == 1 {
+					stepped[target.name] = true
+				}
+			}
+		}
+		for _, s := range body {
+			for _, pair := range assignPairs(s) {
+				target, value := pair[0], pair[1]
+				if target.kind != patIndex || target.y == nil || target.y.kind != patName || !stepped[target.y.name] {
+					continue
+				}
+				for _, v := range loop.loopVars {
+					if refersTo(value, v) {
+						d.add(PatternTwoPointers, s.line, fmt.Sprintf("%s trails %s, writing kept elements", target.y.name, v))
+					}
+				}
+			}
+		}
+	}
+
+	// Fast and slow pointers over a linked list, and list traversal
+	for _, s := range body {
+		for _, pair := range assignPairs(s) {
+			target, value := pair[0], pair[1]
+			if target.kind != patName || value == nil || !isNextAttr(value) {
+				continue
+			}
+			if isNextAttr(value.x) && isName(value.x.x, target.name) {
+				d.add(PatternTwoPointers, s.line, fmt.Sprintf("%s advances two nodes at a time", target.name))
+			} else if isName(value.x, target.name) {
+				d.add(PatternLinkedList, s.line, fmt.Sprintf("%s walks the list", target.name))
+			}
+		}
+	}
+
+	d.detectWindow(loop, body, isLoopVar)
+
+	// Queues and stacks driving the loop
+	for _, name := range loopCollections(loop.value) {
+		pushed, front, back := false, false, false
+		line := loop.line
+		for _, s := range body {
+			if pushedTo(s) == name {
+				pushed = true
+			}
+			if popped, end := poppedFrom(s); popped == name {
+				front = front || end == popFront
+				back = back || end == popBack
+				line = s.line
+			}
+		}
+		switch {
+		case pushed && front:
+			d.queueNames[name] = true
+			d.add(PatternBFS, line, fmt.Sprintf("%s is used as a FIFO queue", name))
+		case pushed && back:
+			d.queueNames[name] = true
+			d.add(PatternDFS, line, fmt.Sprintf("%s is used as an explicit stack", name))
+		}
+	}
+}
+
+// detectWindow finds a window whose right edge is the loop variable and
+// whose left edge follows it
+func (d *patternDetector) detectWindow(loop *patStmt, body []*patStmt, isLoopVar func(string) bool) {
+	if len(loop.loopVars) == 0 {
+		return
+	}
+
+	// The left edge carries over between iterations, so it is never reset
+	// unconditionally inside the loop. It may jump forward under a condition,
+	// as in start = seen[ch] + 1.
+	reset := map[string]bool{}
+	moved := map[string]bool{}
+	for _, s := range body {
+		if s.kind == patLoop {
+			for _, v := range s.loopVars {
+				reset[v] = true
+			}
+		}
+		for _, pair := range assignPairs(s) {
+			if target := pair[0]; target.kind == patName {
+				moved[target.name] = true
+			}
+		}
+	}
+	for _, s := range loop.body {
+		for _, pair := range assignPairs(s) {
+			if target, value := pair[0], pair[1]; target.kind == patName && s.op == "=" && !refersTo(value, target.name) {
+				reset[target.name] = true
+			}
+		}
+	}
+	isLeftEdge := func(name string) bool { return !isLoopVar(name) && !reset[name] }
+
+	// The edge must mark a position: it indexes the input or is measured
+	// against the loop variable
+	indexed := map[string]bool{}
+	for _, e := range exprsIn(loop.body) {
+		if e.kind == patIndex && e.y != nil && e.y.kind == patName {
+			indexed[e.y.name] = true
+		}
+	}
+
+	// Left edge advanced by a nested loop, or used to measure the window
+	for _, inner := range body {
+		if inner.kind != patLoop {
+			continue
+		}
+		for _, s := range stmtsIn(inner.body) {
+			for _, pair := range assignPairs(s) {
+				if target := pair[0]; target.kind == patName && isLeftEdge(target.name) && indexed[target.name] && stepOf(s, target.name) == 1 {
+					d.add(PatternSlidingWindow, inner.line, fmt.Sprintf("window shrinks by advancing %s", target.name))
+				}
+			}
+		}
+	}
+	for _, e := range exprsIn(loop.body) {
+		if e.kind != patBinary || e.op != "-" || e.x == nil || e.y == nil || e.x.kind != patName || e.y.kind != patName {
+			continue
+		}
+		if isLoopVar(e.x.name) && isLeftEdge(e.y.name) && moved[e.y.name] {
+			d.add(PatternSlidingWindow, e.line, fmt.Sprintf("window %s..%s", e.y.name, e.x.name))
+		}
+	}
+
+	// Fixed window: total += a[r]; total -= a[r - k]
+	added := map[string]bool{}
+	for _, s := range body {
+		if s.kind == patAssign && s.op == "+=" && len(s.targets) == 1 && s.targets[0].kind == patName && s.value.kind == patIndex {
+			added[s.targets[0].name] = true
+		}
+	}
+	for _, s := range body {
+		if s.kind != patAssign || s.op != "-=" || len(s.targets) != 1 || !added[rootName(s.targets[0])] || s.value.kind != patIndex {
+			continue
+		}
+		if index := s.value.y; index != nil && index.kind == patBinary && index.op == "-" && index.x.kind == patName && isLoopVar(index.x.name) {
+			d.add(PatternSlidingWindow, s.line, fmt.Sprintf("%s adds the entering element and drops the leaving one", s.targets[0].name))
+		}
+	}
+}
+
+// detectTables finds tables filled from their own earlier entries, and
+// hash tables
+func (d *patternDetector) detectTables(all []*patStmt) {
+	tables := map[string]bool{}
+	for _, s := range all {
+		for _, pair := range assignPairs(s) {
+			if target, value := pair[0], pair[1]; target.kind == patName && isAllocation(value) {
+				tables[target.name] = true
+			}
+		}
+	}
+
+	for _, s := range all {
+		if s.kind != patLoop {
+			continue
+		}
+		for _, inner := range stmtsIn(s.body) {
+			for _, pair := range assignPairs(inner) {
+				target, value := pair[0], pair[1]
+				table := rootName(target)
+				if target.kind != patIndex || !tables[table] || value == nil {
+					continue
+				}
+				reads := false
+				walkPatExpr(value, nil, func(e *patExpr) {
+					if e.kind == patIndex && rootName(e) == table {
+						reads = true
+					}
+				})
+				if reads {
+					d.add(PatternDynamicProgramming, inner.line, fmt.Sprintf("%s is filled from its earlier entries", table))
+				}
+			}
+		}
+	}
+
+	for _, s := range all {
+		for _, pair := range assignPairs(s) {
+			target, value := pair[0], pair[1]
+			if isHashAllocation(value) && !(target.kind == patName && d.memoTables[target.name]) {
+				d.add(PatternHashTable, s.line, fmt.Sprintf("%s is a hash table", targetText(target)))
+			}
+		}
+		if s.kind == patAssign {
+			continue
+		}
+		for _, e := range []*patExpr{s.value, s.iter} {
+			walkPatExpr(e, nil, func(e *patExpr) {
+				if e.kind == patCall && isHashAllocation(e) {
+					d.add(PatternHashTable, e.line, "counts or groups with a hash table")
+				}
+			})
+		}
+	}
+}
+
+func targetText(e *patExpr) string {
+	if name := rootName(e); name != "" {
+		return name
+	}
+	return "value"
+}
+
+// detectStacks finds collections pushed to and popped from the same end
+// that were not already explained as a DFS stack or backtracking state
+func (d *patternDetector) detectStacks(all []*patStmt) {
+	pushed := map[string]bool{}
+	for _, s := range all {
+		if name := pushedTo(s); name != "" {
+			pushed[name] = true
+		}
+	}
+	for _, s := range all {
+		name, end := poppedFrom(s)
+		if end == popBack && pushed[name] && !d.queueNames[name] && !d.undoneNames[name] {
+			d.add(PatternStack, s.line, fmt.Sprintf("%s is used as a stack", name))
+		}
+	}
+}
+
+// midpointOf looks for mid = (lo + hi) / 2 in a loop body with lo or hi
+// then moved to mid
+func midpointOf(body []*patStmt, lo, hi string) (string, bool) {
+	for _, s := range body {
+		for _, pair := range assignPairs(s) {
+			target, value := pair[0], pair[1]
+			if target.kind != patName || value == nil || !(refersTo(value, lo) || refersTo(value, hi)) || !halves(value) {
+				continue
+			}
+			mid := target.name
+			for _, other := range body {
+				for _, pair := range assignPairs(other) {
+					if (isName(pair[0], lo) || isName(pair[0], hi)) && refersTo(pair[1], mid) {
+						return mid, true
+					}
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// halves reports whether e divides by two somewhere
+func halves(e *patExpr) bool {
+	found := false
+	walkPatExpr(e, nil, func(e *patExpr) {
+		if e.kind == patBinary && (((e.op == "/" || e.op == "//") && isNum(e.y, "2")) || (e.op == ">>" && isNum(e.y, "1"))) {
+			found = true
+		}
+	})
+	return found
+}
+
+func stepBits(step int) int {
+	switch step {
+	case 1:
+		return 1
+	case -1:
+		return 2
+	}
+	return 0
+}
+
+func isNextAttr(e *patExpr) bool {
+	return e != nil && e.kind == patAttr && (e.name == "next" || e.name == "Next")
+}
+
+// loopCollections returns the collections a loop condition tests for
+// emptiness: while q, while len(q) > 0, for len(stack) != 0, ...
+func loopCollections(cond *patExpr) []string {
+	var names []string
+	walkPatExpr(cond, nil, func(e *patExpr) {
+		switch {
+		case e.kind == patName && e.name != "True" && e.name != "true":
+			names = append(names, e.name)
+		case e.kind == patCall:
+			if recv, name := callee(e); recv == "" && name == "len" && len(e.args) == 1 && e.args[0].kind == patName {
+				names = append(names, e.args[0].name)
+			}
+		}
+	})
+	sort.Strings(names)
+	return names
+}
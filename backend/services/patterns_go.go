@@ -0,0 +1,339 @@
+package services
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// parseGoPatterns parses Go source into the pattern tree. Code without a
+// package clause, such as a lone solution function, is parsed as if it were
+// in package main.
+func parseGoPatterns(code string) ([]*patStmt, error) {
+	fset := token.NewFileSet()
+	offset := 0
+	file, err := parser.ParseFile(fset, "", code, parser.SkipObjectResolution)
+	if err != nil && !strings.Contains(code, "package ") {
+		var retryErr error
+		file, retryErr = parser.ParseFile(fset, "", "package main\n"+code, parser.SkipObjectResolution)
+		if retryErr == nil {
+			err = nil
+			offset = 1
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &goPatternConverter{fset: fset, offset: offset}
+	var stmts []*patStmt
+	for _, decl := range file.Decls {
+		stmts = append(stmts, c.decl(decl)...)
+	}
+	return stmts, nil
+}
+
+// goPatternConverter turns go/ast nodes into pattern tree nodes
+type goPatternConverter struct {
+	fset   *token.FileSet
+	offset int // lines added before the submitted code
+}
+
+func (c *goPatternConverter) line(node ast.Node) int {
+	return c.fset.Position(node.Pos()).Line - c.offset
+}
+
+func (c *goPatternConverter) decl(decl ast.Decl) []*patStmt {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		fn := &patStmt{kind: patFunc, line: c.line(d), name: d.Name.Name, params: c.fieldNames(d.Type.Params)}
+		if d.Recv != nil {
+			if names := c.fieldNames(d.Recv); len(names) > 0 {
+				fn.receiver = names[0]
+			}
+		}
+		if d.Body != nil {
+			fn.body = c.stmts(d.Body.List)
+		}
+		return []*patStmt{fn}
+	case *ast.GenDecl:
+		var stmts []*patStmt
+		for _, spec := range d.Specs {
+			value, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			stmts = append(stmts, c.valueSpec(value))
+		}
+		return stmts
+	}
+	return nil
+}
+
+func (c *goPatternConverter) fieldNames(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var names []string
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+func (c *goPatternConverter) valueSpec(spec *ast.ValueSpec) *patStmt {
+	s := &patStmt{kind: patAssign, line: c.line(spec), op: "="}
+	for _, name := range spec.Names {
+		s.targets = append(s.targets, &patExpr{kind: patName, line: c.line(name), name: name.Name})
+	}
+	s.value = c.exprList(spec.Values)
+	return s
+}
+
+func (c *goPatternConverter) stmts(list []ast.Stmt) []*patStmt {
+	var stmts []*patStmt
+	for _, stmt := range list {
+		stmts = append(stmts, c.stmt(stmt)...)
+	}
+	return stmts
+}
+
+// stmt converts one statement; init statements of if, for and switch come
+// out before the statement they belong to
+func (c *goPatternConverter) stmt(stmt ast.Stmt) []*patStmt {
+	line := 0
+	if stmt != nil {
+		line = c.line(stmt)
+	}
+
+	switch s := stmt.(type) {
+	case nil:
+		return nil
+	case *ast.ExprStmt:
+		return []*patStmt{{kind: patExprStmt, line: line, value: c.expr(s.X)}}
+	case *ast.AssignStmt:
+		// dfs := func(...) {...} declares a function the closure can recurse into
+		if len(s.Lhs) == 1 && len(s.Rhs) == 1 {
+			if lit, ok := s.Rhs[0].(*ast.FuncLit); ok {
+				if name, ok := s.Lhs[0].(*ast.Ident); ok {
+					return []*patStmt{{
+						kind:   patFunc,
+						line:   line,
+						name:   name.Name,
+						params: c.fieldNames(lit.Type.Params),
+						body:   c.stmts(lit.Body.List),
+					}}
+				}
+			}
+		}
+		op := s.Tok.String()
+		if s.Tok == token.DEFINE {
+			op = "="
+		}
+		assign := &patStmt{kind: patAssign, line: line, op: op}
+		for _, lhs := range s.Lhs {
+			assign.targets = append(assign.targets, c.expr(lhs))
+		}
+		assign.value = c.exprList(s.Rhs)
+		return []*patStmt{assign}
+	case *ast.IncDecStmt:
+		op := "+="
+		if s.Tok == token.DEC {
+			op = "-="
+		}
+		return []*patStmt{{
+			kind:    patAssign,
+			line:    line,
+			op:      op,
+			targets: []*patExpr{c.expr(s.X)},
+			value:   &patExpr{kind: patNum, line: line, name: "1"},
+		}}
+	case *ast.DeclStmt:
+		return c.decl(s.Decl)
+	case *ast.ReturnStmt:
+		return []*patStmt{{kind: patReturn, line: line, value: c.exprList(s.Results)}}
+	case *ast.BlockStmt:
+		return c.stmts(s.List)
+	case *ast.IfStmt:
+		stmts := c.stmt(s.Init)
+		ifStmt := &patStmt{kind: patIf, line: line, value: c.expr(s.Cond), body: c.stmts(s.Body.List)}
+		ifStmt.orelse = c.stmt(s.Else)
+		return append(stmts, ifStmt)
+	case *ast.ForStmt:
+		stmts := c.stmt(s.Init)
+		loop := &patStmt{kind: patLoop, line: line, value: c.expr(s.Cond), body: c.stmts(s.Body.List)}
+		if init, ok := s.Init.(*ast.AssignStmt); ok {
+			for _, lhs := range init.Lhs {
+				if name, ok := lhs.(*ast.Ident); ok {
+					loop.loopVars = append(loop.loopVars, name.Name)
+				}
+			}
+		}
+		loop.body = append(loop.body, c.stmt(s.Post)...)
+		return append(stmts, loop)
+	case *ast.RangeStmt:
+		loop := &patStmt{kind: patLoop, line: line, iter: c.expr(s.X), body: c.stmts(s.Body.List)}
+		for _, key := range []ast.Expr{s.Key, s.Value} {
+			if name, ok := key.(*ast.Ident); ok && name.Name != "_" {
+				loop.loopVars = append(loop.loopVars, name.Name)
+			}
+		}
+		if len(loop.loopVars) == 0 {
+			// for range n still iterates; keep it a for-in loop
+			loop.loopVars = []string{"_"}
+		}
+		return []*patStmt{loop}
+	case *ast.SwitchStmt:
+		stmts := c.stmt(s.Init)
+		block := &patStmt{kind: patBlock, line: line, value: c.expr(s.Tag)}
+		for _, clause := range s.Body.List {
+			block.body = append(block.body, c.caseClause(clause)...)
+		}
+		return append(stmts, block)
+	case *ast.TypeSwitchStmt:
+		stmts := c.stmt(s.Init)
+		block := &patStmt{kind: patBlock, line: line}
+		for _, clause := range s.Body.List {
+			block.body = append(block.body, c.caseClause(clause)...)
+		}
+		return append(stmts, block)
+	case *ast.SelectStmt:
+		block := &patStmt{kind: patBlock, line: line}
+		for _, clause := range s.Body.List {
+			if comm, ok := clause.(*ast.CommClause); ok {
+				block.body = append(block.body, c.stmt(comm.Comm)...)
+				block.body = append(block.body, c.stmts(comm.Body)...)
+			}
+		}
+		return []*patStmt{block}
+	case *ast.LabeledStmt:
+		return c.stmt(s.Stmt)
+	case *ast.GoStmt:
+		return []*patStmt{{kind: patExprStmt, line: line, value: c.expr(s.Call)}}
+	case *ast.DeferStmt:
+		return []*patStmt{{kind: patExprStmt, line: line, value: c.expr(s.Call)}}
+	case *ast.SendStmt:
+		return []*patStmt{{kind: patExprStmt, line: line, value: c.expr(s.Value)}}
+	}
+	return nil
+}
+
+func (c *goPatternConverter) caseClause(clause ast.Stmt) []*patStmt {
+	cc, ok := clause.(*ast.CaseClause)
+	if !ok {
+		return nil
+	}
+	return c.stmts(cc.Body)
+}
+
+// exprList converts the right-hand side of an assignment, a tuple when it
+// has more than one expression
+func (c *goPatternConverter) exprList(exprs []ast.Expr) *patExpr {
+	switch len(exprs) {
+	case 0:
+		return nil
+	case 1:
+		return c.expr(exprs[0])
+	}
+	tuple := &patExpr{kind: patTuple, line: c.line(exprs[0])}
+	for _, e := range exprs {
+		tuple.args = append(tuple.args, c.expr(e))
+	}
+	return tuple
+}
+
+func (c *goPatternConverter) expr(expr ast.Expr) *patExpr {
+	if expr == nil {
+		return nil
+	}
+	line := c.line(expr)
+
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "true", "false", "nil":
+			return &patExpr{kind: patConst, line: line, name: e.Name}
+		}
+		return &patExpr{kind: patName, line: line, name: e.Name}
+	case *ast.BasicLit:
+		if e.Kind == token.STRING || e.Kind == token.CHAR {
+			return &patExpr{kind: patStr, line: line, name: e.Value}
+		}
+		return &patExpr{kind: patNum, line: line, name: e.Value}
+	case *ast.ParenExpr:
+		return c.expr(e.X)
+	case *ast.StarExpr:
+		return c.expr(e.X)
+	case *ast.TypeAssertExpr:
+		return c.expr(e.X)
+	case *ast.SelectorExpr:
+		return &patExpr{kind: patAttr, line: line, name: e.Sel.Name, x: c.expr(e.X)}
+	case *ast.IndexExpr:
+		return &patExpr{kind: patIndex, line: line, x: c.expr(e.X), y: c.expr(e.Index)}
+	case *ast.IndexListExpr:
+		return c.expr(e.X) // generic instantiation
+	case *ast.SliceExpr:
+		return &patExpr{kind: patSlice, line: line, x: c.expr(e.X), y: c.expr(e.Low), z: c.expr(e.High)}
+	case *ast.BinaryExpr:
+		op := e.Op.String()
+		switch e.Op {
+		case token.LAND:
+			op = "and"
+		case token.LOR:
+			op = "or"
+		}
+		return &patExpr{kind: patBinary, line: line, op: op, x: c.expr(e.X), y: c.expr(e.Y)}
+	case *ast.UnaryExpr:
+		if e.Op == token.AND {
+			return c.expr(e.X) // &T{...}
+		}
+		op := e.Op.String()
+		if e.Op == token.NOT {
+			op = "not"
+		}
+		return &patExpr{kind: patUnary, line: line, op: op, x: c.expr(e.X)}
+	case *ast.CallExpr:
+		if ident, ok := e.Fun.(*ast.Ident); ok && ident.Name == "make" && len(e.Args) > 0 {
+			switch e.Args[0].(type) {
+			case *ast.MapType:
+				return &patExpr{kind: patDict, line: line}
+			case *ast.ArrayType:
+				return &patExpr{kind: patList, line: line, sized: len(e.Args) > 1 && !isZeroLit(e.Args[1])}
+			}
+		}
+		call := &patExpr{kind: patCall, line: line, x: c.expr(e.Fun)}
+		for _, arg := range e.Args {
+			call.args = append(call.args, c.expr(arg))
+		}
+		return call
+	case *ast.CompositeLit:
+		lit := &patExpr{kind: patOther, line: line}
+		switch e.Type.(type) {
+		case *ast.MapType:
+			lit.kind = patDict
+		case *ast.ArrayType:
+			lit.kind = patList
+		}
+		for _, elt := range e.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				lit.args = append(lit.args, c.expr(kv.Key), c.expr(kv.Value))
+				continue
+			}
+			lit.args = append(lit.args, c.expr(elt))
+		}
+		return lit
+	case *ast.FuncLit:
+		return &patExpr{kind: patLambda, line: line, body: c.stmts(e.Body.List)}
+	case *ast.KeyValueExpr:
+		return c.expr(e.Value)
+	}
+	return &patExpr{kind: patOther, line: line}
+}
+
+func isZeroLit(expr ast.Expr) bool {
+	lit, ok := expr.(*ast.BasicLit)
+	return ok && lit.Value == "0"
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"fmt"
 
 	"gorm.io/gorm"
 	"github.com/yourusername/algoholic/models"
@@ -9,12 +10,17 @@ import (
 
 // ProblemService handles problem-related operations
 type ProblemService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	languages *LanguageRegistry
 }
 
-// NewProblemService creates a new problem service
-func NewProblemService(db *gorm.DB) *ProblemService {
-	return &ProblemService{db: db}
+// NewProblemService creates a new problem service. A nil registry uses the
+// default languages.
+func NewProblemService(db *gorm.DB, languages *LanguageRegistry) *ProblemService {
+	if languages == nil {
+		languages = NewLanguageRegistry(nil)
+	}
+	return &ProblemService{db: db, languages: languages}
 }
 
 // GetProblems retrieves problems with filters
@@ -54,6 +60,31 @@ func (s *ProblemService) GetProblemByID(id int) (*models.Problem, error) {
 	return &problem, nil
 }
 
+// GetStarterCode returns the code users start from for a problem: the
+// function stub when it has a function signature, otherwise the language's
+// stdin/stdout template
+func (s *ProblemService) GetStarterCode(problemID int, language string) (string, error) {
+	lang, ok := s.languages.Lookup(language)
+	if !ok {
+		return "", fmt.Errorf("unsupported language: %s", language)
+	}
+
+	problem, err := s.GetProblemByID(problemID)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := problemSignature(problem)
+	if err != nil {
+		return "", err
+	}
+	if sig == nil {
+		return lang.StarterCode, nil
+	}
+
+	return sig.StarterCode(lang.ID)
+}
+
 // GetProblemBySlug retrieves a problem by slug
 func (s *ProblemService) GetProblemBySlug(slug string) (*models.Problem, error) {
 	var problem models.Problem
@@ -97,8 +128,8 @@ func (s *ProblemService) GetProblemTopics(problemID int) ([]models.Topic, error)
 	return topics, err
 }
 
-// UpdateProblemStats updates problem statistics after an attempt
-func (s *ProblemService) UpdateProblemStats(problemID int, solved bool, timeTaken int) error {
+// UpdateProblemStats counts an attempt at a problem, and a solve if solved
+func (s *ProblemService) UpdateProblemStats(problemID int, solved bool) error {
 	updates := map[string]interface{}{
 		"total_attempts": gorm.Expr("total_attempts + 1"),
 	}
@@ -0,0 +1,1254 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A recursive descent parser for Python 3 that builds the pattern tree
+// directly. It covers the statements and expressions found in solutions:
+// functions, classes, decorators, comprehensions, lambdas, slicing,
+// annotations and the walrus operator. match statements, type aliases and
+// f-string internals are not parsed (f-strings are read as plain strings).
+
+type pyTokenKind int
+
+const (
+	pyName pyTokenKind = iota
+	pyNumber
+	pyString
+	pyOp
+	pyNewline
+	pyIndent
+	pyDedent
+	pyEOF
+)
+
+type pyToken struct {
+	kind pyTokenKind
+	text string
+	line int
+}
+
+// pySyntaxError aborts parsing; parsePythonPatterns recovers it
+type pySyntaxError struct {
+	line int
+	msg  string
+}
+
+func (e *pySyntaxError) Error() string {
+	return fmt.Sprintf("python syntax error on line %d: %s", e.line, e.msg)
+}
+
+// pyOperators are matched longest first
+var pyOperators = []string{
+	"**=", "//=", ">>=", "<<=", "...",
+	"->", ":=", "**", "//", "<<", ">>", "<=", ">=", "==", "!=",
+	"+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "@=",
+	"+", "-", "*", "/", "%", "@", "&", "|", "^", "~", "<", ">",
+	"(", ")", "[", "]", "{", "}", ",", ":", ".", ";", "=",
+}
+
+// tokenizePython splits source into tokens with INDENT and DEDENT tokens
+// for block structure. Newlines inside brackets and after a backslash
+// continue the logical line.
+func tokenizePython(src string) ([]pyToken, error) {
+	var tokens []pyToken
+	indents := []int{0}
+	line := 1
+	depth := 0
+	atLineStart := true
+	pos := 0
+
+	emit := func(kind pyTokenKind, text string) {
+		tokens = append(tokens, pyToken{kind: kind, text: text, line: line})
+	}
+
+	for pos < len(src) {
+		if atLineStart && depth == 0 {
+			width := 0
+			for pos < len(src) && (src[pos] == ' ' || src[pos] == '\t' || src[pos] == '\f') {
+				if src[pos] == '\t' {
+					width = (width/8 + 1) * 8
+				} else if src[pos] == ' ' {
+					width++
+				}
+				pos++
+			}
+			if pos >= len(src) {
+				break
+			}
+			// Blank and comment-only lines do not affect indentation
+			if src[pos] == '\n' || src[pos] == '\r' || src[pos] == '#' {
+				for pos < len(src) && src[pos] != '\n' {
+					pos++
+				}
+				if pos < len(src) {
+					pos++
+					line++
+				}
+				continue
+			}
+			atLineStart = false
+
+			switch top := indents[len(indents)-1]; {
+			case width > top:
+				indents = append(indents, width)
+				emit(pyIndent, "")
+			case width < top:
+				for width < indents[len(indents)-1] {
+					indents = indents[:len(indents)-1]
+					emit(pyDedent, "")
+				}
+				if width != indents[len(indents)-1] {
+					return nil, &pySyntaxError{line, "unindent does not match any outer indentation level"}
+				}
+			}
+		}
+
+		ch := src[pos]
+		switch {
+		case ch == '\n':
+			if depth == 0 {
+				emit(pyNewline, "")
+				atLineStart = true
+			}
+			line++
+			pos++
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\f':
+			pos++
+		case ch == '#':
+			for pos < len(src) && src[pos] != '\n' {
+				pos++
+			}
+		case ch == '\\':
+			pos++
+			if pos < len(src) && src[pos] == '\r' {
+				pos++
+			}
+			if pos >= len(src) || src[pos] != '\n' {
+				return nil, &pySyntaxError{line, "unexpected character after line continuation"}
+			}
+			pos++
+			line++
+		case ch == '"' || ch == '\'':
+			end, lines, err := scanPythonString(src, pos, line)
+			if err != nil {
+				return nil, err
+			}
+			emit(pyString, src[pos:end])
+			line += lines
+			pos = end
+		case ch >= '0' && ch <= '9' || (ch == '.' && pos+1 < len(src) && src[pos+1] >= '0' && src[pos+1] <= '9'):
+			start := pos
+			for pos < len(src) {
+				c := src[pos]
+				if isIdentByte(c) || c == '.' {
+					pos++
+				} else if (c == '+' || c == '-') && (src[pos-1] == 'e' || src[pos-1] == 'E') &&
+					!strings.HasPrefix(strings.ToLower(src[start:pos]), "0x") {
+					pos++
+				} else {
+					break
+				}
+			}
+			emit(pyNumber, src[start:pos])
+		case ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= utf8.RuneSelf:
+			start := pos
+			for pos < len(src) {
+				r, size := utf8.DecodeRuneInString(src[pos:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				pos += size
+			}
+			if pos == start {
+				return nil, &pySyntaxError{line, "invalid character"}
+			}
+			// String prefixes: r"", b'', f"""...""", rb'' and so on
+			if pos < len(src) && (src[pos] == '"' || src[pos] == '\'') && pos-start <= 2 &&
+				strings.Trim(strings.ToLower(src[start:pos]), "rbuf") == "" {
+				end, lines, err := scanPythonString(src, pos, line)
+				if err != nil {
+					return nil, err
+				}
+				emit(pyString, src[start:end])
+				line += lines
+				pos = end
+				continue
+			}
+			emit(pyName, src[start:pos])
+		default:
+			matched := ""
+			for _, op := range pyOperators {
+				if strings.HasPrefix(src[pos:], op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, &pySyntaxError{line, fmt.Sprintf("invalid character %q", ch)}
+			}
+			switch matched {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			}
+			emit(pyOp, matched)
+			pos += len(matched)
+		}
+	}
+
+	if len(tokens) > 0 && tokens[len(tokens)-1].kind != pyNewline {
+		emit(pyNewline, "")
+	}
+	for len(indents) > 1 {
+		indents = indents[:len(indents)-1]
+		emit(pyDedent, "")
+	}
+	emit(pyEOF, "")
+	return tokens, nil
+}
+
+// scanPythonString returns the end of the string literal whose opening quote
+// is at pos and how many newlines it spans
+func scanPythonString(src string, pos, line int) (end, lines int, err error) {
+	quote := src[pos : pos+1]
+	if strings.HasPrefix(src[pos:], strings.Repeat(quote, 3)) {
+		quote = strings.Repeat(quote, 3)
+	}
+	i := pos + len(quote)
+	for i < len(src) {
+		switch {
+		case src[i] == '\\':
+			if i+1 < len(src) && src[i+1] == '\n' {
+				lines++
+			}
+			i += 2
+			continue
+		case strings.HasPrefix(src[i:], quote):
+			return i + len(quote), lines, nil
+		case src[i] == '\n':
+			if len(quote) == 1 {
+				return 0, 0, &pySyntaxError{line + lines, "unterminated string literal"}
+			}
+			lines++
+		}
+		i++
+	}
+	return 0, 0, &pySyntaxError{line, "unterminated string literal"}
+}
+
+// parsePythonPatterns parses Python source into the pattern tree
+func parsePythonPatterns(code string) (stmts []*patStmt, err error) {
+	tokens, err := tokenizePython(code)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*pySyntaxError)
+			if !ok {
+				panic(r)
+			}
+			stmts, err = nil, syntaxErr
+		}
+	}()
+
+	p := &pyParser{tokens: tokens}
+	for !p.at(pyEOF) {
+		if p.at(pyNewline) {
+			p.next()
+			continue
+		}
+		stmts = append(stmts, p.statement()...)
+	}
+	return stmts, nil
+}
+
+type pyParser struct {
+	tokens []pyToken
+	pos    int
+}
+
+func (p *pyParser) peek() pyToken {
+	return p.tokens[p.pos]
+}
+
+func (p *pyParser) peekAt(offset int) pyToken {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *pyParser) next() pyToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != pyEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *pyParser) at(kind pyTokenKind) bool {
+	return p.peek().kind == kind
+}
+
+// is reports whether the next token is the given operator or keyword
+func (p *pyParser) is(text string) bool {
+	tok := p.peek()
+	return (tok.kind == pyOp || tok.kind == pyName) && tok.text == text
+}
+
+func (p *pyParser) accept(text string) bool {
+	if p.is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *pyParser) expect(text string) pyToken {
+	if !p.is(text) {
+		p.fail(fmt.Sprintf("expected %q", text))
+	}
+	return p.next()
+}
+
+func (p *pyParser) expectName() pyToken {
+	if !p.at(pyName) {
+		p.fail("expected a name")
+	}
+	return p.next()
+}
+
+func (p *pyParser) fail(msg string) {
+	tok := p.peek()
+	found := tok.text
+	switch tok.kind {
+	case pyNewline:
+		found = "end of line"
+	case pyIndent:
+		found = "indent"
+	case pyDedent:
+		found = "dedent"
+	case pyEOF:
+		found = "end of file"
+	}
+	panic(&pySyntaxError{tok.line, fmt.Sprintf("%s, found %s", msg, found)})
+}
+
+// pyKeywords cannot start an expression
+var pyKeywords = map[string]bool{
+	"and": true, "as": true, "assert": true, "async": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true, "except": true,
+	"finally": true, "for": true, "from": true, "global": true, "if": true, "import": true,
+	"in": true, "is": true, "nonlocal": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// Statements
+
+func (p *pyParser) statement() []*patStmt {
+	tok := p.peek()
+	if tok.kind == pyIndent {
+		p.fail("unexpected indent")
+	}
+	if tok.kind == pyOp && tok.text == "@" {
+		return []*patStmt{p.decorated()}
+	}
+	if tok.kind == pyName {
+		switch tok.text {
+		case "def":
+			return []*patStmt{p.funcDef(nil)}
+		case "class":
+			return []*patStmt{p.classDef()}
+		case "if":
+			return []*patStmt{p.ifStmt()}
+		case "while":
+			return []*patStmt{p.whileStmt()}
+		case "for":
+			return []*patStmt{p.forStmt()}
+		case "try":
+			return []*patStmt{p.tryStmt()}
+		case "with":
+			return []*patStmt{p.withStmt()}
+		case "async":
+			p.next()
+			return p.statement()
+		}
+	}
+	return p.simpleStatements()
+}
+
+// block parses the body after a colon: an indented suite or simple
+// statements on the same line
+func (p *pyParser) block() []*patStmt {
+	p.expect(":")
+	if !p.at(pyNewline) {
+		return p.simpleStatements()
+	}
+	p.next()
+	if !p.at(pyIndent) {
+		p.fail("expected an indented block")
+	}
+	p.next()
+	var stmts []*patStmt
+	for !p.at(pyDedent) && !p.at(pyEOF) {
+		if p.at(pyNewline) {
+			p.next()
+			continue
+		}
+		stmts = append(stmts, p.statement()...)
+	}
+	p.next()
+	return stmts
+}
+
+func (p *pyParser) decorated() *patStmt {
+	var decorators []*patExpr
+	for p.accept("@") {
+		decorators = append(decorators, p.namedExpr())
+		if !p.at(pyNewline) {
+			p.fail("expected end of line after decorator")
+		}
+		p.next()
+	}
+	p.accept("async")
+	switch {
+	case p.is("def"):
+		return p.funcDef(decorators)
+	case p.is("class"):
+		class := p.classDef()
+		class.decorators = decorators
+		return class
+	}
+	p.fail("expected def or class after decorator")
+	return nil
+}
+
+func (p *pyParser) funcDef(decorators []*patExpr) *patStmt {
+	line := p.expect("def").line
+	fn := &patStmt{kind: patFunc, line: line, name: p.expectName().text, decorators: decorators}
+	p.expect("(")
+	fn.params = p.params(")")
+	p.expect(")")
+	if p.accept("->") {
+		p.test()
+	}
+	fn.body = p.block()
+	return fn
+}
+
+// params parses parameters up to the closing token, with annotations when
+// closing is ")"
+func (p *pyParser) params(closing string) []string {
+	var names []string
+	for !p.is(closing) {
+		switch {
+		case p.accept("/"):
+		case p.accept("*"), p.accept("**"):
+			if p.at(pyName) {
+				names = append(names, p.next().text)
+				if closing == ")" && p.accept(":") {
+					p.test()
+				}
+			}
+		default:
+			names = append(names, p.expectName().text)
+			if closing == ")" && p.accept(":") {
+				p.test()
+			}
+			if p.accept("=") {
+				p.test()
+			}
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return names
+}
+
+func (p *pyParser) classDef() *patStmt {
+	line := p.expect("class").line
+	p.expectName()
+	if p.accept("(") {
+		p.arguments()
+		p.expect(")")
+	}
+	class := &patStmt{kind: patBlock, line: line, body: p.block()}
+	// Methods recurse through their first parameter, usually self
+	for _, s := range class.body {
+		if s.kind == patFunc && len(s.params) > 0 {
+			s.receiver = s.params[0]
+		}
+	}
+	return class
+}
+
+func (p *pyParser) ifStmt() *patStmt {
+	line := p.next().line // if or elif
+	s := &patStmt{kind: patIf, line: line, value: p.namedExpr()}
+	s.body = p.block()
+	switch {
+	case p.is("elif"):
+		s.orelse = []*patStmt{p.ifStmt()}
+	case p.accept("else"):
+		s.orelse = p.block()
+	}
+	return s
+}
+
+func (p *pyParser) whileStmt() *patStmt {
+	line := p.expect("while").line
+	s := &patStmt{kind: patLoop, line: line, value: p.namedExpr()}
+	s.body = p.block()
+	if p.accept("else") {
+		s.orelse = p.block()
+	}
+	return s
+}
+
+func (p *pyParser) forStmt() *patStmt {
+	line := p.expect("for").line
+	target := p.targetList()
+	p.expect("in")
+	s := &patStmt{kind: patLoop, line: line, loopVars: targetNames(target), iter: p.testListStar()}
+	s.body = p.block()
+	if p.accept("else") {
+		s.orelse = p.block()
+	}
+	return s
+}
+
+// targetNames returns the names bound by a for target such as i, (a, b)
+func targetNames(target *patExpr) []string {
+	switch target.kind {
+	case patName:
+		return []string{target.name}
+	case patTuple, patList:
+		var names []string
+		for _, elt := range target.args {
+			names = append(names, targetNames(elt)...)
+		}
+		return names
+	case patUnary:
+		if target.op == "*" {
+			return targetNames(target.x)
+		}
+	}
+	return nil
+}
+
+func (p *pyParser) tryStmt() *patStmt {
+	line := p.expect("try").line
+	s := &patStmt{kind: patBlock, line: line, body: p.block()}
+	for p.is("except") {
+		p.next()
+		p.accept("*")
+		if !p.is(":") {
+			p.test()
+			if p.accept("as") {
+				p.expectName()
+			} else if p.accept(",") {
+				p.test()
+			}
+		}
+		s.body = append(s.body, p.block()...)
+	}
+	if p.accept("else") {
+		s.body = append(s.body, p.block()...)
+	}
+	if p.accept("finally") {
+		s.body = append(s.body, p.block()...)
+	}
+	return s
+}
+
+func (p *pyParser) withStmt() *patStmt {
+	line := p.expect("with").line
+	s := &patStmt{kind: patBlock, line: line}
+	parens := p.is("(") && p.withItemsParenthesized()
+	if parens {
+		p.next()
+	}
+	for {
+		item := p.test()
+		if p.accept("as") {
+			target := p.bitOr()
+			s.body = append(s.body, &patStmt{kind: patAssign, line: line, op: "=", targets: []*patExpr{target}, value: item})
+		} else {
+			s.body = append(s.body, &patStmt{kind: patExprStmt, line: line, value: item})
+		}
+		if !p.accept(",") || (parens && p.is(")")) {
+			break
+		}
+	}
+	if parens {
+		p.expect(")")
+	}
+	s.body = append(s.body, p.block()...)
+	return s
+}
+
+// withItemsParenthesized reports whether with ( starts a parenthesized list
+// of items rather than an expression: the matching ) is followed by a colon
+// and the group contains "as"
+func (p *pyParser) withItemsParenthesized() bool {
+	depth, hasAs := 0, false
+	for i := p.pos; i < len(p.tokens); i++ {
+		tok := p.tokens[i]
+		switch {
+		case tok.kind == pyOp && (tok.text == "(" || tok.text == "[" || tok.text == "{"):
+			depth++
+		case tok.kind == pyOp && (tok.text == ")" || tok.text == "]" || tok.text == "}"):
+			depth--
+			if depth == 0 {
+				next := p.tokens[i+1]
+				return hasAs && next.kind == pyOp && next.text == ":"
+			}
+		case tok.kind == pyName && tok.text == "as" && depth == 1:
+			hasAs = true
+		case tok.kind == pyNewline || tok.kind == pyEOF:
+			return false
+		}
+	}
+	return false
+}
+
+func (p *pyParser) simpleStatements() []*patStmt {
+	var stmts []*patStmt
+	for {
+		if s := p.simpleStatement(); s != nil {
+			stmts = append(stmts, s)
+		}
+		if !p.accept(";") || p.at(pyNewline) {
+			break
+		}
+	}
+	if !p.at(pyNewline) && !p.at(pyEOF) {
+		p.fail("expected end of statement")
+	}
+	p.next()
+	return stmts
+}
+
+func (p *pyParser) simpleStatement() *patStmt {
+	tok := p.peek()
+	line := tok.line
+	if tok.kind == pyName {
+		switch tok.text {
+		case "pass", "break", "continue":
+			p.next()
+			return nil
+		case "return":
+			p.next()
+			s := &patStmt{kind: patReturn, line: line}
+			if !p.atStatementEnd() {
+				s.value = p.testListStar()
+			}
+			return s
+		case "raise":
+			p.next()
+			if !p.atStatementEnd() {
+				value := p.test()
+				if p.accept("from") {
+					p.test()
+				}
+				return &patStmt{kind: patExprStmt, line: line, value: value}
+			}
+			return nil
+		case "global", "nonlocal":
+			p.next()
+			p.expectName()
+			for p.accept(",") {
+				p.expectName()
+			}
+			return nil
+		case "import":
+			p.next()
+			p.dottedImport()
+			for p.accept(",") {
+				p.dottedImport()
+			}
+			return nil
+		case "from":
+			p.next()
+			for p.accept(".") || p.accept("...") {
+			}
+			if !p.is("import") {
+				p.dottedName()
+			}
+			p.expect("import")
+			if p.accept("*") {
+				return nil
+			}
+			parens := p.accept("(")
+			for p.at(pyName) {
+				p.next()
+				if p.accept("as") {
+					p.expectName()
+				}
+				if !p.accept(",") {
+					break
+				}
+			}
+			if parens {
+				p.expect(")")
+			}
+			return nil
+		case "del":
+			p.next()
+			return &patStmt{kind: patExprStmt, line: line, value: p.targetList()}
+		case "assert":
+			p.next()
+			value := p.test()
+			if p.accept(",") {
+				p.test()
+			}
+			return &patStmt{kind: patExprStmt, line: line, value: value}
+		}
+	}
+
+	first := p.testListStar()
+	switch {
+	case p.accept(":"):
+		// Annotated assignment: x: int = 0
+		p.test()
+		if p.accept("=") {
+			return &patStmt{kind: patAssign, line: line, op: "=", targets: []*patExpr{first}, value: p.assignValue()}
+		}
+		return nil
+	case p.at(pyOp) && strings.HasSuffix(p.peek().text, "=") && len(p.peek().text) >= 2 &&
+		p.peek().text != "==" && p.peek().text != "<=" && p.peek().text != ">=" && p.peek().text != "!=":
+		op := p.next().text
+		return &patStmt{kind: patAssign, line: line, op: op, targets: []*patExpr{first}, value: p.assignValue()}
+	case p.is("="):
+		targets := []*patExpr{first}
+		var value *patExpr
+		for p.accept("=") {
+			value = p.assignValue()
+			if p.is("=") {
+				targets = append(targets, value)
+			}
+		}
+		return &patStmt{kind: patAssign, line: line, op: "=", targets: targets, value: value}
+	}
+	return &patStmt{kind: patExprStmt, line: line, value: first}
+}
+
+func (p *pyParser) assignValue() *patExpr {
+	if p.is("yield") {
+		return p.yieldExpr()
+	}
+	return p.testListStar()
+}
+
+func (p *pyParser) atStatementEnd() bool {
+	return p.at(pyNewline) || p.at(pyEOF) || p.is(";")
+}
+
+func (p *pyParser) dottedImport() {
+	p.dottedName()
+	if p.accept("as") {
+		p.expectName()
+	}
+}
+
+func (p *pyParser) dottedName() {
+	p.expectName()
+	for p.accept(".") {
+		p.expectName()
+	}
+}
+
+// Expressions
+
+// targetList parses for and del targets, which stop before "in"
+func (p *pyParser) targetList() *patExpr {
+	line := p.peek().line
+	first := p.starOr(p.bitOr)
+	if !p.is(",") {
+		return first
+	}
+	tuple := &patExpr{kind: patTuple, line: line, args: []*patExpr{first}}
+	for p.accept(",") {
+		if p.is("in") || p.is("=") || p.atStatementEnd() {
+			break
+		}
+		tuple.args = append(tuple.args, p.starOr(p.bitOr))
+	}
+	return tuple
+}
+
+// testListStar parses comma-separated expressions, a tuple if there is a
+// comma
+func (p *pyParser) testListStar() *patExpr {
+	line := p.peek().line
+	first := p.starOr(p.namedExpr)
+	if !p.is(",") {
+		return first
+	}
+	tuple := &patExpr{kind: patTuple, line: line, args: []*patExpr{first}}
+	for p.accept(",") {
+		if !p.startsExpr() {
+			break
+		}
+		tuple.args = append(tuple.args, p.starOr(p.namedExpr))
+	}
+	return tuple
+}
+
+// startsExpr reports whether the next token can begin an expression
+func (p *pyParser) startsExpr() bool {
+	tok := p.peek()
+	switch tok.kind {
+	case pyName:
+		return !pyKeywords[tok.text] || tok.text == "yield"
+	case pyNumber, pyString:
+		return true
+	case pyOp:
+		switch tok.text {
+		case "(", "[", "{", "-", "+", "~", "*", "**", "...":
+			return true
+		}
+	}
+	return false
+}
+
+func (p *pyParser) starOr(parse func() *patExpr) *patExpr {
+	if p.is("*") {
+		line := p.next().line
+		return &patExpr{kind: patUnary, line: line, op: "*", x: p.bitOr()}
+	}
+	return parse()
+}
+
+func (p *pyParser) namedExpr() *patExpr {
+	if p.at(pyName) && p.peekAt(1).kind == pyOp && p.peekAt(1).text == ":=" {
+		name := p.next()
+		p.next()
+		// The walrus target reads as an assignment of the value
+		return &patExpr{kind: patBinary, line: name.line, op: ":=",
+			x: &patExpr{kind: patName, line: name.line, name: name.text}, y: p.test()}
+	}
+	return p.test()
+}
+
+func (p *pyParser) test() *patExpr {
+	if p.is("lambda") {
+		line := p.next().line
+		p.params(":")
+		p.expect(":")
+		body := p.test()
+		return &patExpr{kind: patLambda, line: line, body: []*patStmt{{kind: patReturn, line: line, value: body}}}
+	}
+	line := p.peek().line
+	value := p.orTest()
+	// Comprehension conditions are parsed with orTest, so an if here is
+	// always a conditional expression
+	if p.accept("if") {
+		cond := p.orTest()
+		p.expect("else")
+		return &patExpr{kind: patCond, line: line, x: value, y: cond, z: p.test()}
+	}
+	return value
+}
+
+func (p *pyParser) orTest() *patExpr {
+	left := p.andTest()
+	for p.is("or") {
+		line := p.next().line
+		left = &patExpr{kind: patBinary, line: line, op: "or", x: left, y: p.andTest()}
+	}
+	return left
+}
+
+func (p *pyParser) andTest() *patExpr {
+	left := p.notTest()
+	for p.is("and") {
+		line := p.next().line
+		left = &patExpr{kind: patBinary, line: line, op: "and", x: left, y: p.notTest()}
+	}
+	return left
+}
+
+func (p *pyParser) notTest() *patExpr {
+	if p.is("not") {
+		line := p.next().line
+		return &patExpr{kind: patUnary, line: line, op: "not", x: p.notTest()}
+	}
+	return p.comparison()
+}
+
+// comparison splits chains: a < b < c becomes a < b and b < c
+func (p *pyParser) comparison() *patExpr {
+	left := p.bitOr()
+	var result *patExpr
+	for {
+		line := p.peek().line
+		op := ""
+		switch {
+		case p.is("<"), p.is(">"), p.is("=="), p.is(">="), p.is("<="), p.is("!="), p.is("in"):
+			op = p.next().text
+		case p.is("not") && p.peekAt(1).kind == pyName && p.peekAt(1).text == "in":
+			p.next()
+			p.next()
+			op = "not in"
+		case p.is("is"):
+			p.next()
+			op = "is"
+			if p.accept("not") {
+				op = "is not"
+			}
+		}
+		if op == "" {
+			break
+		}
+		right := p.bitOr()
+		compare := &patExpr{kind: patBinary, line: line, op: op, x: left, y: right}
+		if result == nil {
+			result = compare
+		} else {
+			result = &patExpr{kind: patBinary, line: line, op: "and", x: result, y: compare}
+		}
+		left = right
+	}
+	if result == nil {
+		return left
+	}
+	return result
+}
+
+// binaryLevels are the binary operator precedence levels from loosest to
+// tightest, below comparisons
+var binaryLevels = [][]string{
+	{"|"}, {"^"}, {"&"}, {"<<", ">>"}, {"+", "-"}, {"*", "/", "//", "%", "@"},
+}
+
+func (p *pyParser) bitOr() *patExpr {
+	return p.binary(0)
+}
+
+func (p *pyParser) binary(level int) *patExpr {
+	if level == len(binaryLevels) {
+		return p.factor()
+	}
+	left := p.binary(level + 1)
+	for {
+		tok := p.peek()
+		matched := false
+		if tok.kind == pyOp {
+			for _, op := range binaryLevels[level] {
+				if tok.text == op {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return left
+		}
+		p.next()
+		left = &patExpr{kind: patBinary, line: tok.line, op: tok.text, x: left, y: p.binary(level + 1)}
+	}
+}
+
+func (p *pyParser) factor() *patExpr {
+	if p.is("-") || p.is("+") || p.is("~") {
+		tok := p.next()
+		operand := p.factor()
+		if tok.text == "-" && operand.kind == patNum {
+			return &patExpr{kind: patNum, line: tok.line, name: "-" + operand.name}
+		}
+		return &patExpr{kind: patUnary, line: tok.line, op: tok.text, x: operand}
+	}
+	return p.power()
+}
+
+func (p *pyParser) power() *patExpr {
+	p.accept("await")
+	base := p.primary()
+	if p.is("**") {
+		line := p.next().line
+		return &patExpr{kind: patBinary, line: line, op: "**", x: base, y: p.factor()}
+	}
+	return base
+}
+
+func (p *pyParser) primary() *patExpr {
+	e := p.atom()
+	for {
+		tok := p.peek()
+		if tok.kind != pyOp {
+			return e
+		}
+		switch tok.text {
+		case "(":
+			p.next()
+			call := &patExpr{kind: patCall, line: tok.line, x: e, args: p.arguments()}
+			p.expect(")")
+			e = call
+		case "[":
+			p.next()
+			e = p.subscript(e, tok.line)
+			p.expect("]")
+		case ".":
+			p.next()
+			e = &patExpr{kind: patAttr, line: tok.line, name: p.expectName().text, x: e}
+		default:
+			return e
+		}
+	}
+}
+
+// arguments parses call arguments up to the closing parenthesis, keeping
+// only their values
+func (p *pyParser) arguments() []*patExpr {
+	var args []*patExpr
+	for !p.is(")") {
+		line := p.peek().line
+		switch {
+		case p.accept("*"), p.accept("**"):
+			args = append(args, p.test())
+		case p.at(pyName) && p.peekAt(1).kind == pyOp && p.peekAt(1).text == "=":
+			p.next()
+			p.next()
+			args = append(args, p.test())
+		default:
+			arg := p.namedExpr()
+			if p.is("for") || p.is("async") {
+				arg = p.comprehension("gen", arg, line)
+			}
+			args = append(args, arg)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return args
+}
+
+func (p *pyParser) subscript(base *patExpr, line int) *patExpr {
+	first := p.sliceItem(base, line)
+	if !p.is(",") {
+		return first
+	}
+	// a[i, j]: keep it an index by a tuple
+	index := &patExpr{kind: patTuple, line: line, args: []*patExpr{first.y}}
+	for p.accept(",") {
+		if p.is("]") {
+			break
+		}
+		item := p.sliceItem(base, line)
+		index.args = append(index.args, item.y)
+	}
+	return &patExpr{kind: patIndex, line: line, x: base, y: index}
+}
+
+// sliceItem parses x[i] or x[lo:hi:step]
+func (p *pyParser) sliceItem(base *patExpr, line int) *patExpr {
+	var lo *patExpr
+	if !p.is(":") {
+		lo = p.starOr(p.namedExpr)
+		if !p.is(":") {
+			return &patExpr{kind: patIndex, line: line, x: base, y: lo}
+		}
+	}
+	p.expect(":")
+	var hi *patExpr
+	if !p.is(":") && !p.is("]") && !p.is(",") {
+		hi = p.test()
+	}
+	if p.accept(":") && !p.is("]") && !p.is(",") {
+		p.test()
+	}
+	return &patExpr{kind: patSlice, line: line, x: base, y: lo, z: hi}
+}
+
+func (p *pyParser) atom() *patExpr {
+	tok := p.peek()
+	switch tok.kind {
+	case pyName:
+		if pyKeywords[tok.text] && tok.text != "yield" {
+			p.fail("unexpected keyword")
+		}
+		if tok.text == "yield" {
+			return p.yieldExpr()
+		}
+		p.next()
+		switch tok.text {
+		case "True", "False", "None":
+			return &patExpr{kind: patConst, line: tok.line, name: tok.text}
+		}
+		return &patExpr{kind: patName, line: tok.line, name: tok.text}
+	case pyNumber:
+		p.next()
+		return &patExpr{kind: patNum, line: tok.line, name: tok.text}
+	case pyString:
+		p.next()
+		for p.at(pyString) {
+			p.next() // implicit concatenation
+		}
+		return &patExpr{kind: patStr, line: tok.line, name: tok.text}
+	case pyOp:
+		switch tok.text {
+		case "(":
+			p.next()
+			e := p.parenthesized(tok.line)
+			p.expect(")")
+			return e
+		case "[":
+			p.next()
+			e := p.listDisplay(tok.line)
+			p.expect("]")
+			return e
+		case "{":
+			p.next()
+			e := p.braceDisplay(tok.line)
+			p.expect("}")
+			return e
+		case "...":
+			p.next()
+			return &patExpr{kind: patConst, line: tok.line, name: "..."}
+		}
+	}
+	p.fail("expected an expression")
+	return nil
+}
+
+func (p *pyParser) yieldExpr() *patExpr {
+	line := p.expect("yield").line
+	p.accept("from")
+	e := &patExpr{kind: patOther, line: line}
+	if p.startsExpr() {
+		e.x = p.testListStar()
+	}
+	return e
+}
+
+// parenthesized parses (), (x), (x, y) and (x for ...)
+func (p *pyParser) parenthesized(line int) *patExpr {
+	if p.is(")") {
+		return &patExpr{kind: patTuple, line: line}
+	}
+	if p.is("yield") {
+		return p.yieldExpr()
+	}
+	first := p.starOr(p.namedExpr)
+	if p.is("for") || p.is("async") {
+		return p.comprehension("gen", first, line)
+	}
+	if !p.is(",") {
+		return first
+	}
+	tuple := &patExpr{kind: patTuple, line: line, args: []*patExpr{first}}
+	for p.accept(",") {
+		if p.is(")") {
+			break
+		}
+		tuple.args = append(tuple.args, p.starOr(p.namedExpr))
+	}
+	return tuple
+}
+
+func (p *pyParser) listDisplay(line int) *patExpr {
+	list := &patExpr{kind: patList, line: line}
+	if p.is("]") {
+		return list
+	}
+	first := p.starOr(p.namedExpr)
+	if p.is("for") || p.is("async") {
+		return p.comprehension("list", first, line)
+	}
+	list.args = append(list.args, first)
+	for p.accept(",") {
+		if p.is("]") {
+			break
+		}
+		list.args = append(list.args, p.starOr(p.namedExpr))
+	}
+	return list
+}
+
+// braceDisplay parses dict and set displays and comprehensions
+func (p *pyParser) braceDisplay(line int) *patExpr {
+	if p.is("}") {
+		return &patExpr{kind: patDict, line: line}
+	}
+
+	var first *patExpr
+	isDict := false
+	if p.accept("**") {
+		first = p.bitOr()
+		isDict = true
+	} else {
+		first = p.starOr(p.namedExpr)
+		if p.accept(":") {
+			value := p.test()
+			first = &patExpr{kind: patTuple, line: line, args: []*patExpr{first, value}}
+			isDict = true
+		}
+	}
+
+	kind := "set"
+	display := &patExpr{kind: patSet, line: line}
+	if isDict {
+		kind = "dict"
+		display.kind = patDict
+	}
+	if p.is("for") || p.is("async") {
+		return p.comprehension(kind, first, line)
+	}
+
+	display.args = append(display.args, first)
+	for p.accept(",") {
+		if p.is("}") {
+			break
+		}
+		switch {
+		case isDict && p.accept("**"):
+			display.args = append(display.args, p.bitOr())
+		case isDict:
+			key := p.test()
+			p.expect(":")
+			display.args = append(display.args, key, p.test())
+		default:
+			display.args = append(display.args, p.starOr(p.namedExpr))
+		}
+	}
+	return display
+}
+
+// comprehension parses the for and if clauses after a comprehension's
+// element
+func (p *pyParser) comprehension(kind string, element *patExpr, line int) *patExpr {
+	comp := &patExpr{kind: patComp, line: line, name: kind, x: element}
+	for p.is("for") || p.is("async") {
+		p.accept("async")
+		p.expect("for")
+		p.targetList()
+		p.expect("in")
+		comp.args = append(comp.args, p.orTest())
+		for p.is("if") {
+			p.next()
+			comp.args = append(comp.args, p.orTestNoCond())
+		}
+	}
+	return comp
+}
+
+// orTestNoCond parses a comprehension condition, which may be a lambda but
+// not a conditional expression
+func (p *pyParser) orTestNoCond() *patExpr {
+	if p.is("lambda") {
+		return p.test()
+	}
+	return p.orTest()
+}
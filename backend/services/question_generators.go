@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// maxGeneratedLength bounds the arrays generators draw
+const maxGeneratedLength = 100
+
+// builtinGenerators returns the question generators supported out of the
+// box
+func builtinGenerators() []QuestionGenerator {
+	return []QuestionGenerator{
+		arrayGenerator{},
+		binarySearchGenerator{},
+		heapGenerator{},
+	}
+}
+
+// arrayGenerator draws a random array. Parameters: "length" and "values"
+// (see randomArray), and "distinct" and "sorted". Values: array, length,
+// sum, min, max, sorted and reversed.
+type arrayGenerator struct{}
+
+func (arrayGenerator) Name() string { return "array" }
+
+func (arrayGenerator) Generate(params map[string]interface{}, rng *rand.Rand) (map[string]interface{}, error) {
+	distinct, _ := params["distinct"].(bool)
+	sorted, _ := params["sorted"].(bool)
+	array, err := randomArray(params, rng, distinct, sorted)
+	if err != nil {
+		return nil, err
+	}
+
+	sum, lowest, highest := 0, array[0], array[0]
+	for _, n := range array {
+		sum += n
+		if n < lowest {
+			lowest = n
+		}
+		if n > highest {
+			highest = n
+		}
+	}
+	ascending := append([]int(nil), array...)
+	sort.Ints(ascending)
+	reversed := make([]int, len(array))
+	for i, n := range array {
+		reversed[len(array)-1-i] = n
+	}
+
+	return map[string]interface{}{
+		"array":    array,
+		"length":   len(array),
+		"sum":      sum,
+		"min":      lowest,
+		"max":      highest,
+		"sorted":   ascending,
+		"reversed": reversed,
+	}, nil
+}
+
+// binarySearchGenerator traces a binary search for a target in a sorted
+// array of distinct values. Parameters: "length" and "values" (see
+// randomArray), and "present", the chance the target is in the array
+// (default 0.5). Values: array, target, index (-1 when absent),
+// insertion_point (where the target would be inserted), mids (the indices
+// probed, in order) and probes (how many).
+type binarySearchGenerator struct{}
+
+func (binarySearchGenerator) Name() string { return "binary_search" }
+
+func (binarySearchGenerator) Generate(params map[string]interface{}, rng *rand.Rand) (map[string]interface{}, error) {
+	array, err := randomArray(params, rng, true, true)
+	if err != nil {
+		return nil, err
+	}
+	present := 0.5
+	if p, ok := params["present"].(float64); ok {
+		present = p
+	}
+
+	var target int
+	if rng.Float64() < present {
+		target = array[rng.Intn(len(array))]
+	} else {
+		target = absentValue(params, array, rng)
+	}
+
+	// Search with mid = lo + (hi-lo)/2, recording each index probed
+	index, lo, hi := -1, 0, len(array)-1
+	mids := []int{}
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		mids = append(mids, mid)
+		if array[mid] == target {
+			index = mid
+			break
+		}
+		if array[mid] < target {
+			lo = mid + 1
+		} else {
+			hi = mid - 1
+		}
+	}
+	insertionPoint := sort.SearchInts(array, target)
+
+	return map[string]interface{}{
+		"array":           array,
+		"target":          target,
+		"index":           index,
+		"insertion_point": insertionPoint,
+		"mids":            mids,
+		"probes":          len(mids),
+	}, nil
+}
+
+// absentValue draws a value in the "values" range that is not in a sorted
+// array, or one just past it when the array fills the range
+func absentValue(params map[string]interface{}, array []int, rng *rand.Rand) int {
+	lo, hi, _ := rangeParam(params, "values", 0, 99)
+	for i := 0; i < 100; i++ {
+		n := lo + rng.Intn(hi-lo+1)
+		if j := sort.SearchInts(array, n); j == len(array) || array[j] != n {
+			return n
+		}
+	}
+	return array[len(array)-1] + 1
+}
+
+// heapGenerator pushes random values onto an empty binary heap stored in
+// an array. Parameters: "pushes", how many values are pushed (a number or
+// a [min, max] range, default [5, 8]), "values" (see randomArray) and
+// "kind", "min" (the default) or "max". Values: pushes, heap (the array
+// after the pushes), top and kind.
+type heapGenerator struct{}
+
+func (heapGenerator) Name() string { return "heap" }
+
+func (heapGenerator) Generate(params map[string]interface{}, rng *rand.Rand) (map[string]interface{}, error) {
+	kind, _ := params["kind"].(string)
+	if kind == "" {
+		kind = "min"
+	}
+	if kind != "min" && kind != "max" {
+		return nil, fmt.Errorf(`"kind" must be "min" or "max", not %q`, kind)
+	}
+	count, err := drawLength(params, "pushes", 5, 8, rng)
+	if err != nil {
+		return nil, err
+	}
+	pushes, err := drawValues(params, count, rng, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// above reports whether a belongs above b in the heap
+	above := func(a, b int) bool {
+		if kind == "max" {
+			return a > b
+		}
+		return a < b
+	}
+	heap := make([]int, 0, len(pushes))
+	for _, n := range pushes {
+		heap = append(heap, n)
+		for i := len(heap) - 1; i > 0; {
+			parent := (i - 1) / 2
+			if !above(heap[i], heap[parent]) {
+				break
+			}
+			heap[i], heap[parent] = heap[parent], heap[i]
+			i = parent
+		}
+	}
+
+	return map[string]interface{}{
+		"pushes": pushes,
+		"heap":   heap,
+		"top":    heap[0],
+		"kind":   kind,
+	}, nil
+}
+
+// randomArray draws an array whose "length" is a number or a [min, max]
+// range (default [5, 10]) and whose values are drawn from the "values"
+// range (default [0, 99])
+func randomArray(params map[string]interface{}, rng *rand.Rand, distinct, sorted bool) ([]int, error) {
+	length, err := drawLength(params, "length", 5, 10, rng)
+	if err != nil {
+		return nil, err
+	}
+	// Checked against the longest length, so that every instance can be drawn
+	if distinct {
+		_, longest, _ := rangeParam(params, "length", 5, 10)
+		lo, hi, err := rangeParam(params, "values", 0, 99)
+		if err != nil {
+			return nil, err
+		}
+		if hi-lo+1 < longest {
+			return nil, fmt.Errorf(`"values" range is too small for %d distinct values`, longest)
+		}
+	}
+	array, err := drawValues(params, length, rng, distinct)
+	if err != nil {
+		return nil, err
+	}
+	if sorted {
+		sort.Ints(array)
+	}
+	return array, nil
+}
+
+// drawLength draws the length of a generated array from a range parameter
+func drawLength(params map[string]interface{}, name string, lo, hi int, rng *rand.Rand) (int, error) {
+	lo, hi, err := rangeParam(params, name, lo, hi)
+	if err != nil {
+		return 0, err
+	}
+	if lo < 1 || hi > maxGeneratedLength {
+		return 0, fmt.Errorf("%q must be between 1 and %d", name, maxGeneratedLength)
+	}
+	return lo + rng.Intn(hi-lo+1), nil
+}
+
+// drawValues draws count values from the "values" range parameter. The
+// range must hold count values when they are distinct.
+func drawValues(params map[string]interface{}, count int, rng *rand.Rand, distinct bool) ([]int, error) {
+	lo, hi, err := rangeParam(params, "values", 0, 99)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int, 0, count)
+	seen := make(map[int]bool, count)
+	for len(values) < count {
+		n := lo + rng.Intn(hi-lo+1)
+		if distinct && seen[n] {
+			continue
+		}
+		seen[n] = true
+		values = append(values, n)
+	}
+	return values, nil
+}
+
+// rangeParam reads an integer range parameter given as a number or a
+// [min, max] pair, defaulting to [lo, hi]
+func rangeParam(params map[string]interface{}, name string, lo, hi int) (int, int, error) {
+	switch v := params[name].(type) {
+	case nil:
+		return lo, hi, nil
+	case float64:
+		return int(v), int(v), nil
+	case []interface{}:
+		if len(v) == 2 {
+			first, firstOK := v[0].(float64)
+			last, lastOK := v[1].(float64)
+			if firstOK && lastOK && first <= last {
+				return int(first), int(last), nil
+			}
+		}
+	}
+	return 0, 0, fmt.Errorf("%q must be a number or a [min, max] range", name)
+}
@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
 
 	"github.com/yourusername/algoholic/models"
 	"gorm.io/gorm"
@@ -11,12 +15,47 @@ import (
 
 // QuestionService handles question-related operations
 type QuestionService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	executor     *CodeExecutor
+	assessor     *AssessmentService
+	graders      *GraderRegistry
+	generators   *GeneratorRegistry
+	memorization *MemorizationDetector
 }
 
-// NewQuestionService creates a new question service
-func NewQuestionService(db *gorm.DB) *QuestionService {
-	return &QuestionService{db: db}
+// NewQuestionService creates a new question service with graders for the
+// built-in question formats. Open-ended answers are graded by the assessor's
+// model when it is not nil, and by rubric keywords otherwise.
+func NewQuestionService(db *gorm.DB, executor *CodeExecutor, assessor *AssessmentService) *QuestionService {
+	if executor == nil {
+		executor = NewCodeExecutor(nil, DefaultResourceLimits, nil, nil, nil)
+	}
+	s := &QuestionService{db: db, executor: executor, assessor: assessor, graders: NewGraderRegistry(),
+		generators:   NewGeneratorRegistry(),
+		memorization: NewMemorizationDetector(db)}
+	for _, grader := range s.builtinGraders() {
+		if err := s.graders.Register(grader); err != nil {
+			log.Printf("Warning: failed to register %s grader: %v", grader.Format(), err)
+		}
+	}
+	for _, generator := range builtinGenerators() {
+		if err := s.generators.Register(generator); err != nil {
+			log.Printf("Warning: failed to register %s generator: %v", generator.Name(), err)
+		}
+	}
+	return s
+}
+
+// Graders returns the registry of question format graders. Registering a
+// grader adds a question format.
+func (s *QuestionService) Graders() *GraderRegistry {
+	return s.graders
+}
+
+// Generators returns the registry of template question generators.
+// Registering a generator adds a kind of template.
+func (s *QuestionService) Generators() *GeneratorRegistry {
+	return s.generators
 }
 
 // GetQuestions retrieves questions with filters
@@ -54,6 +93,35 @@ func (s *QuestionService) GetQuestionByID(id int) (*models.Question, error) {
 	return &question, nil
 }
 
+// ValidateQuestion checks that a question's correct answer and data fit its
+// format. A template question is checked by generating an instance.
+func (s *QuestionService) ValidateQuestion(question *models.Question) error {
+	question, err := s.Instantiate(question, 1)
+	if err != nil {
+		return err
+	}
+	grader, ok := s.graders.Lookup(question.QuestionFormat)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownQuestionFormat, question.QuestionFormat)
+	}
+	decoded, err := decodedQuestion(question)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuestion, err)
+	}
+	if err := grader.ValidateQuestion(decoded); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuestion, err)
+	}
+	return nil
+}
+
+// CreateQuestion validates and stores a new question
+func (s *QuestionService) CreateQuestion(question *models.Question) error {
+	if err := s.ValidateQuestion(question); err != nil {
+		return err
+	}
+	return s.db.Create(question).Error
+}
+
 // GetQuestionsByProblem retrieves all questions for a problem
 func (s *QuestionService) GetQuestionsByProblem(problemID int) ([]models.Question, error) {
 	var questions []models.Question
@@ -61,6 +129,16 @@ func (s *QuestionService) GetQuestionsByProblem(problemID int) ([]models.Questio
 	return questions, err
 }
 
+// GetQuestionTopicIDs retrieves the topics of a question's problem
+func (s *QuestionService) GetQuestionTopicIDs(questionID int) ([]int, error) {
+	var topicIDs []int
+	err := s.db.Table("problem_topics").
+		Joins("JOIN questions ON questions.problem_id = problem_topics.problem_id").
+		Where("questions.question_id = ?", questionID).
+		Pluck("problem_topics.topic_id", &topicIDs).Error
+	return topicIDs, err
+}
+
 // GetRandomQuestion gets a random question with optional filters
 func (s *QuestionService) GetRandomQuestion(questionType string, minDifficulty, maxDifficulty float64, excludeIDs []int) (*models.Question, error) {
 	query := s.db.Model(&models.Question{})
@@ -89,22 +167,34 @@ func (s *QuestionService) GetRandomQuestion(questionType string, minDifficulty,
 // AnswerRequest represents a question answer submission
 type AnswerRequest struct {
 	QuestionID     int                    `json:"question_id"`
+	SessionToken   string                 `json:"session_token"`
 	UserAnswer     map[string]interface{} `json:"user_answer"`
-	TimeTaken      int                    `json:"time_taken_seconds"`
-	HintsUsed      int                    `json:"hints_used"`
 	Confidence     *int                   `json:"confidence_level,omitempty"`
 	TrainingPlanID *int                   `json:"training_plan_id,omitempty"`
+
+	// Measured on the server from the question session
+	SessionID *string `json:"-"`
+	TimeTaken int     `json:"-"`
+	HintsUsed int     `json:"-"`
+	// Seed of the template question instance the session showed
+	Seed *int64 `json:"-"`
 }
 
 // AnswerResponse represents the result of answering a question
 type AnswerResponse struct {
 	IsCorrect              bool                   `json:"is_correct"`
+	Score                  float64                `json:"score"`
 	CorrectAnswer          map[string]interface{} `json:"correct_answer"`
 	Explanation            string                 `json:"explanation"`
 	WrongAnswerExplanation string                 `json:"wrong_answer_explanation,omitempty"`
 	AttemptID              int                    `json:"attempt_id"`
 	PointsEarned           int                    `json:"points_earned"`
 	NewProficiencyLevel    float64                `json:"new_proficiency_level,omitempty"`
+	Feedback               string                 `json:"feedback,omitempty"`
+	Mistakes               []string               `json:"mistakes,omitempty"`
+	Details                interface{}            `json:"details,omitempty"`
+	PatternFeedback        *PatternFeedback       `json:"pattern_feedback,omitempty"`
+	Memorization           *MemorizationCheck     `json:"memorization,omitempty"`
 }
 
 // SubmitAnswer processes a question answer
@@ -115,8 +205,33 @@ func (s *QuestionService) SubmitAnswer(userID int, req AnswerRequest) (*AnswerRe
 		return nil, err
 	}
 
-	// Check if answer is correct
-	isCorrect := s.CheckAnswer(question, req.UserAnswer)
+	// Template questions are graded against the instance the user was shown
+	if IsTemplate(question) {
+		if req.Seed == nil {
+			return nil, fmt.Errorf("%w: template questions are answered from a started session", ErrInvalidSession)
+		}
+		if question, err = s.Instantiate(question, *req.Seed); err != nil {
+			return nil, err
+		}
+	}
+
+	// Code answers are executed, so they wait their turn in the queue
+	if s.executesCode(question) {
+		job, err := s.executor.Queue().Admit(userID)
+		if err != nil {
+			return nil, err
+		}
+		job.Wait()
+		defer job.Done()
+	}
+
+	// Grade the answer; only full marks count as correct
+	result, err := s.Grade(question, req.UserAnswer)
+	if err != nil {
+		return nil, err
+	}
+	score := result.Score
+	isCorrect := result.Correct
 
 	// Create user attempt record
 	userAnswerJSON, _ := json.Marshal(req.UserAnswer)
@@ -128,10 +243,19 @@ func (s *QuestionService) SubmitAnswer(userID int, req AnswerRequest) (*AnswerRe
 		QuestionID:       &req.QuestionID,
 		UserAnswer:       userAnswerMap,
 		IsCorrect:        isCorrect,
+		Score:            &score,
+		MistakesMade:     models.StringArray(result.Mistakes),
 		TimeTakenSeconds: req.TimeTaken,
 		HintsUsed:        req.HintsUsed,
 		ConfidenceLevel:  req.Confidence,
 		TrainingPlanID:   req.TrainingPlanID,
+		SessionID:        req.SessionID,
+	}
+
+	// Record the techniques a code answer uses
+	var patternFeedback *PatternFeedback
+	if question.QuestionFormat == "code" {
+		attempt.DetectedPatterns, patternFeedback = s.analyzePatterns(question, req.UserAnswer)
 	}
 
 	// Get attempt number for this user/question
@@ -141,6 +265,10 @@ func (s *QuestionService) SubmitAnswer(userID int, req AnswerRequest) (*AnswerRe
 		Count(&attemptCount)
 	attempt.AttemptNumber = int(attemptCount) + 1
 
+	// Flag correct answers that look memorized rather than understood
+	memorization := s.memorization.Check(question, &attempt)
+	attempt.ShowsMemorization = &memorization.ShowsMemorization
+
 	if err := s.db.Create(&attempt).Error; err != nil {
 		return nil, err
 	}
@@ -149,15 +277,23 @@ func (s *QuestionService) SubmitAnswer(userID int, req AnswerRequest) (*AnswerRe
 	s.UpdateQuestionStats(req.QuestionID, isCorrect, req.TimeTaken)
 
 	// Calculate points
-	points := s.CalculatePoints(question, isCorrect, req.TimeTaken, req.HintsUsed)
+	points := s.CalculatePoints(question, score, req.TimeTaken, req.HintsUsed)
 
 	// Build response
 	response := &AnswerResponse{
-		IsCorrect:     isCorrect,
-		CorrectAnswer: question.CorrectAnswer,
-		Explanation:   question.Explanation,
-		AttemptID:     attempt.AttemptID,
-		PointsEarned:  points,
+		IsCorrect:       isCorrect,
+		Score:           score,
+		CorrectAnswer:   models.PublicCorrectAnswer(question.CorrectAnswer),
+		Explanation:     question.Explanation,
+		AttemptID:       attempt.AttemptID,
+		PointsEarned:    points,
+		Feedback:        result.Feedback,
+		Mistakes:        result.Mistakes,
+		Details:         result.Details,
+		PatternFeedback: patternFeedback,
+	}
+	if memorization.ShowsMemorization {
+		response.Memorization = memorization
 	}
 
 	// Add wrong answer explanation if applicable
@@ -172,147 +308,214 @@ func (s *QuestionService) SubmitAnswer(userID int, req AnswerRequest) (*AnswerRe
 	return response, nil
 }
 
-// CheckAnswer validates if the user's answer is correct
+// Grade validates an answer and grades it with the grader for the
+// question's format. The score is clamped to between 0 and 1, and only a
+// score of 1 is correct.
+func (s *QuestionService) Grade(question *models.Question, userAnswer map[string]interface{}) (*GradeResult, error) {
+	grader, ok := s.graders.Lookup(question.QuestionFormat)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownQuestionFormat, question.QuestionFormat)
+	}
+	decoded, err := decodedQuestion(question)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuestion, err)
+	}
+	answer, err := decodeJSON(userAnswer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnswer, err)
+	}
+	if answer == nil {
+		answer = map[string]interface{}{}
+	}
+	if err := grader.ValidateAnswer(decoded, answer); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidAnswer, err)
+	}
+
+	result, err := grader.Grade(decoded, answer)
+	if err != nil {
+		return nil, err
+	}
+	if result.Score < 0 || math.IsNaN(result.Score) {
+		result.Score = 0
+	} else if result.Score > 1 {
+		result.Score = 1
+	}
+	result.Correct = result.Score >= 1
+	if result.Feedback == "" {
+		switch {
+		case result.Correct:
+			result.Feedback = "Correct"
+		case result.Score > 0:
+			result.Feedback = "Partially correct"
+		default:
+			result.Feedback = "Incorrect"
+		}
+	}
+	return result, nil
+}
+
+// CheckAnswer reports whether an answer is fully correct. Answers that
+// cannot be graded are not.
 func (s *QuestionService) CheckAnswer(question *models.Question, userAnswer map[string]interface{}) bool {
-	switch question.QuestionFormat {
-	case "multiple_choice":
-		return s.CheckMultipleChoice(question, userAnswer)
-	case "code":
-		return s.CheckCode(question, userAnswer)
-	case "text":
-		return s.CheckText(question, userAnswer)
-	case "ranking":
-		return s.CheckRanking(question, userAnswer)
-	default:
+	result, err := s.Grade(question, userAnswer)
+	if err != nil {
+		log.Printf("Warning: failed to grade answer to question %d: %v", question.QuestionID, err)
 		return false
 	}
+	return result.Correct
 }
 
-// CheckMultipleChoice validates multiple choice answers
-func (s *QuestionService) CheckMultipleChoice(question *models.Question, userAnswer map[string]interface{}) bool {
-	userSelection, ok := userAnswer["answer"].(string)
-	if !ok {
-		return false
+// ScoreAnswer grades an answer from 0 to 1. Answers that cannot be graded
+// score 0.
+func (s *QuestionService) ScoreAnswer(question *models.Question, userAnswer map[string]interface{}) float64 {
+	result, err := s.Grade(question, userAnswer)
+	if err != nil {
+		log.Printf("Warning: failed to grade answer to question %d: %v", question.QuestionID, err)
+		return 0
 	}
+	return result.Score
+}
 
-	correctAnswer, ok := question.CorrectAnswer["answer"].(string)
+// executesCode reports whether grading an answer to the question runs code
+func (s *QuestionService) executesCode(question *models.Question) bool {
+	grader, ok := s.graders.Lookup(question.QuestionFormat)
 	if !ok {
 		return false
 	}
-
-	return userSelection == correctAnswer
+	runner, ok := grader.(CodeGrader)
+	return ok && runner.ExecutesCode(question)
 }
 
-// CheckCode validates code answers with actual execution
-func (s *QuestionService) CheckCode(question *models.Question, userAnswer map[string]interface{}) bool {
-	code, ok := userAnswer["code"].(string)
-	if !ok || len(code) == 0 {
-		return false
+// analyzePatterns detects the algorithm patterns in a code answer and, when
+// the question belongs to a problem with a primary pattern, compares them
+// with it. Code that cannot be analyzed records no patterns.
+func (s *QuestionService) analyzePatterns(question *models.Question, userAnswer map[string]interface{}) (models.StringArray, *PatternFeedback) {
+	code, _ := userAnswer["code"].(string)
+	if strings.TrimSpace(code) == "" {
+		return nil, nil
 	}
-
 	language, _ := userAnswer["language"].(string)
 	if language == "" {
-		language = "python" // default to Python
+		language = "python"
 	}
-
-	// Get test cases from correct_answer
-	testCases, ok := question.CorrectAnswer["test_cases"]
-	if !ok {
-		// Fallback: if no test cases, just validate code structure
-		executor := NewCodeExecutor("")
-		return executor.ValidateCode(code, language)
+	if lang, ok := s.executor.languages.Lookup(language); ok {
+		language = lang.ID
 	}
 
-	// Convert test cases to proper format
-	var testCaseList []interface{}
-	switch v := testCases.(type) {
-	case []interface{}:
-		testCaseList = v
-	default:
-		// Invalid format, fallback to structure validation
-		executor := NewCodeExecutor("")
-		return executor.ValidateCode(code, language)
+	analysis, err := DetectPatterns(code, language)
+	if err != nil {
+		if !errors.Is(err, ErrPatternLanguageUnsupported) {
+			log.Printf("Warning: pattern detection failed for question %d: %v", question.QuestionID, err)
+		}
+		return nil, nil
 	}
+	detected := analysis.Names()
 
-	// Run code execution tests
-	executor := NewCodeExecutor("") // Uses default Judge0 URL
-	result, err := executor.RunTests(code, language, testCaseList)
-
-	if err != nil {
-		// If execution service is unavailable, fallback to validation
-		return executor.ValidateCode(code, language)
+	var feedback *PatternFeedback
+	if question.ProblemID != nil {
+		var problem models.Problem
+		if err := s.db.First(&problem, *question.ProblemID).Error; err == nil {
+			feedback = ComparePatterns(detected, &problem)
+		}
 	}
+	return models.StringArray(detected), feedback
+}
 
-	return result.AllPassed
+// RunRequest represents a request to run code without grading it
+type RunRequest struct {
+	Code       string  `json:"code"`
+	Language   string  `json:"language"`
+	Stdin      *string `json:"stdin,omitempty"`
+	RunSamples bool    `json:"run_samples"`
 }
 
-// CheckText validates text answers with fuzzy matching
-func (s *QuestionService) CheckText(question *models.Question, userAnswer map[string]interface{}) bool {
-	userText, ok := userAnswer["answer"].(string)
-	if !ok {
-		return false
-	}
+// RunResponse contains the output of an ungraded run
+type RunResponse struct {
+	Output  *ExecutionOutput `json:"output,omitempty"`
+	Samples []SampleRun      `json:"samples,omitempty"`
+}
 
-	correctAnswer, ok := question.CorrectAnswer["answer"]
-	if !ok {
-		return false
+// RunCode runs code for a code question against custom stdin and/or the
+// question's sample tests. Nothing is recorded: no attempt, stats or
+// proficiency change.
+func (s *QuestionService) RunCode(userID, questionID int, req RunRequest) (*RunResponse, error) {
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, errors.New("code is required")
+	}
+	if req.Stdin == nil && !req.RunSamples {
+		return nil, errors.New("provide stdin or set run_samples")
+	}
+	if req.Language == "" {
+		req.Language = "python" // default to Python
 	}
 
-	validator := NewTextValidator()
+	question, err := s.GetQuestionByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+	if question.QuestionFormat != "code" {
+		return nil, errors.New("question does not accept code")
+	}
 
-	// Support multiple correct answer formats
-	switch v := correctAnswer.(type) {
-	case string:
-		// Single correct answer
-		return validator.FuzzyMatch(userText, v)
+	testCases, _ := question.CorrectAnswer["test_cases"].([]interface{})
+	suite, err := s.codeTestSuite(question, testCases)
+	if err != nil {
+		return nil, err
+	}
 
-	case []interface{}:
-		// Multiple acceptable answers
-		acceptableAnswers := make([]string, 0, len(v))
-		for _, ans := range v {
-			if ansStr, ok := ans.(string); ok {
-				acceptableAnswers = append(acceptableAnswers, ansStr)
-			}
-		}
-		return validator.MatchMultiple(userText, acceptableAnswers)
+	job, err := s.executor.Queue().Admit(userID)
+	if err != nil {
+		return nil, err
+	}
+	defer job.Done()
+	job.Wait()
 
-	default:
-		// Fallback to exact match if format is unexpected
-		if str, ok := v.(string); ok {
-			return validator.FuzzyMatch(userText, str)
+	response := &RunResponse{}
+	if req.Stdin != nil {
+		response.Output, err = s.executor.Run(req.Code, req.Language, *req.Stdin, suite.Signature)
+		if err != nil {
+			return nil, err
 		}
-		return false
 	}
-}
 
-// CheckRanking validates ranking answers
-func (s *QuestionService) CheckRanking(question *models.Question, userAnswer map[string]interface{}) bool {
-	userRanking, ok := userAnswer["ranking"].([]interface{})
-	if !ok {
-		return false
+	if req.RunSamples {
+		response.Samples, err = s.executor.RunSamples(req.Code, req.Language, suite)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	correctRanking, ok := question.CorrectAnswer["ranking"].([]interface{})
-	if !ok {
-		return false
-	}
+	return response, nil
+}
 
-	if len(userRanking) != len(correctRanking) {
-		return false
+// codeTestSuite builds the test suite for a code question, including the
+// function signature of its problem if it has one
+func (s *QuestionService) codeTestSuite(question *models.Question, testCases []interface{}) (TestSuite, error) {
+	suite := TestSuite{TestCases: testCases, Checker: question.CorrectAnswer["checker"]}
+	if question.ProblemID == nil {
+		return suite, nil
 	}
 
-	for i := range userRanking {
-		if fmt.Sprint(userRanking[i]) != fmt.Sprint(correctRanking[i]) {
-			return false
+	var problem models.Problem
+	if err := s.db.First(&problem, *question.ProblemID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return suite, nil
 		}
+		return suite, err
 	}
 
-	return true
+	sig, err := problemSignature(&problem)
+	if err != nil {
+		return suite, err
+	}
+	suite.Signature = sig
+	return suite, nil
 }
 
-// CalculatePoints calculates points earned for an answer
-func (s *QuestionService) CalculatePoints(question *models.Question, isCorrect bool, timeTaken, hintsUsed int) int {
-	if !isCorrect {
+// CalculatePoints calculates points earned for an answer, scaled by its
+// score from 0 to 1
+func (s *QuestionService) CalculatePoints(question *models.Question, score float64, timeTaken, hintsUsed int) int {
+	if score <= 0 {
 		return 0
 	}
 
@@ -331,7 +534,7 @@ func (s *QuestionService) CalculatePoints(question *models.Question, isCorrect b
 	// Hint penalty (each hint costs 10% of base points)
 	hintPenalty := hintsUsed * basePoints / 10
 
-	points := basePoints + timeBonus - hintPenalty
+	points := int(float64(basePoints+timeBonus-hintPenalty) * score)
 	if points < 0 {
 		points = 0
 	}
@@ -415,11 +618,35 @@ func (s *QuestionService) GetHint(questionID int, hintLevel int) (string, error)
 	return *hint, nil
 }
 
-// RecordHintUsage records that a user used a hint
-func (s *QuestionService) RecordHintUsage(userID, questionID, hintLevel int) error {
+// RecordHintUsage records that a user used a hint during a question
+// session, or outside of sessions when sessionID is nil
+func (s *QuestionService) RecordHintUsage(userID, questionID, hintLevel int, sessionID *string) error {
+	query := s.db.Model(&models.QuestionHintUsage{}).
+		Where("user_id = ? AND question_id = ? AND hint_level = ?", userID, questionID, hintLevel)
+	if sessionID != nil {
+		query = query.Where("session_id = ?", *sessionID)
+	} else {
+		query = query.Where("session_id IS NULL")
+	}
+	var recorded int64
+	if err := query.Count(&recorded).Error; err != nil {
+		return err
+	}
+	if recorded > 0 {
+		if sessionID == nil {
+			// Hints seen outside of sessions count toward the next session
+			// started, so keep when it was last seen
+			return s.db.Model(&models.QuestionHintUsage{}).
+				Where("user_id = ? AND question_id = ? AND hint_level = ? AND session_id IS NULL", userID, questionID, hintLevel).
+				Update("used_at", time.Now()).Error
+		}
+		return nil
+	}
+
 	usage := models.QuestionHintUsage{
 		UserID:     userID,
 		QuestionID: questionID,
+		SessionID:  sessionID,
 		HintLevel:  hintLevel,
 	}
 
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+var (
+	// ErrInvalidSession is returned for a missing, forged or expired
+	// session token, or one issued for another user or question
+	ErrInvalidSession = errors.New("invalid or expired question session")
+	// ErrSessionInProgress is returned while another submission with the
+	// same session is being graded
+	ErrSessionInProgress = errors.New("an answer for this session is already being graded")
+)
+
+// DefaultQuestionSessionTTL is how long a started question can be answered
+const DefaultQuestionSessionTTL = 24 * time.Hour
+
+// QuestionSessionClaims are the claims of a signed question session token
+type QuestionSessionClaims struct {
+	UserID     int `json:"user_id"`
+	QuestionID int `json:"question_id"`
+	jwt.RegisteredClaims
+}
+
+// QuestionSessionService starts questions on the server and checks the
+// signed session tokens answers are submitted with, so that the time taken
+// and hints used are measured on the server rather than reported by the
+// client
+type QuestionSessionService struct {
+	db  *gorm.DB
+	key []byte
+	ttl time.Duration
+}
+
+// NewQuestionSessionService creates a question session service. Tokens are
+// signed with a key derived from secret, so they are never valid as login
+// tokens.
+func NewQuestionSessionService(db *gorm.DB, secret string) *QuestionSessionService {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("question sessions"))
+	return &QuestionSessionService{
+		db:  db,
+		key: mac.Sum(nil),
+		ttl: DefaultQuestionSessionTTL,
+	}
+}
+
+// StartedQuestion is a started question session
+type StartedQuestion struct {
+	SessionToken string    `json:"session_token"`
+	SessionID    string    `json:"session_id"`
+	QuestionID   int       `json:"question_id"`
+	StartedAt    time.Time `json:"started_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	// Instance is what the session shows of a template question
+	Instance *QuestionInstance `json:"instance,omitempty"`
+	// Seed generates the instance of a template question
+	Seed *int64 `json:"-"`
+}
+
+// Start starts a session for a user answering a question. It expires the
+// user's other open sessions for the question, so hints are always recorded
+// on the session that will be answered. Sessions of template questions draw
+// a seed for a fresh instance.
+func (s *QuestionSessionService) Start(userID, questionID int) (*StartedQuestion, error) {
+	var question models.Question
+	if err := s.db.Select("question_id", "template").First(&question, questionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("question not found")
+		}
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := models.QuestionSession{
+		SessionID:  hex.EncodeToString(id),
+		UserID:     userID,
+		QuestionID: questionID,
+		StartedAt:  now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+	if IsTemplate(&question) {
+		seed := make([]byte, 8)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+		n := int64(binary.BigEndian.Uint64(seed) >> 1)
+		session.Seed = &n
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.QuestionSession{}).
+			Where("user_id = ? AND question_id = ? AND submitted_at IS NULL AND expires_at > ?", userID, questionID, now).
+			Update("expires_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&session).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &QuestionSessionClaims{
+		UserID:     userID,
+		QuestionID: questionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        session.SessionID,
+			IssuedAt:  jwt.NewNumericDate(session.StartedAt),
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StartedQuestion{
+		SessionToken: token,
+		SessionID:    session.SessionID,
+		QuestionID:   questionID,
+		StartedAt:    session.StartedAt,
+		ExpiresAt:    session.ExpiresAt,
+		Seed:         session.Seed,
+	}, nil
+}
+
+// Claim checks a session token and claims its session for grading an
+// answer. When the session's answer has already been graded, its response
+// is returned instead and the session is nil. A claimed session must be
+// completed, or released if grading fails.
+func (s *QuestionSessionService) Claim(token string, userID, questionID int) (*models.QuestionSession, *AnswerResponse, error) {
+	claims := &QuestionSessionClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	if err != nil || !parsed.Valid || claims.UserID != userID || claims.QuestionID != questionID {
+		return nil, nil, ErrInvalidSession
+	}
+
+	var session models.QuestionSession
+	if err := s.db.First(&session, "session_id = ?", claims.ID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidSession
+		}
+		return nil, nil, err
+	}
+
+	// Only one submission claims the session; the rest are replays
+	now := time.Now()
+	result := s.db.Model(&models.QuestionSession{}).
+		Where("session_id = ? AND submitted_at IS NULL AND expires_at > ?", session.SessionID, now).
+		Update("submitted_at", now)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := s.db.First(&session, "session_id = ?", session.SessionID).Error; err != nil {
+			return nil, nil, err
+		}
+		if session.SubmittedAt == nil {
+			// Expired, most likely by a newer session
+			return nil, nil, ErrInvalidSession
+		}
+		if session.Response == nil {
+			return nil, nil, ErrSessionInProgress
+		}
+		replay, err := decodeAnswerResponse(session.Response)
+		return nil, replay, err
+	}
+	session.SubmittedAt = &now
+	return &session, nil, nil
+}
+
+// Elapsed is the time in seconds from starting a session to submitting its
+// answer
+func (s *QuestionSessionService) Elapsed(session *models.QuestionSession) int {
+	if session.SubmittedAt == nil {
+		return 0
+	}
+	return int(session.SubmittedAt.Sub(session.StartedAt).Seconds())
+}
+
+// HintsUsed counts the hints seen for a session: those seen during it, and
+// those seen with no session open since the user last answered the question
+func (s *QuestionSessionService) HintsUsed(session *models.QuestionSession) int {
+	var since time.Time
+	var previous models.QuestionSession
+	err := s.db.Select("submitted_at").
+		Where("user_id = ? AND question_id = ? AND session_id <> ? AND submitted_at <= ?",
+			session.UserID, session.QuestionID, session.SessionID, session.StartedAt).
+		Order("submitted_at DESC").
+		First(&previous).Error
+	if err == nil && previous.SubmittedAt != nil {
+		since = *previous.SubmittedAt
+	}
+	until := time.Now()
+	if session.SubmittedAt != nil {
+		until = *session.SubmittedAt
+	}
+
+	var count int64
+	s.db.Model(&models.QuestionHintUsage{}).
+		Where("session_id = ? OR (session_id IS NULL AND user_id = ? AND question_id = ? AND used_at > ? AND used_at <= ?)",
+			session.SessionID, session.UserID, session.QuestionID, since, until).
+		Distinct("hint_level").
+		Count(&count)
+	return int(count)
+}
+
+// OpenSession returns the ID of a user's latest session for a question that
+// is neither submitted nor expired, or nil when there is none
+func (s *QuestionSessionService) OpenSession(userID, questionID int) *string {
+	var session models.QuestionSession
+	err := s.db.Select("session_id").
+		Where("user_id = ? AND question_id = ? AND submitted_at IS NULL AND expires_at > ?", userID, questionID, time.Now()).
+		Order("started_at DESC").
+		First(&session).Error
+	if err != nil {
+		return nil
+	}
+	return &session.SessionID
+}
+
+// Complete stores the graded answer of a claimed session, to be returned
+// when the submission is replayed
+func (s *QuestionSessionService) Complete(session *models.QuestionSession, response *AnswerResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	var stored models.JSONB
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	return s.db.Model(&models.QuestionSession{}).
+		Where("session_id = ?", session.SessionID).
+		Updates(map[string]interface{}{"attempt_id": response.AttemptID, "response": stored}).Error
+}
+
+// Release gives up a claimed session whose answer could not be graded, so
+// that it can be submitted again
+func (s *QuestionSessionService) Release(session *models.QuestionSession) error {
+	return s.db.Model(&models.QuestionSession{}).
+		Where("session_id = ?", session.SessionID).
+		Update("submitted_at", nil).Error
+}
+
+// decodeAnswerResponse decodes a stored answer response
+func decodeAnswerResponse(stored models.JSONB) (*AnswerResponse, error) {
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	var response AnswerResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+// A template question has a "template" naming a generator and its
+// parameters:
+//
+//	{"generator": "binary_search", "params": {"length": [6, 10], "values": [1, 99]}}
+//
+// Each instance of the question is generated from a seed. The generator
+// draws the instance's values, such as an array and a target, and computes
+// the answers from them in Go. The values replace the {{name}} placeholders
+// in the question's text, data, options, correct answer and explanation.
+// The seed is stored with the question session, so the answer is graded
+// against the instance the user was shown.
+
+// QuestionGenerator generates the values of template question instances
+type QuestionGenerator interface {
+	// Name is the generator a question template names
+	Name() string
+	// Generate draws the values of one instance, answers included, from
+	// the template's parameters. Parameters reach it as decoded JSON.
+	Generate(params map[string]interface{}, rng *rand.Rand) (map[string]interface{}, error)
+}
+
+// QuestionTemplate is the template of a template question
+type QuestionTemplate struct {
+	Generator string                 `json:"generator"`
+	Params    map[string]interface{} `json:"params,omitempty"`
+}
+
+// QuestionInstance is what a user is shown of a template question instance
+type QuestionInstance struct {
+	QuestionText  string       `json:"question_text"`
+	QuestionData  models.JSONB `json:"question_data,omitempty"`
+	AnswerOptions models.JSONB `json:"answer_options,omitempty"`
+}
+
+// GeneratorRegistry maps generator names to question generators
+type GeneratorRegistry struct {
+	mu         sync.RWMutex
+	generators map[string]QuestionGenerator
+}
+
+// NewGeneratorRegistry creates an empty generator registry
+func NewGeneratorRegistry() *GeneratorRegistry {
+	return &GeneratorRegistry{generators: make(map[string]QuestionGenerator)}
+}
+
+// Register adds a generator under its name. Each name has one generator.
+func (r *GeneratorRegistry) Register(generator QuestionGenerator) error {
+	name := generator.Name()
+	if name == "" {
+		return errors.New("generator has no name")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.generators[name]; exists {
+		return fmt.Errorf("a generator is already registered as %q", name)
+	}
+	r.generators[name] = generator
+	return nil
+}
+
+// Lookup returns the generator registered under a name
+func (r *GeneratorRegistry) Lookup(name string) (QuestionGenerator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	generator, ok := r.generators[name]
+	return generator, ok
+}
+
+// Names lists the registered generators in order
+func (r *GeneratorRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.generators))
+	for name := range r.generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsTemplate reports whether a question is a template for generated
+// instances
+func IsTemplate(question *models.Question) bool {
+	return len(question.Template) > 0
+}
+
+// Instantiate returns the instance of a template question generated from a
+// seed. The same seed always gives the same instance. Questions that are
+// not templates are returned as they are.
+func (s *QuestionService) Instantiate(question *models.Question, seed int64) (*models.Question, error) {
+	if !IsTemplate(question) {
+		return question, nil
+	}
+
+	var template QuestionTemplate
+	if err := decodeJSONB(question.Template, &template); err != nil {
+		return nil, fmt.Errorf("%w: template: %v", ErrInvalidQuestion, err)
+	}
+	generator, ok := s.generators.Lookup(template.Generator)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown generator %q", ErrInvalidQuestion, template.Generator)
+	}
+	values, err := generator.Generate(template.Params, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s generator: %v", ErrInvalidQuestion, template.Generator, err)
+	}
+
+	instance := *question
+	texts := []struct {
+		field string
+		text  *string
+	}{
+		{"question_text", &instance.QuestionText},
+		{"explanation", &instance.Explanation},
+	}
+	for _, t := range texts {
+		if *t.text, err = fillPlaceholders(*t.text, values); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuestion, t.field, err)
+		}
+	}
+	fields := []struct {
+		field string
+		value *models.JSONB
+	}{
+		{"question_data", &instance.QuestionData},
+		{"answer_options", &instance.AnswerOptions},
+		{"correct_answer", &instance.CorrectAnswer},
+		{"wrong_answer_explanations", &instance.WrongAnswerExplanations},
+	}
+	for _, f := range fields {
+		if *f.value == nil {
+			continue
+		}
+		decoded, err := decodeJSON(*f.value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuestion, f.field, err)
+		}
+		filled, err := fillJSONPlaceholders(decoded, values)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidQuestion, f.field, err)
+		}
+		*f.value = filled.(map[string]interface{})
+	}
+	return &instance, nil
+}
+
+// ShowInstance returns what a user is shown of a question's instance for a
+// seed
+func (s *QuestionService) ShowInstance(questionID int, seed int64) (*QuestionInstance, error) {
+	question, err := s.GetQuestionByID(questionID)
+	if err != nil {
+		return nil, err
+	}
+	instance, err := s.Instantiate(question, seed)
+	if err != nil {
+		return nil, err
+	}
+	return &QuestionInstance{
+		QuestionText:  instance.QuestionText,
+		QuestionData:  instance.QuestionData,
+		AnswerOptions: instance.AnswerOptions,
+	}, nil
+}
+
+// placeholderPattern matches a {{name}} placeholder
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// fillPlaceholders replaces the placeholders in text with the values they
+// name
+func fillPlaceholders(text string, values map[string]interface{}) (string, error) {
+	var missing string
+	filled := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		value, ok := values[name]
+		if !ok {
+			if missing == "" {
+				missing = name
+			}
+			return placeholder
+		}
+		return formatTemplateValue(value)
+	})
+	if missing != "" {
+		return "", fmt.Errorf("no value for {{%s}}", missing)
+	}
+	return filled, nil
+}
+
+// fillJSONPlaceholders replaces the placeholders in every string of decoded
+// JSON, keys included
+func fillJSONPlaceholders(value interface{}, values map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return fillPlaceholders(v, values)
+	case map[string]interface{}:
+		filled := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			key, err := fillPlaceholders(key, values)
+			if err != nil {
+				return nil, err
+			}
+			if filled[key], err = fillJSONPlaceholders(item, values); err != nil {
+				return nil, err
+			}
+		}
+		return filled, nil
+	case []interface{}:
+		filled := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if filled[i], err = fillJSONPlaceholders(item, values); err != nil {
+				return nil, err
+			}
+		}
+		return filled, nil
+	}
+	return value, nil
+}
+
+// formatTemplateValue formats a generated value for a placeholder. Lists
+// are written as [1, 2, 3].
+func formatTemplateValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case []int:
+		parts := make([]string, len(v))
+		for i, n := range v {
+			parts[i] = strconv.Itoa(n)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
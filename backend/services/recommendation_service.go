@@ -10,12 +10,13 @@ import (
 
 // RecommendationService handles question recommendations
 type RecommendationService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	calibration *CalibrationService
 }
 
 // NewRecommendationService creates a new recommendation service
 func NewRecommendationService(db *gorm.DB) *RecommendationService {
-	return &RecommendationService{db: db}
+	return &RecommendationService{db: db, calibration: NewCalibrationService(db)}
 }
 
 // Recommendation represents a recommended question
@@ -60,22 +61,36 @@ func (rs *RecommendationService) GetRecommendations(userID int, limit int) ([]Re
 	return recommendations, nil
 }
 
-// GetWeaknessBasedRecommendations finds questions for weak topics
+// GetWeaknessBasedRecommendations finds questions for weak topics. Topics
+// where the user is often confidently wrong come first.
 func (rs *RecommendationService) GetWeaknessBasedRecommendations(userID int, limit int) []Recommendation {
-	// Find user's weak topics (proficiency < 50)
+	// Find user's weak topics (proficiency < 50, or confidently wrong)
 	var weakTopics []struct {
 		TopicID          int
 		TopicName        string
 		ProficiencyLevel float64
 	}
 
-	rs.db.Table("user_skills us").
+	flagged, _ := rs.calibration.ConfidentlyWrongTopics(userID)
+	confidentlyWrong := make(map[int]bool, len(flagged))
+	for _, id := range flagged {
+		confidentlyWrong[id] = true
+	}
+
+	query := rs.db.Table("user_skills us").
 		Select("us.topic_id, t.name as topic_name, us.proficiency_level").
-		Joins("JOIN topics t ON us.topic_id = t.topic_id").
-		Where("us.user_id = ? AND us.proficiency_level < 50", userID).
-		Order("us.proficiency_level ASC").
-		Limit(5). // Top 5 weakest topics
+		Joins("JOIN topics t ON us.topic_id = t.topic_id")
+	if len(flagged) > 0 {
+		query = query.Where("us.user_id = ? AND (us.proficiency_level < 50 OR us.topic_id IN ?)", userID, flagged)
+	} else {
+		query = query.Where("us.user_id = ? AND us.proficiency_level < 50", userID)
+	}
+	query.Order("us.proficiency_level ASC").
+		Limit(5 + len(flagged)). // Top 5 weakest topics, and the flagged ones
 		Scan(&weakTopics)
+	sort.SliceStable(weakTopics, func(i, j int) bool {
+		return confidentlyWrong[weakTopics[i].TopicID] && !confidentlyWrong[weakTopics[j].TopicID]
+	})
 
 	recommendations := []Recommendation{}
 
@@ -100,13 +115,19 @@ func (rs *RecommendationService) GetWeaknessBasedRecommendations(userID int, lim
 			Limit(2). // 2 questions per weak topic
 			Scan(&questions)
 
+		reason := fmt.Sprintf("Practice %s (proficiency: %.0f%%)", wt.TopicName, wt.ProficiencyLevel)
+		priority := 90.0 - wt.ProficiencyLevel // Lower proficiency = higher priority
+		if confidentlyWrong[wt.TopicID] {
+			reason = fmt.Sprintf("Practice %s (confidently wrong answers)", wt.TopicName)
+			priority = 95.0
+		}
 		for _, q := range questions {
 			recommendations = append(recommendations, Recommendation{
 				QuestionID:   q.QuestionID,
 				QuestionText: q.QuestionText,
 				ProblemID:    q.ProblemID,
-				Reason:       fmt.Sprintf("Practice %s (proficiency: %.0f%%)", wt.TopicName, wt.ProficiencyLevel),
-				Priority:     90.0 - wt.ProficiencyLevel, // Lower proficiency = higher priority
+				Reason:       reason,
+				Priority:     priority,
 				Difficulty:   q.Difficulty,
 			})
 		}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// ResultCache stores the results of grading code against a test suite, keyed
+// by ResultCacheKey. Implementations treat any failure as a miss.
+type ResultCache interface {
+	Name() string
+	Get(key string) (*ExecutionResult, bool)
+	Set(key string, result *ExecutionResult)
+}
+
+// NewResultCache creates the result cache described by cfg: Redis when
+// redis is enabled and reachable, otherwise in memory. It returns nil when
+// caching is disabled.
+func NewResultCache(cfg config.ResultCacheConfig, redis config.RedisConfig) ResultCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	ttl := time.Duration(cfg.TTL) * time.Second
+
+	if redis.Enabled {
+		cache := NewRedisResultCache(redis, ttl)
+		err := cache.Ping()
+		if err == nil {
+			return cache
+		}
+		log.Printf("Warning: redis unavailable for result cache (%v), caching in memory", err)
+	}
+	return NewMemoryResultCache(cfg.MaxEntries, ttl)
+}
+
+// ResultCacheKey identifies a grading run. The suite version covers the test
+// cases, checker and signature, so editing a question's tests changes the
+// key and earlier results are never served for it. Limits are included
+// because they decide time and memory verdicts.
+func ResultCacheKey(code, language string, suite TestSuite, limits ResourceLimits) (string, error) {
+	version, err := json.Marshal(struct {
+		TestCases []interface{}      `json:"test_cases"`
+		Checker   interface{}        `json:"checker"`
+		Signature *FunctionSignature `json:"signature"`
+	}{suite.TestCases, suite.Checker, suite.Signature})
+	if err != nil {
+		return "", err
+	}
+	suiteVersion := sha256.Sum256(version)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%x\x00%g/%g/%d\x00", language, suiteVersion, limits.CPUTime, limits.WallTime, limits.MemoryKB)
+	io.WriteString(h, normalizeSource(code))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// normalizeSource removes the one difference that cannot change what code
+// does: line endings. Whitespace is left alone, since it can matter, e.g. in
+// a string literal or a line continuation.
+func normalizeSource(code string) string {
+	return strings.ReplaceAll(code, "\r\n", "\n")
+}
+
+// cacheable reports whether a result would be the same if the code ran
+// again. Time limits and internal errors depend on load, so results with
+// either are always re-run.
+func cacheable(result *ExecutionResult) bool {
+	for _, test := range result.TestResults {
+		if test.Verdict == VerdictTimeLimitExceeded || test.Verdict == VerdictInternalError {
+			return false
+		}
+	}
+	return true
+}
+
+// MemoryResultCache is a least recently used ResultCache in process memory
+type MemoryResultCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*list.Element
+	order      *list.List // most recently used first
+}
+
+type memoryCacheEntry struct {
+	key       string
+	result    *ExecutionResult
+	expiresAt time.Time
+}
+
+// NewMemoryResultCache creates an in-memory cache holding up to maxEntries
+// results (unbounded if zero) for ttl (forever if zero)
+func NewMemoryResultCache(maxEntries int, ttl time.Duration) *MemoryResultCache {
+	return &MemoryResultCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (c *MemoryResultCache) Name() string { return "memory" }
+
+func (c *MemoryResultCache) Get(key string) (*ExecutionResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return copyResult(entry.result), true
+}
+
+func (c *MemoryResultCache) Set(key string, result *ExecutionResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, result: copyResult(result)}
+	if c.ttl > 0 {
+		entry.expiresAt = time.Now().Add(c.ttl)
+	}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// copyResult returns a copy of a result that shares no slices with it, so
+// callers cannot modify cached results
+func copyResult(result *ExecutionResult) *ExecutionResult {
+	copied := *result
+	copied.Failures = append([]FailureDetail{}, result.Failures...)
+	copied.TestResults = append([]TestResult{}, result.TestResults...)
+	return &copied
+}
+
+// redisKeyPrefix namespaces result cache keys in a shared Redis database
+const redisKeyPrefix = "algoholic:exec:"
+
+const (
+	// defaultRedisPoolSize is the pool size when redis.pool_size is unset
+	defaultRedisPoolSize = 10
+	redisTimeout         = 2 * time.Second
+)
+
+// RedisResultCache is a ResultCache in Redis, so results are shared between
+// API instances and survive restarts. Lookups time out quickly, so an
+// unreachable Redis slows grading down as little as possible.
+type RedisResultCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisResultCache creates a Redis result cache. It connects on first use.
+func NewRedisResultCache(cfg config.RedisConfig, ttl time.Duration) *RedisResultCache {
+	size := cfg.PoolSize
+	if size <= 0 {
+		size = defaultRedisPoolSize
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:            cfg.GetAddr(),
+		Password:        cfg.Password,
+		DB:              cfg.DB,
+		Protocol:        2,
+		DisableIdentity: true,
+		PoolSize:        size,
+		MaxRetries:      1,
+		DialTimeout:     redisTimeout,
+		ReadTimeout:     redisTimeout,
+		WriteTimeout:    redisTimeout,
+	})
+	return &RedisResultCache{client: client, ttl: ttl}
+}
+
+func (c *RedisResultCache) Name() string { return "redis" }
+
+// Ping checks that Redis is reachable
+func (c *RedisResultCache) Ping() error {
+	return c.client.Ping(context.Background()).Err()
+}
+
+func (c *RedisResultCache) Get(key string) (*ExecutionResult, bool) {
+	reply, err := c.client.Get(context.Background(), redisKeyPrefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("Warning: result cache get failed: %v", err)
+		}
+		return nil, false
+	}
+
+	var result ExecutionResult
+	if err := json.Unmarshal(reply, &result); err != nil {
+		log.Printf("Warning: invalid cached result for %s: %v", key, err)
+		return nil, false
+	}
+	if result.Failures == nil {
+		// Dropped from the JSON when empty
+		result.Failures = []FailureDetail{}
+	}
+	return &result, true
+}
+
+func (c *RedisResultCache) Set(key string, result *ExecutionResult) {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	if err := c.client.Set(context.Background(), redisKeyPrefix+key, payload, c.ttl).Err(); err != nil {
+		log.Printf("Warning: result cache set failed: %v", err)
+	}
+}
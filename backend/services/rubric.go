@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultRubricPassingScore is the share of rubric points an open-ended
+// answer needs when the question does not set passing_score
+const defaultRubricPassingScore = 0.7
+
+// criterionMetScore is the share of a criterion an answer must cover for it
+// to count as met
+const criterionMetScore = 0.5
+
+// How a rubric result was graded
+const (
+	RubricGradedByModel    = "model"
+	RubricGradedByKeywords = "keywords"
+)
+
+// RubricCriterion is one thing an open-ended answer is expected to cover.
+// It is met when the answer mentions any of its keywords.
+type RubricCriterion struct {
+	Criterion string   `json:"criterion"`
+	Keywords  []string `json:"keywords,omitempty"`
+	Points    float64  `json:"points"`
+	Required  bool     `json:"required,omitempty"`
+}
+
+// Rubric is the grading scheme of an open-ended question
+type Rubric struct {
+	Criteria     []RubricCriterion `json:"criteria"`
+	PassingScore float64           `json:"passing_score"`
+}
+
+// CriterionResult reports how well an answer met one criterion. Score is
+// the share of the criterion covered, from 0 to 1; keyword grading only
+// gives 0 or 1.
+type CriterionResult struct {
+	Criterion string  `json:"criterion"`
+	Met       bool    `json:"met"`
+	Score     float64 `json:"score"`
+	Points    float64 `json:"points"`
+	Earned    float64 `json:"earned"`
+	Feedback  string  `json:"feedback,omitempty"`
+}
+
+// RubricResult is the outcome of grading an answer against a rubric. Score
+// is the share of points earned, from 0 to 1.
+type RubricResult struct {
+	Score    float64           `json:"score"`
+	Passed   bool              `json:"passed"`
+	Criteria []CriterionResult `json:"criteria"`
+	Feedback string            `json:"feedback,omitempty"`
+	GradedBy string            `json:"graded_by"`
+}
+
+// ParseRubric reads a rubric from a question's correct_answer:
+//
+//	{"rubric": [{"criterion": "...", "keywords": ["..."], "points": 2, "required": true}, "..."],
+//	 "passing_score": 0.7}
+//
+// A criterion given as a plain string is worth one point and is its own
+// keyword.
+func ParseRubric(correctAnswer map[string]interface{}) (*Rubric, error) {
+	items, ok := correctAnswer["rubric"].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.New("rubric is missing")
+	}
+
+	rubric := &Rubric{PassingScore: defaultRubricPassingScore}
+	if score, ok := correctAnswer["passing_score"].(float64); ok && score > 0 && score <= 1 {
+		rubric.PassingScore = score
+	}
+
+	for i, item := range items {
+		criterion := RubricCriterion{Points: 1}
+		switch v := item.(type) {
+		case string:
+			criterion.Criterion = v
+		case map[string]interface{}:
+			criterion.Criterion, _ = v["criterion"].(string)
+			if keywords, ok := v["keywords"].([]interface{}); ok {
+				for _, keyword := range keywords {
+					if s, ok := keyword.(string); ok && strings.TrimSpace(s) != "" {
+						criterion.Keywords = append(criterion.Keywords, s)
+					}
+				}
+			}
+			if points, ok := v["points"].(float64); ok {
+				criterion.Points = points
+			}
+			criterion.Required, _ = v["required"].(bool)
+		default:
+			return nil, fmt.Errorf("rubric criterion %d is invalid", i+1)
+		}
+		if len(criterion.Keywords) == 0 {
+			if strings.TrimSpace(criterion.Criterion) == "" {
+				return nil, fmt.Errorf("rubric criterion %d has no keywords", i+1)
+			}
+			criterion.Keywords = []string{criterion.Criterion}
+		}
+		if criterion.Points < 0 {
+			return nil, fmt.Errorf("rubric criterion %d has negative points", i+1)
+		}
+		rubric.Criteria = append(rubric.Criteria, criterion)
+	}
+	return rubric, nil
+}
+
+// GradeRubric grades an answer against a rubric. The answer passes when it
+// earns the passing share of points and meets every required criterion.
+func (tv *TextValidator) GradeRubric(answer string, rubric *Rubric) *RubricResult {
+	normalized := tv.NormalizeText(answer)
+	words := strings.Fields(normalized)
+
+	scores := make([]float64, len(rubric.Criteria))
+	for i, criterion := range rubric.Criteria {
+		if normalized == "" {
+			continue
+		}
+		for _, keyword := range criterion.Keywords {
+			if tv.mentions(normalized, words, keyword) {
+				scores[i] = 1
+				break
+			}
+		}
+	}
+
+	result := rubric.result(scores, nil)
+	result.Passed = result.Passed && normalized != ""
+	result.GradedBy = RubricGradedByKeywords
+	return result
+}
+
+// result totals an answer's score for each criterion, from 0 to 1, into a
+// rubric result. feedback, if not nil, holds a comment on each criterion.
+func (rubric *Rubric) result(scores []float64, feedback []string) *RubricResult {
+	result := &RubricResult{Criteria: make([]CriterionResult, len(rubric.Criteria))}
+	var total, earned float64
+	requiredMet := true
+	for i, criterion := range rubric.Criteria {
+		cr := CriterionResult{
+			Criterion: criterion.Criterion,
+			Met:       scores[i] >= criterionMetScore,
+			Score:     scores[i],
+			Points:    criterion.Points,
+			Earned:    scores[i] * criterion.Points,
+		}
+		if feedback != nil {
+			cr.Feedback = feedback[i]
+		}
+		if criterion.Required && !cr.Met {
+			requiredMet = false
+		}
+		total += cr.Points
+		earned += cr.Earned
+		result.Criteria[i] = cr
+	}
+
+	if total > 0 {
+		result.Score = earned / total
+	}
+	result.Passed = requiredMet && result.Score >= rubric.PassingScore
+	return result
+}
+
+// mentions reports whether a normalized answer contains a keyword as whole
+// words, either verbatim or with every word of it present allowing for
+// typos
+func (tv *TextValidator) mentions(normalized string, words []string, keyword string) bool {
+	keyword = tv.NormalizeText(keyword)
+	if keyword == "" {
+		return false
+	}
+	if strings.Contains(" "+normalized+" ", " "+keyword+" ") {
+		return true
+	}
+
+	for _, want := range strings.Fields(keyword) {
+		found := false
+		for _, word := range words {
+			if word == want || (len(want) >= 5 && tv.CalculateSimilarity(word, want) >= tv.threshold) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
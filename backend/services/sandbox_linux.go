@@ -0,0 +1,543 @@
+//go:build linux
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// sandboxInitEnv carries the JSON sandboxSpec to the re-executed binary
+	sandboxInitEnv = "ALGOHOLIC_SANDBOX_INIT"
+	// sandboxErrorFD is where the sandbox init reports a setup failure. It
+	// is close-on-exec, so the user program can never write to it and pass
+	// its own failure off as the sandbox's.
+	sandboxErrorFD = 3
+	// sandboxSetupExitCode is the exit code of a failed setup
+	sandboxSetupExitCode = 125
+)
+
+// Namespaces the sandboxed process is isolated in
+const sandboxCloneFlags = syscall.CLONE_NEWUSER |
+	syscall.CLONE_NEWNS |
+	syscall.CLONE_NEWPID |
+	syscall.CLONE_NEWNET |
+	syscall.CLONE_NEWIPC |
+	syscall.CLONE_NEWUTS
+
+// The sandbox is entered by re-executing the current binary with
+// sandboxInitEnv set. The child is already inside fresh namespaces; it
+// finishes the setup that cannot be expressed through SysProcAttr (mounts,
+// rlimits, capabilities, seccomp) and then execs the user program in place.
+func init() {
+	if raw := os.Getenv(sandboxInitEnv); raw != "" {
+		sandboxInit(raw)
+	}
+}
+
+// sandboxSupported checks that the kernel exposes user namespaces
+func sandboxSupported() bool {
+	if _, err := seccompAuditArch(); err != nil {
+		return false
+	}
+	_, err := os.Stat("/proc/self/ns/user")
+	return err == nil
+}
+
+// runSandboxed runs a single process in the sandbox and collects its usage.
+//
+// Memory is reported from ru_maxrss, which Linux keeps across execve, so it
+// never drops below the footprint of the sandbox init itself.
+func runSandboxed(spec sandboxSpec, stdin string, wallTime float64, maxOutputBytes int) (*sandboxResult, error) {
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate sandbox binary: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(wallTime*float64(time.Second)))
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxOutputBytes}
+
+	setupErrors, setupErrorsWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer setupErrors.Close()
+
+	cmd := exec.CommandContext(ctx, self)
+	cmd.Env = []string{sandboxInitEnv + "=" + string(specJSON)}
+	cmd.Dir = spec.Dir
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.ExtraFiles = []*os.File{setupErrorsWriter} // sandboxErrorFD
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags: sandboxCloneFlags,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getuid(), Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: os.Getgid(), Size: 1},
+		},
+		GidMappingsEnableSetgroups: false,
+		Pdeathsig:                  syscall.SIGKILL,
+	}
+
+	var cgroup *runCgroup
+	if spec.CgroupParent != "" {
+		cgroup, err = newRunCgroup(spec.CgroupParent, spec.MemoryKB, spec.MaxProcesses)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cgroup: %w", err)
+		}
+		defer cgroup.remove()
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = int(cgroup.dir.Fd())
+	}
+
+	if err := cmd.Start(); err != nil {
+		setupErrorsWriter.Close()
+		return nil, fmt.Errorf("failed to start sandbox: %w", err)
+	}
+	// The pipe reaches EOF when the init execs the user program or exits
+	setupErrorsWriter.Close()
+	setupError, _ := io.ReadAll(setupErrors)
+	cmd.Wait()
+	state := cmd.ProcessState
+
+	res := &sandboxResult{
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		OutputTruncated: stdout.truncated || stderr.truncated,
+		WallTimeout:     errors.Is(ctx.Err(), context.DeadlineExceeded),
+		CPUTimeMs:       float64(state.UserTime()+state.SystemTime()) / float64(time.Millisecond),
+		OOMKilled:       cgroup != nil && cgroup.oomKilled(),
+	}
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		res.MaxRSSKB = int(rusage.Maxrss)
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		res.Signaled = true
+		res.Signal = unix.SignalName(ws.Signal())
+	} else {
+		res.ExitCode = state.ExitCode()
+	}
+
+	if len(setupError) > 0 {
+		return nil, fmt.Errorf("sandbox setup failed: %s", strings.TrimSpace(string(setupError)))
+	}
+
+	return res, nil
+}
+
+// prepareCgroupParent checks that a delegated cgroup v2 directory offers the
+// memory and pids controllers and enables them for the per-run cgroups
+// created below it
+func prepareCgroupParent(parent string) error {
+	controllers, err := os.ReadFile(filepath.Join(parent, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("not a cgroup v2 directory: %w", err)
+	}
+	for _, controller := range []string{"memory", "pids"} {
+		if !slices.Contains(strings.Fields(string(controllers)), controller) {
+			return fmt.Errorf("%s controller not delegated to %s", controller, parent)
+		}
+	}
+	return os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+memory +pids"), 0o644)
+}
+
+// runCgroup is the cgroup one sandboxed process is started in. Its
+// memory.max is a hard limit on everything the process tree allocates,
+// whatever the runtime reserves up front.
+type runCgroup struct {
+	path string
+	dir  *os.File
+}
+
+func newRunCgroup(parent string, memoryKB, maxProcesses uint64) (*runCgroup, error) {
+	path, err := os.MkdirTemp(parent, "run-*")
+	if err != nil {
+		return nil, err
+	}
+	settings := []struct{ file, value string }{
+		{"memory.max", strconv.FormatUint(memoryKB*1024, 10)},
+		{"memory.swap.max", "0"},
+		{"pids.max", strconv.FormatUint(maxProcesses, 10)},
+	}
+	for _, setting := range settings {
+		err := os.WriteFile(filepath.Join(path, setting.file), []byte(setting.value), 0o644)
+		// memory.swap.max only exists with swap accounting
+		if err != nil && !(setting.file == "memory.swap.max" && errors.Is(err, os.ErrNotExist)) {
+			os.Remove(path)
+			return nil, fmt.Errorf("%s: %w", setting.file, err)
+		}
+	}
+	dir, err := os.Open(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return &runCgroup{path: path, dir: dir}, nil
+}
+
+// oomKilled reports whether the kernel killed a process for exceeding
+// memory.max
+func (cg *runCgroup) oomKilled() bool {
+	data, err := os.ReadFile(filepath.Join(cg.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "oom_kill" {
+			return fields[1] != "0"
+		}
+	}
+	return false
+}
+
+// remove deletes the cgroup. The PID namespace is gone once its init has
+// exited, but the kernel may still be tearing down its last processes.
+func (cg *runCgroup) remove() {
+	cg.dir.Close()
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(cg.path); err == nil || !errors.Is(err, unix.EBUSY) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	log.Printf("Warning: failed to remove cgroup %s", cg.path)
+}
+
+// sandboxInit runs inside the new namespaces and never returns
+func sandboxInit(raw string) {
+	// Seccomp filters and no_new_privs are per-thread; keep them on the
+	// thread that performs the final execve.
+	runtime.LockOSThread()
+	unix.CloseOnExec(sandboxErrorFD)
+
+	var spec sandboxSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		sandboxFail(fmt.Errorf("invalid spec: %w", err))
+	}
+	if len(spec.Argv) == 0 {
+		sandboxFail(errors.New("empty command"))
+	}
+
+	if err := setupSandboxMounts(spec); err != nil {
+		sandboxFail(fmt.Errorf("mounts: %w", err))
+	}
+	unix.Sethostname([]byte("sandbox"))
+
+	if err := applySandboxRlimits(spec); err != nil {
+		sandboxFail(fmt.Errorf("rlimits: %w", err))
+	}
+	if err := dropCapabilities(); err != nil {
+		sandboxFail(fmt.Errorf("capabilities: %w", err))
+	}
+	if err := installSeccompFilter(); err != nil {
+		sandboxFail(fmt.Errorf("seccomp: %w", err))
+	}
+
+	if err := unix.Exec(spec.Argv[0], spec.Argv, spec.Env); err != nil {
+		sandboxFail(fmt.Errorf("exec %s: %w", spec.Argv[0], err))
+	}
+}
+
+func sandboxFail(err error) {
+	unix.Write(sandboxErrorFD, []byte(err.Error()))
+	os.Exit(sandboxSetupExitCode)
+}
+
+// sandboxDevices are the device nodes bound into the sandbox root
+var sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+// setupSandboxMounts builds a minimal root filesystem on a tmpfs: the
+// toolchain paths in spec.Binds, a few devices, a /proc for the new PID
+// namespace and the working directory. It pivots into that root, so the
+// rest of the host filesystem is unreachable, and leaves everything but the
+// working directory read-only.
+func setupSandboxMounts(spec sandboxSpec) error {
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return err
+	}
+
+	root := spec.Root
+	if err := unix.Mount("tmpfs", root, "tmpfs", unix.MS_NOSUID|unix.MS_NODEV, "size=16m,mode=0755"); err != nil {
+		return fmt.Errorf("root: %w", err)
+	}
+	paths := append(append(append([]string{}, spec.Binds...), sandboxDevices...), spec.Dir)
+	for _, path := range paths {
+		if err := bindIntoRoot(root, path); err != nil {
+			return fmt.Errorf("bind %s: %w", path, err)
+		}
+	}
+
+	// Without a fresh /proc the host's would be the only view of processes,
+	// so failing to mount it aborts the run
+	if err := os.Mkdir(filepath.Join(root, "proc"), 0o555); err != nil {
+		return err
+	}
+	if err := unix.Mount("proc", filepath.Join(root, "proc"), "proc", unix.MS_NOSUID|unix.MS_NODEV|unix.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("proc: %w", err)
+	}
+
+	oldRoot := filepath.Join(root, ".oldroot")
+	if err := os.Mkdir(oldRoot, 0o700); err != nil {
+		return err
+	}
+	if err := unix.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := unix.Chdir("/"); err != nil {
+		return err
+	}
+	if err := unix.Unmount("/.oldroot", unix.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach old root: %w", err)
+	}
+	if err := os.Remove("/.oldroot"); err != nil {
+		return err
+	}
+
+	if err := unix.MountSetattr(-1, "/", unix.AT_RECURSIVE, &unix.MountAttr{
+		Attr_set: unix.MOUNT_ATTR_RDONLY | unix.MOUNT_ATTR_NOSUID,
+	}); err != nil {
+		return err
+	}
+	if err := unix.MountSetattr(-1, spec.Dir, 0, &unix.MountAttr{
+		Attr_clr: unix.MOUNT_ATTR_RDONLY,
+	}); err != nil {
+		return err
+	}
+
+	return unix.Chdir(spec.Dir)
+}
+
+// bindIntoRoot makes a host path visible at the same location below root.
+// Symlinks are recreated rather than followed, so merged-/usr layouts keep
+// resolving inside the sandbox.
+func bindIntoRoot(root, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	case info.IsDir():
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return err
+		}
+	default:
+		if err := os.WriteFile(target, nil, 0o644); err != nil {
+			return err
+		}
+	}
+	return unix.Mount(path, target, "", unix.MS_BIND|unix.MS_REC, "")
+}
+
+// applySandboxRlimits sets the resource limits inherited across execve
+func applySandboxRlimits(spec sandboxSpec) error {
+	limits := []struct {
+		resource int
+		cur, max uint64
+	}{
+		{unix.RLIMIT_CPU, spec.CPUSeconds, spec.CPUSeconds + 1},
+		{unix.RLIMIT_FSIZE, spec.FileSizeBytes, spec.FileSizeBytes},
+		{unix.RLIMIT_NPROC, spec.MaxProcesses, spec.MaxProcesses},
+		{unix.RLIMIT_NOFILE, spec.MaxOpenFiles, spec.MaxOpenFiles},
+		{unix.RLIMIT_CORE, 0, 0},
+	}
+	if spec.StackKB > 0 {
+		limits = append(limits, struct {
+			resource int
+			cur, max uint64
+		}{unix.RLIMIT_STACK, spec.StackKB * 1024, spec.StackKB * 1024})
+	}
+	if spec.AddressSpaceKB > 0 {
+		limits = append(limits, struct {
+			resource int
+			cur, max uint64
+		}{unix.RLIMIT_AS, spec.AddressSpaceKB * 1024, spec.AddressSpaceKB * 1024})
+	}
+
+	for _, l := range limits {
+		if err := unix.Setrlimit(l.resource, &unix.Rlimit{Cur: l.cur, Max: l.max}); err != nil {
+			return fmt.Errorf("resource %d: %w", l.resource, err)
+		}
+	}
+	return nil
+}
+
+// dropCapabilities empties the bounding set so the exec'd program holds no
+// capabilities even though it runs as root inside the user namespace
+func dropCapabilities() error {
+	for capability := 0; capability <= unix.CAP_LAST_CAP; capability++ {
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(capability), 0, 0, 0); err != nil && !errors.Is(err, unix.EINVAL) {
+			return err
+		}
+	}
+	return unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0)
+}
+
+// deniedSyscalls fail with EPERM inside the sandbox
+var deniedSyscalls = []uintptr{
+	unix.SYS_PTRACE,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_CHROOT,
+	unix.SYS_REBOOT,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+	unix.SYS_SETNS,
+	unix.SYS_UNSHARE,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_KEYCTL,
+	unix.SYS_ADD_KEY,
+	unix.SYS_REQUEST_KEY,
+	unix.SYS_USERFAULTFD,
+	unix.SYS_PROCESS_VM_READV,
+	unix.SYS_PROCESS_VM_WRITEV,
+	unix.SYS_SETHOSTNAME,
+	unix.SYS_SETDOMAINNAME,
+	unix.SYS_ACCT,
+	unix.SYS_SETTIMEOFDAY,
+}
+
+// installSeccompFilter loads a BPF filter that denies privileged syscalls and
+// namespace creation through clone. clone3 is answered with ENOSYS so libc
+// falls back to clone, whose flags the filter can inspect.
+func installSeccompFilter() error {
+	arch, err := seccompAuditArch()
+	if err != nil {
+		return err
+	}
+
+	const (
+		offsetNr   = 0
+		offsetArch = 4
+		offsetArg0 = 16
+
+		x32SyscallBit = 0x40000000
+	)
+	namespaceFlags := uint32(unix.CLONE_NEWUSER | unix.CLONE_NEWNS | unix.CLONE_NEWPID |
+		unix.CLONE_NEWNET | unix.CLONE_NEWIPC | unix.CLONE_NEWUTS | unix.CLONE_NEWCGROUP)
+	retErrno := func(errno syscall.Errno) uint32 {
+		return unix.SECCOMP_RET_ERRNO | uint32(errno)
+	}
+
+	filter := []unix.SockFilter{
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetArch),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, arch, 1, 0),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_KILL_PROCESS),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetNr),
+		// Reject the x32 syscall range, which shares AUDIT_ARCH_X86_64
+		bpfJump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, x32SyscallBit, 0, 1),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, retErrno(unix.EPERM)),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(unix.SYS_CLONE3), 0, 1),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, retErrno(unix.ENOSYS)),
+	}
+	for _, nr := range deniedSyscalls {
+		filter = append(filter,
+			bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(nr), 0, 1),
+			bpfStmt(unix.BPF_RET|unix.BPF_K, retErrno(unix.EPERM)),
+		)
+	}
+	filter = append(filter,
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, uint32(unix.SYS_CLONE), 0, 3),
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, offsetArg0),
+		bpfJump(unix.BPF_JMP|unix.BPF_JSET|unix.BPF_K, namespaceFlags, 0, 1),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, retErrno(unix.EPERM)),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, unix.SECCOMP_RET_ALLOW),
+	)
+
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	return unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0)
+}
+
+// seccompAuditArch returns the AUDIT_ARCH value for the running binary
+func seccompAuditArch() (uint32, error) {
+	switch runtime.GOARCH {
+	case "amd64":
+		return unix.AUDIT_ARCH_X86_64, nil
+	case "arm64":
+		return unix.AUDIT_ARCH_AARCH64, nil
+	default:
+		return 0, fmt.Errorf("seccomp filter not available on %s", runtime.GOARCH)
+	}
+}
+
+func bpfStmt(code uint16, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: code, K: k}
+}
+
+func bpfJump(code uint16, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: code, Jt: jt, Jf: jf, K: k}
+}
+
+// limitedBuffer captures output up to a limit and discards the rest, so a
+// chatty program cannot exhaust server memory or block on a full pipe
+type limitedBuffer struct {
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (lb *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := lb.limit - len(lb.buf)
+	if remaining <= 0 {
+		lb.truncated = true
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		lb.buf = append(lb.buf, p[:remaining]...)
+		lb.truncated = true
+		return len(p), nil
+	}
+	lb.buf = append(lb.buf, p...)
+	return len(p), nil
+}
+
+func (lb *limitedBuffer) String() string {
+	return string(lb.buf)
+}
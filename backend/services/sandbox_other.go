@@ -0,0 +1,16 @@
+//go:build !linux
+
+package services
+
+// sandboxSupported reports false: the local sandbox needs Linux namespaces
+func sandboxSupported() bool {
+	return false
+}
+
+func prepareCgroupParent(parent string) error {
+	return errSandboxUnsupported
+}
+
+func runSandboxed(spec sandboxSpec, stdin string, wallTime float64, maxOutputBytes int) (*sandboxResult, error) {
+	return nil, errSandboxUnsupported
+}
@@ -0,0 +1,59 @@
+package services
+
+import "github.com/yourusername/algoholic/models"
+
+// Ranking scoring methods, set by correct_answer's "scoring"
+const (
+	RankingScoringKendall    = "kendall"
+	RankingScoringPositional = "positional"
+)
+
+// kendallScore is the share of pairs of correct items that the given
+// ranking puts in the right order. A pair with a missing item is out of
+// order.
+func kendallScore(given, correct []string) float64 {
+	position := make(map[string]int, len(given))
+	for i, item := range given {
+		if _, seen := position[item]; !seen {
+			position[item] = i
+		}
+	}
+
+	if len(correct) == 1 {
+		if _, ok := position[correct[0]]; ok {
+			return 1
+		}
+		return 0
+	}
+
+	concordant, pairs := 0, 0
+	for i := 0; i < len(correct); i++ {
+		for j := i + 1; j < len(correct); j++ {
+			pairs++
+			a, okA := position[correct[i]]
+			b, okB := position[correct[j]]
+			if okA && okB && a < b {
+				concordant++
+			}
+		}
+	}
+	return float64(concordant) / float64(pairs)
+}
+
+// positionalScore is the share of correct positions holding the right item
+func positionalScore(given, correct []string) float64 {
+	matches := 0
+	for i := range correct {
+		if i < len(given) && given[i] == correct[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(correct))
+}
+
+// isMultiSelect reports whether a multiple choice question has several
+// correct options, given as a list under correct_answer's "answers"
+func isMultiSelect(question *models.Question) bool {
+	_, ok := question.CorrectAnswer["answers"].([]interface{})
+	return ok
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// Text questions with "matching": "semantic" in their correct answer are
+// matched by meaning as well as wording. The answer and each reference
+// answer are embedded, and the cosine similarity of the embeddings is
+// combined with a lexical similarity: the better of edit-distance similarity
+// and the share of the reference's technical keywords the answer mentions.
+// The combined score must reach the question's "semantic_threshold". Near
+// verbatim answers are always accepted; keywords alone no longer are.
+
+const (
+	// TextMatchingFuzzy matches answers by edit distance and keywords
+	TextMatchingFuzzy = "fuzzy"
+	// TextMatchingSemantic also compares answer embeddings
+	TextMatchingSemantic = "semantic"
+
+	// DefaultSemanticThreshold is the combined score an answer needs when
+	// the question sets no threshold
+	DefaultSemanticThreshold = 0.8
+	// semanticWeight is the share of the combined score that comes from
+	// the embeddings
+	semanticWeight = 0.7
+)
+
+// TextMatch explains how a text answer was matched against the closest
+// reference answer
+type TextMatch struct {
+	Method    string   `json:"method"`
+	Reference string   `json:"reference"`
+	Semantic  *float64 `json:"semantic,omitempty"`
+	Lexical   float64  `json:"lexical"`
+	Keywords  *float64 `json:"keywords,omitempty"`
+	Combined  float64  `json:"combined"`
+	Threshold float64  `json:"threshold"`
+	Matched   bool     `json:"matched"`
+	Reason    string   `json:"reason"`
+}
+
+// MatchText matches an answer against reference answers by meaning and
+// wording, returning the best match. When embeddings are unavailable only
+// near verbatim answers match; keywords alone never do.
+func (as *AssessmentService) MatchText(answer string, references []string, threshold float64) *TextMatch {
+	if threshold <= 0 {
+		threshold = DefaultSemanticThreshold
+	}
+
+	var answerVector []float32
+	if strings.TrimSpace(answer) != "" {
+		vector, err := as.embed(answer, false)
+		if err != nil {
+			log.Printf("Warning: answer embedding failed, matching by wording: %v", err)
+		} else {
+			answerVector = vector
+		}
+	}
+
+	var best *TextMatch
+	for _, reference := range references {
+		match := as.validator.lexicalMatch(answer, reference)
+		match.Threshold = threshold
+		if answerVector != nil {
+			if referenceVector, err := as.embed(reference, true); err != nil {
+				log.Printf("Warning: reference embedding failed, matching by wording: %v", err)
+			} else {
+				semantic := math.Max(0, cosineSimilarity(answerVector, referenceVector))
+				match.Method = TextMatchingSemantic
+				match.Semantic = &semantic
+				match.Combined = semanticWeight*semantic + (1-semanticWeight)*match.Lexical
+				match.Matched = match.Matched || match.Combined >= threshold
+			}
+		}
+		match.Reason = match.reason()
+		if best == nil || match.Matched && !best.Matched || match.Matched == best.Matched && match.Combined > best.Combined {
+			best = match
+		}
+	}
+	return best
+}
+
+// embed embeds text. Reference answers are embedded once and cached.
+func (as *AssessmentService) embed(text string, cache bool) ([]float32, error) {
+	as.embeddingsMu.Lock()
+	vector, ok := as.embeddings[text]
+	as.embeddingsMu.Unlock()
+	if ok {
+		return vector, nil
+	}
+
+	vector, err := as.embedder.Generate(text)
+	if err != nil {
+		return nil, err
+	}
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("embedding model returned an empty embedding")
+	}
+	if !cache {
+		return vector, nil
+	}
+	as.embeddingsMu.Lock()
+	if len(as.embeddings) >= maxCachedEmbeddings {
+		as.embeddings = make(map[string][]float32)
+	}
+	as.embeddings[text] = vector
+	as.embeddingsMu.Unlock()
+	return vector, nil
+}
+
+// maxCachedEmbeddings bounds the embedding cache, which is cleared when full
+const maxCachedEmbeddings = 1000
+
+// lexicalMatch scores an answer against a reference by wording alone. Only
+// near verbatim answers match.
+func (tv *TextValidator) lexicalMatch(answer, reference string) *TextMatch {
+	user := tv.NormalizeText(answer)
+	correct := tv.NormalizeText(reference)
+
+	similarity := tv.CalculateSimilarity(user, correct)
+	match := &TextMatch{
+		Method:    TextMatchingFuzzy,
+		Reference: reference,
+		Lexical:   similarity,
+		Matched:   similarity >= tv.threshold,
+	}
+	if coverage, ok := tv.KeywordCoverage(user, correct); ok {
+		match.Keywords = &coverage
+		match.Lexical = math.Max(match.Lexical, coverage)
+	}
+	match.Combined = match.Lexical
+	return match
+}
+
+// reason explains a match in one sentence
+func (m *TextMatch) reason() string {
+	if m.Semantic == nil {
+		if m.Matched {
+			return "Embeddings are unavailable; the wording matches an accepted answer"
+		}
+		return "Embeddings are unavailable; the wording does not match an accepted answer"
+	}
+	verdict := "below"
+	if m.Combined >= m.Threshold {
+		verdict = "meets"
+	}
+	reason := fmt.Sprintf("Meaning similarity %.2f and wording similarity %.2f give %.2f, which %s the threshold of %.2f",
+		*m.Semantic, m.Lexical, m.Combined, verdict, m.Threshold)
+	if m.Matched && m.Combined < m.Threshold {
+		reason += ", but the wording matches an accepted answer"
+	}
+	return reason
+}
+
+// cosineSimilarity is the cosine of the angle between two vectors, 0 when
+// either is zero or their lengths differ
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
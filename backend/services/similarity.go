@@ -0,0 +1,546 @@
+package services
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Submissions to the same problem are compared by winnowing (Schleimer,
+// Wilkerson and Aiken, "Winnowing: Local Algorithms for Document
+// Fingerprinting", 2003) over normalized tokens: comments and whitespace are
+// dropped, keywords and operators are kept, and identifiers, numbers and
+// strings are replaced by a placeholder, so renaming variables or reformatting
+// does not hide a copy. Every run of KGram tokens is hashed, and the smallest
+// hash in each window of Window consecutive k-grams is kept as a fingerprint.
+// Any match of at least KGram+Window-1 tokens is guaranteed to share one.
+
+// SimilarityOptions controls plagiarism detection
+type SimilarityOptions struct {
+	Enabled   bool
+	KGram     int
+	Window    int
+	Threshold float64
+}
+
+// DefaultSimilarityOptions are used when no options are configured
+var DefaultSimilarityOptions = SimilarityOptions{
+	Enabled:   true,
+	KGram:     5,
+	Window:    4,
+	Threshold: 0.6,
+}
+
+// SimilarityOptionsFromConfig builds similarity options from configuration
+func SimilarityOptionsFromConfig(cfg config.SimilarityConfig) SimilarityOptions {
+	options := SimilarityOptions{
+		Enabled:   cfg.Enabled,
+		KGram:     cfg.KGram,
+		Window:    cfg.Window,
+		Threshold: cfg.Threshold,
+	}
+	if options.KGram <= 0 {
+		options.KGram = DefaultSimilarityOptions.KGram
+	}
+	if options.Window <= 0 {
+		options.Window = DefaultSimilarityOptions.Window
+	}
+	return options
+}
+
+// SimilarityRegion is a run of matching tokens, as 1-based inclusive line
+// ranges in each submission
+type SimilarityRegion struct {
+	StartLineA int    `json:"start_line_a"`
+	EndLineA   int    `json:"end_line_a"`
+	StartLineB int    `json:"start_line_b"`
+	EndLineB   int    `json:"end_line_b"`
+	Tokens     int    `json:"tokens"`
+	ExcerptA   string `json:"excerpt_a"`
+	ExcerptB   string `json:"excerpt_b"`
+}
+
+// SimilarityReport compares two pieces of code. Similarity is the share of
+// fingerprints the smaller one has in common with the other; coverage is
+// the share of each side's tokens inside matching regions.
+type SimilarityReport struct {
+	Similarity float64            `json:"similarity"`
+	CoverageA  float64            `json:"coverage_a"`
+	CoverageB  float64            `json:"coverage_b"`
+	Regions    []SimilarityRegion `json:"regions"`
+}
+
+// codeToken is a normalized token and the line it starts on
+type codeToken struct {
+	text string
+	line int
+}
+
+// fingerprintedCode is tokenized code with its winnowed fingerprints,
+// mapping each selected k-gram hash to the token positions it starts at
+type fingerprintedCode struct {
+	lines        []string
+	tokens       []codeToken
+	fingerprints map[uint64][]int
+}
+
+// CompareCode fingerprints two submissions in the same language and reports
+// how much of them matches
+func CompareCode(a, b, language string, options SimilarityOptions) SimilarityReport {
+	return compareFingerprinted(
+		fingerprintCode(a, language, options),
+		fingerprintCode(b, language, options),
+		nil, options.KGram)
+}
+
+func fingerprintCode(code, language string, options SimilarityOptions) *fingerprintedCode {
+	tokens := tokenizeCode(code, language)
+	return &fingerprintedCode{
+		lines:        strings.Split(code, "\n"),
+		tokens:       tokens,
+		fingerprints: winnow(tokens, options.KGram, options.Window),
+	}
+}
+
+// winnow selects the rightmost minimal k-gram hash of every window
+func winnow(tokens []codeToken, k, w int) map[uint64][]int {
+	fingerprints := make(map[uint64][]int)
+	if len(tokens) < k {
+		return fingerprints
+	}
+
+	hashes := make([]uint64, len(tokens)-k+1)
+	for i := range hashes {
+		h := fnv.New64a()
+		for _, token := range tokens[i : i+k] {
+			h.Write([]byte(token.text))
+			h.Write([]byte{0})
+		}
+		hashes[i] = h.Sum64()
+	}
+
+	if w > len(hashes) {
+		w = len(hashes)
+	}
+	last := -1
+	for start := 0; start+w <= len(hashes); start++ {
+		min := start
+		for i := start + 1; i < start+w; i++ {
+			if hashes[i] <= hashes[min] {
+				min = i
+			}
+		}
+		if min != last {
+			fingerprints[hashes[min]] = append(fingerprints[hashes[min]], min)
+			last = min
+		}
+	}
+	return fingerprints
+}
+
+// compareFingerprinted scores two fingerprinted submissions, skipping
+// fingerprints in ignore (boilerplate such as the starter code), and aligns
+// the matching regions by greedily tiling the longest common token runs
+// seeded at shared fingerprints
+func compareFingerprinted(a, b *fingerprintedCode, ignore map[uint64][]int, k int) SimilarityReport {
+	report := SimilarityReport{Regions: []SimilarityRegion{}}
+
+	countA, countB, shared := 0, 0, 0
+	for hash := range a.fingerprints {
+		if _, skip := ignore[hash]; !skip {
+			countA++
+		}
+	}
+	for hash := range b.fingerprints {
+		if _, skip := ignore[hash]; skip {
+			continue
+		}
+		countB++
+		if _, ok := a.fingerprints[hash]; ok {
+			shared++
+		}
+	}
+	if shared == 0 {
+		return report
+	}
+	smaller := countA
+	if countB < smaller {
+		smaller = countB
+	}
+	report.Similarity = float64(shared) / float64(smaller)
+
+	type run struct{ startA, startB, length int }
+	var runs []run
+	seen := make(map[[2]int]bool)
+	for hash, positionsB := range b.fingerprints {
+		positionsA, ok := a.fingerprints[hash]
+		if _, skip := ignore[hash]; !ok || skip {
+			continue
+		}
+		for _, pa := range positionsA {
+			for _, pb := range positionsB {
+				// Walk back to the start of the diagonal so each run is
+				// found once however many fingerprints it contains
+				i, j := pa, pb
+				for i > 0 && j > 0 && a.tokens[i-1].text == b.tokens[j-1].text {
+					i--
+					j--
+				}
+				if seen[[2]int{i, j}] {
+					continue
+				}
+				seen[[2]int{i, j}] = true
+
+				length := 0
+				for i+length < len(a.tokens) && j+length < len(b.tokens) &&
+					a.tokens[i+length].text == b.tokens[j+length].text {
+					length++
+				}
+				if length >= k {
+					runs = append(runs, run{i, j, length})
+				}
+			}
+		}
+	}
+
+	sort.Slice(runs, func(x, y int) bool {
+		if runs[x].length != runs[y].length {
+			return runs[x].length > runs[y].length
+		}
+		return runs[x].startA < runs[y].startA
+	})
+
+	usedA := make([]bool, len(a.tokens))
+	usedB := make([]bool, len(b.tokens))
+	var tiled []run
+	coveredA, coveredB := 0, 0
+	for _, r := range runs {
+		overlaps := false
+		for m := 0; m < r.length; m++ {
+			if usedA[r.startA+m] || usedB[r.startB+m] {
+				overlaps = true
+				break
+			}
+		}
+		if overlaps {
+			continue
+		}
+		for m := 0; m < r.length; m++ {
+			usedA[r.startA+m] = true
+			usedB[r.startB+m] = true
+		}
+		coveredA += r.length
+		coveredB += r.length
+		tiled = append(tiled, r)
+	}
+
+	sort.Slice(tiled, func(x, y int) bool { return tiled[x].startA < tiled[y].startA })
+	for _, r := range tiled {
+		region := SimilarityRegion{
+			StartLineA: a.tokens[r.startA].line,
+			EndLineA:   a.tokens[r.startA+r.length-1].line,
+			StartLineB: b.tokens[r.startB].line,
+			EndLineB:   b.tokens[r.startB+r.length-1].line,
+			Tokens:     r.length,
+		}
+		region.ExcerptA = strings.Join(a.lines[region.StartLineA-1:region.EndLineA], "\n")
+		region.ExcerptB = strings.Join(b.lines[region.StartLineB-1:region.EndLineB], "\n")
+		report.Regions = append(report.Regions, region)
+	}
+	report.CoverageA = float64(coveredA) / float64(len(a.tokens))
+	report.CoverageB = float64(coveredB) / float64(len(b.tokens))
+
+	return report
+}
+
+// SimilarityService records pairwise similarity between submissions to the
+// same problem
+type SimilarityService struct {
+	db             *gorm.DB
+	problemService *ProblemService
+	options        SimilarityOptions
+}
+
+// NewSimilarityService creates a new similarity service
+func NewSimilarityService(db *gorm.DB, problemService *ProblemService, options SimilarityOptions) *SimilarityService {
+	if options.KGram <= 0 {
+		options.KGram = DefaultSimilarityOptions.KGram
+	}
+	if options.Window <= 0 {
+		options.Window = DefaultSimilarityOptions.Window
+	}
+	return &SimilarityService{db: db, problemService: problemService, options: options}
+}
+
+// Threshold is the similarity at which a pair is suspicious
+func (s *SimilarityService) Threshold() float64 {
+	return s.options.Threshold
+}
+
+// RecordSubmission compares a submission with the latest submission of every
+// other user to the same problem in the same language and stores the scores
+// of pairs that share any fingerprints
+func (s *SimilarityService) RecordSubmission(submission *models.CodeSubmission) error {
+	latest := s.db.Model(&models.CodeSubmission{}).
+		Select("MAX(submission_id)").
+		Where("problem_id = ? AND language = ? AND user_id <> ?", submission.ProblemID, submission.Language, submission.UserID).
+		Group("user_id")
+
+	var others []models.CodeSubmission
+	if err := s.db.Where("submission_id IN (?)", latest).Find(&others).Error; err != nil {
+		return err
+	}
+	if len(others) == 0 {
+		return nil
+	}
+
+	// Code every solution starts from is not evidence of copying
+	ignore := map[uint64][]int{}
+	if s.problemService != nil {
+		if starter, err := s.problemService.GetStarterCode(submission.ProblemID, submission.Language); err == nil {
+			ignore = fingerprintCode(starter, submission.Language, s.options).fingerprints
+		}
+	}
+
+	current := fingerprintCode(submission.Code, submission.Language, s.options)
+	for _, other := range others {
+		first, second := &other, submission
+		a, b := fingerprintCode(other.Code, other.Language, s.options), current
+		if other.SubmissionID > submission.SubmissionID {
+			first, second = submission, &other
+			a, b = b, a
+		}
+
+		report := compareFingerprinted(a, b, ignore, s.options.KGram)
+		if report.Similarity == 0 {
+			continue
+		}
+
+		pair := models.SubmissionSimilarity{
+			ProblemID:     submission.ProblemID,
+			Language:      submission.Language,
+			SubmissionAID: first.SubmissionID,
+			SubmissionBID: second.SubmissionID,
+			UserAID:       first.UserID,
+			UserBID:       second.UserID,
+			Similarity:    report.Similarity,
+			CoverageA:     report.CoverageA,
+			CoverageB:     report.CoverageB,
+			Matches:       toJSONBArray(report.Regions),
+		}
+		err := s.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "submission_a_id"}, {Name: "submission_b_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"similarity", "coverage_a", "coverage_b", "matches", "computed_at"}),
+		}).Create(&pair).Error
+		if err != nil {
+			log.Printf("Warning: failed to save similarity of submissions %d and %d: %v", first.SubmissionID, second.SubmissionID, err)
+		}
+	}
+	return nil
+}
+
+// GetSuspiciousPairs lists submission pairs at or above a similarity, most
+// similar first. A zero problemID lists pairs for all problems.
+func (s *SimilarityService) GetSuspiciousPairs(problemID int, minSimilarity float64, limit, offset int) ([]models.SubmissionSimilarity, int64, error) {
+	query := s.db.Model(&models.SubmissionSimilarity{}).Where("similarity >= ?", minSimilarity)
+	if problemID > 0 {
+		query = query.Where("problem_id = ?", problemID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var pairs []models.SubmissionSimilarity
+	err := query.Order("similarity DESC, similarity_id").
+		Limit(limit).
+		Offset(offset).
+		Find(&pairs).Error
+
+	return pairs, total, err
+}
+
+// toJSONBArray converts a slice into a JSONB array column value
+func toJSONBArray(v interface{}) models.JSONBArray {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out models.JSONBArray
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// Keywords kept verbatim by the tokenizer, per language
+var languageKeywords = map[string]string{
+	"python":     "and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield None True False",
+	"javascript": "async await break case catch class const continue default delete do else export extends finally for function if import in instanceof let new of return static super switch this throw try typeof var void while yield null undefined true false",
+	"typescript": "abstract any as async await boolean break case catch class const continue default delete do else enum export extends finally for function if implements import in instanceof interface let new number of private protected public readonly return static string super switch this throw try type typeof var void while yield null undefined true false",
+	"java":       "abstract boolean break byte case catch char class continue default do double else enum extends final finally float for if implements import instanceof int interface long new package private protected public return short static super switch this throw throws try var void while null true false",
+	"kotlin":     "as break class continue do else false for fun if in interface is null object package return super this throw true try typealias val var when while",
+	"go":         "break case chan const continue default defer else fallthrough for func go goto if import interface map package range return select struct switch type var nil true false",
+	"cpp":        "auto bool break case catch char class const continue default delete do double else enum for if int long namespace new nullptr operator private public return short signed sizeof static struct switch template this throw try typedef typename unsigned using void while true false",
+	"c":          "auto break case char const continue default do double else enum extern float for goto if int long register return short signed sizeof static struct switch typedef union unsigned void while NULL",
+	"rust":       "as break const continue crate else enum fn for if impl in let loop match mod move mut pub ref return self Self static struct trait type unsafe use where while true false",
+	"ruby":       "alias and begin break case class def do else elsif end ensure false for if in module next nil not or redo rescue retry return self super then true unless until when while yield",
+	"php":        "abstract and array as break case catch class const continue declare default do echo else elseif empty extends final finally fn for foreach function global if implements include interface isset list new or private protected public require return static switch throw try unset use var while null true false",
+	"swift":      "as break case catch class continue default defer do else enum extension fallthrough false for func guard if import in init inout is let nil private protocol public repeat return self static struct subscript super switch throw throws true try var where while",
+}
+
+var keywordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(languageKeywords))
+	for language, words := range languageKeywords {
+		set := make(map[string]bool)
+		for _, word := range strings.Fields(words) {
+			set[word] = true
+		}
+		sets[language] = set
+	}
+	return sets
+}()
+
+// Multi-character operators, longest first
+var codeOperators = []string{
+	"===", "!==", "**=", "<<=", ">>=", "...",
+	"==", "!=", "<=", ">=", "&&", "||", "++", "--", "+=", "-=", "*=", "/=", "%=",
+	"->", "=>", "::", "<<", ">>", "**", "//", ":=",
+}
+
+// tokenizeCode lexes code into normalized tokens: keywords and operators
+// verbatim, identifiers as "V", numbers as "N" and strings as "S"
+func tokenizeCode(code, language string) []codeToken {
+	keywords := keywordSets[language]
+	lineComments := []string{"//"}
+	blockComments := true
+	switch language {
+	case "python", "ruby":
+		lineComments = []string{"#"}
+		blockComments = false
+	case "php":
+		lineComments = []string{"//", "#"}
+	}
+
+	var tokens []codeToken
+	line := 1
+	for i := 0; i < len(code); {
+		c := code[i]
+		rest := code[i:]
+
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		}
+
+		comment := false
+		for _, marker := range lineComments {
+			if strings.HasPrefix(rest, marker) {
+				end := strings.IndexByte(rest, '\n')
+				if end < 0 {
+					end = len(rest)
+				}
+				i += end
+				comment = true
+				break
+			}
+		}
+		if comment {
+			continue
+		}
+		if blockComments && strings.HasPrefix(rest, "/*") {
+			end := strings.Index(rest[2:], "*/")
+			if end < 0 {
+				end = len(rest) - 4
+			}
+			line += strings.Count(rest[:end+4], "\n")
+			i += end + 4
+			continue
+		}
+
+		start := line
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			n := stringLiteralLength(rest, language)
+			line += strings.Count(rest[:n], "\n")
+			i += n
+			// A Python docstring starts its line and is a comment in effect
+			docstring := language == "python" && strings.HasPrefix(rest, strings.Repeat(rest[:1], 3)) &&
+				(len(tokens) == 0 || tokens[len(tokens)-1].line < start)
+			if !docstring {
+				tokens = append(tokens, codeToken{"S", start})
+			}
+		case c >= '0' && c <= '9':
+			n := 1
+			for n < len(rest) && (isIdentByte(rest[n]) || rest[n] == '.') {
+				n++
+			}
+			tokens = append(tokens, codeToken{"N", start})
+			i += n
+		case isIdentByte(c) || c == '$':
+			n := 1
+			for n < len(rest) && isIdentByte(rest[n]) {
+				n++
+			}
+			word := rest[:n]
+			if keywords[word] {
+				tokens = append(tokens, codeToken{word, start})
+			} else {
+				tokens = append(tokens, codeToken{"V", start})
+			}
+			i += n
+		default:
+			op := rest[:1]
+			for _, candidate := range codeOperators {
+				if strings.HasPrefix(rest, candidate) {
+					op = candidate
+					break
+				}
+			}
+			tokens = append(tokens, codeToken{op, start})
+			i += len(op)
+		}
+	}
+	return tokens
+}
+
+// stringLiteralLength returns the length of the string literal at the start
+// of s. Only triple-quoted Python strings and backtick strings span lines.
+func stringLiteralLength(s, language string) int {
+	quote := s[0]
+	if language == "python" && len(s) >= 3 && s[1] == quote && s[2] == quote {
+		if end := strings.Index(s[3:], s[:3]); end >= 0 {
+			return end + 6
+		}
+		return len(s)
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case s[i] == quote:
+			return i + 1
+		case s[i] == '\n' && quote != '`':
+			return i
+		}
+	}
+	return len(s)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,562 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/algoholic/models"
+)
+
+// Stress testing limits
+const (
+	defaultStressIterations = 100
+	maxStressIterations     = 500
+	stressBatchSize         = 20
+	// stressMaxSize is the size hint generator scripts receive on the last
+	// iteration
+	stressMaxSize = 100
+)
+
+// Stress test outcomes
+const (
+	StressPassed          = "passed"
+	StressFailed          = "failed"
+	StressReferenceFailed = "reference_failed"
+)
+
+// ReferenceSolution is a problem's known-correct solution: a full program,
+// or just the function for problems with a function signature
+type ReferenceSolution struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+// InputGenerator produces random test inputs for stress testing, either by
+// running a script or from per-parameter constraints.
+//
+// A script reads "<seed> <size>" from stdin, where size is a hint from 1 to
+// 100 that grows over the run so early inputs are small, and prints one test
+// input in the format of the problem's test cases.
+//
+// Constraints only apply to problems with a function signature, e.g.
+//
+//	{"params": {"nums": {"length": [2, 8], "min": -10, "max": 10, "distinct": true},
+//	            "target": {"min": -20, "max": 20}}}
+type InputGenerator struct {
+	Language string                      `json:"language,omitempty"`
+	Code     string                      `json:"code,omitempty"`
+	Params   map[string]ParamConstraints `json:"params,omitempty"`
+}
+
+// ParamConstraints bound the generated values of one parameter. Numbers and
+// array elements fall in [Min, Max] (default [-10, 10]). Arrays, strings,
+// lists and trees have Length elements (default [1, 10]); rows of a matrix,
+// lists of a ListNode[] and strings of a string[] have InnerLength elements
+// (default [1, 5]). Strings draw from Charset (default "abc"). Distinct
+// integers are never repeated within an array, list or tree, and Sorted
+// arrays and lists ascend while Sorted trees are binary search trees.
+type ParamConstraints struct {
+	Min         *float64 `json:"min,omitempty"`
+	Max         *float64 `json:"max,omitempty"`
+	Length      []int    `json:"length,omitempty"`
+	InnerLength []int    `json:"inner_length,omitempty"`
+	Charset     string   `json:"charset,omitempty"`
+	Distinct    bool     `json:"distinct,omitempty"`
+	Sorted      bool     `json:"sorted,omitempty"`
+}
+
+// StressTest configures a stress run: the reference to compare against, the
+// generator of inputs and how outputs are compared (see TestSuite)
+type StressTest struct {
+	Reference  ReferenceSolution
+	Generator  InputGenerator
+	Signature  *FunctionSignature
+	Checker    interface{}
+	Iterations int
+	Seed       int64
+}
+
+// StressResult is the outcome of a stress run. Seed reproduces the run.
+type StressResult struct {
+	Status         string      `json:"status"`
+	Iterations     int         `json:"iterations"`
+	Seed           int64       `json:"seed"`
+	Counterexample *StressCase `json:"counterexample,omitempty"`
+}
+
+// StressCase is a generated input the code and the reference disagree on,
+// or that the reference itself fails on. Input and Expected form a ready
+// test case.
+type StressCase struct {
+	Input    string  `json:"input"`
+	Expected string  `json:"expected"`
+	Got      string  `json:"got"`
+	Verdict  Verdict `json:"verdict"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// StressTest runs code and a reference solution on generated inputs and
+// reports the smallest input found where they disagree. Inputs grow over the
+// run and it stops after the first batch with a disagreement, so the
+// counterexample is the shortest one in the earliest failing batch.
+func (ce *CodeExecutor) StressTest(code, language string, test StressTest) (*StressResult, error) {
+	language, err := ce.resolveLanguage(language)
+	if err != nil {
+		return nil, err
+	}
+	if test.Reference.Language, err = ce.resolveLanguage(test.Reference.Language); err != nil {
+		return nil, fmt.Errorf("reference solution: %w", err)
+	}
+	if test.Generator.Code != "" {
+		if test.Generator.Language, err = ce.resolveLanguage(test.Generator.Language); err != nil {
+			return nil, fmt.Errorf("input generator: %w", err)
+		}
+	}
+
+	code, err = ce.wrapCode(code, language, test.Signature)
+	if err != nil {
+		return nil, err
+	}
+	reference, err := ce.wrapCode(test.Reference.Code, test.Reference.Language, test.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("reference solution: %w", err)
+	}
+	if err := test.Generator.validate(test.Signature); err != nil {
+		return nil, err
+	}
+
+	checkerSpec := test.Checker
+	if checkerSpec == nil && test.Signature != nil {
+		checkerSpec = CheckerJSON
+	}
+	checker, err := ce.NewChecker(checkerSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	iterations := stressIterations(test.Iterations)
+	result := &StressResult{Status: StressPassed, Seed: test.Seed}
+	if result.Seed == 0 {
+		result.Seed = time.Now().UnixNano()
+	}
+
+	for start := 0; start < iterations; start += stressBatchSize {
+		count := stressBatchSize
+		if start+count > iterations {
+			count = iterations - start
+		}
+
+		inputs, err := ce.generateStressInputs(test.Generator, test.Signature, result.Seed, start, count, iterations)
+		if err != nil {
+			return nil, err
+		}
+
+		requests := make([]ExecutionRequest, 0, 2*count)
+		for i, input := range inputs {
+			stdin := input
+			if test.Signature != nil {
+				if stdin, err = test.Signature.EncodeInput(input); err != nil {
+					return nil, fmt.Errorf("generated input %d: %w", start+i+1, err)
+				}
+			}
+			requests = append(requests,
+				ExecutionRequest{SourceCode: reference, Language: test.Reference.Language, Stdin: stdin, Limits: ce.limitsFor(test.Reference.Language)},
+				ExecutionRequest{SourceCode: code, Language: language, Stdin: stdin, Limits: ce.limitsFor(language)})
+		}
+		outputs, err := ce.executeAll(requests)
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s): %v", ErrExecutionFailed, ce.executor.Name(), err)
+		}
+		result.Iterations += count
+
+		var referenceFailure, failure *StressCase
+		for i, input := range inputs {
+			expected, got := outputs[2*i], outputs[2*i+1]
+			if test.Signature != nil {
+				test.Signature.ExtractResult(expected)
+				test.Signature.ExtractResult(got)
+			}
+			if expected.Verdict != VerdictAccepted {
+				referenceFailure = smallerStressCase(referenceFailure, &StressCase{
+					Input:   input,
+					Verdict: expected.Verdict,
+					Message: expected.ErrorMessage(),
+				})
+				continue
+			}
+
+			candidate := &StressCase{
+				Input:    input,
+				Expected: strings.TrimSpace(expected.Stdout),
+				Got:      strings.TrimSpace(got.Stdout),
+				Verdict:  got.Verdict,
+			}
+			if got.Verdict != VerdictAccepted {
+				candidate.Message = got.ErrorMessage()
+				failure = smallerStressCase(failure, candidate)
+				continue
+			}
+			passed, message, err := checker.Check(input, expected.Stdout, got.Stdout)
+			if err != nil {
+				return nil, err
+			}
+			if !passed {
+				candidate.Verdict = VerdictWrongAnswer
+				candidate.Message = message
+				failure = smallerStressCase(failure, candidate)
+			}
+		}
+
+		// A reference that fails means the generator or the reference is
+		// broken, so comparisons on this batch cannot be trusted
+		switch {
+		case referenceFailure != nil:
+			result.Status = StressReferenceFailed
+			result.Counterexample = referenceFailure
+			return result, nil
+		case failure != nil:
+			result.Status = StressFailed
+			result.Counterexample = failure
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// stressIterations is the number of iterations a run makes when asked for
+// requested
+func stressIterations(requested int) int {
+	if requested <= 0 {
+		return defaultStressIterations
+	}
+	if requested > maxStressIterations {
+		return maxStressIterations
+	}
+	return requested
+}
+
+// stressBatches is the number of batches a run of requested iterations
+// takes at most
+func stressBatches(requested int) int {
+	return (stressIterations(requested) + stressBatchSize - 1) / stressBatchSize
+}
+
+// smallerStressCase keeps whichever case has the shorter input
+func smallerStressCase(current, candidate *StressCase) *StressCase {
+	if current == nil || len(candidate.Input) < len(current.Input) {
+		return candidate
+	}
+	return current
+}
+
+// validate checks that a generator is a script or constraints on the
+// signature's parameters
+func (g InputGenerator) validate(sig *FunctionSignature) error {
+	if g.Code != "" {
+		if g.Language == "" {
+			return errors.New("input generator script has no language")
+		}
+		return nil
+	}
+	if sig == nil {
+		return errors.New("input generator constraints need a function signature")
+	}
+
+	params := make(map[string]bool, len(sig.Params))
+	for _, param := range sig.Params {
+		params[param.Name] = true
+	}
+	for name, c := range g.Params {
+		if !params[name] {
+			return fmt.Errorf("input generator: unknown parameter %s", name)
+		}
+		for _, bounds := range [][]int{c.Length, c.InnerLength} {
+			if bounds != nil && (len(bounds) != 2 || bounds[0] < 0 || bounds[0] > bounds[1]) {
+				return fmt.Errorf("input generator: parameter %s: lengths must be [min, max]", name)
+			}
+		}
+		if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+			return fmt.Errorf("input generator: parameter %s: min exceeds max", name)
+		}
+	}
+	return nil
+}
+
+// generateStressInputs produces inputs start..start+count-1 of a run. Each
+// input depends only on the seed and its index.
+func (ce *CodeExecutor) generateStressInputs(g InputGenerator, sig *FunctionSignature, seed int64, start, count, iterations int) ([]string, error) {
+	inputs := make([]string, count)
+	if g.Code == "" {
+		for i := range inputs {
+			n := start + i
+			rng := rand.New(rand.NewSource(seed + int64(n)))
+			inputs[i] = sig.generateConstrainedInput(rng, g.Params, float64(n+1)/float64(iterations))
+		}
+		return inputs, nil
+	}
+
+	requests := make([]ExecutionRequest, count)
+	for i := range requests {
+		n := start + i
+		size := 1 + (stressMaxSize-1)*n/iterations
+		requests[i] = ExecutionRequest{
+			SourceCode: g.Code,
+			Language:   g.Language,
+			Stdin:      fmt.Sprintf("%d %d\n", seed+int64(n), size),
+			Limits:     ce.limitsFor(g.Language),
+		}
+	}
+	outputs, err := ce.executeAll(requests)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %v", ErrExecutionFailed, ce.executor.Name(), err)
+	}
+	for i, output := range outputs {
+		if output.Verdict != VerdictAccepted {
+			return nil, fmt.Errorf("input generator failed (%s): %s", output.Verdict, output.ErrorMessage())
+		}
+		inputs[i] = strings.TrimSpace(output.Stdout)
+	}
+	return inputs, nil
+}
+
+// generateConstrainedInput builds a test input of JSON-encoded arguments.
+// Lengths are capped at progress (0 to 1] of their range so inputs grow
+// over a run.
+func (sig *FunctionSignature) generateConstrainedInput(rng *rand.Rand, constraints map[string]ParamConstraints, progress float64) string {
+	args := make([]string, len(sig.Params))
+	for i, param := range sig.Params {
+		c := constraints[param.Name]
+		g := constrainedGenerator{
+			rng:         rng,
+			c:           c,
+			min:         -10,
+			max:         10,
+			length:      scaledRange(c.Length, []int{1, 10}, progress),
+			innerLength: scaledRange(c.InnerLength, []int{1, 5}, progress),
+			charset:     c.Charset,
+		}
+		if c.Min != nil {
+			g.min = *c.Min
+		}
+		if c.Max != nil {
+			g.max = *c.Max
+		}
+		if g.charset == "" {
+			g.charset = "abc"
+		}
+
+		data, _ := json.Marshal(g.value(param.typ))
+		args[i] = string(data)
+	}
+	return strings.Join(args, "\n")
+}
+
+// scaledRange caps the upper bound of a [min, max] range at progress
+func scaledRange(bounds, defaults []int, progress float64) [2]int {
+	if bounds == nil {
+		bounds = defaults
+	}
+	low, high := bounds[0], bounds[1]
+	high = low + int(math.Ceil(float64(high-low)*progress))
+	return [2]int{low, high}
+}
+
+// constrainedGenerator generates values for one parameter
+type constrainedGenerator struct {
+	rng         *rand.Rand
+	c           ParamConstraints
+	min, max    float64
+	length      [2]int
+	innerLength [2]int
+	charset     string
+}
+
+func (g constrainedGenerator) value(t valueType) interface{} {
+	switch {
+	case t.base == harnessTreeNode:
+		return g.tree(g.pick(g.length))
+	case t.dims == 2:
+		rows := make([]interface{}, g.pick(g.length))
+		cols := g.pick(g.innerLength)
+		for i := range rows {
+			rows[i] = g.array(t.base, cols)
+		}
+		return rows
+	case t.dims == 1 && t.base == harnessListNode:
+		lists := make([]interface{}, g.pick(g.length))
+		for i := range lists {
+			lists[i] = g.array(harnessInt, g.pick(g.innerLength))
+		}
+		return lists
+	case t.dims == 1:
+		return g.array(t.base, g.pick(g.length))
+	case t.base == harnessListNode:
+		return g.array(harnessInt, g.pick(g.length))
+	case t.base == harnessString:
+		return g.string(g.pick(g.length))
+	}
+	return g.scalar(t.base)
+}
+
+// pick returns a length in a range
+func (g constrainedGenerator) pick(bounds [2]int) int {
+	return bounds[0] + g.rng.Intn(bounds[1]-bounds[0]+1)
+}
+
+// array generates n elements, distinct and sorted as constrained
+func (g constrainedGenerator) array(base string, n int) []interface{} {
+	if base == harnessString {
+		words := make([]string, n)
+		for i := range words {
+			words[i] = g.string(g.pick(g.innerLength))
+		}
+		if g.c.Sorted {
+			sort.Strings(words)
+		}
+		values := make([]interface{}, n)
+		for i, word := range words {
+			values[i] = word
+		}
+		return values
+	}
+
+	if g.c.Distinct && (base == harnessInt || base == harnessLong) {
+		span := int(math.Floor(g.max) - math.Ceil(g.min) + 1)
+		if n > span {
+			n = span
+		}
+		values := make([]interface{}, n)
+		for i, offset := range g.rng.Perm(span)[:n] {
+			values[i] = int64(math.Ceil(g.min)) + int64(offset)
+		}
+		g.sortNumbers(values)
+		return values
+	}
+
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = g.scalar(base)
+	}
+	g.sortNumbers(values)
+	return values
+}
+
+// sortNumbers sorts numbers ascending when the parameter is Sorted
+func (g constrainedGenerator) sortNumbers(values []interface{}) {
+	if !g.c.Sorted {
+		return
+	}
+	number := func(v interface{}) float64 {
+		if i, ok := v.(int64); ok {
+			return float64(i)
+		}
+		f, _ := v.(float64)
+		return f
+	}
+	sort.Slice(values, func(i, j int) bool { return number(values[i]) < number(values[j]) })
+}
+
+// scalar generates a number or boolean
+func (g constrainedGenerator) scalar(base string) interface{} {
+	switch base {
+	case harnessBool:
+		return g.rng.Intn(2) == 1
+	case harnessDouble:
+		return math.Round((g.min+g.rng.Float64()*(g.max-g.min))*1000) / 1000
+	}
+	low, high := int64(math.Ceil(g.min)), int64(math.Floor(g.max))
+	return low + g.rng.Int63n(high-low+1)
+}
+
+// string generates n characters from the charset
+func (g constrainedGenerator) string(n int) string {
+	charset := []rune(g.charset)
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteRune(charset[g.rng.Intn(len(charset))])
+	}
+	return b.String()
+}
+
+// tree generates a random binary tree of n nodes in LeetCode level order.
+// Values are assigned in order, so a sorted tree is a binary search tree.
+func (g constrainedGenerator) tree(n int) []interface{} {
+	type node struct {
+		value       interface{}
+		left, right *node
+	}
+
+	values := g.array(harnessInt, n)
+	var build func(count int) *node
+	next := 0
+	build = func(count int) *node {
+		if count == 0 {
+			return nil
+		}
+		leftCount := g.rng.Intn(count)
+		left := build(leftCount)
+		root := &node{value: values[next], left: left}
+		next++
+		root.right = build(count - 1 - leftCount)
+		return root
+	}
+	root := build(len(values))
+
+	var order []interface{}
+	for queue := []*node{root}; len(queue) > 0; queue = queue[1:] {
+		current := queue[0]
+		if current == nil {
+			order = append(order, nil)
+			continue
+		}
+		order = append(order, current.value)
+		queue = append(queue, current.left, current.right)
+	}
+	for len(order) > 0 && order[len(order)-1] == nil {
+		order = order[:len(order)-1]
+	}
+	if order == nil {
+		order = []interface{}{}
+	}
+	return order
+}
+
+// problemStressTest reads a problem's reference solution and input
+// generator
+func problemStressTest(problem *models.Problem) (ReferenceSolution, InputGenerator, error) {
+	var reference ReferenceSolution
+	var generator InputGenerator
+	if len(problem.ReferenceSolution) == 0 {
+		return reference, generator, errors.New("problem has no reference solution")
+	}
+	if len(problem.InputGenerator) == 0 {
+		return reference, generator, errors.New("problem has no input generator")
+	}
+
+	if err := decodeJSONB(problem.ReferenceSolution, &reference); err != nil {
+		return reference, generator, fmt.Errorf("problem %d: invalid reference solution: %w", problem.ProblemID, err)
+	}
+	if reference.Code == "" || reference.Language == "" {
+		return reference, generator, fmt.Errorf("problem %d: reference solution needs language and code", problem.ProblemID)
+	}
+	if err := decodeJSONB(problem.InputGenerator, &generator); err != nil {
+		return reference, generator, fmt.Errorf("problem %d: invalid input generator: %w", problem.ProblemID, err)
+	}
+	return reference, generator, nil
+}
+
+// decodeJSONB converts a JSONB map into a struct via its JSON encoding
+func decodeJSONB(data models.JSONB, v interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
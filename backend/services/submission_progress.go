@@ -0,0 +1,109 @@
+package services
+
+import "sync"
+
+// Submission event types, in addition to the TestEventType of each test
+const (
+	SubmissionEventResult = "result"
+	SubmissionEventError  = "error"
+)
+
+// SubmissionEvent is one step of a submission's evaluation: a TestEvent for
+// each test case as it is queued, running and judged, then a final result
+// event carrying the ExecutionResult, or an error event
+type SubmissionEvent struct {
+	Type string
+	Data interface{}
+}
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped; evaluation never waits for slow readers
+const subscriberBuffer = 256
+
+// progressHub fans out the events of submissions being evaluated. Events are
+// kept until the evaluation finishes so late subscribers see them all.
+type progressHub struct {
+	mu   sync.Mutex
+	runs map[int]*progressRun
+}
+
+type progressRun struct {
+	events      []SubmissionEvent
+	subscribers map[chan SubmissionEvent]struct{}
+}
+
+func newProgressHub() *progressHub {
+	return &progressHub{runs: make(map[int]*progressRun)}
+}
+
+// track starts recording events for a submission; it is a no-op if the
+// submission is already tracked
+func (h *progressHub) track(submissionID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.runs[submissionID]; !ok {
+		h.runs[submissionID] = &progressRun{subscribers: make(map[chan SubmissionEvent]struct{})}
+	}
+}
+
+// publish records an event and sends it to current subscribers
+func (h *progressHub) publish(submissionID int, event SubmissionEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	run, ok := h.runs[submissionID]
+	if !ok {
+		return
+	}
+	run.events = append(run.events, event)
+	for ch := range run.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(run.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// finish closes every subscriber and forgets the submission
+func (h *progressHub) finish(submissionID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	run, ok := h.runs[submissionID]
+	if !ok {
+		return
+	}
+	for ch := range run.subscribers {
+		delete(run.subscribers, ch)
+		close(ch)
+	}
+	delete(h.runs, submissionID)
+}
+
+// subscribe returns the events of a tracked submission so far followed by
+// live ones, and a function to stop receiving them. ok is false when the
+// submission is not being evaluated.
+func (h *progressHub) subscribe(submissionID int) (events <-chan SubmissionEvent, cancel func(), ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	run, ok := h.runs[submissionID]
+	if !ok {
+		return nil, nil, false
+	}
+
+	ch := make(chan SubmissionEvent, len(run.events)+subscriberBuffer)
+	for _, event := range run.events {
+		ch <- event
+	}
+	run.subscribers[ch] = struct{}{}
+
+	cancel = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := run.subscribers[ch]; ok {
+			delete(run.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, cancel, true
+}
@@ -0,0 +1,484 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/algoholic/models"
+	"gorm.io/gorm"
+)
+
+// Submission statuses stored on CodeSubmission.Status
+const (
+	SubmissionStatusPending             = "pending"
+	SubmissionStatusRunning             = "running"
+	SubmissionStatusAccepted            = "accepted"
+	SubmissionStatusWrongAnswer         = "wrong_answer"
+	SubmissionStatusTimeLimitExceeded   = "time_limit_exceeded"
+	SubmissionStatusMemoryLimitExceeded = "memory_limit_exceeded"
+	SubmissionStatusRuntimeError        = "runtime_error"
+	SubmissionStatusCompilationError    = "compilation_error"
+	SubmissionStatusError               = "error"
+)
+
+// SubmissionService handles code submissions for problems
+type SubmissionService struct {
+	db             *gorm.DB
+	executor       *CodeExecutor
+	problemService *ProblemService
+	complexity     ComplexityOptions
+	similarity     *SimilarityService
+	progress       *progressHub
+}
+
+// NewSubmissionService creates a new submission service. Evaluated
+// submissions are checked for copying when similarity is not nil.
+func NewSubmissionService(db *gorm.DB, executor *CodeExecutor, problemService *ProblemService, complexity ComplexityOptions, similarity *SimilarityService) *SubmissionService {
+	if executor == nil {
+		executor = NewCodeExecutor(nil, DefaultResourceLimits, nil, nil, nil)
+	}
+	if complexity.MaxSize <= 0 {
+		complexity.MaxSize = DefaultComplexityOptions.MaxSize
+	}
+	return &SubmissionService{
+		db:             db,
+		executor:       executor,
+		problemService: problemService,
+		complexity:     complexity,
+		similarity:     similarity,
+		progress:       newProgressHub(),
+	}
+}
+
+// SubmissionRequest represents a code submission for a problem
+type SubmissionRequest struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+}
+
+// CreateSubmission stores a pending submission and evaluates it in the background
+func (s *SubmissionService) CreateSubmission(userID, problemID int, req SubmissionRequest) (*models.CodeSubmission, error) {
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, errors.New("code is required")
+	}
+	if req.Language == "" {
+		req.Language = "python"
+	}
+	// Store the canonical ID so aliases of a language compare as one
+	language, err := s.executor.resolveLanguage(req.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.problemService.GetProblemByID(problemID); err != nil {
+		return nil, err
+	}
+
+	job, err := s.executor.Queue().Admit(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	submission := models.CodeSubmission{
+		UserID:    userID,
+		ProblemID: problemID,
+		Code:      req.Code,
+		Language:  language,
+		Status:    SubmissionStatusPending,
+	}
+	if err := s.db.Create(&submission).Error; err != nil {
+		job.Done()
+		return nil, err
+	}
+
+	// Track progress before returning so a client subscribing right away
+	// sees every event
+	s.progress.track(submission.SubmissionID)
+	go s.evaluate(submission.SubmissionID, job)
+
+	return &submission, nil
+}
+
+// Evaluate runs a stored submission against the problem's test cases and
+// records the outcome, publishing progress to SubscribeSubmission. The
+// submission waits its turn in the execution queue without being checked
+// against its user's limits again.
+func (s *SubmissionService) Evaluate(submissionID int) {
+	s.evaluate(submissionID, nil)
+}
+
+// evaluate is Evaluate with the submission's place in the execution queue,
+// reserving one when job is nil
+func (s *SubmissionService) evaluate(submissionID int, job *ExecutionJob) {
+	s.progress.track(submissionID)
+	defer s.progress.finish(submissionID)
+	if job != nil {
+		defer job.Done()
+	}
+
+	var submission models.CodeSubmission
+	if err := s.db.First(&submission, submissionID).Error; err != nil {
+		log.Printf("Warning: submission %d not found for evaluation: %v", submissionID, err)
+		return
+	}
+
+	if job == nil {
+		job = s.executor.Queue().Reserve(submission.UserID)
+		defer job.Done()
+	}
+	job.Wait()
+
+	s.db.Model(&submission).Update("status", SubmissionStatusRunning)
+
+	updates := map[string]interface{}{}
+	result, err := s.runSubmission(&submission, func(event TestEvent) {
+		s.progress.publish(submissionID, SubmissionEvent{Type: string(event.Type), Data: event})
+	})
+	if err != nil {
+		log.Printf("Warning: failed to evaluate submission %d: %v", submissionID, err)
+		updates["status"] = SubmissionStatusError
+		updates["test_results"] = models.JSONB{"error": err.Error()}
+	} else {
+		updates["status"] = submissionStatus(result)
+		updates["test_results"] = toJSONB(result)
+		updates["execution_time_ms"] = int(result.TimeTaken)
+		updates["memory_used_kb"] = result.MemoryUsed
+	}
+	updates["evaluated_at"] = time.Now()
+
+	if err := s.db.Model(&submission).Updates(updates).Error; err != nil {
+		log.Printf("Warning: failed to save submission %d: %v", submissionID, err)
+		s.progress.publish(submissionID, SubmissionEvent{Type: SubmissionEventError, Data: map[string]string{"error": "failed to save submission"}})
+		return
+	}
+	if err != nil {
+		s.progress.publish(submissionID, SubmissionEvent{Type: SubmissionEventError, Data: map[string]string{"error": err.Error()}})
+	} else {
+		s.progress.publish(submissionID, SubmissionEvent{Type: SubmissionEventResult, Data: result})
+	}
+
+	// Infrastructure failures are not counted as attempts
+	if err == nil {
+		if err := s.problemService.UpdateProblemStats(submission.ProblemID, result.AllPassed); err != nil {
+			log.Printf("Warning: failed to update stats for problem %d: %v", submission.ProblemID, err)
+		}
+	}
+
+	if err == nil && result.AllPassed && s.complexity.Enabled {
+		s.estimateComplexity(&submission)
+	}
+
+	if err == nil && s.similarity != nil {
+		if err := s.similarity.RecordSubmission(&submission); err != nil {
+			log.Printf("Warning: similarity check of submission %d failed: %v", submissionID, err)
+		}
+	}
+}
+
+// estimateComplexity records the empirical complexity of an accepted
+// submission. Only problems with a function signature can generate inputs.
+func (s *SubmissionService) estimateComplexity(submission *models.CodeSubmission) {
+	problem, err := s.problemService.GetProblemByID(submission.ProblemID)
+	if err != nil {
+		log.Printf("Warning: failed to load problem %d for complexity analysis: %v", submission.ProblemID, err)
+		return
+	}
+	sig, err := problemSignature(problem)
+	if err != nil || sig == nil {
+		return
+	}
+
+	var referenceTime, referenceSpace string
+	if problem.TimeComplexity != nil {
+		referenceTime = *problem.TimeComplexity
+	}
+	if problem.SpaceComplexity != nil {
+		referenceSpace = *problem.SpaceComplexity
+	}
+
+	updates := map[string]interface{}{}
+	analysis, err := s.executor.AnalyzeComplexity(submission.Code, submission.Language, sig, s.complexity, referenceTime, referenceSpace)
+	if err != nil {
+		log.Printf("Warning: complexity analysis of submission %d failed: %v", submission.SubmissionID, err)
+		updates["complexity_analysis"] = models.JSONB{"error": err.Error()}
+	} else {
+		updates["complexity_analysis"] = toJSONB(analysis)
+		if analysis.TimeComplexity != "" {
+			updates["time_complexity"] = string(analysis.TimeComplexity)
+			updates["space_complexity"] = string(analysis.SpaceComplexity)
+		}
+	}
+
+	if err := s.db.Model(submission).Updates(updates).Error; err != nil {
+		log.Printf("Warning: failed to save complexity of submission %d: %v", submission.SubmissionID, err)
+	}
+}
+
+// ResumePending re-queues submissions left unevaluated by a previous process
+// and returns once they are evaluated. They are evaluated concurrently; the
+// execution queue decides how many run at once.
+func (s *SubmissionService) ResumePending() {
+	var ids []int
+	if err := s.db.Model(&models.CodeSubmission{}).
+		Where("status IN ?", []string{SubmissionStatusPending, SubmissionStatusRunning}).
+		Pluck("submission_id", &ids).Error; err != nil {
+		log.Printf("Warning: failed to load pending submissions: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		s.progress.track(id)
+	}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			s.Evaluate(id)
+		}(id)
+	}
+	wg.Wait()
+}
+
+// runSubmission executes the submitted code against the problem's test
+// cases, reporting each test's progress
+func (s *SubmissionService) runSubmission(submission *models.CodeSubmission, progress func(TestEvent)) (*ExecutionResult, error) {
+	testCases, err := s.GetProblemTestCases(submission.ProblemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(testCases) == 0 {
+		return nil, errors.New("problem has no test cases")
+	}
+
+	problem, err := s.problemService.GetProblemByID(submission.ProblemID)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := problemSignature(problem)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.executor.RunTestsWithProgress(submission.Code, submission.Language, TestSuite{TestCases: testCases, Signature: sig}, progress)
+}
+
+// StressRequest is code to stress test against a problem's reference
+// solution. A zero seed picks a random one.
+type StressRequest struct {
+	Code       string `json:"code"`
+	Language   string `json:"language"`
+	Iterations int    `json:"iterations"`
+	Seed       int64  `json:"seed"`
+}
+
+// StressTest compares code with the problem's reference solution on inputs
+// from its input generator. Nothing is recorded. Each batch of the run
+// counts as a job against the user's daily quota.
+func (s *SubmissionService) StressTest(userID, problemID int, req StressRequest) (*StressResult, error) {
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, errors.New("code is required")
+	}
+	if req.Language == "" {
+		req.Language = "python"
+	}
+
+	problem, err := s.problemService.GetProblemByID(problemID)
+	if err != nil {
+		return nil, err
+	}
+	reference, generator, err := problemStressTest(problem)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := problemSignature(problem)
+	if err != nil {
+		return nil, err
+	}
+	checker, err := s.problemChecker(problemID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.executor.Queue().AdmitCost(userID, stressBatches(req.Iterations))
+	if err != nil {
+		return nil, err
+	}
+	defer job.Done()
+	job.Wait()
+
+	return s.executor.StressTest(req.Code, req.Language, StressTest{
+		Reference:  reference,
+		Generator:  generator,
+		Signature:  sig,
+		Checker:    checker,
+		Iterations: req.Iterations,
+		Seed:       req.Seed,
+	})
+}
+
+// problemChecker returns the checker of the problem's first code question
+// that names one, or nil for the default
+func (s *SubmissionService) problemChecker(problemID int) (interface{}, error) {
+	var questions []models.Question
+	if err := s.db.Where("problem_id = ? AND question_format = ?", problemID, "code").
+		Order("question_id ASC").
+		Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	for _, question := range questions {
+		if checker, ok := question.CorrectAnswer["checker"]; ok {
+			return checker, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetProblemTestCases collects the test cases of a problem's code questions.
+// Each case carries its question's checker unless it names its own.
+func (s *SubmissionService) GetProblemTestCases(problemID int) ([]interface{}, error) {
+	var questions []models.Question
+	if err := s.db.Where("problem_id = ? AND question_format = ?", problemID, "code").
+		Order("question_id ASC").
+		Find(&questions).Error; err != nil {
+		return nil, err
+	}
+
+	var testCases []interface{}
+	for _, question := range questions {
+		cases, ok := question.CorrectAnswer["test_cases"].([]interface{})
+		if !ok {
+			continue
+		}
+		checker, hasChecker := question.CorrectAnswer["checker"]
+		for _, tc := range cases {
+			testCase, ok := tc.(map[string]interface{})
+			if !ok || !hasChecker {
+				testCases = append(testCases, tc)
+				continue
+			}
+			if _, ok := testCase["checker"]; !ok {
+				withChecker := make(map[string]interface{}, len(testCase)+1)
+				for k, v := range testCase {
+					withChecker[k] = v
+				}
+				withChecker["checker"] = checker
+				testCase = withChecker
+			}
+			testCases = append(testCases, testCase)
+		}
+	}
+
+	return testCases, nil
+}
+
+// GetUserSubmissions retrieves a user's submissions for a problem, newest first
+func (s *SubmissionService) GetUserSubmissions(userID, problemID, limit, offset int) ([]models.CodeSubmission, int64, error) {
+	query := s.db.Model(&models.CodeSubmission{}).
+		Where("user_id = ? AND problem_id = ?", userID, problemID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var submissions []models.CodeSubmission
+	if err := query.Order("submitted_at DESC, submission_id DESC").
+		Limit(limit).Offset(offset).
+		Find(&submissions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return submissions, total, nil
+}
+
+// GetSubmission retrieves a single submission owned by the user
+func (s *SubmissionService) GetSubmission(userID, problemID, submissionID int) (*models.CodeSubmission, error) {
+	var submission models.CodeSubmission
+	if err := s.db.Where("submission_id = ? AND user_id = ? AND problem_id = ?", submissionID, userID, problemID).
+		First(&submission).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("submission not found")
+		}
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// SubscribeSubmission streams the evaluation of a user's submission. While it
+// is being evaluated, the events so far are replayed and live ones follow;
+// otherwise the only event is its stored result. The channel is closed after
+// the final result or error event; call cancel to stop early.
+func (s *SubmissionService) SubscribeSubmission(userID, problemID, submissionID int) (events <-chan SubmissionEvent, cancel func(), err error) {
+	submission, err := s.GetSubmission(userID, problemID, submissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if events, cancel, ok := s.progress.subscribe(submissionID); ok {
+		return events, cancel, nil
+	}
+
+	// Evaluation may have finished since the submission was read
+	if err := s.db.First(submission, submissionID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	stored := make(chan SubmissionEvent, 1)
+	switch submission.Status {
+	case SubmissionStatusPending, SubmissionStatusRunning:
+		stored <- SubmissionEvent{Type: SubmissionEventError, Data: map[string]string{"error": "submission has not been evaluated yet"}}
+	case SubmissionStatusError:
+		stored <- SubmissionEvent{Type: SubmissionEventError, Data: submission.TestResults}
+	default:
+		stored <- SubmissionEvent{Type: SubmissionEventResult, Data: submission.TestResults}
+	}
+	close(stored)
+	return stored, func() {}, nil
+}
+
+// submissionStatus maps an execution result to a submission status using the
+// first failing test
+func submissionStatus(result *ExecutionResult) string {
+	if result.AllPassed {
+		return SubmissionStatusAccepted
+	}
+
+	for _, test := range result.TestResults {
+		if test.Passed {
+			continue
+		}
+		switch test.Verdict {
+		case VerdictWrongAnswer:
+			return SubmissionStatusWrongAnswer
+		case VerdictTimeLimitExceeded:
+			return SubmissionStatusTimeLimitExceeded
+		case VerdictMemoryLimitExceeded:
+			return SubmissionStatusMemoryLimitExceeded
+		case VerdictRuntimeError:
+			return SubmissionStatusRuntimeError
+		case VerdictCompilationError:
+			return SubmissionStatusCompilationError
+		default:
+			return SubmissionStatusError
+		}
+	}
+
+	return SubmissionStatusWrongAnswer
+}
+
+// toJSONB converts a value into a JSONB map via its JSON encoding
+func toJSONB(v interface{}) models.JSONB {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out models.JSONB
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
@@ -53,6 +53,37 @@ func (tv *TextValidator) MatchMultiple(userText string, acceptableAnswers []stri
 	return false
 }
 
+// MatchBlank checks the text written in one blank against an acceptable
+// answer. Whitespace is ignored. Answers containing code, such as "<=" or
+// "len(nums)", and exact answers must otherwise match as written; other
+// answers are fuzzy matched.
+func (tv *TextValidator) MatchBlank(userText, correctText string, exact bool) bool {
+	if strings.Join(strings.Fields(userText), "") == strings.Join(strings.Fields(correctText), "") {
+		return true
+	}
+	if exact || isCodeLike(correctText) {
+		return false
+	}
+	return tv.FuzzyMatch(userText, correctText)
+}
+
+// isCodeLike reports whether text contains operators or brackets, where
+// fuzzy matching would accept a different program. Hyphens and apostrophes
+// inside words, as in "depth-first", are not operators.
+func isCodeLike(text string) bool {
+	runes := []rune(text)
+	for i, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			continue
+		}
+		if (r == '-' || r == '\'') && i > 0 && i < len(runes)-1 && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1]) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
 // NormalizeText prepares text for comparison
 func (tv *TextValidator) NormalizeText(text string) string {
 	// Convert to lowercase
@@ -155,22 +186,31 @@ func (tv *TextValidator) LevenshteinDistance(s1, s2 string) int {
 
 // HasRequiredKeywords checks if user answer contains key technical terms
 func (tv *TextValidator) HasRequiredKeywords(userText, correctText string) bool {
-	keywords := tv.ExtractKeywords(correctText)
-
-	if len(keywords) == 0 {
+	coverage, ok := tv.KeywordCoverage(userText, correctText)
+	if !ok {
 		return false // No keywords to match
 	}
 
 	// User must mention at least 70% of keywords
+	threshold := 0.7
+	return coverage >= threshold
+}
+
+// KeywordCoverage is the share of the correct answer's technical terms the
+// user's answer mentions. It reports false when the correct answer has none.
+func (tv *TextValidator) KeywordCoverage(userText, correctText string) (float64, bool) {
+	keywords := tv.ExtractKeywords(correctText)
+	if len(keywords) == 0 {
+		return 0, false
+	}
+
 	matchCount := 0
 	for _, keyword := range keywords {
 		if strings.Contains(userText, keyword) {
 			matchCount++
 		}
 	}
-
-	threshold := 0.7
-	return float64(matchCount)/float64(len(keywords)) >= threshold
+	return float64(matchCount) / float64(len(keywords)), true
 }
 
 // ExtractKeywords extracts technical terms from text
@@ -216,6 +256,13 @@ func (tv *TextValidator) ExtractKeywords(text string) []string {
 
 // ValidateComplexityAnswer specifically validates time/space complexity answers
 func (tv *TextValidator) ValidateComplexityAnswer(userAnswer, correctAnswer string) bool {
+	// Compare canonical forms when both complexities parse
+	if user, err := ExtractBigO(userAnswer); err == nil {
+		if correct, err := ExtractBigO(correctAnswer); err == nil {
+			return user.Equal(correct)
+		}
+	}
+
 	// Normalize both answers
 	user := tv.NormalizeText(userAnswer)
 	correct := tv.NormalizeText(correctAnswer)
@@ -10,12 +10,13 @@ import (
 
 // UserService handles user-related operations
 type UserService struct {
-	db *gorm.DB
+	db          *gorm.DB
+	calibration *CalibrationService
 }
 
 // NewUserService creates a new user service
 func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+	return &UserService{db: db, calibration: NewCalibrationService(db)}
 }
 
 // UserStats represents user statistics
@@ -31,6 +32,9 @@ type UserStats struct {
 	AverageDifficulty  float64 `json:"average_difficulty"`
 	StrongTopics       []string `json:"strong_topics"`
 	WeakTopics         []string `json:"weak_topics"`
+	// MemorizationScore is the share of checked correct answers that look
+	// memorized, from 0 to 1
+	MemorizationScore  float64  `json:"memorization_score"`
 }
 
 // GetUserStats retrieves comprehensive user statistics
@@ -53,15 +57,25 @@ func (s *UserService) GetUserStats(userID int) (*UserStats, error) {
 
 	stats.TotalAttempts = len(attempts)
 	correctCount := 0
+	checked, memorized := 0, 0
 	for _, attempt := range attempts {
 		if attempt.IsCorrect {
 			correctCount++
+			if attempt.ShowsMemorization != nil {
+				checked++
+				if *attempt.ShowsMemorization {
+					memorized++
+				}
+			}
 		}
 	}
 	stats.CorrectAttempts = correctCount
 	if stats.TotalAttempts > 0 {
 		stats.AccuracyRate = float64(correctCount) / float64(stats.TotalAttempts) * 100
 	}
+	if checked > 0 {
+		stats.MemorizationScore = float64(memorized) / float64(checked)
+	}
 
 	// Get problem statistics
 	var problemAttempts []struct {
@@ -101,8 +115,11 @@ func (s *UserService) GetUserStats(userID int) (*UserStats, error) {
 	return stats, nil
 }
 
-// UpdateUserProgress updates user progress after an attempt
-func (s *UserService) UpdateUserProgress(userID, topicID int, isCorrect bool, timeTaken int) error {
+// UpdateUserProgress updates user progress after an attempt scored from 0
+// to 1, where only a score of 1 counts as correct
+func (s *UserService) UpdateUserProgress(userID, topicID int, score float64, timeTaken int) error {
+	isCorrect := score >= 1
+
 	// Get or create user skill for this topic
 	var skill models.UserSkill
 	result := s.db.Where("user_id = ? AND topic_id = ?", userID, topicID).First(&skill)
@@ -132,10 +149,11 @@ func (s *UserService) UpdateUserProgress(userID, topicID int, isCorrect bool, ti
 	}
 	skill.LastPracticedAt = &now
 
-	// Calculate new proficiency level (simple formula: correct_rate * 100)
+	// Calculate new proficiency level: the average score * 100, so partial
+	// credit counts toward it
 	if skill.QuestionsAttempted > 0 {
 		oldProficiency := skill.ProficiencyLevel
-		skill.ProficiencyLevel = float64(skill.QuestionsCorrect) / float64(skill.QuestionsAttempted) * 100
+		skill.ProficiencyLevel = oldProficiency + (score*100-oldProficiency)/float64(skill.QuestionsAttempted)
 
 		// Calculate improvement rate
 		if oldProficiency > 0 {
@@ -190,26 +208,98 @@ func (s *UserService) GetStrongTopics(userID int, limit int) ([]models.Topic, er
 	return topics, err
 }
 
-// GetWeakTopics retrieves user's weakest topics
+// GetWeakTopics retrieves user's weakest topics. Topics where the user is
+// often confidently wrong come first, whatever their proficiency.
 func (s *UserService) GetWeakTopics(userID int, limit int) ([]models.Topic, error) {
-	var topics []models.Topic
-	err := s.db.Table("topics").
+	flagged, err := s.calibration.ConfidentlyWrongTopics(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(flagged) > limit {
+		flagged = flagged[:limit]
+	}
+	topics, err := s.topicsInOrder(flagged)
+	if err != nil || len(topics) >= limit {
+		return topics, err
+	}
+
+	var lowProficiency []models.Topic
+	query := s.db.Table("topics").
 		Joins("JOIN user_skills ON user_skills.topic_id = topics.topic_id").
-		Where("user_skills.user_id = ? AND user_skills.proficiency_level < 50", userID).
-		Order("user_skills.proficiency_level ASC").
-		Limit(limit).
-		Find(&topics).Error
-	return topics, err
+		Where("user_skills.user_id = ? AND user_skills.proficiency_level < 50", userID)
+	if len(flagged) > 0 {
+		query = query.Where("topics.topic_id NOT IN ?", flagged)
+	}
+	err = query.Order("user_skills.proficiency_level ASC").
+		Limit(limit - len(topics)).
+		Find(&lowProficiency).Error
+	return append(topics, lowProficiency...), err
+}
+
+// ConfidentlyWrongTopics retrieves the topics where a user is often sure of
+// wrong answers
+func (s *UserService) ConfidentlyWrongTopics(userID int) ([]models.Topic, error) {
+	flagged, err := s.calibration.ConfidentlyWrongTopics(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.topicsInOrder(flagged)
 }
 
-// GetReviewQueue gets topics that need review
+// topicsInOrder loads topics, keeping the order of their IDs
+func (s *UserService) topicsInOrder(topicIDs []int) ([]models.Topic, error) {
+	topics := []models.Topic{}
+	if len(topicIDs) == 0 {
+		return topics, nil
+	}
+	var found []models.Topic
+	if err := s.db.Where("topic_id IN ?", topicIDs).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int]models.Topic, len(found))
+	for _, topic := range found {
+		byID[topic.TopicID] = topic
+	}
+	for _, id := range topicIDs {
+		if topic, ok := byID[id]; ok {
+			topics = append(topics, topic)
+		}
+	}
+	return topics, nil
+}
+
+// GetReviewQueue gets topics that need review. Topics where the user is
+// often confidently wrong are due now and come first.
 func (s *UserService) GetReviewQueue(userID int) ([]models.UserSkill, error) {
-	var skills []models.UserSkill
+	flagged, err := s.calibration.ConfidentlyWrongTopics(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	skills := []models.UserSkill{}
+	if len(flagged) > 0 {
+		var flaggedSkills []models.UserSkill
+		if err := s.db.Where("user_id = ? AND topic_id IN ?", userID, flagged).Find(&flaggedSkills).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range flagged {
+			for _, skill := range flaggedSkills {
+				if skill.TopicID == id {
+					skills = append(skills, skill)
+				}
+			}
+		}
+	}
+
+	var due []models.UserSkill
 	now := time.Now()
-	err := s.db.Where("user_id = ? AND needs_review = TRUE AND next_review_at <= ?", userID, now).
-		Order("next_review_at ASC").
-		Find(&skills).Error
-	return skills, err
+	query := s.db.Where("user_id = ? AND needs_review = TRUE AND next_review_at <= ?", userID, now)
+	if len(flagged) > 0 {
+		query = query.Where("topic_id NOT IN ?", flagged)
+	}
+	err = query.Order("next_review_at ASC").
+		Find(&due).Error
+	return append(skills, due...), err
 }
 
 // UpdateStreak updates the user's practice streak
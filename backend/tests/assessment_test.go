@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// fakeOllama serves /api/generate, replying with the given model output and
+// recording each request
+func fakeOllama(t *testing.T, reply string) (*httptest.Server, *[]map[string]interface{}) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			http.NotFound(w, r)
+			return
+		}
+		var req map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(map[string]interface{}{"model": req["model"], "response": reply, "done": true})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// openEndedQuestion asks why a greedy algorithm works
+func openEndedQuestion() *models.Question {
+	return &models.Question{
+		QuestionFormat: "open_ended",
+		QuestionText:   "Why does picking the interval that ends first give the most non-overlapping intervals?",
+		CorrectAnswer: models.JSONB{
+			"rubric": []interface{}{
+				map[string]interface{}{"criterion": "Exchange argument", "keywords": []interface{}{"exchange", "swap"}, "points": 2.0, "required": true},
+				map[string]interface{}{"criterion": "Earliest end leaves the most room", "keywords": []interface{}{"most room", "ends first"}, "points": 1.0},
+				map[string]interface{}{"criterion": "Sorting cost", "keywords": []interface{}{"n log n", "sort"}, "points": 1.0},
+			},
+			"passing_score": 0.7,
+		},
+	}
+}
+
+// Test that the model's per-criterion scores and feedback grade the answer
+func TestAssessmentGradesRubric(t *testing.T) {
+	server, requests := fakeOllama(t, `{
+		"criteria": [
+			{"criterion": 1, "score": 1, "feedback": "Clear exchange argument."},
+			{"criterion": 2, "score": 0.4, "feedback": "Only hinted at."},
+			{"criterion": 3, "score": 0.8, "feedback": "Mentions sorting."}
+		],
+		"feedback": "Solid proof; say why the earliest end leaves the most room."
+	}`)
+	assessor := services.NewAssessmentService(config.OllamaConfig{URL: server.URL, AssessmentModel: "grader", AssessmentTemp: 0.2})
+	qs := services.NewQuestionService(nil, nil, assessor)
+
+	answer := "Any optimal schedule can swap its first interval for ours without overlaps, and we sort by end time."
+	result, err := qs.Grade(openEndedQuestion(), map[string]interface{}{"answer": answer})
+	require.NoError(t, err)
+	assert.True(t, result.Correct)
+	assert.Equal(t, "Solid proof; say why the earliest end leaves the most room.", result.Feedback)
+	assert.Equal(t, []string{"missing: Earliest end leaves the most room"}, result.Mistakes)
+
+	rubric := result.Details.(*services.RubricResult)
+	assert.Equal(t, services.RubricGradedByModel, rubric.GradedBy)
+	assert.InDelta(t, 0.8, rubric.Score, 1e-9)
+	assert.InDelta(t, 0.4, rubric.Criteria[1].Earned, 1e-9)
+	assert.Equal(t, "Only hinted at.", rubric.Criteria[1].Feedback)
+
+	require.Len(t, *requests, 1)
+	req := (*requests)[0]
+	assert.Equal(t, "grader", req["model"])
+	assert.Equal(t, "json", req["format"])
+	assert.Equal(t, false, req["stream"])
+	assert.Equal(t, 0.2, req["options"].(map[string]interface{})["temperature"])
+	assert.Contains(t, req["prompt"], answer)
+	assert.Contains(t, req["prompt"], "1. Exchange argument (related terms: exchange, swap)")
+}
+
+// Test that answers are graded by keywords when the model cannot grade them
+func TestAssessmentFallsBackToKeywords(t *testing.T) {
+	answer := "Swap the first interval of any optimal schedule for the one that ends first."
+
+	// The model skips a criterion
+	server, _ := fakeOllama(t, `{"criteria": [{"criterion": 1, "score": 1}, {"criterion": 2, "score": 1}], "feedback": "Good."}`)
+	assessor := services.NewAssessmentService(config.OllamaConfig{URL: server.URL})
+	rubric, err := services.ParseRubric(openEndedQuestion().CorrectAnswer)
+	require.NoError(t, err)
+	result := assessor.Grade("", answer, rubric)
+	assert.Equal(t, services.RubricGradedByKeywords, result.GradedBy)
+	assert.True(t, result.Passed)
+	assert.Equal(t, 0.75, result.Score)
+
+	// The model replies with prose
+	server, _ = fakeOllama(t, "This answer looks good to me!")
+	result = services.NewAssessmentService(config.OllamaConfig{URL: server.URL}).Grade("", answer, rubric)
+	assert.Equal(t, services.RubricGradedByKeywords, result.GradedBy)
+
+	// Ollama is down
+	server.Close()
+	qs := services.NewQuestionService(nil, nil, services.NewAssessmentService(config.OllamaConfig{URL: server.URL}))
+	graded, err := qs.Grade(openEndedQuestion(), map[string]interface{}{"answer": answer})
+	require.NoError(t, err)
+	assert.True(t, graded.Correct)
+	assert.Equal(t, services.RubricGradedByKeywords, graded.Details.(*services.RubricResult).GradedBy)
+}
+
+// fakeEmbeddings serves /api/embeddings with fixed embeddings per text
+func fakeEmbeddings(t *testing.T, vectors map[string][]float32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		vector, ok := vectors[req["prompt"]]
+		if r.URL.Path != "/api/embeddings" || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embedding": vector})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Test that semantic text questions accept paraphrases and reject keyword
+// stuffing, explaining the score
+func TestSemanticTextMatching(t *testing.T) {
+	reference := "Store each value's index in a hash map and look up the complement"
+	paraphrase := "Remember where every number was seen so the partner can be found in constant time"
+	stuffed := "A hash map is not needed here, so I would sort the values and scan a map of pairs"
+	server := fakeEmbeddings(t, map[string][]float32{
+		reference:  {1, 0, 0},
+		paraphrase: {0.95, 0.3, 0},
+		stuffed:    {0.2, 0, 1},
+	})
+	question := &models.Question{
+		QuestionFormat: "text",
+		QuestionType:   "conceptual_understanding",
+		CorrectAnswer:  models.JSONB{"answer": reference, "matching": "semantic", "semantic_threshold": 0.65},
+	}
+	qs := services.NewQuestionService(nil, nil, services.NewAssessmentService(config.OllamaConfig{URL: server.URL}))
+	require.NoError(t, qs.ValidateQuestion(question))
+
+	result, err := qs.Grade(question, map[string]interface{}{"answer": paraphrase})
+	require.NoError(t, err)
+	assert.True(t, result.Correct)
+	match := result.Details.(*services.TextMatch)
+	assert.Equal(t, services.TextMatchingSemantic, match.Method)
+	assert.InDelta(t, 0.954, *match.Semantic, 1e-3)
+	assert.Equal(t, 0.65, match.Threshold)
+	assert.Contains(t, result.Feedback, "meets the threshold of 0.65")
+
+	// Keywords alone no longer pass
+	assert.True(t, services.NewTextValidator().FuzzyMatch(stuffed, reference))
+	result, err = qs.Grade(question, map[string]interface{}{"answer": stuffed})
+	require.NoError(t, err)
+	assert.False(t, result.Correct)
+	assert.Contains(t, result.Feedback, "below the threshold")
+
+	// A stricter question rejects the paraphrase
+	question.CorrectAnswer["semantic_threshold"] = 0.95
+	result, err = qs.Grade(question, map[string]interface{}{"answer": paraphrase})
+	require.NoError(t, err)
+	assert.False(t, result.Correct)
+
+	// Without embeddings only a near verbatim answer passes
+	server.Close()
+	result, err = qs.Grade(question, map[string]interface{}{"answer": stuffed})
+	require.NoError(t, err)
+	assert.False(t, result.Correct)
+	assert.Equal(t, services.TextMatchingFuzzy, result.Details.(*services.TextMatch).Method)
+	assert.Contains(t, result.Feedback, "does not match")
+	result, err = qs.Grade(question, map[string]interface{}{"answer": strings.ToLower(reference) + "."})
+	require.NoError(t, err)
+	assert.True(t, result.Correct)
+	assert.Contains(t, result.Feedback, "the wording matches")
+}
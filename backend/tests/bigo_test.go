@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that equivalent ways of writing a complexity share a canonical form
+func TestParseBigO(t *testing.T) {
+	tests := []struct {
+		canonical string
+		forms     []string
+	}{
+		{"O(n log n)", []string{"O(n*log(n))", "O(nlogn)", "O(N lg N)", "O(log n * n)", "Θ(n log2 n)", "n ln(n^2)", "O(3n log n + 10n)"}},
+		{"O(m + n)", []string{"O(n+m)", "O(m+n)", "O(|N| + |M|)", "O(2n + m + 5)"}},
+		{"O(n^2)", []string{"O(n^2)", "O(n²)", "O(n*n)", "O(n squared)", "O(n^2 + n log n)", "O((n+1)^2)", "O(n2)"}},
+		{"O(1)", []string{"O(1)", "O(42)", "O(2^10)"}},
+		{"O(2^n)", []string{"O(2^n)", "O(2^n + n^3)", "O(2^(n+0))"}},
+		{"O(4^n)", []string{"O(2^(2n))", "O(4^n)"}},
+		{"O(n!)", []string{"O(n!)", "O(n! + 2^n)"}},
+		{"O(sqrt(n))", []string{"O(sqrt(n))", "O(√n)", "O(n^(1/2))"}},
+		{"O(log^2 n)", []string{"O(log^2 n)", "O(log(n)^2)", "O(log n log n)"}},
+		{"O(log log n)", []string{"O(log log n)", "O(lglgn)"}},
+		{"O(m*n)", []string{"O(mn)", "O(n*m)", "O(m times n)"}},
+		{"O(e log v + v log v)", []string{"O((V + E) log V)", "O(E log V + V log V)"}},
+	}
+	for _, tt := range tests {
+		for _, form := range tt.forms {
+			b, err := services.ParseBigO(form)
+			if assert.NoError(t, err, form) {
+				assert.Equal(t, tt.canonical, b.String(), form)
+			}
+		}
+	}
+
+	for _, invalid := range []string{"", "O(n", "O(b^d)", "O(n/log n)", "O(n^-1)", "squared"} {
+		_, err := services.ParseBigO(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// Test that loose upper bounds are told apart from wrong complexities
+func TestCompareComplexity(t *testing.T) {
+	tests := []struct {
+		given, correct string
+		verdict        services.ComplexityVerdict
+	}{
+		{"O(nlogn)", "O(n log n)", services.ComplexityTight},
+		{"O(n^2)", "O(n log n)", services.ComplexityNotTight},
+		{"O(2^n)", "O(n^3)", services.ComplexityNotTight},
+		{"O(n log n)", "O(n^2)", services.ComplexityWrong},
+		{"O(n)", "O(n + m)", services.ComplexityWrong},
+		{"O(n * m)", "O(n + m)", services.ComplexityNotTight},
+		{"O(n)", "O(m)", services.ComplexityWrong},
+	}
+	for _, tt := range tests {
+		given, err := services.ParseBigO(tt.given)
+		require.NoError(t, err)
+		correct, err := services.ParseBigO(tt.correct)
+		require.NoError(t, err)
+		assert.Equal(t, tt.verdict, services.CompareComplexity(given, correct), "%s vs %s", tt.given, tt.correct)
+	}
+}
+
+// Test that complexity questions grade the parsed complexity
+func TestGradeComplexityAnswer(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	subtype := "time_complexity"
+	question := &models.Question{
+		QuestionFormat:  "text",
+		QuestionType:    "complexity_analysis",
+		QuestionSubtype: &subtype,
+		CorrectAnswer:   models.JSONB{"answer": []interface{}{"O(n log n) - sorting dominates", "n log n"}},
+	}
+
+	result, err := qs.Grade(question, map[string]interface{}{"answer": "It's O(N lg N) because of the sort"})
+	require.NoError(t, err)
+	assert.True(t, result.Correct)
+	assert.Equal(t, services.ComplexityTight, result.Details.(*services.ComplexityMatch).Verdict)
+
+	result, err = qs.Grade(question, map[string]interface{}{"answer": "O(n^2)"})
+	require.NoError(t, err)
+	assert.False(t, result.Correct)
+	assert.Equal(t, "Correct but not tight", result.Feedback)
+	assert.Equal(t, []string{"O(n^2) is an upper bound but not tight"}, result.Mistakes)
+
+	result, err = qs.Grade(question, map[string]interface{}{"answer": "linear"})
+	require.NoError(t, err)
+	assert.Equal(t, "Incorrect", result.Feedback)
+	assert.Equal(t, []string{"O(n) is not an upper bound"}, result.Mistakes)
+
+	// Answers without a complexity are matched as text
+	result, err = qs.Grade(question, map[string]interface{}{"answer": "whatever the sort costs"})
+	require.NoError(t, err)
+	assert.False(t, result.Correct)
+	assert.Nil(t, result.Details)
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test calibration metrics and that confidently wrong topics are treated as
+// weaknesses
+func TestCalibration(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+
+	// One question in each of two topics
+	questionIDs := make(map[string]int)
+	for _, name := range []string{"Graphs", "Arrays"} {
+		topic := &models.Topic{Name: name, Slug: name}
+		require.NoError(t, db.Create(topic).Error)
+		problem := &models.Problem{Title: name, Slug: name, Description: name, DifficultyScore: 30, Examples: models.JSONBArray{}}
+		require.NoError(t, db.Create(problem).Error)
+		require.NoError(t, db.Create(&models.ProblemTopic{ProblemID: problem.ProblemID, TopicID: topic.TopicID}).Error)
+		question := &models.Question{ProblemID: &problem.ProblemID, QuestionType: "concept", QuestionFormat: "text",
+			QuestionText: name, CorrectAnswer: models.JSONB{"answer": "x"}, DifficultyScore: 30}
+		require.NoError(t, db.Create(question).Error)
+		questionIDs[name] = question.QuestionID
+	}
+
+	attempt := func(topic string, confidence *int, score float64) {
+		questionID := questionIDs[topic]
+		require.NoError(t, db.Create(&models.UserAttempt{UserID: 1, QuestionID: &questionID, UserAnswer: models.JSONB{},
+			IsCorrect: score >= 1, Score: &score, ConfidenceLevel: confidence}).Error)
+	}
+	sure, unsure := 5, 2
+	attempt("Graphs", &sure, 0)
+	attempt("Graphs", &sure, 0)
+	attempt("Graphs", &sure, 1)
+	attempt("Arrays", &unsure, 1)
+	attempt("Arrays", &unsure, 1)
+	attempt("Arrays", nil, 0)
+
+	report, err := services.NewCalibrationService(db).GetCalibration(1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Overall.Attempts)
+	assert.InDelta(t, 0.544, report.Overall.BrierScore, 1e-9)
+	assert.InDelta(t, 0.4, report.Overall.Overconfidence, 1e-9)
+	assert.InDelta(t, 0.24, report.Overall.Underconfidence, 1e-9)
+	assert.Equal(t, 2, report.Overall.ConfidentlyWrongAnswers)
+	require.Len(t, report.Overall.Reliability, 2)
+	assert.Equal(t, services.CalibrationBin{ConfidenceLevel: 2, Confidence: 0.4, Attempts: 2, Accuracy: 1, Gap: -0.6}, report.Overall.Reliability[0])
+	assert.InDelta(t, 1.0/3, report.Overall.Reliability[1].Accuracy, 1e-9)
+
+	require.Len(t, report.Topics, 2)
+	assert.Equal(t, "Graphs", report.Topics[0].TopicName)
+	assert.True(t, report.Topics[0].ConfidentlyWrong)
+	assert.False(t, report.Topics[1].ConfidentlyWrong)
+	assert.InDelta(t, 0.6, report.Topics[1].Underconfidence, 1e-9)
+
+	// Graphs is proficient enough not to be weak or due, but comes first
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(24 * time.Hour)
+	graphs, arrays := report.Topics[0].TopicID, report.Topics[1].TopicID
+	require.NoError(t, db.Create(&models.UserSkill{UserID: 1, TopicID: graphs, ProficiencyLevel: 80, NextReviewAt: &future}).Error)
+	require.NoError(t, db.Create(&models.UserSkill{UserID: 1, TopicID: arrays, ProficiencyLevel: 30, NeedsReview: true, NextReviewAt: &past}).Error)
+
+	users := services.NewUserService(db)
+	weak, err := users.GetWeakTopics(1, 3)
+	require.NoError(t, err)
+	require.Len(t, weak, 2)
+	assert.Equal(t, []int{graphs, arrays}, []int{weak[0].TopicID, weak[1].TopicID})
+
+	queue, err := users.GetReviewQueue(1)
+	require.NoError(t, err)
+	require.Len(t, queue, 2)
+	assert.Equal(t, []int{graphs, arrays}, []int{queue[0].TopicID, queue[1].TopicID})
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test built-in output checkers
+func TestOutputCheckers(t *testing.T) {
+	executor := services.NewCodeExecutor(nil, services.DefaultResourceLimits, nil, nil, nil)
+
+	cases := []struct {
+		name     string
+		spec     interface{}
+		expected string
+		got      string
+		pass     bool
+	}{
+		{"exact default", nil, "1 2\n", "1 2\r\n\n", true},
+		{"exact rejects layout", nil, "1 2", "1\n2", false},
+		{"tokens ignores layout", "tokens", "1 2\n3", "1\n2   3", true},
+		{"tokens rejects extra", "tokens", "1 2", "1 2 3", false},
+		{"float within abs epsilon", map[string]interface{}{"type": "float", "abs_epsilon": 1e-3}, "0.3333", "0.33332", true},
+		{"float within rel epsilon", map[string]interface{}{"type": "float", "abs_epsilon": 0.0, "rel_epsilon": 1e-6}, "1000000", "1000000.5", true},
+		{"float outside epsilon", "float", "0.5", "0.51", false},
+		{"float compares words exactly", "float", "YES 1.0", "NO 1.0", false},
+		{"unordered lines", "unordered_lines", "a b\nc d", "c d\na b\n", true},
+		{"unordered lines counts duplicates", "unordered_lines", "a\na", "a", false},
+		{"set ignores duplicates", "set", "1 2 3", "3 2 1 1", true},
+		{"set rejects missing", "set", "1 2 3", "1 2", false},
+		{"json ignores layout", "json", "[0, 1]", "[0,1]\n", true},
+		{"json compares integers exactly", "json", "[9007199254740993]", "[9007199254740992]", false},
+		{"json floats within epsilon", "json", `{"a": 0.1}`, `{"a":0.1000000001}`, true},
+		{"json rejects invalid output", "json", "[1]", "[1", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checker, err := executor.NewChecker(tc.spec)
+			assert.NoError(t, err)
+
+			ok, _, err := checker.Check("", tc.expected, tc.got)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.pass, ok)
+		})
+	}
+
+	_, err := executor.NewChecker("levenshtein")
+	assert.Error(t, err)
+	_, err = executor.NewChecker(map[string]interface{}{"type": "custom"})
+	assert.Error(t, err)
+}
+
+// concurrentBackend runs a batch concurrently, finishing the second test
+// once the first is being checked. Its checker holds up the first test
+// until the second has been judged.
+type concurrentBackend struct {
+	fakeExecutor
+	checking chan struct{}
+	judged   chan struct{}
+}
+
+func newConcurrentBackend() concurrentBackend {
+	b := concurrentBackend{checking: make(chan struct{}), judged: make(chan struct{})}
+	b.fakeExecutor = func(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		if strings.Contains(req.Stdin, `"input":"1"`) {
+			close(b.checking)
+			select {
+			case <-b.judged:
+			case <-time.After(time.Second):
+				return &services.ExecutionOutput{Verdict: services.VerdictRuntimeError, Stderr: "second test never judged"}, nil
+			}
+		}
+		return &services.ExecutionOutput{Verdict: services.VerdictAccepted, Stdout: "AC\n"}, nil
+	}
+	return b
+}
+
+func (b concurrentBackend) ExecuteBatchWithProgress(reqs []services.ExecutionRequest, onProgress func(int, services.RunState, *services.ExecutionOutput)) ([]*services.ExecutionOutput, error) {
+	outputs := make([]*services.ExecutionOutput, len(reqs))
+	var wg sync.WaitGroup
+	for i := range reqs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if i > 0 {
+				<-b.checking
+			}
+			outputs[i] = &services.ExecutionOutput{Verdict: services.VerdictAccepted, Stdout: reqs[i].Stdin}
+			onProgress(i, services.RunFinished, outputs[i])
+		}(i)
+	}
+	wg.Wait()
+	return outputs, nil
+}
+
+// Test that custom checkers run outside the progress lock, so a slow
+// checker never holds up the judging of other tests
+func TestCustomCheckerDoesNotBlockProgress(t *testing.T) {
+	backend := newConcurrentBackend()
+	executor := services.NewCodeExecutor(backend, services.DefaultResourceLimits, nil, nil, nil)
+
+	var calls atomic.Int32
+	result, err := executor.RunTestsWithProgress("code", "python", services.TestSuite{
+		TestCases: []interface{}{
+			map[string]interface{}{"input": "1", "expected": "1"},
+			map[string]interface{}{"input": "2", "expected": "2"},
+		},
+		Checker: map[string]interface{}{"type": "custom", "source": "checker"},
+	}, func(event services.TestEvent) {
+		assert.Equal(t, int32(1), calls.Add(1), "progress called concurrently")
+		if event.Type == services.TestEventJudged && event.TestNumber == 2 {
+			close(backend.judged)
+		}
+		calls.Add(-1)
+	})
+	require.NoError(t, err)
+	assert.True(t, result.AllPassed, "%+v", result.Failures)
+}
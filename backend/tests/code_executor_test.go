@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that hidden test cases report only their verdict and index
+func TestHiddenTestCaseRedaction(t *testing.T) {
+	executor := services.NewCodeExecutor(echoExecutor(), services.DefaultResourceLimits, nil, nil, nil)
+
+	testCases := []interface{}{
+		map[string]interface{}{"input": "1", "expected": "2", "sample": true},
+		map[string]interface{}{"input": "3", "expected": "4"},
+		map[string]interface{}{"input": "tle", "expected": "5", "hidden": true},
+		map[string]interface{}{"input": "6", "expected": "6"},
+	}
+
+	result, err := executor.RunTests("code", "python", services.TestSuite{TestCases: testCases})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.PassedCount)
+	assert.Len(t, result.Failures, 3)
+
+	sample := result.Failures[0]
+	assert.False(t, sample.Hidden)
+	assert.Equal(t, services.VerdictWrongAnswer, sample.Verdict)
+	assert.Equal(t, "1", sample.Input)
+	assert.Equal(t, "2", sample.Expected)
+	assert.Equal(t, "1", sample.Got)
+
+	for _, failure := range result.Failures[1:] {
+		assert.True(t, failure.Hidden)
+		assert.Empty(t, failure.Input)
+		assert.Empty(t, failure.Expected)
+		assert.Empty(t, failure.Got)
+		assert.Empty(t, failure.Error)
+	}
+	assert.Equal(t, 2, result.Failures[1].TestNumber)
+	assert.Equal(t, services.VerdictWrongAnswer, result.Failures[1].Verdict)
+	assert.Equal(t, 3, result.Failures[2].TestNumber)
+	assert.Equal(t, services.VerdictTimeLimitExceeded, result.Failures[2].Verdict)
+}
+
+// limitsExecutor is an echo backend that records the limits of its last run
+func limitsExecutor(limits *services.ResourceLimits) fakeExecutor {
+	return func(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		*limits = req.Limits
+		return &services.ExecutionOutput{Verdict: services.VerdictAccepted, Stdout: req.Stdin}, nil
+	}
+}
+
+// Test that configured languages resolve aliases, scale limits and can be
+// disabled
+func TestLanguageRegistry(t *testing.T) {
+	languages := config.DefaultLanguages()
+	python := languages["python"]
+	python.TimeMultiplier = 3
+	languages["python"] = python
+	kotlin := languages["kotlin"]
+	kotlin.Enabled = false
+	languages["kotlin"] = kotlin
+
+	var limits services.ResourceLimits
+	executor := services.NewCodeExecutor(limitsExecutor(&limits), services.DefaultResourceLimits,
+		services.NewLanguageRegistry(languages), nil, nil)
+
+	assert.True(t, executor.SupportsLanguage("Python3"))
+	assert.False(t, executor.SupportsLanguage("kotlin"))
+	assert.False(t, executor.SupportsLanguage("cobol"))
+	for _, lang := range executor.Languages() {
+		assert.NotEqual(t, "kotlin", lang.ID)
+	}
+
+	suite := services.TestSuite{TestCases: []interface{}{
+		map[string]interface{}{"input": "1", "expected": "1"},
+	}}
+	result, err := executor.RunTests("code", "py", suite)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.PassedCount)
+	assert.Equal(t, services.DefaultResourceLimits.CPUTime*3, limits.CPUTime)
+
+	_, err = executor.RunTests("code", "kotlin", suite)
+	assert.EqualError(t, err, "unsupported language: kotlin")
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/algoholic/services"
+)
+
+// costExecutor is a fake backend whose runs take a fixed startup time plus
+// cost(n) milliseconds, where n is the length of the first encoded argument
+func costExecutor(cost func(n float64) float64) fakeExecutor {
+	return func(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		n, _ := strconv.Atoi(strings.Fields(req.Stdin)[0])
+		return &services.ExecutionOutput{
+			Verdict:  services.VerdictAccepted,
+			TimeMs:   40 + cost(float64(n)),
+			MemoryKB: 9000 + n/64,
+		}, nil
+	}
+}
+
+// Test fitting measurements to complexity classes
+func TestFitComplexity(t *testing.T) {
+	sizes := []float64{8, 16, 32, 64, 128, 256, 512, 1024, 2048, 4096}
+	curves := map[services.ComplexityClass]func(n float64) float64{
+		services.ComplexityConstant:     func(n float64) float64 { return 50 },
+		services.ComplexityLinear:       func(n float64) float64 { return 50 + n/10 },
+		services.ComplexityLinearithmic: func(n float64) float64 { return 50 + n*math.Log2(n)/10 },
+		services.ComplexityQuadratic:    func(n float64) float64 { return 50 + n*n/1000 },
+	}
+
+	for class, curve := range curves {
+		values := make([]float64, len(sizes))
+		for i, n := range sizes {
+			values[i] = curve(n)
+		}
+		assert.Equal(t, class, services.FitComplexity(sizes, values, 1), string(class))
+	}
+
+	small := []float64{8, 12, 16, 20, 24, 28, 32}
+	values := make([]float64, len(small))
+	for i, n := range small {
+		values[i] = 50 + math.Exp2(n)/1e6
+	}
+	assert.Equal(t, services.ComplexityExponential, services.FitComplexity(small, values, 1))
+
+	// A single spike is noise, not growth
+	assert.Equal(t, services.ComplexityConstant,
+		services.FitComplexity(sizes, []float64{50, 52, 49, 51, 50, 50, 48, 51, 50, 90}, 1))
+
+	for input, class := range map[string]services.ComplexityClass{
+		"O(1)":       services.ComplexityConstant,
+		"O(log n)":   services.ComplexityLogarithmic,
+		"O(N)":       services.ComplexityLinear,
+		"O(n log n)": services.ComplexityLinearithmic,
+		"O(n²)":      services.ComplexityQuadratic,
+		"O(2^n)":     services.ComplexityExponential,
+	} {
+		parsed, ok := services.ParseComplexityClass(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, class, parsed, input)
+	}
+	_, ok := services.ParseComplexityClass("O(m*n)")
+	assert.False(t, ok, "multi-variable complexity")
+}
+
+// Test that a solution slower than the reference complexity is flagged
+func TestAnalyzeComplexity(t *testing.T) {
+	sig := twoSumSignature(t)
+	options := services.ComplexityOptions{Enabled: true, MaxSize: 1 << 12}
+
+	quadratic := services.NewCodeExecutor(costExecutor(func(n float64) float64 { return n * n / 10000 }), services.DefaultResourceLimits, nil, nil, nil)
+	analysis, err := quadratic.AnalyzeComplexity("code", "python", sig, options, "O(n)", "O(n)")
+	assert.NoError(t, err)
+	assert.Equal(t, services.ComplexityQuadratic, analysis.TimeComplexity)
+	assert.Equal(t, services.ComplexityLinear, analysis.ReferenceTime)
+	assert.True(t, analysis.WorseThanReference)
+
+	// Reading the input is linear, so an O(log n) reference is not violated
+	// by linear measurements
+	linear := services.NewCodeExecutor(costExecutor(func(n float64) float64 { return n / 10 }), services.DefaultResourceLimits, nil, nil, nil)
+	analysis, err = linear.AnalyzeComplexity("code", "python", sig, options, "O(log n)", "O(1)")
+	assert.NoError(t, err)
+	assert.Equal(t, services.ComplexityLinear, analysis.TimeComplexity)
+	assert.Equal(t, services.ComplexityLinear, analysis.InputBound)
+	assert.False(t, analysis.WorseThanReference)
+}
@@ -0,0 +1,25 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/algoholic/config"
+)
+
+// Test that environment variables reach keys with underscores in their names
+func TestConfigFromEnvironment(t *testing.T) {
+	t.Setenv("ALGOHOLIC_EXECUTOR_JUDGE0_URL", "http://judge0:2358")
+	t.Setenv("ALGOHOLIC_EXECUTOR_BATCH_SIZE", "7")
+	t.Setenv("ALGOHOLIC_EXECUTOR_QUEUE_MAX_CONCURRENT", "3")
+	t.Setenv("ALGOHOLIC_DATABASE_HOST", "db.example.com")
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+	assert.Equal(t, "http://judge0:2358", cfg.Executor.Judge0URL)
+	assert.Equal(t, 7, cfg.Executor.BatchSize)
+	assert.Equal(t, 3, cfg.Executor.Queue.MaxConcurrent)
+	assert.Equal(t, "db.example.com", cfg.Database.Host)
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that users over their rate or in-flight limit are told when to retry
+func TestExecutionQueueLimits(t *testing.T) {
+	queue := services.NewExecutionQueue(services.QueueOptions{RatePerMinute: 1, Burst: 2, MaxQueuedPerUser: 5})
+
+	for i := 0; i < 2; i++ {
+		job, err := queue.Admit(1)
+		assert.NoError(t, err)
+		job.Wait()
+		job.Done()
+	}
+
+	_, err := queue.Admit(1)
+	var rejected *services.QueueRejectedError
+	if assert.True(t, errors.As(err, &rejected)) {
+		assert.Equal(t, services.RejectRateLimited, rejected.Reason)
+		assert.InDelta(t, time.Minute.Seconds(), rejected.RetryAfter.Seconds(), 1)
+	}
+
+	// Other users have their own limits
+	job, err := queue.Admit(2)
+	assert.NoError(t, err)
+	job.Done()
+
+	perUser := services.NewExecutionQueue(services.QueueOptions{MaxQueuedPerUser: 1})
+	first, err := perUser.Admit(1)
+	assert.NoError(t, err)
+	_, err = perUser.Admit(1)
+	if assert.True(t, errors.As(err, &rejected)) {
+		assert.Equal(t, services.RejectQueueFull, rejected.Reason)
+	}
+	first.Done()
+	last, err := perUser.Admit(1)
+	assert.NoError(t, err)
+
+	// A costly job uses up as much of the daily quota as that many jobs
+	quota := services.NewExecutionQueue(services.QueueOptions{DailyQuota: 10})
+	job, err = quota.AdmitCost(1, 8)
+	assert.NoError(t, err)
+	job.Done()
+	_, err = quota.AdmitCost(1, 3)
+	if assert.True(t, errors.As(err, &rejected)) {
+		assert.Equal(t, services.RejectQuotaExceeded, rejected.Reason)
+	}
+	job, err = quota.AdmitCost(1, 2)
+	assert.NoError(t, err)
+	job.Done()
+
+	// A user is forgotten once nothing sets them apart from a new user
+	assert.Equal(t, 1, quota.Stats().Users)
+	assert.Equal(t, 1, perUser.Stats().Users)
+	last.Done()
+	assert.Equal(t, 0, perUser.Stats().Users)
+
+	stats := queue.Stats()
+	assert.Equal(t, int64(3), stats.Admitted)
+	assert.Equal(t, int64(1), stats.Rejected[services.RejectRateLimited])
+	assert.Equal(t, 2, stats.Users)
+}
+
+// Test that waiting jobs start one user at a time rather than first come,
+// first served
+func TestExecutionQueueFairness(t *testing.T) {
+	queue := services.NewExecutionQueue(services.QueueOptions{MaxConcurrent: 1})
+
+	running, err := queue.Admit(0)
+	assert.NoError(t, err)
+	running.Wait()
+
+	started := make(chan int, 4)
+	enqueue := func(userID int) {
+		job, err := queue.Admit(userID)
+		assert.NoError(t, err)
+		go func() {
+			job.Wait()
+			started <- userID
+			job.Done()
+		}()
+		// Let the job join the waiting list before the next one
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// User 1 queues three jobs before user 2 queues one
+	enqueue(1)
+	enqueue(1)
+	enqueue(1)
+	enqueue(2)
+	assert.Equal(t, 4, queue.Stats().Queued)
+
+	running.Done()
+	var order []int
+	for i := 0; i < 4; i++ {
+		order = append(order, <-started)
+	}
+	assert.Equal(t, []int{1, 2, 1, 1}, order)
+}
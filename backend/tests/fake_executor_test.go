@@ -0,0 +1,32 @@
+package tests
+
+import (
+	"strings"
+
+	"github.com/yourusername/algoholic/services"
+)
+
+// fakeExecutor is a fake backend that answers every run with its function,
+// so each test only writes how its programs behave
+type fakeExecutor func(req services.ExecutionRequest) (*services.ExecutionOutput, error)
+
+func (fakeExecutor) Name() string                 { return "fake" }
+func (fakeExecutor) SupportsLanguage(string) bool { return true }
+func (fakeExecutor) IsAvailable() bool            { return true }
+func (f fakeExecutor) Execute(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+	return f(req)
+}
+
+// echoRun prints a program's stdin back, except "tle" which times out
+func echoRun(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+	if strings.TrimSpace(req.Stdin) == "tle" {
+		return &services.ExecutionOutput{Verdict: services.VerdictTimeLimitExceeded}, nil
+	}
+	return &services.ExecutionOutput{Verdict: services.VerdictAccepted, Stdout: req.Stdin}, nil
+}
+
+// echoExecutor is a fake backend whose programs print their stdin back,
+// except "tle" which times out
+func echoExecutor() fakeExecutor {
+	return echoRun
+}
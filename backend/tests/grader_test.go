@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/seed"
+	"github.com/yourusername/algoholic/services"
+)
+
+// trueFalseGrader is a question format added outside the services package
+type trueFalseGrader struct{}
+
+func (trueFalseGrader) Format() string { return "true_false" }
+
+func (trueFalseGrader) ValidateQuestion(question *models.Question) error {
+	if _, ok := question.CorrectAnswer["answer"].(bool); !ok {
+		return errors.New("answer must be true or false")
+	}
+	return nil
+}
+
+func (trueFalseGrader) ValidateAnswer(question *models.Question, answer map[string]interface{}) error {
+	if _, ok := answer["answer"].(bool); !ok {
+		return errors.New("answer must be true or false")
+	}
+	return nil
+}
+
+func (trueFalseGrader) Grade(question *models.Question, answer map[string]interface{}) (*services.GradeResult, error) {
+	if answer["answer"] == question.CorrectAnswer["answer"] {
+		return &services.GradeResult{Score: 1}, nil
+	}
+	return &services.GradeResult{Mistakes: []string{"the statement is the other way round"}}, nil
+}
+
+// formatGrader renames a grader's format
+type formatGrader struct {
+	services.Grader
+	format string
+}
+
+func (g formatGrader) Format() string { return g.format }
+
+// brokenExecutor is a backend that fails every run
+func brokenExecutor() fakeExecutor {
+	return func(services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		return nil, errors.New("connection refused")
+	}
+}
+
+// Test that new formats register against the grader registry
+func TestGraderRegistry(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	assert.Equal(t, []string{"code", "debug", "fill_blank", "multiple_choice", "open_ended", "ranking", "text"}, qs.Graders().Formats())
+	assert.Error(t, qs.Graders().Register(formatGrader{Grader: trueFalseGrader{}, format: "text"}))
+
+	question := &models.Question{QuestionFormat: "true_false", CorrectAnswer: models.JSONB{"answer": true}}
+	_, err := qs.Grade(question, map[string]interface{}{"answer": true})
+	assert.ErrorIs(t, err, services.ErrUnknownQuestionFormat)
+	assert.ErrorIs(t, qs.ValidateQuestion(question), services.ErrUnknownQuestionFormat)
+
+	require.NoError(t, qs.Graders().Register(trueFalseGrader{}))
+	require.NoError(t, qs.ValidateQuestion(question))
+
+	result, err := qs.Grade(question, map[string]interface{}{"answer": false})
+	require.NoError(t, err)
+	assert.False(t, result.Correct)
+	assert.Equal(t, "Incorrect", result.Feedback)
+	assert.Equal(t, []string{"the statement is the other way round"}, result.Mistakes)
+
+	_, err = qs.Grade(question, map[string]interface{}{"answer": "yes"})
+	assert.ErrorIs(t, err, services.ErrInvalidAnswer)
+	assert.ErrorIs(t, qs.ValidateQuestion(&models.Question{QuestionFormat: "true_false", CorrectAnswer: models.JSONB{}}), services.ErrInvalidQuestion)
+}
+
+// Test that questions are checked against their format when created
+func TestValidateQuestion(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	tests := []struct {
+		name     string
+		question models.Question
+		valid    bool
+	}{
+		{"choice", models.Question{QuestionFormat: "multiple_choice", CorrectAnswer: models.JSONB{"answer": "b"},
+			AnswerOptions: models.JSONB{"options": []map[string]string{{"id": "a"}, {"id": "b"}}}}, true},
+		{"choice not an option", models.Question{QuestionFormat: "multiple_choice", CorrectAnswer: models.JSONB{"answers": []string{"b", "c"}},
+			AnswerOptions: models.JSONB{"options": []map[string]string{{"id": "a"}, {"id": "b"}}}}, false},
+		{"text without answer", models.Question{QuestionFormat: "text", CorrectAnswer: models.JSONB{"answer": []string{}}}, false},
+		{"text matching", models.Question{QuestionFormat: "text", CorrectAnswer: models.JSONB{"answer": "x", "matching": "exact"}}, false},
+		{"semantic threshold", models.Question{QuestionFormat: "text", CorrectAnswer: models.JSONB{"answer": "x", "matching": "semantic", "semantic_threshold": 1.5}}, false},
+		{"ranking with repeats", models.Question{QuestionFormat: "ranking", CorrectAnswer: models.JSONB{"ranking": []string{"a", "b", "a"}}}, false},
+		{"ranking scoring", models.Question{QuestionFormat: "ranking", CorrectAnswer: models.JSONB{"ranking": []string{"a", "b"}, "scoring": "spearman"}}, false},
+		{"code checker", models.Question{QuestionFormat: "code", CorrectAnswer: models.JSONB{
+			"test_cases": []map[string]interface{}{{"input": "1", "expected": "1"}}, "checker": "fuzzy"}}, false},
+		{"blank without answers", models.Question{QuestionFormat: "fill_blank", CorrectAnswer: models.JSONB{"blanks": []interface{}{"x", []string{}}}}, false},
+		{"debug line out of range", models.Question{QuestionFormat: "debug", QuestionData: models.JSONB{"code": "x = 1\n"},
+			CorrectAnswer: models.JSONB{"bug_line": 3, "fix": "x = 2"}}, false},
+		{"debug", models.Question{QuestionFormat: "debug", QuestionData: models.JSONB{"code": "x = 1\n"},
+			CorrectAnswer: models.JSONB{"bug_line": 1, "fix": "x = 2"}}, true},
+		{"rubric", models.Question{QuestionFormat: "open_ended", CorrectAnswer: models.JSONB{"rubric": []interface{}{42}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := qs.ValidateQuestion(&tt.question)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, services.ErrInvalidQuestion)
+			}
+		})
+	}
+}
+
+// Test that every seed question is valid for its format
+func TestSeedQuestionsValidate(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+
+	problemIDs := make(map[int]int)
+	for i, problem := range seed.GetSeedProblems() {
+		require.NoError(t, db.Create(&problem).Error)
+		problemIDs[i+1] = problem.ProblemID
+	}
+
+	qs := services.NewQuestionService(db, nil, nil)
+	for i, question := range seed.GetSeedQuestions() {
+		if question.ProblemID != nil {
+			id := problemIDs[*question.ProblemID]
+			question.ProblemID = &id
+		}
+		assert.NoError(t, qs.CreateQuestion(&question), "seed question %d", i+1)
+	}
+}
+
+// Test the mistakes graders report
+func TestGradeMistakes(t *testing.T) {
+	executor := services.NewCodeExecutor(echoExecutor(), services.DefaultResourceLimits, nil, nil, nil)
+	qs := services.NewQuestionService(nil, executor, nil)
+
+	ranking := &models.Question{QuestionFormat: "ranking", CorrectAnswer: models.JSONB{"ranking": []interface{}{"a", "b", "c"}}}
+	result, err := qs.Grade(ranking, map[string]interface{}{"ranking": []interface{}{"b", "a", "c", "x"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"x does not belong in the ranking", "a should come before b"}, result.Mistakes)
+	assert.Equal(t, "Partially correct", result.Feedback)
+	result, err = qs.Grade(ranking, map[string]interface{}{"ranking": []interface{}{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c is missing"}, result.Mistakes)
+
+	choice := &models.Question{QuestionFormat: "multiple_choice", CorrectAnswer: models.JSONB{"answers": []interface{}{"a", "c"}}}
+	result, err = qs.Grade(choice, map[string]interface{}{"answers": []interface{}{"a", "d"}})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, result.Score)
+	assert.Equal(t, []string{"option d is not correct", "missed option c"}, result.Mistakes)
+
+	code := &models.Question{QuestionFormat: "code", CorrectAnswer: models.JSONB{"test_cases": []interface{}{
+		map[string]interface{}{"input": "1", "expected": "1", "sample": true},
+		map[string]interface{}{"input": "2", "expected": "3", "sample": true},
+		map[string]interface{}{"input": "tle", "expected": "4", "hidden": true},
+	}}}
+	result, err = qs.Grade(code, map[string]interface{}{"code": "print(input())", "language": "python"})
+	require.NoError(t, err)
+	assert.Equal(t, "Passed 1 of 3 tests", result.Feedback)
+	assert.Equal(t, []string{"test 2: wrong answer", "hidden test 3: time limit exceeded"}, result.Mistakes)
+	assert.IsType(t, &services.ExecutionResult{}, result.Details)
+
+	_, err = qs.Grade(code, map[string]interface{}{"code": "print(input())", "language": "cobol"})
+	assert.ErrorIs(t, err, services.ErrInvalidAnswer)
+	_, err = qs.Grade(code, map[string]interface{}{"answer": "print(input())"})
+	assert.ErrorIs(t, err, services.ErrInvalidAnswer)
+
+	// Code the backend fails to run is not graded at all
+	broken := services.NewQuestionService(nil, services.NewCodeExecutor(brokenExecutor(), services.DefaultResourceLimits, nil, nil, nil), nil)
+	_, err = broken.Grade(code, map[string]interface{}{"code": strings.Repeat("x", 20)})
+	assert.ErrorIs(t, err, services.ErrExecutionFailed)
+}
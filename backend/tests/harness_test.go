@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/services"
+)
+
+func twoSumSignature(t *testing.T) *services.FunctionSignature {
+	sig, err := services.ParseFunctionSignature(map[string]interface{}{
+		"function": "twoSum",
+		"params": []interface{}{
+			map[string]interface{}{"name": "nums", "type": "int[]"},
+			map[string]interface{}{"name": "target", "type": "int"},
+		},
+		"return": "int[]",
+	})
+	assert.NoError(t, err)
+	return sig
+}
+
+// Test function signature validation and input encoding
+func TestFunctionSignature(t *testing.T) {
+	sig := twoSumSignature(t)
+
+	stdin, err := sig.EncodeInput("[2,7,11,15]\n9")
+	assert.NoError(t, err)
+	assert.Equal(t, "4 2 7 11 15 \n9 \n", stdin)
+
+	_, err = sig.EncodeInput("[2,7]")
+	assert.Error(t, err, "missing argument")
+	_, err = sig.EncodeInput("[2,7]\n\"9\"")
+	assert.Error(t, err, "wrong argument type")
+
+	tree, err := services.ParseFunctionSignature(map[string]interface{}{
+		"function": "isSameLabel",
+		"params": []interface{}{
+			map[string]interface{}{"name": "root", "type": "TreeNode"},
+			map[string]interface{}{"name": "label", "type": "string"},
+		},
+		"return": "bool",
+	})
+	assert.NoError(t, err)
+	stdin, err = tree.EncodeInput(`[1,null,2]` + "\n" + `"a b"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "3 1 null 2 \n3 a b \n", stdin)
+
+	for _, bad := range []map[string]interface{}{
+		{"function": "f", "params": []interface{}{}, "return": "int[][][]"},
+		{"function": "f", "params": []interface{}{}, "return": "TreeNode[]"},
+		{"function": "f", "params": []interface{}{}, "return": "char"},
+		{"function": "1f", "params": []interface{}{}, "return": "int"},
+		{"function": "f", "params": []interface{}{
+			map[string]interface{}{"name": "a", "type": "int"},
+			map[string]interface{}{"name": "a", "type": "int"},
+		}, "return": "int"},
+	} {
+		_, err := services.ParseFunctionSignature(bad)
+		assert.Error(t, err, "%v", bad)
+	}
+}
+
+// Test that every Judge0 language has a starter and a driver
+func TestFunctionHarnessLanguages(t *testing.T) {
+	sig := twoSumSignature(t)
+
+	starter, err := sig.StarterCode("python")
+	assert.NoError(t, err)
+	assert.Equal(t, "class Solution:\n    def twoSum(self, nums: List[int], target: int) -> List[int]:\n        pass\n", starter)
+
+	starter, err = sig.StarterCode("rust")
+	assert.NoError(t, err)
+	assert.Contains(t, starter, "pub fn two_sum(nums: Vec<i32>, target: i32) -> Vec<i32>")
+
+	for _, language := range []string{"python", "javascript", "typescript", "java", "kotlin", "cpp", "c", "go", "rust", "ruby", "php", "swift"} {
+		starter, err := sig.StarterCode(language)
+		assert.NoError(t, err, language)
+
+		driver, err := sig.WrapCode(language, starter)
+		assert.NoError(t, err, language)
+		assert.Contains(t, driver, starter, language)
+	}
+
+	_, err = sig.StarterCode("cobol")
+	assert.Error(t, err)
+}
+
+// Test that what the user's function prints is never taken for its result.
+// Skipped where the local sandbox cannot run python3.
+func TestFunctionHarnessIgnoresUserOutput(t *testing.T) {
+	local, err := services.NewLocalExecutor(config.LocalExecutorConfig{})
+	if err != nil {
+		t.Skipf("local sandbox unavailable: %v", err)
+	}
+	executor := services.NewCodeExecutor(local, services.ResourceLimits{CPUTime: 2, WallTime: 5, MemoryKB: 128000}, nil, nil, nil)
+	sig := twoSumSignature(t)
+	if _, err := executor.Run("class Solution:\n    def twoSum(self, nums, target):\n        return []\n", "python", "[1]\n1", sig); err != nil {
+		t.Skipf("local sandbox unavailable: %v", err)
+	}
+
+	suite := services.TestSuite{
+		TestCases: []interface{}{map[string]interface{}{"input": "[2,7,11,15]\n9", "expected": "[0,1]"}},
+		Signature: sig,
+	}
+
+	// Debug prints, even one without a newline, are dropped
+	result, err := executor.RunTests("class Solution:\n    def twoSum(self, nums, target):\n        print('checking', end='')\n        return [0, 1]\n", "python", suite)
+	assert.NoError(t, err)
+	assert.True(t, result.AllPassed)
+
+	// Printing the expected result and exiting is not an answer
+	result, err = executor.RunTests("import sys\nclass Solution:\n    def twoSum(self, nums, target):\n        print('[0,1]')\n        sys.exit(0)\n", "python", suite)
+	assert.NoError(t, err)
+	assert.False(t, result.AllPassed)
+	assert.Equal(t, services.VerdictWrongAnswer, result.TestResults[0].Verdict)
+
+	output, err := executor.Run("class Solution:\n    def twoSum(self, nums, target):\n        print('[5,5]')\n        return [1, 2]\n", "python", "[2,7,11,15]\n9", sig)
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2]\n", output.Stdout)
+}
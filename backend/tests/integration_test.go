@@ -15,6 +15,7 @@ import (
 	"github.com/yourusername/algoholic/config"
 	"github.com/yourusername/algoholic/models"
 	"github.com/yourusername/algoholic/routes"
+	"github.com/yourusername/algoholic/services"
 )
 
 var (
@@ -45,10 +46,11 @@ func setupTestApp(t *testing.T) {
 			Debug:       true,
 		},
 		Auth: config.AuthConfig{
-			Enabled:    false, // Disable auth for easier testing
-			JWTSecret:  "test-secret",
-			JWTExpiry:  24,
-			BCryptCost: 4, // Lower cost for faster tests
+			Enabled:      false, // Disable auth for easier testing
+			JWTSecret:    "test-secret",
+			JWTExpiry:    24,
+			BCryptCost:   4,        // Lower cost for faster tests
+			AdminUserIDs: []int{1}, // the first user registered
 		},
 	}
 
@@ -304,6 +306,37 @@ func TestGetUserStats(t *testing.T) {
 	assert.Contains(t, result, "current_streak_days")
 }
 
+// Test that admin endpoints need an admin's token even with auth disabled
+func TestAdminRoutesRequireAdmin(t *testing.T) {
+	setupTestApp(t)
+	defer teardownTestApp(t)
+
+	authService := services.NewAuthService(testDB, testCfg)
+	token := func(username string) string {
+		user, err := authService.Register(username, username+"@example.com", "password123")
+		assert.NoError(t, err)
+		token, err := authService.GenerateToken(user)
+		assert.NoError(t, err)
+		return token
+	}
+	request := func(token string) int {
+		req := makeRequest("GET", "/api/admin/similarity", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := testApp.Test(req)
+		assert.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	adminToken := token("ada")
+	assert.Equal(t, http.StatusUnauthorized, request(""))
+	// Being called admin doesn't make a user one
+	assert.Equal(t, http.StatusForbidden, request(token("admin")))
+	// Similarity detection is not enabled in the test config
+	assert.Equal(t, http.StatusServiceUnavailable, request(adminToken))
+}
+
 // Run all tests
 func TestMain(m *testing.M) {
 	// Setup code before all tests if needed
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/services"
+)
+
+// fakeJudge0 serves Judge0's batch API. Each submission's token is its
+// stdin, and it echoes its stdin once it has been polled twice: first in
+// the queue, then processing. Polls fail while failPolls is positive, and
+// batches holding a submission with stdin "reject" are refused.
+type fakeJudge0 struct {
+	mu        sync.Mutex
+	polls     map[string]int
+	failPolls int
+	submitted atomic.Int32
+}
+
+func (f *fakeJudge0) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/submissions/batch":
+		var batch struct {
+			Submissions []services.Judge0Submission `json:"submissions"`
+		}
+		json.NewDecoder(r.Body).Decode(&batch)
+		f.submitted.Add(1)
+		tokens := make([]map[string]string, len(batch.Submissions))
+		for i, submission := range batch.Submissions {
+			if submission.Stdin == "reject" {
+				http.Error(w, "queue is full", http.StatusServiceUnavailable)
+				return
+			}
+			tokens[i] = map[string]string{"token": submission.Stdin}
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tokens)
+
+	case r.Method == http.MethodGet && r.URL.Path == "/submissions/batch":
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.failPolls > 0 {
+			f.failPolls--
+			http.Error(w, "try again", http.StatusBadGateway)
+			return
+		}
+		var responses []services.Judge0Response
+		for _, token := range strings.Split(r.URL.Query().Get("tokens"), ",") {
+			f.polls[token]++
+			resp := services.Judge0Response{Token: token}
+			switch f.polls[token] {
+			case 1:
+				resp.Status.ID = 1
+			case 2:
+				resp.Status.ID = 2
+			default:
+				resp.Status.ID = 3
+				resp.Stdout = token
+				resp.Time = "0.001"
+			}
+			responses = append(responses, resp)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"submissions": responses})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newFakeJudge0(t *testing.T, batchSize, pollWorkers int) (*fakeJudge0, *services.Judge0Executor) {
+	fake := &fakeJudge0{polls: map[string]int{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, services.NewJudge0Executor(config.ExecutorConfig{
+		Judge0URL:      server.URL,
+		BatchSize:      batchSize,
+		PollWorkers:    pollWorkers,
+		PollIntervalMs: 1,
+	})
+}
+
+func judge0Requests(stdins ...string) []services.ExecutionRequest {
+	reqs := make([]services.ExecutionRequest, len(stdins))
+	for i, stdin := range stdins {
+		reqs[i] = services.ExecutionRequest{Language: "python", SourceCode: "print(input())", Stdin: stdin, Limits: services.DefaultResourceLimits}
+	}
+	return reqs
+}
+
+// Test that batches split into chunks keep their order and report every run
+func TestJudge0Batch(t *testing.T) {
+	fake, executor := newFakeJudge0(t, 2, 3)
+
+	stdins := make([]string, 7)
+	for i := range stdins {
+		stdins[i] = fmt.Sprintf("run-%d", i)
+	}
+	var mu sync.Mutex
+	states := map[int][]services.RunState{}
+	outputs, err := executor.ExecuteBatchWithProgress(judge0Requests(stdins...), func(i int, state services.RunState, _ *services.ExecutionOutput) {
+		mu.Lock()
+		defer mu.Unlock()
+		states[i] = append(states[i], state)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), fake.submitted.Load())
+
+	require.Len(t, outputs, len(stdins))
+	for i, output := range outputs {
+		assert.Equal(t, services.VerdictAccepted, output.Verdict)
+		assert.Equal(t, stdins[i], output.Stdout)
+		assert.Equal(t, []services.RunState{services.RunQueued, services.RunRunning, services.RunFinished}, states[i])
+	}
+}
+
+// Test that failed polls are retried, and that the batch fails when they
+// keep failing
+func TestJudge0BatchPollRetries(t *testing.T) {
+	fake, executor := newFakeJudge0(t, 20, 1)
+	fake.failPolls = 3
+	outputs, err := executor.ExecuteBatch(judge0Requests("a", "b"))
+	require.NoError(t, err)
+	assert.Equal(t, "a", outputs[0].Stdout)
+	assert.Equal(t, "b", outputs[1].Stdout)
+
+	fake.failPolls = 4
+	_, err = executor.ExecuteBatch(judge0Requests("c"))
+	assert.ErrorContains(t, err, "status 502")
+}
+
+// Test that the first failing chunk cancels the chunks after it
+func TestJudge0BatchCancelsOnError(t *testing.T) {
+	fake, executor := newFakeJudge0(t, 1, 1)
+	_, err := executor.ExecuteBatch(judge0Requests("reject", "b", "c", "d", "e"))
+	assert.ErrorContains(t, err, "queue is full")
+	assert.Equal(t, int32(1), fake.submitted.Load())
+}
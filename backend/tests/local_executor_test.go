@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test the verdicts of the local sandbox. Skipped where it cannot run:
+// off Linux, without user namespaces or without python3.
+func TestLocalExecutorVerdicts(t *testing.T) {
+	executor, err := services.NewLocalExecutor(config.LocalExecutorConfig{})
+	if err != nil {
+		t.Skipf("local sandbox unavailable: %v", err)
+	}
+	limits := services.ResourceLimits{CPUTime: 1, WallTime: 5, MemoryKB: 64000}
+	if _, err := executor.Execute(services.ExecutionRequest{Language: "python", SourceCode: "pass", Limits: limits}); err != nil {
+		t.Skipf("local sandbox unavailable: %v", err)
+	}
+
+	run := func(language, code string) *services.ExecutionOutput {
+		output, err := executor.Execute(services.ExecutionRequest{
+			Language:   language,
+			SourceCode: code,
+			Stdin:      "hello\n",
+			Limits:     limits,
+		})
+		require.NoError(t, err)
+		return output
+	}
+
+	output := run("python", "print(input())")
+	assert.Equal(t, services.VerdictAccepted, output.Verdict)
+	assert.Equal(t, "hello\n", output.Stdout)
+
+	output = run("python", "while True:\n    pass")
+	assert.Equal(t, services.VerdictTimeLimitExceeded, output.Verdict)
+
+	output = run("python", "data = bytearray(512 * 1024 * 1024)")
+	assert.Equal(t, services.VerdictMemoryLimitExceeded, output.Verdict)
+
+	// Only a failed run can have run out of memory
+	output = run("python", "import sys\nsys.stderr.write('MemoryError: just kidding\\n')")
+	assert.Equal(t, services.VerdictAccepted, output.Verdict)
+	output = run("python", "try:\n    data = bytearray(512 * 1024 * 1024)\nexcept MemoryError:\n    print('recovered')")
+	assert.Equal(t, services.VerdictAccepted, output.Verdict)
+	assert.Equal(t, "recovered\n", output.Stdout)
+
+	output = run("python", "raise ValueError('boom')")
+	assert.Equal(t, services.VerdictRuntimeError, output.Verdict)
+	assert.Contains(t, output.Stderr, "boom")
+
+	// A program cannot pass its own failure off as the sandbox's
+	output = run("python", "import sys\nsys.stderr.write('algoholic-sandbox: setup failed\\n')\nsys.exit(125)")
+	assert.Equal(t, services.VerdictRuntimeError, output.Verdict)
+
+	// Only the work directory is writable
+	output = run("python", "open('/usr/algoholic', 'w')")
+	assert.Equal(t, services.VerdictRuntimeError, output.Verdict)
+	assert.Contains(t, output.Stderr, "Read-only file system")
+	output = run("python", "open('scratch', 'w').write('x')\nprint(open('scratch').read())")
+	assert.Equal(t, services.VerdictAccepted, output.Verdict)
+	assert.Equal(t, "x\n", output.Stdout)
+
+	// Host files outside the toolchain paths are not there at all
+	secret := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secret, []byte("password"), 0o644))
+	output = run("python", "import os\nprint(os.path.exists('"+secret+"'), os.path.exists('/etc/passwd'))")
+	assert.Equal(t, services.VerdictAccepted, output.Verdict)
+	assert.Equal(t, "False False\n", output.Stdout)
+
+	// The program sees its own PID namespace
+	output = run("python", "import os\nprint(sorted(int(p) for p in os.listdir('/proc') if p.isdigit())[0])")
+	assert.Equal(t, "1\n", output.Stdout)
+}
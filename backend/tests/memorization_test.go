@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that correct answers are flagged as memorized from fast repeats,
+// failed variants and copied solutions
+func TestMemorizationDetection(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+	require.NoError(t, db.Create(&models.User{Username: "ada", Email: "ada@example.com", PasswordHash: "x"}).Error)
+
+	problem := &models.Problem{Title: "Two Sum", Slug: "two-sum", Description: "Two Sum", DifficultyScore: 20,
+		Examples: models.JSONBArray{}, ReferenceSolution: models.JSONB{
+			"language": "python",
+			"code":     "def two_sum(nums, target):\n    seen = {}\n    return []",
+		}}
+	require.NoError(t, db.Create(problem).Error)
+
+	estimated := 120
+	question := func(problemID *int, questionType, format string, concepts []string, answer string) *models.Question {
+		q := &models.Question{ProblemID: problemID, QuestionType: questionType, QuestionFormat: format,
+			QuestionText: "?", CorrectAnswer: models.JSONB{"answer": answer}, RelatedConcepts: concepts,
+			DifficultyScore: 20, EstimatedTimeSeconds: &estimated}
+		require.NoError(t, db.Create(q).Error)
+		return q
+	}
+	original := question(&problem.ProblemID, "concept", "multiple_choice", []string{"hashing"}, "A")
+	variants := []*models.Question{
+		question(nil, "concept", "multiple_choice", []string{"Hashing"}, "A"),
+		question(nil, "concept", "multiple_choice", []string{"hashing", "arrays"}, "A"),
+	}
+	definition := question(nil, "definition", "text", nil, "A hash map stores key value pairs in buckets indexed by hash")
+
+	questions := services.NewQuestionService(db, nil, nil)
+	submit := func(q *models.Question, answer string, seconds int) (*services.AnswerResponse, *models.UserAttempt) {
+		response, err := questions.SubmitAnswer(1, services.AnswerRequest{
+			QuestionID: q.QuestionID,
+			UserAnswer: map[string]interface{}{"answer": answer},
+			TimeTaken:  seconds,
+		})
+		require.NoError(t, err)
+		var attempt models.UserAttempt
+		require.NoError(t, db.First(&attempt, response.AttemptID).Error)
+		require.NotNil(t, attempt.ShowsMemorization)
+		return response, &attempt
+	}
+
+	// A first answer is not a repeat, and a fast repeat alone may be mastery
+	response, attempt := submit(original, "A", 100)
+	assert.False(t, *attempt.ShowsMemorization)
+	response, attempt = submit(original, "A", 10)
+	assert.False(t, *attempt.ShowsMemorization)
+	assert.Nil(t, response.Memorization)
+
+	// Failing the variants of the question makes the repeat suspicious
+	for _, variant := range variants {
+		_, attempt = submit(variant, "B", 60)
+		assert.False(t, *attempt.ShowsMemorization)
+	}
+	response, attempt = submit(original, "A", 10)
+	assert.True(t, *attempt.ShowsMemorization)
+	require.NotNil(t, response.Memorization)
+	assert.Equal(t, []string{services.MemorizationFastRepeat, services.MemorizationVariantGap}, response.Memorization.Signals)
+	assert.InDelta(t, 0.8, response.Memorization.Score, 1e-9)
+
+	// Reciting the accepted answer word for word may be a well-learned
+	// answer, but not when it is also a fast repeat
+	recited := "a hash map stores key value pairs in buckets indexed by hash."
+	response, attempt = submit(definition, recited, 60)
+	assert.False(t, *attempt.ShowsMemorization)
+	assert.Nil(t, response.Memorization)
+	response, attempt = submit(definition, recited, 10)
+	assert.True(t, *attempt.ShowsMemorization)
+	require.NotNil(t, response.Memorization)
+	assert.Equal(t, []string{services.MemorizationFastRepeat, services.MemorizationCanonicalCopy}, response.Memorization.Signals)
+	assert.InDelta(t, 0.7, response.Memorization.Score, 1e-9)
+
+	// Variants are found through a concept alone, matched exactly
+	submit(question(nil, "concept", "multiple_choice", []string{"Arrays"}, "A"), "B", 60)
+	detector := services.NewMemorizationDetector(db)
+	first := &models.UserAttempt{UserID: 1, IsCorrect: true, AttemptNumber: 1}
+	check := detector.Check(question(nil, "concept", "multiple_choice", []string{"ARRAYS"}, "A"), first)
+	assert.Equal(t, []string{services.MemorizationVariantGap}, check.Signals)
+	check = detector.Check(question(nil, "concept", "multiple_choice", []string{"array"}, "A"), first)
+	assert.Empty(t, check.Signals)
+
+	// A code answer that is the reference solution reformatted is a copy;
+	// the same solution written differently is not
+	coding := question(&problem.ProblemID, "implementation", "code", nil, "")
+	copied := &models.UserAttempt{UserID: 1, IsCorrect: true, AttemptNumber: 1, UserAnswer: models.JSONB{
+		"code": "def two_sum(nums, target):\n\tseen = {}\n\n\treturn []\n",
+	}}
+	check = detector.Check(coding, copied)
+	assert.Equal(t, []string{services.MemorizationCanonicalCopy}, check.Signals)
+	assert.False(t, check.ShowsMemorization, "a copy alone is not enough")
+	copied.UserAnswer["code"] = "def two_sum(values, goal):\n    seen = {}\n    return []"
+	assert.Empty(t, detector.Check(coding, copied).Signals)
+
+	// Two of the five correct answers look memorized
+	stats, err := services.NewUserService(db).GetUserStats(1)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.4, stats.MemorizationScore, 1e-9)
+}
@@ -0,0 +1,352 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that common techniques are recognized in Python
+func TestDetectPatternsPython(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected []string
+	}{
+		{"binary search", `
+def search(nums, target):
+    lo, hi = 0, len(nums) - 1
+    while lo <= hi:
+        mid = (lo + hi) // 2
+        if nums[mid] == target:
+            return mid
+        elif nums[mid] < target:
+            lo = mid + 1
+        else:
+            hi = mid - 1
+    return -1
+`, []string{services.PatternBinarySearch}},
+		{"two pointers", `
+class Solution:
+    def twoSum(self, numbers: List[int], target: int) -> List[int]:
+        left, right = 0, len(numbers) - 1
+        while left < right:
+            total = numbers[left] + numbers[right]
+            if total == target:
+                return [left + 1, right + 1]
+            if total < target:
+                left += 1
+            else:
+                right -= 1
+`, []string{services.PatternTwoPointers}},
+		{"sliding window", `
+def longest(s):
+    seen = {}
+    start = best = 0
+    for end, ch in enumerate(s):
+        if ch in seen and seen[ch] >= start:
+            start = seen[ch] + 1
+        seen[ch] = end
+        best = max(best, end - start + 1)
+    return best
+`, []string{services.PatternSlidingWindow, services.PatternHashTable}},
+		{"bfs", `
+from collections import deque
+
+def levels(root):
+    if not root:
+        return []
+    out, q = [], deque([root])
+    while q:
+        node = q.popleft()
+        out.append(node.val)
+        for child in (node.left, node.right):
+            if child:
+                q.append(child)
+    return out
+`, []string{services.PatternBFS}},
+		{"dfs", `
+def islands(grid):
+    def dfs(r, c):
+        if r < 0 or c < 0 or r >= len(grid) or c >= len(grid[0]) or grid[r][c] != "1":
+            return
+        grid[r][c] = "0"
+        for dr, dc in ((1, 0), (-1, 0), (0, 1), (0, -1)):
+            dfs(r + dr, c + dc)
+
+    count = 0
+    for r in range(len(grid)):
+        for c in range(len(grid[0])):
+            if grid[r][c] == "1":
+                dfs(r, c)
+                count += 1
+    return count
+`, []string{services.PatternDFS, services.PatternRecursion}},
+		{"memoization", `
+from functools import lru_cache
+
+@lru_cache(maxsize=None)
+def climb(n):
+    if n <= 2:
+        return n
+    return climb(n - 1) + climb(n - 2)
+`, []string{services.PatternMemoization, services.PatternRecursion}},
+		{"dynamic programming", `
+def coins(amount, options):
+    dp = [float("inf")] * (amount + 1)
+    dp[0] = 0
+    for a in range(1, amount + 1):
+        for c in options:
+            if c <= a:
+                dp[a] = min(dp[a], dp[a - c] + 1)
+    return dp[amount] if dp[amount] != float("inf") else -1
+`, []string{services.PatternDynamicProgramming}},
+		{"backtracking", `
+def subsets(nums):
+    result, path = [], []
+    def backtrack(i):
+        if i == len(nums):
+            result.append(path[:])
+            return
+        path.append(nums[i])
+        backtrack(i + 1)
+        path.pop()
+        backtrack(i + 1)
+    backtrack(0)
+    return result
+`, []string{services.PatternBacktracking, services.PatternRecursion}},
+		{"heap", `
+import heapq
+
+def kth_largest(nums, k):
+    heap = []
+    for n in nums:
+        heapq.heappush(heap, n)
+        if len(heap) > k:
+            heapq.heappop(heap)
+    return heap[0]
+`, []string{services.PatternHeap}},
+		{"stack", `
+def valid(s):
+    stack = []
+    pairs = {")": "(", "]": "[", "}": "{"}
+    for ch in s:
+        if ch in pairs:
+            if not stack or stack.pop() != pairs[ch]:
+                return False
+        else:
+            stack.append(ch)
+    return not stack
+`, []string{services.PatternStack}},
+		{"sorting", `
+def merge(intervals):
+    intervals.sort(key=lambda x: x[0])
+    out = [intervals[0]]
+    for s, e in intervals[1:]:
+        if s <= out[-1][1]:
+            out[-1][1] = max(out[-1][1], e)
+        else:
+            out.append([s, e])
+    return out
+`, []string{services.PatternSorting}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := services.DetectPatterns(tt.code, "python")
+			require.NoError(t, err)
+			assert.Equal(t, "python", analysis.Language)
+			assert.Subset(t, analysis.Names(), tt.expected)
+			assert.Equal(t, tt.expected[0], analysis.Names()[0])
+		})
+	}
+}
+
+// Test that common techniques are recognized in Go, with or without a
+// package clause
+func TestDetectPatternsGo(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected []string
+	}{
+		{"binary search", `
+func search(nums []int, target int) int {
+	lo, hi := 0, len(nums)-1
+	for lo <= hi {
+		mid := lo + (hi-lo)/2
+		switch {
+		case nums[mid] == target:
+			return mid
+		case nums[mid] < target:
+			lo = mid + 1
+		default:
+			hi = mid - 1
+		}
+	}
+	return -1
+}
+`, []string{services.PatternBinarySearch}},
+		{"bfs", `package main
+
+func orangesRotting(grid [][]int) int {
+	queue := [][2]int{}
+	for r := range grid {
+		for c := range grid[r] {
+			if grid[r][c] == 2 {
+				queue = append(queue, [2]int{r, c})
+			}
+		}
+	}
+	minutes := 0
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			r, c := cell[0]+d[0], cell[1]+d[1]
+			if r >= 0 && c >= 0 && r < len(grid) && c < len(grid[0]) && grid[r][c] == 1 {
+				grid[r][c] = 2
+				queue = append(queue, [2]int{r, c})
+			}
+		}
+		minutes++
+	}
+	return minutes
+}
+`, []string{services.PatternBFS}},
+		{"memoized dfs closure", `
+func climbStairs(n int) int {
+	memo := map[int]int{}
+	var climb func(int) int
+	climb = func(i int) int {
+		if i <= 2 {
+			return i
+		}
+		if v, ok := memo[i]; ok {
+			return v
+		}
+		memo[i] = climb(i-1) + climb(i-2)
+		return memo[i]
+	}
+	return climb(n)
+}
+`, []string{services.PatternMemoization, services.PatternRecursion}},
+		{"fast and slow pointers", `
+func hasCycle(head *ListNode) bool {
+	slow, fast := head, head
+	for fast != nil && fast.Next != nil {
+		slow = slow.Next
+		fast = fast.Next.Next
+		if slow == fast {
+			return true
+		}
+	}
+	return false
+}
+`, []string{services.PatternTwoPointers, services.PatternLinkedList}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, err := services.DetectPatterns(tt.code, "golang")
+			require.NoError(t, err)
+			assert.Equal(t, "go", analysis.Language)
+			assert.Subset(t, analysis.Names(), tt.expected)
+			assert.Equal(t, tt.expected[0], analysis.Names()[0])
+		})
+	}
+}
+
+// Test that unparseable code and unsupported languages are errors
+func TestDetectPatternsErrors(t *testing.T) {
+	_, err := services.DetectPatterns("def f(:\n    pass\n", "python")
+	assert.Error(t, err)
+
+	_, err = services.DetectPatterns("func f( {", "go")
+	assert.Error(t, err)
+
+	_, err = services.DetectPatterns("int main() {}", "cpp")
+	assert.ErrorIs(t, err, services.ErrPatternLanguageUnsupported)
+}
+
+// Test feedback against a problem's intended pattern
+func TestComparePatterns(t *testing.T) {
+	primary := "Sliding Window"
+	problem := &models.Problem{PrimaryPattern: &primary, SecondaryPatterns: models.StringArray{"Two Pointers"}}
+
+	feedback := services.ComparePatterns([]string{"Sliding Window", "Hash Table"}, problem)
+	assert.True(t, feedback.UsedIntended)
+	assert.Equal(t, "You used Sliding Window, the intended pattern.", feedback.Message)
+
+	feedback = services.ComparePatterns([]string{"Two Pointers"}, problem)
+	assert.False(t, feedback.UsedIntended)
+	assert.Equal(t, "You used Two Pointers, which also works here, but the intended pattern is Sliding Window.", feedback.Message)
+
+	feedback = services.ComparePatterns([]string{"Sorting", "Hash Table"}, problem)
+	assert.Equal(t, "You used Sorting and Hash Table, the intended pattern is Sliding Window.", feedback.Message)
+
+	feedback = services.ComparePatterns(nil, problem)
+	assert.Equal(t, []string{}, feedback.Detected)
+	assert.Equal(t, "No known pattern was detected; the intended pattern is Sliding Window.", feedback.Message)
+
+	// Aliases count as the intended pattern
+	graph := "Graph Traversal"
+	feedback = services.ComparePatterns([]string{"BFS"}, &models.Problem{PrimaryPattern: &graph})
+	assert.True(t, feedback.UsedIntended)
+	assert.Equal(t, "You used BFS, which fits the intended pattern, Graph Traversal.", feedback.Message)
+
+	assert.Nil(t, services.ComparePatterns([]string{"BFS"}, &models.Problem{}))
+}
+
+// Test that code answers record their patterns and get feedback
+func TestSubmitAnswerDetectsPatterns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+
+	intended := "Two Pointers"
+	problem := &models.Problem{Title: "Pair Sum", Slug: "pair-sum", Description: "Find a pair", DifficultyScore: 30,
+		Examples: models.JSONBArray{}, PrimaryPattern: &intended, SecondaryPatterns: models.StringArray{"Hash Table"}}
+	require.NoError(t, db.Create(problem).Error)
+	question := &models.Question{
+		ProblemID:      &problem.ProblemID,
+		QuestionType:   "code",
+		QuestionFormat: "code",
+		QuestionText:   "Find a pair summing to the target",
+		CorrectAnswer: models.JSONB{"test_cases": []interface{}{
+			map[string]interface{}{"input": "1", "expected": "1"},
+		}},
+		DifficultyScore: 30,
+	}
+	require.NoError(t, db.Create(question).Error)
+
+	executor := services.NewCodeExecutor(echoExecutor(), services.DefaultResourceLimits, nil, nil, nil)
+	questions := services.NewQuestionService(db, executor, nil)
+	response, err := questions.SubmitAnswer(1, services.AnswerRequest{
+		QuestionID: question.QuestionID,
+		UserAnswer: map[string]interface{}{"language": "python3", "code": `
+def pair(nums, target):
+    seen = set()
+    for n in nums:
+        if target - n in seen:
+            return True
+        seen.add(n)
+    return False
+`},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, response.PatternFeedback)
+	assert.False(t, response.PatternFeedback.UsedIntended)
+	assert.Equal(t, "You used Hash Table, which also works here, but the intended pattern is Two Pointers.",
+		response.PatternFeedback.Message)
+
+	var attempt models.UserAttempt
+	require.NoError(t, db.First(&attempt, response.AttemptID).Error)
+	assert.Equal(t, models.StringArray{"Hash Table"}, attempt.DetectedPatterns)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// fixedExecutor is an echo backend whose programs only work once the
+// binary search bug is fixed
+func fixedExecutor() fakeExecutor {
+	return func(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		if !strings.Contains(req.SourceCode, "            lo = mid + 1\n") {
+			return &services.ExecutionOutput{Verdict: services.VerdictAccepted, Stdout: "wrong"}, nil
+		}
+		return echoRun(req)
+	}
+}
+
+// Test that each blank is graded on its own
+func TestCheckFillBlank(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	question := &models.Question{QuestionFormat: "fill_blank", CorrectAnswer: models.JSONB{"blanks": []interface{}{
+		"mid + 1",
+		[]interface{}{"n", "len(nums)"},
+		"depth-first search",
+		map[string]interface{}{"answers": []interface{}{"O(log n)"}, "exact": true},
+	}}}
+
+	answer := map[string]interface{}{"blanks": []interface{}{"mid+1", "len(nums)", "depth first serch", "O(log n)"}}
+	result, err := qs.Grade(question, answer)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, true, true}, result.Details)
+	assert.True(t, result.Correct)
+
+	// Code is not fuzzy matched, and missing blanks are wrong
+	answer = map[string]interface{}{"blanks": []interface{}{"mid - 1", "n", "Depth-First Search"}}
+	result, err = qs.Grade(question, answer)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{false, true, true, false}, result.Details)
+	assert.Equal(t, []string{"blank 1 is wrong", "blank 4 is empty"}, result.Mistakes)
+	assert.False(t, result.Correct)
+
+	assert.False(t, qs.CheckAnswer(&models.Question{QuestionFormat: "fill_blank", CorrectAnswer: models.JSONB{}}, answer))
+}
+
+// Test that debug answers need the right line and a fix that passes the tests
+func TestCheckDebug(t *testing.T) {
+	buggy := "def search(nums, target):\n" +
+		"    lo, hi = 0, len(nums) - 1\n" +
+		"    while lo <= hi:\n" +
+		"        mid = (lo + hi) // 2\n" +
+		"        if nums[mid] < target:\n" +
+		"            lo = mid\n"
+
+	executor := services.NewCodeExecutor(fixedExecutor(), services.DefaultResourceLimits, nil, nil, nil)
+	qs := services.NewQuestionService(nil, executor, nil)
+	question := &models.Question{
+		QuestionFormat: "debug",
+		QuestionData:   models.JSONB{"code": buggy, "language": "python"},
+		CorrectAnswer: models.JSONB{
+			"bug_line":   6,
+			"fix":        "lo = mid + 1",
+			"test_cases": []interface{}{map[string]interface{}{"input": "1", "expected": "1"}},
+		},
+	}
+
+	// The fix is applied to the buggy code with the line's indentation
+	assert.True(t, qs.CheckAnswer(question, map[string]interface{}{"bug_line": 6, "fix": "lo = mid + 1"}))
+	assert.True(t, qs.CheckAnswer(question, map[string]interface{}{"bug_line": "6", "fix": "lo = mid + 1"}))
+	assert.False(t, qs.CheckAnswer(question, map[string]interface{}{"bug_line": 5, "fix": "lo = mid + 1"}))
+	assert.False(t, qs.CheckAnswer(question, map[string]interface{}{"bug_line": 6, "fix": "lo = mid - 1"}))
+
+	// A whole corrected program is run as given
+	fixed := strings.Replace(buggy, "lo = mid\n", "lo = mid + 1\n", 1)
+	assert.True(t, qs.CheckAnswer(question, map[string]interface{}{"bug_line": 6, "code": fixed}))
+
+	// Without test cases the fix is compared with the accepted ones
+	delete(question.CorrectAnswer, "test_cases")
+	assert.True(t, qs.CheckAnswer(question, map[string]interface{}{"bug_line": 6, "fix": "  lo = mid+1"}))
+	assert.False(t, qs.CheckAnswer(question, map[string]interface{}{"bug_line": 6, "fix": "lo = mid"}))
+}
+
+// Test that open-ended answers are scored against the rubric
+func TestCheckOpenEnded(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	question := &models.Question{QuestionFormat: "open_ended", CorrectAnswer: models.JSONB{
+		"rubric": []interface{}{
+			map[string]interface{}{"criterion": "Uses a hash map", "keywords": []interface{}{"hash map", "dictionary"}, "points": 2.0, "required": true},
+			map[string]interface{}{"criterion": "Single pass", "keywords": []interface{}{"one pass", "single pass"}, "points": 1.0},
+			map[string]interface{}{"criterion": "Linear time", "keywords": []interface{}{"O(n)", "linear"}, "points": 1.0},
+		},
+		"passing_score": 0.7,
+	}}
+
+	grade := func(answer string) *services.RubricResult {
+		result, err := qs.Grade(question, map[string]interface{}{"answer": answer})
+		require.NoError(t, err)
+		return result.Details.(*services.RubricResult)
+	}
+
+	result := grade("Store each number's index in a dictionary and look up the complement in a single pass, which is linear.")
+	assert.True(t, result.Passed)
+	assert.Equal(t, 1.0, result.Score)
+
+	// Typos in keywords are tolerated
+	result = grade("Use a dictonary, O(n) time.")
+	assert.True(t, result.Passed)
+	assert.Equal(t, 0.75, result.Score)
+	assert.False(t, result.Criteria[1].Met)
+
+	// Missing a required criterion fails regardless of score
+	result = grade("Sort, then scan in one pass for linear time.")
+	assert.False(t, result.Passed)
+	assert.Equal(t, 0.5, result.Score)
+
+	// Keywords match whole words: "on" in "dictionary" is not O(n)
+	result = grade("A dictionary.")
+	assert.False(t, result.Criteria[2].Met)
+
+	assert.False(t, qs.CheckAnswer(question, map[string]interface{}{"answer": ""}))
+}
@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/handlers"
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that hidden test cases never reach the client, whether a question is
+// fetched or answered
+func TestQuestionsHideHiddenTestCases(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+	require.NoError(t, db.Create(&models.User{Username: "ada", Email: "ada@example.com", PasswordHash: "x"}).Error)
+
+	question := &models.Question{
+		QuestionType:   "code_completion",
+		QuestionFormat: "code",
+		QuestionText:   "Echo the input",
+		CorrectAnswer: models.JSONB{"test_cases": []map[string]interface{}{
+			{"input": "visible-input", "expected": "visible-input", "sample": true},
+			{"input": "secret-input", "expected": "secret-expected"},
+			{"input": "other-secret", "expected": "other-secret", "hidden": true},
+		}},
+		DifficultyScore: 20,
+	}
+	require.NoError(t, db.Create(question).Error)
+
+	executor := services.NewCodeExecutor(echoExecutor(), services.DefaultResourceLimits, nil, nil, nil)
+	questions := services.NewQuestionService(db, executor, nil)
+	handler := handlers.NewQuestionHandler(questions, services.NewUserService(db), services.NewQuestionSessionService(db, "test-secret"))
+	app := fiber.New()
+	app.Get("/questions", handler.GetQuestions)
+	app.Get("/questions/:id", handler.GetQuestion)
+
+	get := func(url string) string {
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+	for _, body := range []string{get("/questions/" + strconv.Itoa(question.QuestionID)), get("/questions")} {
+		assert.Contains(t, body, "visible-input")
+		assert.NotContains(t, body, "secret")
+	}
+
+	response, err := questions.SubmitAnswer(1, services.AnswerRequest{
+		QuestionID: question.QuestionID,
+		UserAnswer: map[string]interface{}{"code": "print(input())", "language": "python"},
+	})
+	require.NoError(t, err)
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "visible-input")
+	assert.NotContains(t, string(data), "secret")
+
+	// Grading still uses every test case
+	assert.Equal(t, "Passed 2 of 3 tests", response.Feedback)
+}
+
+// Test running code against custom stdin and the sample tests, under the
+// same resource and queue limits as grading
+func TestRunCode(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+	question := &models.Question{
+		QuestionType:   "code_completion",
+		QuestionFormat: "code",
+		QuestionText:   "Echo the input",
+		CorrectAnswer: models.JSONB{"test_cases": []map[string]interface{}{
+			{"input": "visible-input", "expected": "visible-input", "sample": true},
+			{"input": "secret-input", "expected": "secret-expected"},
+		}},
+		DifficultyScore: 20,
+	}
+	require.NoError(t, db.Create(question).Error)
+
+	var limits services.ResourceLimits
+	configured := services.ResourceLimits{CPUTime: 1, WallTime: 2, MemoryKB: 32000}
+	queue := services.NewExecutionQueue(services.QueueOptions{DailyQuota: 2})
+	executor := services.NewCodeExecutor(limitsExecutor(&limits), configured, nil, queue, nil)
+	handler := handlers.NewQuestionHandler(services.NewQuestionService(db, executor, nil), services.NewUserService(db), services.NewQuestionSessionService(db, "test-secret"))
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		return c.Next()
+	})
+	app.Post("/questions/:id/run", handler.RunCode)
+
+	path := "/questions/" + strconv.Itoa(question.QuestionID) + "/run"
+	run := func(body map[string]interface{}) (*http.Response, string) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		text, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(text)
+	}
+
+	resp, _ := run(map[string]interface{}{"code": "print(input())"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "neither stdin nor samples")
+
+	// Custom stdin is run as is, with the configured limits
+	resp, body := run(map[string]interface{}{"code": "print(input())", "stdin": "hello"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var response services.RunResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &response))
+	require.NotNil(t, response.Output)
+	assert.Equal(t, "hello", response.Output.Stdout)
+	assert.Nil(t, response.Samples)
+	assert.Equal(t, configured, limits)
+
+	// Only sample tests run, and hidden ones are never shown
+	resp, body = run(map[string]interface{}{"code": "print(input())", "run_samples": true})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	response = services.RunResponse{}
+	require.NoError(t, json.Unmarshal([]byte(body), &response))
+	assert.Nil(t, response.Output)
+	if assert.Len(t, response.Samples, 1) {
+		assert.Equal(t, 1, response.Samples[0].TestNumber)
+		assert.True(t, response.Samples[0].Passed)
+	}
+	assert.NotContains(t, body, "secret")
+
+	// Runs count against the user's execution quota
+	resp, _ = run(map[string]interface{}{"code": "print(input())", "stdin": "hello"})
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
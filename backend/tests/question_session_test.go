@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/handlers"
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that answers need a started session, are timed on the server and
+// can be replayed safely
+func TestQuestionSessions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+	require.NoError(t, db.Create(&models.User{Username: "ada", Email: "ada@example.com", PasswordHash: "x"}).Error)
+	question := &models.Question{
+		QuestionType:    "multiple_choice",
+		QuestionFormat:  "multiple_choice",
+		QuestionText:    "What is 2+2?",
+		CorrectAnswer:   models.JSONB{"answer": "A"},
+		DifficultyScore: 10,
+	}
+	require.NoError(t, db.Create(question).Error)
+
+	questions := services.NewQuestionService(db, nil, nil)
+	sessions := services.NewQuestionSessionService(db, "test-secret")
+	handler := handlers.NewQuestionHandler(questions, services.NewUserService(db), sessions)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		return c.Next()
+	})
+	app.Post("/questions/:id/start", handler.StartQuestion)
+	app.Post("/questions/:id/answer", handler.SubmitAnswer)
+
+	path := "/questions/" + strconv.Itoa(question.QuestionID)
+	post := func(url string, body interface{}) (int, map[string]interface{}) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", url, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	status, _ := post(path+"/answer", map[string]interface{}{"user_answer": map[string]string{"answer": "A"}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = post("/questions/999/start", nil)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// A hint seen before starting counts toward the session started next
+	require.NoError(t, questions.RecordHintUsage(1, question.QuestionID, 2, nil))
+	require.NoError(t, db.Model(&models.QuestionHintUsage{}).Where("session_id IS NULL").
+		Update("used_at", time.Now().Add(-2*time.Minute)).Error)
+
+	// Starting again expires the earlier session, so hints are never
+	// recorded on a session other than the one answered
+	status, superseded := post(path+"/start", nil)
+	require.Equal(t, http.StatusCreated, status)
+	status, started := post(path+"/start", nil)
+	require.Equal(t, http.StatusCreated, status)
+	token := started["session_token"].(string)
+	_, _, err = sessions.Claim(superseded["session_token"].(string), 1, question.QuestionID)
+	assert.ErrorIs(t, err, services.ErrInvalidSession)
+
+	// A forged token is rejected; so is one for another user or question
+	status, _ = post(path+"/answer", map[string]interface{}{"session_token": token + "x", "user_answer": map[string]string{"answer": "A"}})
+	assert.Equal(t, http.StatusBadRequest, status)
+	_, _, err = sessions.Claim(token, 2, question.QuestionID)
+	assert.ErrorIs(t, err, services.ErrInvalidSession)
+	_, _, err = sessions.Claim(token, 1, question.QuestionID+1)
+	assert.ErrorIs(t, err, services.ErrInvalidSession)
+
+	// An answer that cannot be graded leaves the session open
+	status, _ = post(path+"/answer", map[string]interface{}{"session_token": token, "user_answer": map[string]interface{}{"answer": 4}})
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Time and hints are the server's, whatever the client reports
+	require.Equal(t, started["session_id"], *sessions.OpenSession(1, question.QuestionID))
+	require.NoError(t, questions.RecordHintUsage(1, question.QuestionID, 1, sessions.OpenSession(1, question.QuestionID)))
+	require.NoError(t, db.Model(&models.QuestionSession{}).Where("session_id = ?", started["session_id"]).
+		Update("started_at", time.Now().Add(-90*time.Second)).Error)
+	answer := map[string]interface{}{
+		"session_token":      token,
+		"user_answer":        map[string]string{"answer": "A"},
+		"time_taken_seconds": 1,
+		"hints_used":         0,
+	}
+	status, first := post(path+"/answer", answer)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, first["is_correct"])
+
+	var attempt models.UserAttempt
+	require.NoError(t, db.First(&attempt, int(first["attempt_id"].(float64))).Error)
+	assert.InDelta(t, 90, attempt.TimeTakenSeconds, 2)
+	assert.Equal(t, 2, attempt.HintsUsed)
+	assert.Equal(t, started["session_id"], *attempt.SessionID)
+
+	// Replaying the submission returns the same result without a new attempt
+	answer["user_answer"] = map[string]string{"answer": "B"}
+	status, replay := post(path+"/answer", answer)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, first, replay)
+	var attempts int64
+	db.Model(&models.UserAttempt{}).Count(&attempts)
+	assert.Equal(t, int64(1), attempts)
+
+	// A new session counts a hint seen in an earlier one again, once, but
+	// not the hint seen before the earlier one was answered
+	assert.Nil(t, sessions.OpenSession(1, question.QuestionID))
+	status, next := post(path+"/start", nil)
+	require.Equal(t, http.StatusCreated, status)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, questions.RecordHintUsage(1, question.QuestionID, 1, sessions.OpenSession(1, question.QuestionID)))
+	}
+	status, second := post(path+"/answer", map[string]interface{}{
+		"session_token": next["session_token"],
+		"user_answer":   map[string]string{"answer": "A"},
+	})
+	require.Equal(t, http.StatusOK, status)
+	var secondAttempt models.UserAttempt
+	require.NoError(t, db.First(&secondAttempt, int(second["attempt_id"].(float64))).Error)
+	assert.Equal(t, 1, secondAttempt.HintsUsed)
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/handlers"
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test that the built-in generators compute the right answers
+func TestQuestionGenerators(t *testing.T) {
+	generators := services.NewQuestionService(nil, nil, nil).Generators()
+	assert.Equal(t, []string{"array", "binary_search", "heap"}, generators.Names())
+
+	search, ok := generators.Lookup("binary_search")
+	require.True(t, ok)
+	heap, ok := generators.Lookup("heap")
+	require.True(t, ok)
+	maxHeap := map[string]interface{}{"kind": "max", "values": []interface{}{1.0, 9.0}}
+
+	for seed := int64(0); seed < 50; seed++ {
+		values, err := search.Generate(map[string]interface{}{}, rand.New(rand.NewSource(seed)))
+		require.NoError(t, err)
+		array, target, index := values["array"].([]int), values["target"].(int), values["index"].(int)
+		assert.True(t, sort.IntsAreSorted(array))
+		position := sort.SearchInts(array, target)
+		if position < len(array) && array[position] == target {
+			assert.Equal(t, position, index)
+		} else {
+			assert.Equal(t, -1, index)
+		}
+		assert.Equal(t, position, values["insertion_point"])
+		mids := values["mids"].([]int)
+		assert.LessOrEqual(t, len(mids), 4, "a search of at most 10 elements probes at most 4")
+
+		values, err = heap.Generate(maxHeap, rand.New(rand.NewSource(seed)))
+		require.NoError(t, err)
+		pushes, tree := values["pushes"].([]int), values["heap"].([]int)
+		for i := 1; i < len(tree); i++ {
+			assert.LessOrEqual(t, tree[i], tree[(i-1)/2])
+		}
+		assert.ElementsMatch(t, pushes, tree)
+	}
+
+	// A single push is the whole heap
+	values, err := heap.Generate(map[string]interface{}{"pushes": 1.0}, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Len(t, values["heap"], 1)
+
+	_, err = heap.Generate(map[string]interface{}{"kind": "middle"}, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+	_, err = search.Generate(map[string]interface{}{"length": 10.0, "values": []interface{}{1.0, 5.0}}, rand.New(rand.NewSource(1)))
+	assert.Error(t, err)
+}
+
+// Test that template questions are instantiated deterministically and
+// validated through an instance
+func TestQuestionTemplates(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	question := &models.Question{
+		QuestionFormat: "fill_blank",
+		QuestionText:   "Where is {{target}} in {{array}}?",
+		CorrectAnswer:  models.JSONB{"blanks": []interface{}{map[string]interface{}{"answers": []interface{}{"{{index}}"}, "exact": true}}},
+		Explanation:    "Probes {{mids}}",
+		Template:       models.JSONB{"generator": "binary_search", "params": map[string]interface{}{"present": 1}},
+	}
+	require.NoError(t, qs.ValidateQuestion(question))
+
+	first, err := qs.Instantiate(question, 42)
+	require.NoError(t, err)
+	again, err := qs.Instantiate(question, 42)
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.NotContains(t, first.QuestionText, "{{")
+	assert.Equal(t, "Where is {{target}} in {{array}}?", question.QuestionText)
+
+	texts := make(map[string]bool)
+	for seed := int64(0); seed < 10; seed++ {
+		instance, err := qs.Instantiate(question, seed)
+		require.NoError(t, err)
+		texts[instance.QuestionText] = true
+	}
+	assert.Greater(t, len(texts), 1)
+
+	// The target is always present, so the answer is its index
+	answer := first.CorrectAnswer["blanks"].([]interface{})[0].(map[string]interface{})["answers"].([]interface{})[0].(string)
+	assert.NotEqual(t, "-1", answer)
+
+	unknown := *question
+	unknown.Template = models.JSONB{"generator": "graph"}
+	assert.ErrorIs(t, qs.ValidateQuestion(&unknown), services.ErrInvalidQuestion)
+	typo := *question
+	typo.QuestionText = "Where is {{taget}}?"
+	assert.ErrorIs(t, qs.ValidateQuestion(&typo), services.ErrInvalidQuestion)
+}
+
+// Test that a started template question shows a fresh instance and is
+// graded against it
+func TestTemplateQuestionSessions(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+	require.NoError(t, db.Create(&models.User{Username: "ada", Email: "ada@example.com", PasswordHash: "x"}).Error)
+
+	questions := services.NewQuestionService(db, nil, nil)
+	question := &models.Question{
+		QuestionType:    "algorithm_trace",
+		QuestionFormat:  "fill_blank",
+		QuestionText:    "Push {{pushes}} onto an empty min-heap. What is the array?",
+		CorrectAnswer:   models.JSONB{"blanks": []interface{}{map[string]interface{}{"answers": []interface{}{"{{heap}}"}, "exact": true}}},
+		Explanation:     "The heap is {{heap}}",
+		DifficultyScore: 30,
+		Template:        models.JSONB{"generator": "heap"},
+	}
+	require.NoError(t, questions.CreateQuestion(question))
+
+	handler := handlers.NewQuestionHandler(questions, services.NewUserService(db), services.NewQuestionSessionService(db, "test-secret"))
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		return c.Next()
+	})
+	app.Post("/questions/:id/start", handler.StartQuestion)
+	app.Post("/questions/:id/answer", handler.SubmitAnswer)
+
+	path := "/questions/" + strconv.Itoa(question.QuestionID)
+	post := func(url string, body interface{}) (int, map[string]interface{}) {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", url, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var result map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+	start := func() (string, *models.Question) {
+		status, started := post(path+"/start", nil)
+		require.Equal(t, http.StatusCreated, status)
+		var session models.QuestionSession
+		require.NoError(t, db.First(&session, "session_id = ?", started["session_id"]).Error)
+		require.NotNil(t, session.Seed)
+		instance, err := questions.Instantiate(question, *session.Seed)
+		require.NoError(t, err)
+		shown := started["instance"].(map[string]interface{})
+		assert.Equal(t, instance.QuestionText, shown["question_text"])
+		assert.NotContains(t, shown["question_text"], "{{")
+		return started["session_token"].(string), instance
+	}
+	heapOf := func(instance *models.Question) string {
+		return strings.TrimPrefix(instance.Explanation, "The heap is ")
+	}
+
+	token, instance := start()
+	status, result := post(path+"/answer", map[string]interface{}{
+		"session_token": token,
+		"user_answer":   map[string]interface{}{"blanks": []string{heapOf(instance)}},
+	})
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, true, result["is_correct"])
+	assert.Equal(t, instance.Explanation, result["explanation"])
+
+	// Another session draws another instance; the last one's answer is
+	// wrong for it
+	var other *models.Question
+	for i := 0; i < 5 && (other == nil || heapOf(other) == heapOf(instance)); i++ {
+		token, other = start()
+	}
+	require.NotEqual(t, heapOf(instance), heapOf(other))
+	status, result = post(path+"/answer", map[string]interface{}{
+		"session_token": token,
+		"user_answer":   map[string]interface{}{"blanks": []string{heapOf(instance)}},
+	})
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, false, result["is_correct"])
+
+	// Template questions cannot be answered without a session's instance
+	_, err = questions.SubmitAnswer(1, services.AnswerRequest{
+		QuestionID: question.QuestionID,
+		UserAnswer: map[string]interface{}{"blanks": []interface{}{"[1]"}},
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidSession)
+}
@@ -0,0 +1,236 @@
+package tests
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/algoholic/config"
+	"github.com/yourusername/algoholic/services"
+)
+
+// countingExecutor is an echo backend that counts its runs
+func countingExecutor(runs *atomic.Int64) fakeExecutor {
+	return func(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		runs.Add(1)
+		return echoRun(req)
+	}
+}
+
+// Test that identical code is graded once per suite version
+func TestResultCache(t *testing.T) {
+	var runs atomic.Int64
+	executor := services.NewCodeExecutor(countingExecutor(&runs), services.DefaultResourceLimits, nil, nil,
+		services.NewMemoryResultCache(100, time.Hour))
+
+	suite := services.TestSuite{TestCases: []interface{}{
+		map[string]interface{}{"input": "1", "expected": "1"},
+		map[string]interface{}{"input": "2", "expected": "3", "hidden": true},
+	}}
+	first, err := executor.RunTests("print(x)\n", "python", suite)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), runs.Load())
+
+	// Line endings do not change the key, and cached results still report
+	// every test
+	var judged int
+	again, err := executor.RunTestsWithProgress("print(x)\r\n", "python3", suite, func(event services.TestEvent) {
+		assert.Equal(t, services.TestEventJudged, event.Type)
+		judged++
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), runs.Load())
+	assert.Equal(t, first, again)
+	assert.Equal(t, 2, judged)
+
+	// Changing the test cases invalidates the result
+	suite.TestCases = append(suite.TestCases, map[string]interface{}{"input": "4", "expected": "4"})
+	changed, err := executor.RunTests("print(x)", "python", suite)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), runs.Load())
+	assert.Equal(t, 2, changed.PassedCount)
+
+	// Time limits depend on load and are never cached
+	tle := services.TestSuite{TestCases: []interface{}{map[string]interface{}{"input": "tle", "expected": "1"}}}
+	for i := 0; i < 2; i++ {
+		_, err = executor.RunTests("print(x)", "python", tle)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, int64(7), runs.Load())
+
+	stats := executor.CacheStats()
+	assert.Equal(t, "memory", stats.Backend)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(4), stats.Misses)
+}
+
+// Test that results round-trip through Redis
+func TestRedisResultCache(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer listener.Close()
+	go newFakeRedis().serve(listener)
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	cache := services.NewRedisResultCache(config.RedisConfig{Host: "127.0.0.1", Port: port, DB: 2}, time.Hour)
+	assert.NoError(t, cache.Ping())
+
+	_, ok := cache.Get("missing")
+	assert.False(t, ok)
+
+	result := &services.ExecutionResult{AllPassed: true, PassedCount: 1, TotalCount: 1, Failures: []services.FailureDetail{},
+		TestResults: []services.TestResult{{TestNumber: 1, Passed: true, Verdict: services.VerdictAccepted}}}
+	cache.Set("key", result)
+	cached, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, result, cached)
+
+	// Concurrent lookups share a small pool
+	cache = services.NewRedisResultCache(config.RedisConfig{Host: "127.0.0.1", Port: port, PoolSize: 2}, time.Hour)
+	var wg sync.WaitGroup
+	var hits atomic.Int64
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, ok := cache.Get("key"); ok {
+				hits.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(20), hits.Load())
+}
+
+// Test that the Redis cache recovers from dropped connections and from
+// Redis going away
+func TestRedisResultCacheConnectionLoss(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := newFakeRedis()
+	go server.serve(listener)
+
+	addr := listener.Addr().(*net.TCPAddr)
+	cache := services.NewRedisResultCache(config.RedisConfig{Host: "127.0.0.1", Port: addr.Port}, time.Hour)
+	result := &services.ExecutionResult{AllPassed: true, Failures: []services.FailureDetail{}, TestResults: []services.TestResult{}}
+	cache.Set("key", result)
+
+	// A pooled connection closed by the server is replaced transparently
+	server.dropConnections()
+	_, ok := cache.Get("key")
+	assert.True(t, ok)
+
+	// While Redis is down, lookups miss
+	listener.Close()
+	server.dropConnections()
+	_, ok = cache.Get("key")
+	assert.False(t, ok)
+	assert.Error(t, cache.Ping())
+
+	// Once Redis is back, the cache reconnects
+	listener, err = net.Listen("tcp", addr.String())
+	if err != nil {
+		t.Skipf("cannot listen on %s again: %v", addr, err)
+	}
+	defer listener.Close()
+	go server.serve(listener)
+	assert.Eventually(t, func() bool { return cache.Ping() == nil }, 2*time.Second, 50*time.Millisecond)
+	cached, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, result, cached)
+}
+
+// fakeRedis answers PING, SELECT, GET and SET from a map
+type fakeRedis struct {
+	mu    sync.Mutex
+	store map[string]string
+	conns []net.Conn
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{store: map[string]string{}}
+}
+
+// dropConnections closes every open client connection
+func (f *fakeRedis) dropConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeRedis) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conns = append(f.conns, conn)
+		f.mu.Unlock()
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			io.WriteString(conn, "+PONG\r\n")
+		case "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case "SET":
+			f.store[args[1]] = args[2]
+			io.WriteString(conn, "+OK\r\n")
+		case "GET":
+			if value, ok := f.store[args[1]]; ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+			} else {
+				io.WriteString(conn, "$-1\r\n")
+			}
+		default:
+			io.WriteString(conn, "-ERR unknown command\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		args[i] = string(data[:size])
+	}
+	return args, nil
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// Test partial credit for rankings by pair order and by position
+func TestScoreRanking(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	question := &models.Question{QuestionFormat: "ranking", CorrectAnswer: models.JSONB{
+		"ranking": []interface{}{"a", "b", "c", "d"},
+	}}
+	rank := func(items ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"ranking": items}
+	}
+
+	assert.Equal(t, 1.0, qs.ScoreAnswer(question, rank("a", "b", "c", "d")))
+	assert.True(t, qs.CheckAnswer(question, rank("a", "b", "c", "d")))
+
+	// One adjacent swap breaks one of six pairs
+	assert.InDelta(t, 5.0/6, qs.ScoreAnswer(question, rank("a", "c", "b", "d")), 1e-9)
+	assert.False(t, qs.CheckAnswer(question, rank("a", "c", "b", "d")))
+	assert.Equal(t, 0.0, qs.ScoreAnswer(question, rank("d", "c", "b", "a")))
+
+	// Missing items break their pairs, and extra items scale the score down
+	assert.InDelta(t, 3.0/6, qs.ScoreAnswer(question, rank("a", "b", "c")), 1e-9)
+	assert.InDelta(t, 4.0/5, qs.ScoreAnswer(question, rank("a", "b", "c", "d", "e")), 1e-9)
+
+	question.CorrectAnswer["scoring"] = services.RankingScoringPositional
+	assert.Equal(t, 0.5, qs.ScoreAnswer(question, rank("a", "c", "b", "d")))
+	assert.Equal(t, 0.0, qs.ScoreAnswer(question, rank("b", "c", "d", "a")))
+}
+
+// Test per-option credit for multi-select questions
+func TestScoreMultiSelect(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	question := &models.Question{QuestionFormat: "multiple_choice", CorrectAnswer: models.JSONB{
+		"answers": []interface{}{"a", "c"},
+	}}
+	choose := func(options ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"answers": options}
+	}
+
+	assert.Equal(t, 1.0, qs.ScoreAnswer(question, choose("c", "a")))
+	assert.True(t, qs.CheckAnswer(question, choose("c", "a")))
+	assert.Equal(t, 0.5, qs.ScoreAnswer(question, choose("a")))
+	assert.Equal(t, 0.5, qs.ScoreAnswer(question, choose("a", "a")))
+	assert.Equal(t, 0.5, qs.ScoreAnswer(question, choose("a", "c", "d")))
+	assert.False(t, qs.CheckAnswer(question, choose("a", "c", "d")))
+
+	// Choosing everything earns nothing
+	assert.Equal(t, 0.0, qs.ScoreAnswer(question, choose("a", "b", "c", "d")))
+	assert.Equal(t, 0.0, qs.ScoreAnswer(question, choose()))
+
+	// Single-answer questions are unchanged
+	single := &models.Question{QuestionFormat: "multiple_choice", CorrectAnswer: models.JSONB{"answer": "b"}}
+	assert.Equal(t, 1.0, qs.ScoreAnswer(single, map[string]interface{}{"answer": "b"}))
+	assert.Equal(t, 0.0, qs.ScoreAnswer(single, map[string]interface{}{"answer": "a"}))
+}
+
+// Test that points and proficiency follow the score
+func TestPartialCreditProgress(t *testing.T) {
+	qs := services.NewQuestionService(nil, nil, nil)
+	question := &models.Question{DifficultyScore: 40}
+	assert.Equal(t, 400, qs.CalculatePoints(question, 1, 0, 0))
+	assert.Equal(t, 300, qs.CalculatePoints(question, 0.75, 0, 0))
+	assert.Equal(t, 0, qs.CalculatePoints(question, 0, 0, 0))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, models.AutoMigrate(db))
+	users := services.NewUserService(db)
+
+	for _, score := range []float64{1, 0.5, 0.75, 0} {
+		require.NoError(t, users.UpdateUserProgress(1, 7, score, 30))
+	}
+	skill, err := users.GetUserProgress(1, 7)
+	require.NoError(t, err)
+	assert.Equal(t, 4, skill.QuestionsAttempted)
+	assert.Equal(t, 1, skill.QuestionsCorrect)
+	assert.InDelta(t, 56.25, skill.ProficiencyLevel, 1e-9)
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+const twoSumSolution = `def two_sum(nums, target):
+    seen = {}
+    for i, num in enumerate(nums):
+        # look for the complement
+        if target - num in seen:
+            return [seen[target - num], i]
+        seen[num] = i
+    return []
+`
+
+// Same solution with renamed variables, a different comment and layout
+const twoSumRenamed = `import sys
+
+def solve(arr, goal):
+    """Find the pair."""
+    index = {}
+    for j, value in enumerate(arr):
+        if goal - value in index: return [index[goal - value], j]
+        index[value] = j
+    return []
+`
+
+const twoSumBruteForce = `def two_sum(nums, target):
+    n = len(nums)
+    for i in range(n):
+        for j in range(i + 1, n):
+            if nums[i] + nums[j] == target:
+                return [i, j]
+    return []
+`
+
+// Test that renaming and reformatting do not hide copied code
+func TestCompareCode(t *testing.T) {
+	options := services.DefaultSimilarityOptions
+
+	copied := services.CompareCode(twoSumSolution, twoSumRenamed, "python", options)
+	assert.GreaterOrEqual(t, copied.Similarity, 0.9)
+	assert.NotEmpty(t, copied.Regions)
+	region := copied.Regions[0]
+	assert.Equal(t, 1, region.StartLineA)
+	assert.Equal(t, 3, region.StartLineB)
+	assert.Contains(t, region.ExcerptB, "index[value] = j")
+
+	different := services.CompareCode(twoSumSolution, twoSumBruteForce, "python", options)
+	assert.Less(t, different.Similarity, options.Threshold)
+	assert.Less(t, different.CoverageA, copied.CoverageA)
+}
+
+// Test that submissions are compared with other users' latest submissions
+func TestRecordSubmissionSimilarity(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, models.AutoMigrate(db))
+
+	similarity := services.NewSimilarityService(db, nil, services.DefaultSimilarityOptions)
+	submit := func(userID int, code string) *models.CodeSubmission {
+		submission := &models.CodeSubmission{UserID: userID, ProblemID: 1, Code: code, Language: "python"}
+		assert.NoError(t, db.Create(submission).Error)
+		assert.NoError(t, similarity.RecordSubmission(submission))
+		return submission
+	}
+
+	original := submit(1, twoSumSolution)
+	submit(2, twoSumBruteForce)
+	submit(3, twoSumRenamed)
+	submit(3, twoSumRenamed) // compared with user 1 again, not with user 3
+
+	pairs, total, err := similarity.GetSuspiciousPairs(1, similarity.Threshold(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, pair := range pairs {
+		assert.Equal(t, original.SubmissionID, pair.SubmissionAID)
+		assert.Equal(t, 1, pair.UserAID)
+		assert.Equal(t, 3, pair.UserBID)
+		assert.NotEmpty(t, pair.Matches)
+	}
+}
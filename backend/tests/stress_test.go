@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yourusername/algoholic/services"
+)
+
+// sumExecutor is a fake backend whose programs print the sum of the encoded
+// int[] argument as a driver would, except "buggy" ones which drop the last
+// element of arrays with three or more elements
+func sumExecutor() fakeExecutor {
+	return func(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		tokens := strings.Fields(req.Stdin)
+		values := tokens[1:]
+		if strings.Contains(req.SourceCode, "buggy") && len(values) >= 3 {
+			values = values[:len(values)-1]
+		}
+
+		sum := 0
+		for _, token := range values {
+			n, _ := strconv.Atoi(token)
+			sum += n
+		}
+		stdout := "debug\n" + services.HarnessSentinel + "\n" + strconv.Itoa(sum) + "\n"
+		return &services.ExecutionOutput{Verdict: services.VerdictAccepted, Stdout: stdout}, nil
+	}
+}
+
+// Test that stress testing finds the smallest disagreeing input
+func TestStressTest(t *testing.T) {
+	sig, err := services.ParseFunctionSignature(map[string]interface{}{
+		"function": "sum",
+		"params":   []interface{}{map[string]interface{}{"name": "nums", "type": "int[]"}},
+		"return":   "int",
+	})
+	assert.NoError(t, err)
+
+	min, max := -5.0, 5.0
+	test := services.StressTest{
+		Reference: services.ReferenceSolution{Language: "python", Code: "reference"},
+		Generator: services.InputGenerator{Params: map[string]services.ParamConstraints{
+			"nums": {Length: []int{1, 8}, Min: &min, Max: &max},
+		}},
+		Signature:  sig,
+		Iterations: 60,
+		Seed:       42,
+	}
+	executor := services.NewCodeExecutor(sumExecutor(), services.DefaultResourceLimits, nil, nil, nil)
+
+	result, err := executor.StressTest("reference", "python", test)
+	assert.NoError(t, err)
+	assert.Equal(t, services.StressPassed, result.Status)
+	assert.Equal(t, 60, result.Iterations)
+	assert.Nil(t, result.Counterexample)
+
+	result, err = executor.StressTest("buggy", "python", test)
+	assert.NoError(t, err)
+	assert.Equal(t, services.StressFailed, result.Status)
+	if assert.NotNil(t, result.Counterexample) {
+		var nums []int
+		assert.NoError(t, json.Unmarshal([]byte(result.Counterexample.Input), &nums))
+		assert.Len(t, nums, 3, "smallest failing input")
+		for _, n := range nums {
+			assert.True(t, n >= -5 && n <= 5, "value within constraints")
+		}
+		assert.Equal(t, strconv.Itoa(nums[0]+nums[1]+nums[2]), result.Counterexample.Expected)
+		assert.Equal(t, services.VerdictWrongAnswer, result.Counterexample.Verdict)
+	}
+
+	// The same seed reproduces the run
+	again, err := executor.StressTest("buggy", "python", test)
+	assert.NoError(t, err)
+	assert.Equal(t, result.Counterexample, again.Counterexample)
+
+	test.Generator.Params = map[string]services.ParamConstraints{"missing": {}}
+	_, err = executor.StressTest("buggy", "python", test)
+	assert.Error(t, err, "constraints on an unknown parameter")
+}
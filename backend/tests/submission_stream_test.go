@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/yourusername/algoholic/handlers"
+	"github.com/yourusername/algoholic/models"
+	"github.com/yourusername/algoholic/services"
+)
+
+// gatedExecutor is an echo backend whose runs wait until release is closed
+func gatedExecutor(release chan struct{}) fakeExecutor {
+	return func(req services.ExecutionRequest) (*services.ExecutionOutput, error) {
+		<-release
+		return echoRun(req)
+	}
+}
+
+// Test that per-test progress and the final result are streamed as events
+func TestStreamSubmission(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	assert.NoError(t, models.AutoMigrate(db))
+
+	problem := &models.Problem{Title: "Echo", Slug: "echo", Description: "Print the input", DifficultyScore: 10, Examples: models.JSONBArray{}}
+	assert.NoError(t, db.Create(problem).Error)
+	assert.NoError(t, db.Create(&models.Question{
+		ProblemID:      &problem.ProblemID,
+		QuestionType:   "code",
+		QuestionFormat: "code",
+		QuestionText:   "Echo the input",
+		CorrectAnswer: models.JSONB{"test_cases": []interface{}{
+			map[string]interface{}{"input": "1", "expected": "1", "sample": true},
+			map[string]interface{}{"input": "2", "expected": "3"},
+		}},
+		DifficultyScore: 10,
+	}).Error)
+
+	release := make(chan struct{})
+	executor := services.NewCodeExecutor(gatedExecutor(release), services.DefaultResourceLimits, nil, nil, nil)
+	submissions := services.NewSubmissionService(db, executor, services.NewProblemService(db, nil), services.ComplexityOptions{}, nil)
+	submission, err := submissions.CreateSubmission(1, problem.ProblemID, services.SubmissionRequest{Code: "code", Language: "python"})
+	assert.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		return c.Next()
+	})
+	app.Get("/problems/:id/submissions/:submissionId/events", handlers.NewSubmissionHandler(submissions).StreamSubmission)
+
+	// Events sent before the client connects are replayed
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	path := "/problems/" + strconv.Itoa(problem.ProblemID) + "/submissions/" + strconv.Itoa(submission.SubmissionID) + "/events"
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil), -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var types []string
+	var last string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			types = append(types, strings.TrimPrefix(line, "event: "))
+		}
+		if strings.HasPrefix(line, "data: ") {
+			last = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	assert.Equal(t, []string{"queued", "queued", "running", "judged", "running", "judged", "result"}, types)
+
+	var result services.ExecutionResult
+	assert.NoError(t, json.Unmarshal([]byte(last), &result))
+	assert.Equal(t, 1, result.PassedCount)
+	assert.Equal(t, 2, result.TotalCount)
+	if assert.Len(t, result.Failures, 1) {
+		assert.True(t, result.Failures[0].Hidden)
+		assert.Empty(t, result.Failures[0].Input)
+	}
+}
+
+// newEchoProblem creates a problem whose single test echoes its input
+func newEchoProblem(t *testing.T) (*gorm.DB, *models.Problem) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, models.AutoMigrate(db))
+
+	problem := &models.Problem{Title: "Echo", Slug: "echo", Description: "Print the input", DifficultyScore: 10, Examples: models.JSONBArray{}}
+	require.NoError(t, db.Create(problem).Error)
+	require.NoError(t, db.Create(&models.Question{
+		ProblemID:       &problem.ProblemID,
+		QuestionType:    "code",
+		QuestionFormat:  "code",
+		QuestionText:    "Echo the input",
+		CorrectAnswer:   models.JSONB{"test_cases": []interface{}{map[string]interface{}{"input": "1", "expected": "1"}}},
+		DifficultyScore: 10,
+	}).Error)
+	return db, problem
+}
+
+// submissionStatus reads a submission's stored status
+func submissionStatus(db *gorm.DB, id int) string {
+	var submission models.CodeSubmission
+	db.Select("status").First(&submission, id)
+	return submission.Status
+}
+
+// Test that submissions wait in the queue as pending, run and are recorded
+// when judged
+func TestSubmissionLifecycle(t *testing.T) {
+	db, problem := newEchoProblem(t)
+	release := make(chan struct{})
+	queue := services.NewExecutionQueue(services.QueueOptions{MaxConcurrent: 1})
+	executor := services.NewCodeExecutor(gatedExecutor(release), services.DefaultResourceLimits, nil, queue, nil)
+	submissions := services.NewSubmissionService(db, executor, services.NewProblemService(db, nil), services.ComplexityOptions{}, nil)
+
+	first, err := submissions.CreateSubmission(1, problem.ProblemID, services.SubmissionRequest{Code: "code", Language: "python"})
+	require.NoError(t, err)
+	second, err := submissions.CreateSubmission(2, problem.ProblemID, services.SubmissionRequest{Code: "code", Language: "python"})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return submissionStatus(db, first.SubmissionID) == services.SubmissionStatusRunning
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, services.SubmissionStatusPending, submissionStatus(db, second.SubmissionID))
+
+	close(release)
+	for _, id := range []int{first.SubmissionID, second.SubmissionID} {
+		assert.Eventually(t, func() bool {
+			return submissionStatus(db, id) == services.SubmissionStatusAccepted
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	// The submissions' goroutines may still be recording stats
+	assert.Eventually(t, func() bool {
+		var stored models.Problem
+		db.First(&stored, problem.ProblemID)
+		return stored.TotalAttempts == 2 && stored.TotalSolves == 2
+	}, time.Second, 10*time.Millisecond)
+	var judged models.CodeSubmission
+	require.NoError(t, db.First(&judged, first.SubmissionID).Error)
+	assert.NotNil(t, judged.EvaluatedAt)
+	assert.NotEmpty(t, judged.TestResults)
+}
+
+// Test that submissions left pending or running by a previous process are
+// evaluated again on startup, concurrently
+func TestResumePending(t *testing.T) {
+	db, problem := newEchoProblem(t)
+	var ids []int
+	for _, status := range []string{services.SubmissionStatusPending, services.SubmissionStatusPending, services.SubmissionStatusRunning, services.SubmissionStatusWrongAnswer} {
+		submission := &models.CodeSubmission{UserID: 1, ProblemID: problem.ProblemID, Code: "code", Language: "python", Status: status}
+		require.NoError(t, db.Create(submission).Error)
+		ids = append(ids, submission.SubmissionID)
+	}
+
+	release := make(chan struct{})
+	executor := services.NewCodeExecutor(gatedExecutor(release), services.DefaultResourceLimits, nil, nil, nil)
+	submissions := services.NewSubmissionService(db, executor, services.NewProblemService(db, nil), services.ComplexityOptions{}, nil)
+	done := make(chan struct{})
+	go func() {
+		submissions.ResumePending()
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return submissionStatus(db, ids[0]) == services.SubmissionStatusRunning &&
+			submissionStatus(db, ids[1]) == services.SubmissionStatusRunning
+	}, time.Second, 10*time.Millisecond)
+	close(release)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("ResumePending did not return")
+	}
+	for _, id := range ids[:3] {
+		assert.Equal(t, services.SubmissionStatusAccepted, submissionStatus(db, id))
+	}
+	assert.Equal(t, services.SubmissionStatusWrongAnswer, submissionStatus(db, ids[3]))
+}
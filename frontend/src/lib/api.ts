@@ -91,6 +91,16 @@ export interface Question {
   difficulty_score: number;
 }
 
+export interface QuestionSession {
+  session_token: string;
+  session_id: string;
+  question_id: number;
+  started_at: string;
+  expires_at: string;
+  // The instance a template question shows in this session
+  instance?: Pick<Question, 'question_text' | 'answer_options'>;
+}
+
 export interface UserStats {
   total_attempts: number;
   correct_attempts: number;
@@ -203,18 +213,22 @@ export const questionsAPI = {
     return data as Question;
   },
 
+  startQuestion: async (questionId: number) => {
+    const { data } = await api.post(`/questions/${questionId}/start`);
+    return data as QuestionSession;
+  },
+
+  // Time taken and hints used are measured by the server from the session
   submitAnswer: async (
     questionId: number,
+    sessionToken: string,
     userAnswer: any,
-    timeTaken: number,
-    hintsUsed?: number,
     confidenceLevel?: number,
     trainingPlanId?: number
   ) => {
     const { data } = await api.post(`/questions/${questionId}/answer`, {
+      session_token: sessionToken,
       user_answer: userAnswer,
-      time_taken_seconds: timeTaken,
-      hints_used: hintsUsed || 0,
       confidence_level: confidenceLevel,
       training_plan_id: trainingPlanId,
     });
@@ -21,6 +21,14 @@ const mockQuestion = {
   difficulty_score: 50,
 };
 
+const mockSession = {
+  session_token: 'session-token',
+  session_id: 'session-1',
+  question_id: 1,
+  started_at: '2026-01-01T00:00:00Z',
+  expires_at: '2026-01-02T00:00:00Z',
+};
+
 const mockSubmitResponse = {
   is_correct: true,
   correct_answer: 'B',
@@ -31,6 +39,7 @@ const mockSubmitResponse = {
 describe('Practice Component', () => {
   beforeEach(() => {
     vi.clearAllMocks();
+    vi.mocked(api.questionsAPI.startQuestion).mockResolvedValue(mockSession);
   });
 
   it('should show loading state initially', () => {
@@ -196,7 +205,7 @@ describe('Practice Component', () => {
     });
   });
 
-  it('should track time taken for answer submission', async () => {
+  it('should submit the answer with the question session', async () => {
     const user = userEvent.setup({ delay: null });
     vi.mocked(api.questionsAPI.getRandomQuestion).mockResolvedValue(mockQuestion);
     vi.mocked(api.questionsAPI.submitAnswer).mockResolvedValue(mockSubmitResponse);
@@ -213,13 +222,10 @@ describe('Practice Component', () => {
     const submitButton = screen.getByRole('button', { name: /submit answer/i });
     await user.click(submitButton);
 
-    // Verify submission was called with time tracking (actual time will vary)
+    // The server times the answer from the session started for the question
     await waitFor(() => {
-      expect(api.questionsAPI.submitAnswer).toHaveBeenCalledWith(
-        1,
-        { answer: 'B' },
-        expect.any(Number) // Time in seconds
-      );
+      expect(api.questionsAPI.startQuestion).toHaveBeenCalledWith(1);
+      expect(api.questionsAPI.submitAnswer).toHaveBeenCalledWith(1, 'session-token', { answer: 'B' });
     });
   });
 
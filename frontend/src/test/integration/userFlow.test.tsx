@@ -138,6 +138,13 @@ describe('User Flow Integration Tests', () => {
       vi.mocked(api.questionsAPI.getRandomQuestion)
         .mockResolvedValueOnce(mockQuestion1)
         .mockResolvedValueOnce(mockQuestion2);
+      vi.mocked(api.questionsAPI.startQuestion).mockImplementation(async (questionId) => ({
+        session_token: `session-${questionId}`,
+        session_id: `session-${questionId}`,
+        question_id: questionId,
+        started_at: '2026-01-01T00:00:00Z',
+        expires_at: '2026-01-02T00:00:00Z',
+      }));
       vi.mocked(api.questionsAPI.submitAnswer).mockResolvedValue(mockSubmitResponse);
 
       // Render Practice component directly for this test
@@ -187,7 +194,8 @@ describe('User Flow Integration Tests', () => {
 
       // Verify API calls
       expect(api.questionsAPI.getRandomQuestion).toHaveBeenCalledTimes(2);
-      expect(api.questionsAPI.submitAnswer).toHaveBeenCalledTimes(1);
+      expect(api.questionsAPI.startQuestion).toHaveBeenCalledTimes(2);
+      expect(api.questionsAPI.submitAnswer).toHaveBeenCalledWith(1, 'session-1', { answer: 'A' });
     });
   });
 
@@ -0,0 +1,9 @@
+-- 000003_submission_statuses.down.sql
+UPDATE code_submissions SET status = 'passed' WHERE status = 'accepted';
+UPDATE code_submissions SET status = 'failed'
+    WHERE status IN ('wrong_answer', 'time_limit_exceeded', 'memory_limit_exceeded', 'runtime_error', 'compilation_error');
+
+ALTER TABLE code_submissions DROP CONSTRAINT IF EXISTS code_submissions_status_check;
+ALTER TABLE code_submissions ADD CONSTRAINT code_submissions_status_check
+    CHECK (status IN ('pending', 'running', 'passed', 'failed', 'error'));
+ALTER TABLE code_submissions ALTER COLUMN status TYPE VARCHAR(20);
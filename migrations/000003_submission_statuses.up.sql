@@ -0,0 +1,10 @@
+-- 000003_submission_statuses.up.sql
+-- Code submissions store the verdict of their evaluation as the status
+
+-- "memory_limit_exceeded" is longer than the original VARCHAR(20)
+ALTER TABLE code_submissions ALTER COLUMN status TYPE VARCHAR(32);
+ALTER TABLE code_submissions DROP CONSTRAINT IF EXISTS code_submissions_status_check;
+ALTER TABLE code_submissions ADD CONSTRAINT code_submissions_status_check CHECK (status IN (
+    'pending', 'running', 'accepted', 'wrong_answer', 'time_limit_exceeded',
+    'memory_limit_exceeded', 'runtime_error', 'compilation_error', 'error'
+));
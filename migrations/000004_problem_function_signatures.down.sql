@@ -0,0 +1,2 @@
+-- 000004_problem_function_signatures.down.sql
+ALTER TABLE problems DROP COLUMN IF EXISTS function_signature;
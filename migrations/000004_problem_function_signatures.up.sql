@@ -0,0 +1,4 @@
+-- 000004_problem_function_signatures.up.sql
+-- Function signatures for generating LeetCode-style code harnesses
+
+ALTER TABLE problems ADD COLUMN IF NOT EXISTS function_signature JSONB;
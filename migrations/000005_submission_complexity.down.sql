@@ -0,0 +1,2 @@
+-- 000005_submission_complexity.down.sql
+ALTER TABLE code_submissions DROP COLUMN IF EXISTS complexity_analysis;
@@ -0,0 +1,4 @@
+-- 000005_submission_complexity.up.sql
+-- Measured time and space complexity of accepted submissions
+
+ALTER TABLE code_submissions ADD COLUMN IF NOT EXISTS complexity_analysis JSONB;
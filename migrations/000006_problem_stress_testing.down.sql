@@ -0,0 +1,3 @@
+-- 000006_problem_stress_testing.down.sql
+ALTER TABLE problems DROP COLUMN IF EXISTS input_generator;
+ALTER TABLE problems DROP COLUMN IF EXISTS reference_solution;
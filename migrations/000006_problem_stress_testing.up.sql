@@ -0,0 +1,5 @@
+-- 000006_problem_stress_testing.up.sql
+-- Reference solutions and input generators for stress testing
+
+ALTER TABLE problems ADD COLUMN IF NOT EXISTS reference_solution JSONB;
+ALTER TABLE problems ADD COLUMN IF NOT EXISTS input_generator JSONB;
@@ -0,0 +1,2 @@
+-- 000007_submission_similarities.down.sql
+DROP TABLE IF EXISTS submission_similarities CASCADE;
@@ -0,0 +1,21 @@
+-- 000007_submission_similarities.up.sql
+-- Fingerprint similarity between different users' submissions to a problem
+
+CREATE TABLE submission_similarities (
+    similarity_id   SERIAL PRIMARY KEY,
+    problem_id      INT NOT NULL REFERENCES problems(problem_id) ON DELETE CASCADE,
+    language        VARCHAR(50) NOT NULL,
+    submission_a_id INT NOT NULL REFERENCES code_submissions(submission_id) ON DELETE CASCADE,
+    submission_b_id INT NOT NULL REFERENCES code_submissions(submission_id) ON DELETE CASCADE,
+    user_a_id       INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    user_b_id       INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    similarity      FLOAT NOT NULL CHECK (similarity BETWEEN 0 AND 1),
+    coverage_a      FLOAT,
+    coverage_b      FLOAT,
+    matches         JSONB,
+    computed_at     TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_similarity_pair ON submission_similarities(submission_a_id, submission_b_id);
+CREATE INDEX idx_submission_similarities_problem ON submission_similarities(problem_id);
+CREATE INDEX idx_submission_similarities_similarity ON submission_similarities(similarity);
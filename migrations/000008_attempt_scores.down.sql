@@ -0,0 +1,2 @@
+-- 000008_attempt_scores.down.sql
+ALTER TABLE user_attempts DROP COLUMN IF EXISTS score;
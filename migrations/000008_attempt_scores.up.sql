@@ -0,0 +1,4 @@
+-- 000008_attempt_scores.up.sql
+-- Partial credit for graded question attempts
+
+ALTER TABLE user_attempts ADD COLUMN IF NOT EXISTS score FLOAT CHECK (score BETWEEN 0 AND 1);
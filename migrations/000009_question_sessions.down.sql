@@ -0,0 +1,2 @@
+-- 000009_question_sessions.down.sql
+DROP TABLE IF EXISTS question_sessions CASCADE;
@@ -0,0 +1,15 @@
+-- 000009_question_sessions.up.sql
+-- Server-side sessions timing question answers
+
+CREATE TABLE question_sessions (
+    session_id   VARCHAR(64) PRIMARY KEY,
+    user_id      INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    question_id  INT NOT NULL REFERENCES questions(question_id) ON DELETE CASCADE,
+    started_at   TIMESTAMP NOT NULL,
+    expires_at   TIMESTAMP NOT NULL,
+    submitted_at TIMESTAMP,
+    attempt_id   INT REFERENCES user_attempts(attempt_id) ON DELETE SET NULL,
+    response     JSONB
+);
+
+CREATE INDEX idx_question_sessions_user ON question_sessions(user_id);
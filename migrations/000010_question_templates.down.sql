@@ -0,0 +1,3 @@
+-- 000010_question_templates.down.sql
+ALTER TABLE question_sessions DROP COLUMN IF EXISTS seed;
+ALTER TABLE questions DROP COLUMN IF EXISTS template;
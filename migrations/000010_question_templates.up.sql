@@ -0,0 +1,5 @@
+-- 000010_question_templates.up.sql
+-- Parameterized template questions and the seed of each session's instance
+
+ALTER TABLE questions ADD COLUMN IF NOT EXISTS template JSONB;
+ALTER TABLE question_sessions ADD COLUMN IF NOT EXISTS seed BIGINT;
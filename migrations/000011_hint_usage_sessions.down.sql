@@ -0,0 +1,13 @@
+-- 000011_hint_usage_sessions.down.sql
+DELETE FROM question_hint_usage a
+    USING question_hint_usage b
+    WHERE a.user_id = b.user_id
+      AND a.question_id = b.question_id
+      AND a.hint_level = b.hint_level
+      AND a.usage_id > b.usage_id;
+
+DROP INDEX IF EXISTS idx_sessionless_hint;
+DROP INDEX IF EXISTS idx_session_hint;
+ALTER TABLE question_hint_usage DROP COLUMN IF EXISTS session_id;
+ALTER TABLE question_hint_usage ADD CONSTRAINT question_hint_usage_user_id_question_id_hint_level_key
+    UNIQUE (user_id, question_id, hint_level);
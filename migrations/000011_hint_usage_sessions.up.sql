@@ -0,0 +1,10 @@
+-- 000011_hint_usage_sessions.up.sql
+-- Hints are recorded per question session, so each session counts its own
+
+ALTER TABLE question_hint_usage
+    ADD COLUMN IF NOT EXISTS session_id VARCHAR(64) REFERENCES question_sessions(session_id) ON DELETE CASCADE;
+ALTER TABLE question_hint_usage DROP CONSTRAINT IF EXISTS question_hint_usage_user_id_question_id_hint_level_key;
+
+CREATE UNIQUE INDEX idx_session_hint ON question_hint_usage(session_id, hint_level);
+CREATE UNIQUE INDEX idx_sessionless_hint ON question_hint_usage(user_id, question_id, hint_level)
+    WHERE session_id IS NULL;
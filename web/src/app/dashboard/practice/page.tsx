@@ -16,22 +16,22 @@ export default function PracticePage() {
     points_earned?: number;
   } | null>(null);
   const [hint, setHint] = useState<string | null>(null);
-  const [hintsUsed, setHintsUsed] = useState(0);
   const queryClient = useQueryClient();
 
   const { data: question, refetch, isLoading } = useQuery({
     queryKey: ['random-question'],
-    queryFn: () => questionsAPI.getRandomQuestion(),
+    // Each question is answered in a session started when it is shown;
+    // template questions show the instance their session drew
+    queryFn: async () => {
+      const random = await questionsAPI.getRandomQuestion();
+      const session = await questionsAPI.startQuestion(random.question_id);
+      return { ...random, ...session.instance, session_token: session.session_token };
+    },
   });
 
   const submitMutation = useMutation({
     mutationFn: (answer: string) =>
-      questionsAPI.submitAnswer(
-        question!.question_id,
-        { answer },
-        Math.floor((Date.now() - startTime) / 1000),
-        hintsUsed
-      ),
+      questionsAPI.submitAnswer(question!.question_id, question!.session_token, { answer }),
     onSuccess: (data) => {
       setResult(data);
       queryClient.invalidateQueries({ queryKey: ['user-stats'] });
@@ -53,7 +53,6 @@ export default function PracticePage() {
     mutationFn: () => questionsAPI.getHint(question!.question_id),
     onSuccess: (data) => {
       setHint(data.hint);
-      setHintsUsed((prev) => prev + 1);
     },
     onError: () => {
       toast.error('No hint available for this question');
@@ -74,7 +73,6 @@ export default function PracticePage() {
     setSelectedAnswer('');
     setStartTime(Date.now());
     setHint(null);
-    setHintsUsed(0);
     refetch();
   };
 